@@ -1,115 +1,271 @@
 package main
 
 import (
+	"context"
 	"flag"
-	"net/http"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"time"
 
 	"github.com/ctchen222/hotel-system/internal/api"
 	"github.com/ctchen222/hotel-system/internal/api/middleware"
+	"github.com/ctchen222/hotel-system/internal/auth"
 	"github.com/ctchen222/hotel-system/internal/db"
+	"github.com/ctchen222/hotel-system/internal/holds"
+	"github.com/ctchen222/hotel-system/internal/memory"
+	"github.com/ctchen222/hotel-system/internal/notify"
+	"github.com/ctchen222/hotel-system/internal/oidc"
+	"github.com/ctchen222/hotel-system/internal/payments"
 	models "github.com/ctchen222/hotel-system/internal/pg"
+	"github.com/ctchen222/hotel-system/internal/pricing"
 	"github.com/ctchen222/hotel-system/internal/response"
 	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/middleware/requestid"
 )
 
 var config = fiber.Config{
-	ErrorHandler: func(c *fiber.Ctx, err error) error {
-		if apiError, ok := err.(response.Error); ok {
-			return c.Status(apiError.Code).JSON(apiError)
-		}
-		apiError := response.NewError(http.StatusInternalServerError, err.Error())
-		return c.Status(apiError.Code).JSON(apiError)
-	},
-	BodyLimit: 10 * 1024 * 1024,
+	ErrorHandler: response.ErrorHandler,
+	BodyLimit:    10 * 1024 * 1024,
 }
 
 func main() {
 	listenAddr := flag.String("listen", ":8080", "server listen address")
+	holdDuration := flag.Duration("hold", holds.DefaultDuration, "how long a booking hold blocks a room")
+	jwtKeys := flag.String("jwt-keys", os.Getenv("JWT_KEYS_DIR"), "directory of PEM private keys access tokens are signed with")
+	jwtKeyId := flag.String("jwt-kid", os.Getenv("JWT_KEY_ID"), "id of the key that signs new access tokens, the newest key if empty")
+	notifyFile := flag.String("notify-file", os.Getenv("NOTIFY_FILE"), "file emails to users are written to, stderr if empty")
+	oidcIssuer := flag.String("oidc-issuer", os.Getenv("OIDC_ISSUER"), "URL of the OpenID Connect provider for single sign-on, disabled if empty")
+	oidcClientId := flag.String("oidc-client-id", os.Getenv("OIDC_CLIENT_ID"), "client id at the OpenID Connect provider")
+	oidcRedirectURL := flag.String("oidc-redirect-url", os.Getenv("OIDC_REDIRECT_URL"), "public URL of /api/oidc/callback")
+	storeName := flag.String("store", envOr("STORE", "mongo"), "database backend the data is stored in, mongo, postgres or memory")
+	migrate := flag.Bool("migrate", os.Getenv("MIGRATE") == "true", "apply pending postgres schema migrations on startup")
+	trustedProxies := flag.String("trusted-proxies", os.Getenv("TRUSTED_PROXIES"), "comma separated proxy addresses or ranges whose X-Forwarded-For is trusted for client addresses")
 	flag.Parse()
 
-	client := db.NewMongoInstance(db.MONGOURI)
-	defer client.Disconnect(db.Ctx)
-	pool := models.NewPostgresInstance(models.Ctx, models.PGURI)
-	pool.DB.Ping(db.Ctx)
-	defer pool.DB.Close()
+	if flag.Arg(0) == "migrate" {
+		if err := runMigrate(flag.Args()[1:]); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+
+	if *trustedProxies != "" {
+		config.ProxyHeader = fiber.HeaderXForwardedFor
+		config.EnableTrustedProxyCheck = true
+		config.TrustedProxies = strings.Split(*trustedProxies, ",")
+	}
+
+	keys, err := auth.LoadKeySet(*jwtKeys, *jwtKeyId)
+	if err != nil {
+		log.Fatalf("loading token signing keys: %v", err)
+	}
+	issuer, err := auth.NewIssuer(keys, auth.DefaultIssuer, auth.DefaultAudience)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	var notifier notify.Notifier = notify.NewLogNotifier(os.Stderr)
+	if *notifyFile != "" {
+		if notifier, err = notify.NewFileNotifier(*notifyFile); err != nil {
+			log.Fatalf("opening notification file: %v", err)
+		}
+	}
+
+	var oidcProvider *oidc.Provider
+	if *oidcIssuer != "" {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		oidcProvider, err = oidc.NewProvider(ctx, oidc.Config{
+			Issuer:   *oidcIssuer,
+			ClientId: *oidcClientId,
+			// the secret is only read from the environment, to keep it out
+			// of process listings
+			ClientSecret: os.Getenv("OIDC_CLIENT_SECRET"),
+			RedirectURL:  *oidcRedirectURL,
+		}, nil)
+		cancel()
+		if err != nil {
+			log.Fatal(err)
+		}
+	}
+
+	store, closeStore, err := openStore(*storeName, *migrate)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer closeStore()
 
 	// Handler initialization
 	var (
-		pgUserStore    = models.NewPostgresUserStore(pool)
-		pgHotelStore   = models.NewPostgresHotelStore(pool)
-		pgRoomStore    = models.NewPostgresRoomStore(pool)
-		pgBookingStore = models.NewPostgresBookingStore(pool)
-
-		userStore    = db.NewMongoUserStore(client)
-		hotelStore   = db.NewMongoHotelStore(client)
-		roomStore    = db.NewMongoRoomStore(client, hotelStore)
-		bookingStore = db.NewMongoBookingStore(client)
-		store        = &db.Store{
-			Hotel:   hotelStore,
-			Room:    roomStore,
-			User:    userStore,
-			Booking: bookingStore,
-		}
+		pricer     = pricing.NewDefaultEngine()
+		processor  = payments.NewProcessor(payments.NewFakeProvider())
+		loginGuard = auth.NewLoginGuard(auth.DefaultAccountPolicy, auth.DefaultIPPolicy)
+
+		userHandler         = api.NewUserHandler(store, notifier)
+		authHandler         = api.NewAuthHandler(store.User, store.Session, store.Token, store.TwoFactor, issuer, loginGuard)
+		oidcHandler         = api.NewOIDCHandler(oidcProvider, store.User, store.Identity, authHandler, "/api/oidc")
+		passwordHandler     = api.NewPasswordHandler(store, notifier)
+		verificationHandler = api.NewVerificationHandler(store, notifier)
+		twoFactorHandler    = api.NewTwoFactorHandler(store, loginGuard)
+		apiKeyHandler       = api.NewAPIKeyHandler(store)
+		hotelHandler        = api.NewHotelHandler(store)
+		roomHandler         = api.NewRoomHandler(store, pricer)
+		bookingHandler      = api.NewBookingHandler(store, pricer, processor, *holdDuration)
+		ratePlanHandler     = api.NewRatePlanHandler(store)
+		promoCodeHandler    = api.NewPromoCodeHandler(store)
+		jwksHandler         = api.NewJWKSHandler(issuer)
+
+		app      = newApp(config)
+		api      = app.Group("/api")
+		jwtAuth  = middleware.JWTAuthentication(issuer, store.User, store.Session, store.APIKey)
+		adminApi = app.Group("/admin/api", jwtAuth, middleware.TwoFactorEnforcement(store.TwoFactor))
 
-		pgUserHandler    = api.NewPgUserHandler(pgUserStore)
-		pgHotelHandler   = api.NewPgHotelHandler(pgHotelStore, pgRoomStore)
-		pgRoomHandler    = api.NewPgRoomHandler(pgRoomStore)
-		pgAuthHandler    = api.NewPgAuthHandler(pgUserStore)
-		pgBookingHandler = api.NewPgBookingHandler(pgBookingStore)
-
-		userHandler  = api.NewUserHandler(store)
-		authHandler  = api.NewAuthHandler(userStore)
-		hotelHandler = api.NewHotelHandler(store)
-		roomHandler  = api.NewRoomHandler(store)
-
-		app        = fiber.New(config)
-		api        = app.Group("/api")
-		adminPgApi = app.Group("/admin/pg", middleware.PgJWTAuthentication(pgUserStore))
-		adminApi   = app.Group("/admin/api", middleware.MongoJWTAuthentication(userStore))
+		requireStaff    = middleware.RequireRole(auth.RoleHotelStaff)
+		requireManager  = middleware.RequireRole(auth.RoleHotelManager)
+		requireAdmin    = middleware.RequireRole(auth.RoleAdmin)
+		requireVerified = middleware.RequireVerifiedEmail()
+		requireSession  = middleware.RequireSession()
+
+		readHotels      = middleware.RequirePermission(auth.PermHotelsRead)
+		writeHotels     = middleware.RequirePermission(auth.PermHotelsWrite)
+		readRooms       = middleware.RequirePermission(auth.PermRoomsRead)
+		writeRooms      = middleware.RequirePermission(auth.PermRoomsWrite)
+		readBookings    = middleware.RequirePermission(auth.PermBookingsRead)
+		writeBookings   = middleware.RequirePermission(auth.PermBookingsWrite)
+		readRatePlans   = middleware.RequirePermission(auth.PermRatePlansRead)
+		writeRatePlans  = middleware.RequirePermission(auth.PermRatePlansWrite)
+		readPromoCodes  = middleware.RequirePermission(auth.PermPromoCodesRead)
+		writePromoCodes = middleware.RequirePermission(auth.PermPromoCodesWrite)
+		readUsers       = middleware.RequirePermission(auth.PermUsersRead)
+		writeUsers      = middleware.RequirePermission(auth.PermUsersWrite)
 	)
 
-	// MONGODB
+	sweepCtx, stopSweeping := context.WithCancel(context.Background())
+	defer stopSweeping()
+	go holds.Sweep(sweepCtx, holds.DefaultSweepInterval, store.Booking)
+
+	app.Get("/.well-known/jwks.json", jwksHandler.HandleGetJWKS)
+
 	api.Post("/login", authHandler.HandleLogin)
+	api.Post("/refresh", authHandler.HandleRefresh)
+	api.Post("/logout", authHandler.HandleLogout)
+	api.Post("/login/2fa", authHandler.HandleLoginTwoFactor)
+	api.Post("/password/forgot", passwordHandler.HandleForgotPassword)
+	api.Post("/password/reset", passwordHandler.HandleResetPassword)
 	api.Post("/register", userHandler.HandlePostUser)
+	api.Get("/verify", verificationHandler.HandleVerifyEmail)
+	api.Post("/verify/resend", verificationHandler.HandleResendVerification)
+	api.Get("/availability", roomHandler.HandleGetAvailability)
+
+	if oidcProvider != nil {
+		api.Get("/oidc/login", oidcHandler.HandleLogin)
+		api.Get("/oidc/callback", oidcHandler.HandleCallback)
+	}
 
-	adminApi.Get("/user", userHandler.HandleGetUsers)
-	adminApi.Get("/user/:id", userHandler.HandleGetUser)
-	adminApi.Delete("/user/:id", userHandler.HandleDeleteUser)
-	adminApi.Patch("/user/:id", userHandler.HandleUpdateUser)
-
-	adminApi.Post("/hotel", hotelHandler.HandlePostHotel)
-	adminApi.Get("/hotel", hotelHandler.HandleGetHotels)
-	adminApi.Get("/hotel/:id", hotelHandler.HandleGetHotel)
-	adminApi.Put("/hotel/:id", hotelHandler.HandleUpdateHotel)
-	adminApi.Get("/hotel/:id/rooms", hotelHandler.HandleGetRooms)
-
-	adminApi.Post("/room/:id/book", roomHandler.HandleBookRoom)
-	adminApi.Get("/room/booking", roomHandler.HandleGetBookings)
-
-	// POSTGRES
-	api.Post("/pg/login", pgAuthHandler.HandleLogin)
-	api.Post("/pg/signup", pgUserHandler.HandleCreateUser)
-
-	adminPgApi.Get("/user", pgUserHandler.HandleGetUsers)
-	adminPgApi.Get("/user/:id", pgUserHandler.HandleGetUser)
-	adminPgApi.Delete("/user/:id", pgUserHandler.HandleDeleteUser)
-	adminPgApi.Post("/user", pgUserHandler.HandleCreateUser)
-	adminPgApi.Patch("/user/:id", pgUserHandler.HandleUpdateUser)
-
-	adminPgApi.Post("/hotel", pgHotelHandler.HandleCreateHotel)
-	adminPgApi.Get("/hotel", pgHotelHandler.HandleGetHotels)
-	adminPgApi.Get("/hotel/:id", pgHotelHandler.HandleGetHotel)
-	adminPgApi.Patch("/hotel/:id", pgHotelHandler.HandleUpdateHotel)
-	adminPgApi.Delete("/hotel/:id", pgHotelHandler.HandlerDeleteHotel)
-	adminPgApi.Get("/hotel/:id/rooms", pgHotelHandler.HandleGetRooms)
-
-	adminPgApi.Post("/room/:hotelId", pgRoomHandler.HandleCreateRoom)
-	adminPgApi.Get("/room/hotel/:hotelId", pgRoomHandler.HandlerGetRooms)
-	adminPgApi.Get("/room/:roomId", pgRoomHandler.HandleGetRoomById)
-	adminPgApi.Delete("/room/:roomId", pgRoomHandler.HandleDeleteRoom)
-
-	adminPgApi.Post("/booking/:roomId", pgBookingHandler.HandleCreateBooking)
-	adminPgApi.Get("/booking/user/:userId", pgBookingHandler.HandleGetBookingInfo)
+	// two-factor enrollment is outside the admin API, which users who have
+	// to enroll can't use yet
+	api.Post("/2fa/enroll", jwtAuth, requireSession, twoFactorHandler.HandleEnroll)
+	api.Post("/2fa/confirm", jwtAuth, requireSession, twoFactorHandler.HandleConfirmEnrollment)
+	api.Post("/2fa/disable", jwtAuth, requireSession, twoFactorHandler.HandleDisable)
+	adminApi.Get("/2fa/policy", requireAdmin, requireSession, twoFactorHandler.HandleGetPolicy)
+	adminApi.Put("/2fa/policy", requireAdmin, requireSession, twoFactorHandler.HandleUpdatePolicy)
+
+	adminApi.Get("/user", requireAdmin, readUsers, userHandler.HandleGetUsers)
+	adminApi.Post("/user", requireAdmin, writeUsers, userHandler.HandlePostUser)
+	adminApi.Get("/user/:id", requireAdmin, readUsers, userHandler.HandleGetUser)
+	adminApi.Delete("/user/:id", requireAdmin, writeUsers, userHandler.HandleDeleteUser)
+	adminApi.Patch("/user/:id", requireAdmin, writeUsers, userHandler.HandleUpdateUser)
+	adminApi.Patch("/user/:id/role", requireAdmin, requireSession, userHandler.HandleUpdateRole)
+	adminApi.Delete("/user/:id/2fa", requireAdmin, requireSession, twoFactorHandler.HandleResetTwoFactor)
+	adminApi.Post("/user/:id/unlock", requireAdmin, requireSession, authHandler.HandleUnlockUser)
+
+	// API keys can't manage API keys
+	adminApi.Post("/apikey", requireAdmin, requireSession, apiKeyHandler.HandlePostAPIKey)
+	adminApi.Get("/apikey", requireAdmin, requireSession, apiKeyHandler.HandleGetAPIKeys)
+	adminApi.Delete("/apikey/:id", requireAdmin, requireSession, apiKeyHandler.HandleRevokeAPIKey)
+
+	adminApi.Post("/hotel", requireAdmin, writeHotels, hotelHandler.HandlePostHotel)
+	adminApi.Get("/hotel", readHotels, hotelHandler.HandleGetHotels)
+	adminApi.Get("/hotel/:id", readHotels, hotelHandler.HandleGetHotel)
+	adminApi.Put("/hotel/:id", requireManager, writeHotels, hotelHandler.HandleUpdateHotel)
+	adminApi.Delete("/hotel/:id", requireAdmin, writeHotels, hotelHandler.HandleDeleteHotel)
+	adminApi.Get("/hotel/:id/rooms", readRooms, hotelHandler.HandleGetRooms)
+	adminApi.Post("/hotel/:id/rooms", requireManager, writeRooms, roomHandler.HandlePostRoom)
+
+	adminApi.Get("/room/booking", requireAdmin, readBookings, roomHandler.HandleGetBookings)
+	adminApi.Get("/room/:id", readRooms, roomHandler.HandleGetRoom)
+	adminApi.Delete("/room/:id", requireManager, writeRooms, roomHandler.HandleDeleteRoom)
+	adminApi.Post("/room/:id/book", requireVerified, writeBookings, roomHandler.HandleBookRoom)
+
+	adminApi.Post("/booking/hold", requireVerified, writeBookings, bookingHandler.HandleHoldBooking)
+	adminApi.Get("/booking/user/:userId", readBookings, bookingHandler.HandleGetUserBookings)
+	adminApi.Post("/booking/:id/confirm", requireVerified, writeBookings, bookingHandler.HandleConfirmBooking)
+	adminApi.Get("/booking/:id/payments", readBookings, bookingHandler.HandleGetPayments)
+	adminApi.Patch("/booking/:id", writeBookings, bookingHandler.HandleUpdateBooking)
+	adminApi.Post("/booking/:id/cancel", writeBookings, bookingHandler.HandleCancelBooking)
+
+	adminApi.Post("/room/:id/rateplan", requireStaff, writeRatePlans, ratePlanHandler.HandlePostRatePlan)
+	adminApi.Get("/room/:id/rateplan", requireStaff, readRatePlans, ratePlanHandler.HandleGetRatePlans)
+	adminApi.Get("/rateplan/:id", requireStaff, readRatePlans, ratePlanHandler.HandleGetRatePlan)
+	adminApi.Put("/rateplan/:id", requireStaff, writeRatePlans, ratePlanHandler.HandleUpdateRatePlan)
+	adminApi.Delete("/rateplan/:id", requireStaff, writeRatePlans, ratePlanHandler.HandleDeleteRatePlan)
+
+	adminApi.Post("/promocode", requireManager, writePromoCodes, promoCodeHandler.HandlePostPromoCode)
+	adminApi.Get("/promocode", requireManager, readPromoCodes, promoCodeHandler.HandleGetPromoCodes)
+	adminApi.Delete("/promocode/:id", requireManager, writePromoCodes, promoCodeHandler.HandleDeletePromoCode)
 
 	app.Listen(*listenAddr)
 }
+
+// newApp returns the app with the middleware that has to run before every
+// other handler. Fiber runs handlers in the order they are registered, so it
+// is registered before any group adds its own middleware.
+func newApp(config fiber.Config) *fiber.App {
+	app := fiber.New(config)
+	// the request id is echoed in X-Request-ID and in problem details
+	app.Use(requestid.New())
+	return app
+}
+
+// openStore connects to the named backend and returns its stores and a
+// function that disconnects from it. MongoDB collections get their indexes and
+// validators on every start; with migrate, pending PostgreSQL migrations are
+// applied first.
+func openStore(name string, migrate bool) (*db.Store, func(), error) {
+	switch name {
+	case "mongo":
+		client := db.NewMongoInstance(db.MONGOURI)
+		if err := db.EnsureSchema(db.Ctx, client); err != nil {
+			client.Disconnect(db.Ctx)
+			return nil, nil, fmt.Errorf("setting up mongo collections: %w", err)
+		}
+		return db.NewMongoStore(client), func() { client.Disconnect(db.Ctx) }, nil
+	case "postgres":
+		pool := models.NewPostgresInstance(models.Ctx, models.PGURI)
+		if err := pool.Ping(models.Ctx); err != nil {
+			return nil, nil, fmt.Errorf("connecting to postgres: %w", err)
+		}
+		if migrate {
+			latest, err := models.LatestSchemaVersion()
+			if err != nil {
+				return nil, nil, err
+			}
+			if err := pool.Migrate(models.Ctx, latest); err != nil {
+				return nil, nil, err
+			}
+		}
+		return models.NewStore(pool), pool.Close, nil
+	case "memory":
+		return memory.NewStore(), func() {}, nil
+	default:
+		return nil, nil, fmt.Errorf("unknown store %q, want mongo, postgres or memory", name)
+	}
+}
+
+func envOr(key, fallback string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+	return fallback
+}
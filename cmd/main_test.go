@@ -0,0 +1,58 @@
+package main
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/ctchen222/hotel-system/internal/api/middleware"
+	"github.com/ctchen222/hotel-system/internal/auth"
+	"github.com/ctchen222/hotel-system/internal/memory"
+	"github.com/ctchen222/hotel-system/internal/response"
+	"github.com/gofiber/fiber/v2"
+)
+
+func TestNewApp_RequestIdOnGroupMiddlewareErrors(t *testing.T) {
+	_, key, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keySet, err := auth.NewKeySet("", map[string]crypto.Signer{"test": key})
+	if err != nil {
+		t.Fatal(err)
+	}
+	issuer, err := auth.NewIssuer(keySet, auth.DefaultIssuer, auth.DefaultAudience)
+	if err != nil {
+		t.Fatal(err)
+	}
+	store := memory.NewStore()
+
+	app := newApp(config)
+	adminApi := app.Group("/admin/api", middleware.JWTAuthentication(issuer, store.User, store.Session, store.APIKey))
+	adminApi.Get("/hotel", func(c *fiber.Ctx) error {
+		return c.SendStatus(http.StatusOK)
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/admin/api/hotel", nil)
+	req.Header.Set(response.HeaderAPIVersion, "2")
+	resp, err := app.Test(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.StatusCode != http.StatusForbidden {
+		t.Fatalf("status = %d, want %d", resp.StatusCode, http.StatusForbidden)
+	}
+
+	var problem response.Problem
+	if err := json.NewDecoder(resp.Body).Decode(&problem); err != nil {
+		t.Fatal(err)
+	}
+	requestId := resp.Header.Get(fiber.HeaderXRequestID)
+	if requestId == "" || problem.RequestId != requestId {
+		t.Errorf("requestId = %q, X-Request-ID = %q, want the same id in both", problem.RequestId, requestId)
+	}
+}
@@ -0,0 +1,65 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+
+	models "github.com/ctchen222/hotel-system/internal/pg"
+)
+
+const migrateUsage = "usage: api migrate [up | down [steps] | to <version> | version]"
+
+// runMigrate runs the migrate subcommand against the PostgreSQL database:
+//
+//	migrate up            apply every pending migration
+//	migrate down [steps]  roll back the last migration, or the last steps
+//	migrate to <version>  migrate up or down to the version
+//	migrate version       print the current and the latest version
+func runMigrate(args []string) error {
+	pool := models.NewPostgresInstance(models.Ctx, models.PGURI)
+	defer pool.Close()
+
+	latest, err := models.LatestSchemaVersion()
+	if err != nil {
+		return err
+	}
+	current, err := pool.SchemaVersion(models.Ctx)
+	if err != nil {
+		return fmt.Errorf("connecting to postgres: %w", err)
+	}
+
+	command := "up"
+	if len(args) > 0 {
+		command = args[0]
+	}
+	var target int
+	switch {
+	case command == "up" && len(args) <= 1:
+		target = latest
+	case command == "down" && len(args) <= 2:
+		steps := 1
+		if len(args) == 2 {
+			if steps, err = strconv.Atoi(args[1]); err != nil || steps < 1 {
+				return fmt.Errorf("invalid number of steps %q", args[1])
+			}
+		}
+		target = max(current-steps, 0)
+	case command == "to" && len(args) == 2:
+		if target, err = strconv.Atoi(args[1]); err != nil {
+			return fmt.Errorf("invalid version %q", args[1])
+		}
+	case command == "version" && len(args) == 1:
+		fmt.Printf("schema version %d, latest %d\n", current, latest)
+		return nil
+	default:
+		return errors.New(migrateUsage)
+	}
+
+	if err := pool.Migrate(models.Ctx, target); err != nil {
+		return err
+	}
+	log.Printf("migrated schema from version %d to %d", current, target)
+	return nil
+}
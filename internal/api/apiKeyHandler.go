@@ -0,0 +1,78 @@
+package api
+
+import (
+	"time"
+
+	"github.com/ctchen222/hotel-system/internal/auth"
+	"github.com/ctchen222/hotel-system/internal/db"
+	"github.com/ctchen222/hotel-system/internal/response"
+	"github.com/ctchen222/hotel-system/internal/types"
+	"github.com/gofiber/fiber/v2"
+)
+
+type APIKeyHandler struct {
+	store *db.Store
+}
+
+func NewAPIKeyHandler(store *db.Store) *APIKeyHandler {
+	return &APIKeyHandler{
+		store: store,
+	}
+}
+
+// HandlePostAPIKey creates an API key for an integration. The key acts as
+// the given user, restricted to its permissions, and is only returned here.
+func (h *APIKeyHandler) HandlePostAPIKey(c *fiber.Ctx) error {
+	user, ok := c.Context().UserValue("user").(*types.User)
+	if !ok {
+		return response.ErrUnAuthenticated()
+	}
+
+	var params types.CreateAPIKeyParams
+	if err := c.BodyParser(&params); err != nil {
+		return err
+	}
+	now := time.Now()
+	if validationErrors := params.Validate(now); len(validationErrors) > 0 {
+		return response.ErrorResponse(c, validationErrors)
+	}
+
+	userId := user.Id
+	if params.UserId != "" {
+		owner, err := h.store.User.GetUserById(c.Context(), params.UserId)
+		if err != nil {
+			return err
+		}
+		userId = owner.Id
+	}
+
+	apiKey, key, err := auth.NewAPIKey(userId, params.Name, params.Permissions, params.ExpiresAt, now)
+	if err != nil {
+		return err
+	}
+	if err := h.store.APIKey.Insert(c.Context(), apiKey); err != nil {
+		return err
+	}
+
+	return response.SuccessResponse(c, types.APIKeyResponse{APIKey: apiKey, Key: key})
+}
+
+func (h *APIKeyHandler) HandleGetAPIKeys(c *fiber.Ctx) error {
+	apiKeys, err := h.store.APIKey.GetAPIKeys(c.Context())
+	if err != nil {
+		return err
+	}
+	if apiKeys == nil {
+		apiKeys = []*auth.APIKey{}
+	}
+
+	return response.SuccessResponse(c, apiKeys)
+}
+
+func (h *APIKeyHandler) HandleRevokeAPIKey(c *fiber.Ctx) error {
+	if err := h.store.APIKey.Revoke(c.Context(), c.Params("id")); err != nil {
+		return err
+	}
+
+	return response.SuccessResponse(c, fiber.Map{"message": "API key revoked"})
+}
@@ -2,73 +2,299 @@ package api
 
 import (
 	"errors"
-	"fmt"
-	"os"
+	"math"
+	"strconv"
+	"sync"
 	"time"
 
+	"github.com/ctchen222/hotel-system/internal/auth"
 	"github.com/ctchen222/hotel-system/internal/db"
 	"github.com/ctchen222/hotel-system/internal/response"
 	"github.com/ctchen222/hotel-system/internal/types"
 	"github.com/gofiber/fiber/v2"
-	"github.com/golang-jwt/jwt/v5"
-	"go.mongodb.org/mongo-driver/mongo"
 )
 
 type AuthHandler struct {
-	userStore db.UserStore
+	userStore      db.UserStore
+	sessionStore   db.SessionStore
+	tokenStore     db.TokenStore
+	twoFactorStore db.TwoFactorStore
+	issuer         *auth.Issuer
+	guard          *auth.LoginGuard
 }
 
-func NewAuthHandler(userStore db.UserStore) *AuthHandler {
+func NewAuthHandler(userStore db.UserStore, sessionStore db.SessionStore, tokenStore db.TokenStore, twoFactorStore db.TwoFactorStore, issuer *auth.Issuer, guard *auth.LoginGuard) *AuthHandler {
 	return &AuthHandler{
-		userStore: userStore,
+		userStore:      userStore,
+		sessionStore:   sessionStore,
+		tokenStore:     tokenStore,
+		twoFactorStore: twoFactorStore,
+		issuer:         issuer,
+		guard:          guard,
 	}
 }
 
+// HandleLogin checks the password of the user. Users with two-factor
+// authentication get a challenge to finish the login with
+// HandleLoginTwoFactor instead of tokens. Failed logins are throttled per
+// account and per address, and an unknown email fails like a wrong password.
 func (a *AuthHandler) HandleLogin(c *fiber.Ctx) error {
 	var params types.AuthParams
 	if err := c.BodyParser(&params); err != nil {
 		return err
 	}
 
+	if err := checkLoginThrottle(c, a.guard, params.Email); err != nil {
+		return err
+	}
+
 	user, err := a.userStore.GetUserByEmail(c.Context(), params.Email)
 	if err != nil {
-		if errors.Is(err, mongo.ErrNoDocuments) {
-			return fmt.Errorf("Invalid credentials")
+		if errors.Is(err, db.ErrNotFound) {
+			checkDummyPassword(params.Password)
+			return loginFailed(c, a.guard, params.Email)
 		}
 		return err
 	}
 
 	if !types.IsValidPassword(user.EncryptedPassword, params.Password) {
-		return fmt.Errorf("Invalid Password")
+		return loginFailed(c, a.guard, params.Email)
+	}
+
+	return a.continueLogin(c, user)
+}
+
+// continueLogin finishes a login whose first factor, a password or a single
+// sign-on, checked out: users with two-factor authentication get a challenge,
+// everyone else their tokens.
+func (a *AuthHandler) continueLogin(c *fiber.Ctx, user *types.User) error {
+	tf, err := a.twoFactorStore.GetTwoFactor(c.Context(), user.Id)
+	if err != nil && !errors.Is(err, db.ErrNotFound) {
+		return err
+	}
+	if tf != nil && tf.Enabled {
+		token, challenge, err := auth.NewOneTimeToken(user.Id, auth.PurposeLoginChallenge, auth.LoginChallengeTTL, time.Now())
+		if err != nil {
+			return err
+		}
+		if err := a.tokenStore.Insert(c.Context(), token); err != nil {
+			return err
+		}
+		return response.SuccessResponse(c, types.AuthResponse{TwoFactorRequired: true, Challenge: challenge})
+	}
+
+	requiredRoles, err := a.twoFactorStore.GetRequiredRoles(c.Context())
+	if err != nil {
+		return err
+	}
+	return a.login(c, user, auth.TwoFactorRequired(requiredRoles, user.Role))
+}
+
+// HandleLoginTwoFactor finishes a login with a TOTP or recovery code. A
+// challenge can be used once, and wrong codes are throttled per account and
+// per address like wrong passwords.
+func (a *AuthHandler) HandleLoginTwoFactor(c *fiber.Ctx) error {
+	var params types.TwoFactorLoginParams
+	if err := c.BodyParser(&params); err != nil {
+		return err
+	}
+
+	token, err := a.tokenStore.Consume(c.Context(), auth.PurposeLoginChallenge, auth.HashToken(params.Challenge), time.Now())
+	if err != nil {
+		if errors.Is(err, db.ErrNotFound) {
+			return response.ErrInvalidLoginChallenge()
+		}
+		return err
+	}
+
+	user, err := a.userStore.GetUserById(c.Context(), token.UserId)
+	if err != nil {
+		if errors.Is(err, db.ErrNotFound) {
+			return response.ErrInvalidLoginChallenge()
+		}
+		return err
+	}
+	tf, err := a.twoFactorStore.GetTwoFactor(c.Context(), token.UserId)
+	if err != nil {
+		if errors.Is(err, db.ErrNotFound) {
+			return response.ErrInvalidLoginChallenge()
+		}
+		return err
+	}
+	if !tf.Enabled {
+		return response.ErrInvalidLoginChallenge()
+	}
+	if err := useTwoFactorCode(c, a.guard, a.twoFactorStore, tf, user.Email, params.Code); err != nil {
+		return err
+	}
+
+	return a.login(c, user, false)
+}
+
+// login starts a session for the user and returns its tokens. The failed
+// logins of the account are forgotten only then, so a right password doesn't
+// reset the throttling of wrong two-factor codes.
+func (a *AuthHandler) login(c *fiber.Ctx, user *types.User, enrollmentRequired bool) error {
+	session, refreshToken, err := auth.NewSession(user.Id, time.Now())
+	if err != nil {
+		return err
+	}
+	if err := a.sessionStore.Insert(c.Context(), session); err != nil {
+		return err
+	}
+
+	token, err := a.issuer.Issue(user.Id, user.Email, user.Role, session.Id, time.Now())
+	if err != nil {
+		return err
 	}
 
 	resp := types.AuthResponse{
-		User:  user,
-		Token: GenerateToken(user),
+		User:                        user,
+		Token:                       token,
+		RefreshToken:                refreshToken,
+		TwoFactorEnrollmentRequired: enrollmentRequired,
+	}
+	a.guard.Success(user.Email)
+
+	return response.SuccessResponse(c, resp)
+}
+
+// HandleRefresh trades a refresh token for a new access token and a new
+// refresh token. A refresh token can be used once; presenting it again means
+// it was stolen, so the whole session is revoked.
+func (a *AuthHandler) HandleRefresh(c *fiber.Ctx) error {
+	var params types.RefreshParams
+	if err := c.BodyParser(&params); err != nil {
+		return err
+	}
+
+	session, err := a.getSession(c, params.RefreshToken)
+	if err != nil {
+		return err
+	}
+	if !session.Matches(params.RefreshToken) {
+		if err := a.sessionStore.Revoke(c.Context(), session.Id); err != nil {
+			return err
+		}
+		return response.ErrRefreshTokenReused()
+	}
+
+	user, err := a.userStore.GetUserById(c.Context(), session.UserId)
+	if err != nil {
+		if errors.Is(err, db.ErrNotFound) {
+			return response.ErrInvalidRefreshToken()
+		}
+		return err
+	}
+
+	previousHash := session.TokenHash
+	refreshToken, err := session.Rotate(time.Now())
+	if err != nil {
+		return err
+	}
+	if err := a.sessionStore.Rotate(c.Context(), session, previousHash); err != nil {
+		if errors.Is(err, db.ErrNotFound) {
+			// Another request rotated the token first.
+			if err := a.sessionStore.Revoke(c.Context(), session.Id); err != nil {
+				return err
+			}
+			return response.ErrRefreshTokenReused()
+		}
+		return err
+	}
+
+	token, err := a.issuer.Issue(user.Id, user.Email, user.Role, session.Id, time.Now())
+	if err != nil {
+		return err
 	}
 
-	fmt.Println("User logged in ->", user.FirstName, user.LastName)
+	resp := types.AuthResponse{
+		User:         user,
+		Token:        token,
+		RefreshToken: refreshToken,
+	}
 
 	return response.SuccessResponse(c, resp)
 }
 
-func GenerateToken(user *types.User) string {
-	now := time.Now()
-	expires := now.Add(time.Hour * 1)
-	claims := jwt.MapClaims{
-		"id":      user.Id,
-		"email":   user.Email,
-		"expires": expires,
+// HandleLogout revokes the session of the refresh token, which also ends the
+// access tokens issued for it.
+func (a *AuthHandler) HandleLogout(c *fiber.Ctx) error {
+	var params types.RefreshParams
+	if err := c.BodyParser(&params); err != nil {
+		return err
 	}
 
-	// header + payload
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	secret := os.Getenv("JWT_SECRET")
-	// sign token with secret -> hash_alg(header + payload + secret)
-	tokenStr, err := token.SignedString([]byte(secret))
+	session, err := a.getSession(c, params.RefreshToken)
+	if err != nil {
+		return err
+	}
+	if err := a.sessionStore.Revoke(c.Context(), session.Id); err != nil {
+		return err
+	}
+
+	return response.SuccessResponse(c, fiber.Map{"message": "logged out"})
+}
+
+// getSession loads the active session a refresh token belongs to.
+func (a *AuthHandler) getSession(c *fiber.Ctx, refreshToken string) (*auth.Session, error) {
+	sessionId, err := auth.SessionId(refreshToken)
+	if err != nil {
+		return nil, response.ErrInvalidRefreshToken()
+	}
+
+	session, err := a.sessionStore.GetSessionById(c.Context(), sessionId)
+	if err != nil {
+		if errors.Is(err, db.ErrNotFound) {
+			return nil, response.ErrInvalidRefreshToken()
+		}
+		return nil, err
+	}
+	if !session.Active(time.Now()) {
+		return nil, response.ErrInvalidRefreshToken()
+	}
+	return session, nil
+}
+
+// HandleUnlockUser lifts the login lockout of a user.
+func (a *AuthHandler) HandleUnlockUser(c *fiber.Ctx) error {
+	user, err := a.userStore.GetUserById(c.Context(), c.Params("id"))
+	if err != nil {
+		return err
+	}
+	a.guard.Unlock(user.Email)
+
+	return response.SuccessResponse(c, fiber.Map{"message": "user unlocked"})
+}
+
+// checkLoginThrottle rejects a login while the account or the address has to
+// wait after failed attempts, telling the client how long in Retry-After.
+func checkLoginThrottle(c *fiber.Ctx, guard *auth.LoginGuard, email string) error {
+	wait := guard.Wait(email, c.IP(), time.Now())
+	if wait <= 0 {
+		return nil
+	}
+	c.Set(fiber.HeaderRetryAfter, strconv.Itoa(int(math.Ceil(wait.Seconds()))))
+	return response.ErrTooManyLoginAttempts()
+}
+
+// loginFailed records a failed login. Unknown emails and wrong passwords get
+// the same error, so it doesn't tell which accounts exist.
+func loginFailed(c *fiber.Ctx, guard *auth.LoginGuard, email string) error {
+	guard.Failure(email, c.IP(), time.Now())
+	return response.ErrInvalidCredentials()
+}
+
+// dummyPasswordHash is compared against for unknown emails, so they take as
+// long to fail as wrong passwords.
+var dummyPasswordHash = sync.OnceValue(func() string {
+	encpw, err := types.EncryptPassword("not a password")
 	if err != nil {
-		fmt.Println("Failed to sign token with secret")
+		panic(err)
 	}
+	return encpw
+})
 
-	return tokenStr
+func checkDummyPassword(password string) {
+	types.IsValidPassword(dummyPasswordHash(), password)
 }
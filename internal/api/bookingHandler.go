@@ -0,0 +1,377 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"log"
+	"time"
+
+	"github.com/ctchen222/hotel-system/internal/auth"
+	"github.com/ctchen222/hotel-system/internal/db"
+	"github.com/ctchen222/hotel-system/internal/payments"
+	"github.com/ctchen222/hotel-system/internal/pricing"
+	"github.com/ctchen222/hotel-system/internal/response"
+	"github.com/ctchen222/hotel-system/internal/types"
+	"github.com/ctchen222/hotel-system/internal/utils"
+	"github.com/gofiber/fiber/v2"
+)
+
+type BookingHandler struct {
+	store        *db.Store
+	pricer       *pricing.Engine
+	payments     *payments.Processor
+	holdDuration time.Duration
+}
+
+func NewBookingHandler(store *db.Store, pricer *pricing.Engine, processor *payments.Processor, holdDuration time.Duration) *BookingHandler {
+	return &BookingHandler{
+		store:        store,
+		pricer:       pricer,
+		payments:     processor,
+		holdDuration: holdDuration,
+	}
+}
+
+// HandleHoldBooking reserves a room for a short time while the guest checks
+// out. The hold blocks the room like a booking until it is confirmed or
+// expires.
+func (h *BookingHandler) HandleHoldBooking(c *fiber.Ctx) error {
+	var params types.BookingHoldParams
+	if err := c.BodyParser(&params); err != nil {
+		return err
+	}
+
+	expiresAt := time.Now().Add(h.holdDuration)
+	return createBooking(c, h.store, h.pricer, params.RoomId, params.BookingRawParams, &expiresAt)
+}
+
+// HandleConfirmBooking turns a hold into a booking. The booking total is
+// charged to the payment token in the body, and the charge is only captured
+// once the hold has been confirmed. If the capture fails, the booking stays
+// confirmed and confirming it again retries the capture.
+func (h *BookingHandler) HandleConfirmBooking(c *fiber.Ctx) error {
+	var params types.ConfirmBookingParams
+	if err := c.BodyParser(&params); err != nil {
+		return err
+	}
+
+	booking, err := h.getOwnBooking(c)
+	if err != nil {
+		return err
+	}
+	if booking.Status == types.BookingConfirmed {
+		retried, err := h.capturePayments(c.Context(), booking.Id)
+		if err != nil {
+			return paymentError(err)
+		}
+		if !retried {
+			return response.ErrInvalidBookingStatus()
+		}
+		return response.SuccessResponse(c, booking)
+	}
+	if booking.Status != types.BookingPending {
+		return response.ErrInvalidBookingStatus()
+	}
+	if booking.ExpiresAt != nil && !time.Now().Before(*booking.ExpiresAt) {
+		return response.ErrHoldExpired()
+	}
+
+	var confirmed *types.Booking
+	confirm := func() error {
+		confirmed, err = h.store.Booking.ConfirmBooking(c.Context(), booking.Id)
+		return err
+	}
+	if booking.Price.Total > 0 {
+		record, payErr := h.payments.Pay(c.Context(), booking.Price.Total, booking.Price.Currency, params.PaymentToken, confirm)
+		if record != nil {
+			payment := &types.Payment{BookingId: booking.Id, Record: *record}
+			if _, err := h.store.Payment.Insert(c.Context(), payment); err != nil {
+				return err
+			}
+		}
+		err = payErr
+	} else {
+		err = confirm()
+	}
+	if err != nil {
+		return paymentError(err)
+	}
+
+	return response.SuccessResponse(c, confirmed)
+}
+
+// HandleGetUserBookings lists the bookings of the user in the path. Users may
+// list their own bookings; admins may list anyone's.
+func (h *BookingHandler) HandleGetUserBookings(c *fiber.Ctx) error {
+	userId := c.Params("userId")
+	user, ok := c.Context().UserValue("user").(*types.User)
+	if !ok {
+		return response.ErrUnAuthenticated()
+	}
+	if user.Id != userId && !user.Role.AtLeast(auth.RoleAdmin) {
+		return response.ErrUnAuthorized()
+	}
+
+	bookings, err := h.store.Booking.GetBookings(c.Context(), types.BookingFilter{UserId: userId})
+	if err != nil {
+		return err
+	}
+
+	return response.SuccessResponse(c, bookings)
+}
+
+func (h *BookingHandler) HandleGetPayments(c *fiber.Ctx) error {
+	booking, err := h.getOwnBooking(c)
+	if err != nil {
+		return err
+	}
+
+	bookingPayments, err := h.store.Payment.GetPaymentsByBookingId(c.Context(), booking.Id)
+	if err != nil {
+		return err
+	}
+
+	return response.SuccessResponse(c, bookingPayments)
+}
+
+func (h *BookingHandler) HandleUpdateBooking(c *fiber.Ctx) error {
+	var params types.BookingUpdateParams
+	if err := c.BodyParser(&params); err != nil {
+		return err
+	}
+
+	booking, err := h.getOwnBooking(c)
+	if err != nil {
+		return err
+	}
+	if booking.Status != types.BookingPending && booking.Status != types.BookingConfirmed {
+		return response.ErrInvalidBookingStatus()
+	}
+
+	updated := *booking
+	if params.From != nil {
+		if updated.From, err = utils.ParseDate(*params.From); err != nil {
+			return response.ErrInvalidDate()
+		}
+	}
+	if params.To != nil {
+		if updated.To, err = utils.ParseDate(*params.To); err != nil {
+			return response.ErrInvalidDate()
+		}
+	}
+	if params.RoomId != nil {
+		updated.RoomId = *params.RoomId
+	}
+	if params.NumPerson != nil {
+		updated.NumPerson = *params.NumPerson
+	}
+	room, err := h.store.Room.GetRoomById(c.Context(), updated.RoomId)
+	if err != nil {
+		return err
+	}
+	if validationErrors := validateBookingUpdate(booking, &updated, room); len(validationErrors) > 0 {
+		return response.ErrorResponse(c, validationErrors)
+	}
+
+	price, err := quoteRoom(c.Context(), h.store, h.pricer, room, updated.From, updated.To)
+	if err != nil {
+		return err
+	}
+	if updated.Price, err = keepPromotion(c.Context(), h.store, h.pricer, room.HotelId, booking.Price, price); err != nil {
+		return err
+	}
+	// Payments are taken for the total of the booking, and cancellations
+	// refund a share of it, so the total of a paid booking can't change.
+	if updated.Price.Total != booking.Price.Total {
+		paid, err := h.isPaid(c.Context(), booking.Id)
+		if err != nil {
+			return err
+		}
+		if paid {
+			return response.ErrPaidBookingPriceChange()
+		}
+	}
+
+	modified, err := h.store.Booking.UpdateBooking(c.Context(), booking, &updated)
+	if err != nil {
+		return err
+	}
+
+	return response.SuccessResponse(c, modified)
+}
+
+// HandleCancelBooking cancels the booking and refunds what the hotel's
+// cancellation policy allows. If the refund fails, the booking stays cancelled
+// and cancelling it again retries the refund.
+func (h *BookingHandler) HandleCancelBooking(c *fiber.Ctx) error {
+	booking, err := h.getOwnBooking(c)
+	if err != nil {
+		return err
+	}
+	if booking.Status == types.BookingCancelled {
+		retried, err := h.refundPayments(c.Context(), booking.Id, booking.RefundAmount)
+		if err != nil {
+			return err
+		}
+		if !retried {
+			return response.ErrInvalidBookingStatus()
+		}
+		return response.SuccessResponse(c, booking)
+	}
+	if !booking.Status.CanTransitionTo(types.BookingCancelled) {
+		return response.ErrInvalidBookingStatus()
+	}
+
+	room, err := h.store.Room.GetRoomById(c.Context(), booking.RoomId)
+	if err != nil {
+		return err
+	}
+	hotel, err := h.store.Hotel.GetHotelById(c.Context(), room.HotelId)
+	if err != nil {
+		return err
+	}
+
+	refund := hotel.CancellationPolicy.Refund(booking.Price.Total, booking.From, time.Now())
+	// the penalty is kept from the payment, so a payment whose capture failed
+	// has to be captured before the rest is refunded
+	if refund < booking.Price.Total {
+		if _, err := h.capturePayments(c.Context(), booking.Id); err != nil {
+			return paymentError(err)
+		}
+	}
+
+	cancelled, err := h.store.Booking.CancelBooking(c.Context(), booking.Id, refund)
+	if err != nil {
+		return err
+	}
+	if _, err := h.refundPayments(c.Context(), booking.Id, refund); err != nil {
+		return err
+	}
+
+	return response.SuccessResponse(c, cancelled)
+}
+
+// refundPayments pays back what is left of amount on the booking's payments
+// and voids the ones that were only authorized. What was refunded before
+// counts towards amount, so a failed refund can be retried with the same
+// amount. It reports whether any payment changed.
+func (h *BookingHandler) refundPayments(ctx context.Context, bookingId string, amount pricing.Money) (bool, error) {
+	bookingPayments, err := h.store.Payment.GetPaymentsByBookingId(ctx, bookingId)
+	if err != nil {
+		return false, err
+	}
+	for _, payment := range bookingPayments {
+		amount -= payment.Refunded
+	}
+
+	changed := false
+	for _, payment := range bookingPayments {
+		before := payment.Record
+		refunded, err := h.payments.Refund(ctx, &payment.Record, amount)
+		if err != nil {
+			return changed, err
+		}
+		amount -= refunded
+		if payment.Record != before {
+			if err := h.store.Payment.Update(ctx, payment); err != nil {
+				return changed, err
+			}
+			changed = true
+		}
+	}
+	return changed, nil
+}
+
+// isPaid reports whether any of the booking's payments was authorized or
+// captured and hasn't been voided or refunded in full.
+func (h *BookingHandler) isPaid(ctx context.Context, bookingId string) (bool, error) {
+	bookingPayments, err := h.store.Payment.GetPaymentsByBookingId(ctx, bookingId)
+	if err != nil {
+		return false, err
+	}
+	for _, payment := range bookingPayments {
+		if payment.Status == payments.StatusAuthorized || payment.Status == payments.StatusCaptured {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// capturePayments captures the booking's payments that were only authorized.
+// It reports whether any payment was captured.
+func (h *BookingHandler) capturePayments(ctx context.Context, bookingId string) (bool, error) {
+	bookingPayments, err := h.store.Payment.GetPaymentsByBookingId(ctx, bookingId)
+	if err != nil {
+		return false, err
+	}
+
+	captured := false
+	for _, payment := range bookingPayments {
+		if payment.Status != payments.StatusAuthorized {
+			continue
+		}
+		if err := h.payments.Capture(ctx, &payment.Record); err != nil {
+			return captured, err
+		}
+		if err := h.store.Payment.Update(ctx, payment); err != nil {
+			return captured, err
+		}
+		captured = true
+	}
+	return captured, nil
+}
+
+// paymentError turns the payment errors the guest can act on into API
+// errors.
+func paymentError(err error) error {
+	switch {
+	case errors.Is(err, payments.ErrDeclined):
+		return response.ErrPaymentDeclined()
+	case errors.Is(err, payments.ErrCaptureFailed):
+		log.Printf("capturing payment: %v", err)
+		return response.ErrPaymentCaptureFailed()
+	}
+	return err
+}
+
+// getOwnBooking loads the booking named in the route and makes sure it
+// belongs to the authenticated user.
+func (h *BookingHandler) getOwnBooking(c *fiber.Ctx) (*types.Booking, error) {
+	user, ok := c.Context().UserValue("user").(*types.User)
+	if !ok {
+		return nil, response.ErrUnAuthenticated()
+	}
+
+	booking, err := h.store.Booking.GetBookingById(c.Context(), c.Params("id"))
+	if err != nil {
+		return nil, err
+	}
+	if booking.UserId != user.Id {
+		return nil, response.ErrUnAuthorized()
+	}
+
+	return booking, nil
+}
+
+// validateBookingUpdate checks the modified booking in its room. A stay that
+// has already started may still be extended, so the arrival date is only
+// checked against the current time when it changes.
+func validateBookingUpdate(current, updated *types.Booking, room *types.Room) map[string]string {
+	now := time.Now()
+	errors := map[string]string{}
+	if !updated.From.Equal(current.From) && now.After(updated.From) {
+		errors["from"] = "Can't book room in the past"
+	}
+	if now.After(updated.To) {
+		errors["to"] = "Can't book room in the past"
+	}
+	if !updated.To.After(updated.From) {
+		errors["order"] = "From Date After To Date"
+	}
+	if updated.NumPerson < 1 {
+		errors["numPerson"] = "numPerson must be at least 1"
+	} else if updated.NumPerson > room.Capacity {
+		errors["numPerson"] = roomCapacityMessage(room)
+	}
+	return errors
+}
@@ -5,8 +5,6 @@ import (
 	"github.com/ctchen222/hotel-system/internal/response"
 	"github.com/ctchen222/hotel-system/internal/types"
 	"github.com/gofiber/fiber/v2"
-	"go.mongodb.org/mongo-driver/bson"
-	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
 type HotelHandler struct {
@@ -25,12 +23,17 @@ func (h *HotelHandler) HandlePostHotel(c *fiber.Ctx) error {
 		return err
 	}
 
+	if validationErrors := params.CancellationPolicy.Validate(); len(validationErrors) > 0 {
+		return response.ErrorResponse(c, validationErrors)
+	}
+
 	hotel := &types.Hotel{
-		Name:     params.Name,
-		Location: params.Location,
-		Rating:   params.Rating,
+		Name:               params.Name,
+		Location:           params.Location,
+		Rating:             params.Rating,
+		CancellationPolicy: params.CancellationPolicy,
 	}
-	createdHotel, err := h.store.Hotel.Create(c.Context(), hotel)
+	createdHotel, err := h.store.Hotel.Insert(c.Context(), hotel)
 	if err != nil {
 		return err
 	}
@@ -44,7 +47,7 @@ func (h *HotelHandler) HandleGetHotels(c *fiber.Ctx) error {
 		return err
 	}
 
-	hotels, err := h.store.Hotel.GetHotels(c.Context(), bson.M{})
+	hotels, err := h.store.Hotel.GetHotels(c.Context())
 	if err != nil {
 		return err
 	}
@@ -57,18 +60,16 @@ func (h *HotelHandler) HandleGetHotel(c *fiber.Ctx) error {
 	if err != nil {
 		return err
 	}
-	return response.SuccessResponse(c, hotel)
-}
-
-func (h *HotelHandler) HandleGetRooms(c *fiber.Ctx) error {
-	id := c.Params("id")
-	oid, err := primitive.ObjectIDFromHex(id)
+	rooms, err := h.store.Room.GetRooms(c.Context(), id)
 	if err != nil {
 		return err
 	}
+	return response.SuccessResponse(c, &types.HotelEmbed{Hotel: *hotel, Rooms: rooms})
+}
 
-	filter := bson.M{"hotelId": oid}
-	rooms, err := h.store.Room.GetRooms(c.Context(), filter)
+func (h *HotelHandler) HandleGetRooms(c *fiber.Ctx) error {
+	id := c.Params("id")
+	rooms, err := h.store.Room.GetRooms(c.Context(), id)
 	if err != nil {
 		return err
 	}
@@ -78,10 +79,16 @@ func (h *HotelHandler) HandleGetRooms(c *fiber.Ctx) error {
 func (h *HotelHandler) HandleUpdateHotel(c *fiber.Ctx) error {
 	var params types.HotelUpdateParams
 	hotelId := c.Params("id")
+	if err := authorizeHotel(c, hotelId); err != nil {
+		return err
+	}
 
 	if err := c.BodyParser(&params); err != nil {
 		return err
 	}
+	if validationErrors := params.CancellationPolicy.Validate(); len(validationErrors) > 0 {
+		return response.ErrorResponse(c, validationErrors)
+	}
 
 	if err := h.store.Hotel.Update(c.Context(), params, hotelId); err != nil {
 		return err
@@ -89,3 +96,24 @@ func (h *HotelHandler) HandleUpdateHotel(c *fiber.Ctx) error {
 
 	return response.SuccessResponse(c, fiber.Map{"message": "hotel updated"})
 }
+
+// HandleDeleteHotel deletes a hotel together with its rooms.
+func (h *HotelHandler) HandleDeleteHotel(c *fiber.Ctx) error {
+	if err := h.store.Hotel.Delete(c.Context(), c.Params("id")); err != nil {
+		return err
+	}
+
+	return response.SuccessResponse(c, fiber.Map{"message": "hotel deleted"})
+}
+
+// authorizeHotel checks that the authenticated user may manage the hotel.
+func authorizeHotel(c *fiber.Ctx, hotelId string) error {
+	user, ok := c.Context().UserValue("user").(*types.User)
+	if !ok {
+		return response.ErrUnAuthenticated()
+	}
+	if !user.CanManageHotel(hotelId) {
+		return response.ErrUnAuthorized()
+	}
+	return nil
+}
@@ -0,0 +1,24 @@
+package api
+
+import (
+	"github.com/ctchen222/hotel-system/internal/auth"
+	"github.com/gofiber/fiber/v2"
+)
+
+type JWKSHandler struct {
+	issuer *auth.Issuer
+}
+
+func NewJWKSHandler(issuer *auth.Issuer) *JWKSHandler {
+	return &JWKSHandler{
+		issuer: issuer,
+	}
+}
+
+// HandleGetJWKS serves the public keys access tokens are signed with. It
+// answers with a bare JWK set, the format token verifiers expect, instead of
+// the usual response envelope.
+func (h *JWKSHandler) HandleGetJWKS(c *fiber.Ctx) error {
+	c.Set(fiber.HeaderCacheControl, "public, max-age=300")
+	return c.JSON(h.issuer.JWKS())
+}
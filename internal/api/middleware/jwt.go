@@ -0,0 +1,68 @@
+package middleware
+
+import (
+	"strings"
+	"time"
+
+	"github.com/ctchen222/hotel-system/internal/auth"
+	"github.com/ctchen222/hotel-system/internal/db"
+	"github.com/ctchen222/hotel-system/internal/response"
+	"github.com/gofiber/fiber/v2"
+)
+
+func JWTAuthentication(issuer *auth.Issuer, userStore db.UserStore, sessionStore db.SessionStore, apiKeyStore db.APIKeyStore) fiber.Handler {
+	if issuer == nil {
+		panic(auth.ErrNoSigningKeys)
+	}
+
+	return func(c *fiber.Ctx) error {
+		// get token from bearer
+		authHeader := c.Get("Authorization")
+		if authHeader == "" || !strings.HasPrefix(authHeader, "Bearer ") {
+			return response.ErrUnAuthorized()
+		}
+		token := strings.TrimPrefix(authHeader, "Bearer ")
+
+		// API keys of integrations are accepted like access tokens and act
+		// as the user they belong to
+		var userId string
+		if auth.IsAPIKey(token) {
+			apiKey, err := authenticateAPIKey(c, apiKeyStore, token)
+			if err != nil {
+				return err
+			}
+			if now := time.Now(); apiKey.NeedsTouch(now) {
+				if err := apiKeyStore.Touch(c.Context(), apiKey.Id, now); err != nil {
+					return err
+				}
+			}
+			userId = apiKey.UserId
+			c.Context().SetUserValue("apiKey", apiKey)
+		} else {
+			claims, err := issuer.Parse(token)
+			if err != nil {
+				return response.ErrUnAuthorized()
+			}
+
+			// the session is revoked on logout, on refresh token reuse and
+			// when the user is deleted
+			session, err := sessionStore.GetSessionById(c.Context(), claims.SessionId)
+			if err != nil || !session.Active(time.Now()) {
+				return response.ErrUnAuthorized()
+			}
+			userId = claims.Subject
+			c.Context().SetUserValue("session", session)
+		}
+
+		user, err := userStore.GetUserById(c.Context(), userId)
+		if err != nil {
+			return response.ErrUnAuthorized()
+		}
+
+		c.Context().SetUserValue("user", user)
+		c.Context().SetUserValue("role", user.Role)
+		c.Context().SetUserValue("emailVerified", user.EmailVerified)
+
+		return c.Next()
+	}
+}
@@ -0,0 +1,23 @@
+package middleware
+
+import (
+	"github.com/ctchen222/hotel-system/internal/auth"
+	"github.com/ctchen222/hotel-system/internal/response"
+	"github.com/gofiber/fiber/v2"
+)
+
+// RequireRole only lets users with at least the required role through. It runs
+// after the JWT authentication, which stores the role of the user.
+func RequireRole(required auth.Role) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		role, ok := c.Context().UserValue("role").(auth.Role)
+		if !ok {
+			return response.ErrUnAuthenticated()
+		}
+		if !role.AtLeast(required) {
+			return response.ErrUnAuthorized()
+		}
+
+		return c.Next()
+	}
+}
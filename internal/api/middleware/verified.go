@@ -0,0 +1,23 @@
+package middleware
+
+import (
+	"github.com/ctchen222/hotel-system/internal/response"
+	"github.com/gofiber/fiber/v2"
+)
+
+// RequireVerifiedEmail only lets users who verified their email through. It
+// runs after the JWT authentication, which stores whether the email is
+// verified.
+func RequireVerifiedEmail() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		verified, ok := c.Context().UserValue("emailVerified").(bool)
+		if !ok {
+			return response.ErrUnAuthenticated()
+		}
+		if !verified {
+			return response.ErrEmailNotVerified()
+		}
+
+		return c.Next()
+	}
+}
@@ -0,0 +1,149 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/ctchen222/hotel-system/internal/auth"
+	"github.com/ctchen222/hotel-system/internal/db"
+	"github.com/ctchen222/hotel-system/internal/oidc"
+	"github.com/ctchen222/hotel-system/internal/response"
+	"github.com/ctchen222/hotel-system/internal/types"
+	"github.com/gofiber/fiber/v2"
+)
+
+// oidcLoginCookie keeps the login in progress in the browser between the
+// redirect to the provider and the callback.
+const oidcLoginCookie = "oidc_login"
+
+// OIDCHandler logs users in with an OpenID Connect provider. Users are
+// created on their first login; local accounts keep logging in with
+// AuthHandler.
+type OIDCHandler struct {
+	provider      oidc.IdentityProvider
+	userStore     db.UserStore
+	identityStore db.IdentityStore
+	authHandler   *AuthHandler
+	// cookiePath is where the browser sends the login cookie back to, the
+	// callback route.
+	cookiePath string
+}
+
+func NewOIDCHandler(provider oidc.IdentityProvider, userStore db.UserStore, identityStore db.IdentityStore, authHandler *AuthHandler, cookiePath string) *OIDCHandler {
+	return &OIDCHandler{
+		provider:      provider,
+		userStore:     userStore,
+		identityStore: identityStore,
+		authHandler:   authHandler,
+		cookiePath:    cookiePath,
+	}
+}
+
+// HandleLogin sends the user to the provider to log in.
+func (h *OIDCHandler) HandleLogin(c *fiber.Ctx) error {
+	login, err := oidc.NewLogin()
+	if err != nil {
+		return err
+	}
+
+	h.setLoginCookie(c, login.Encode(), time.Now().Add(oidc.LoginTTL))
+	return c.Redirect(h.provider.AuthCodeURL(login), fiber.StatusFound)
+}
+
+// HandleCallback finishes the login the provider sent the user back from and
+// returns tokens, or a two-factor challenge like HandleLogin of AuthHandler.
+func (h *OIDCHandler) HandleCallback(c *fiber.Ctx) error {
+	login, err := oidc.DecodeLogin(c.Cookies(oidcLoginCookie), c.Query("state"))
+	// a login can be finished once
+	h.setLoginCookie(c, "", time.Unix(0, 0))
+	if err != nil {
+		return response.ErrInvalidSingleSignOn()
+	}
+	// the user cancelled or the provider refused
+	if c.Query("error") != "" || c.Query("code") == "" {
+		return response.ErrSingleSignOnFailed()
+	}
+
+	identity, err := h.provider.Exchange(c.Context(), c.Query("code"), login)
+	if err != nil {
+		if errors.Is(err, oidc.ErrExchangeFailed) || errors.Is(err, oidc.ErrInvalidIDToken) {
+			return response.ErrSingleSignOnFailed()
+		}
+		return err
+	}
+
+	user, err := h.provisionUser(c.Context(), identity)
+	if err != nil {
+		return err
+	}
+
+	return h.authHandler.continueLogin(c, user)
+}
+
+func (h *OIDCHandler) setLoginCookie(c *fiber.Ctx, value string, expires time.Time) {
+	c.Cookie(&fiber.Cookie{
+		Name:     oidcLoginCookie,
+		Value:    value,
+		Path:     h.cookiePath,
+		Expires:  expires,
+		Secure:   true,
+		HTTPOnly: true,
+		// the callback is a top level navigation from the provider
+		SameSite: fiber.CookieSameSiteLaxMode,
+	})
+}
+
+// provisionUser returns the user linked to the identity. On the first login
+// the identity is linked to the guest account with its email, if the provider
+// verified the email, or a new account is created. Staff and admin accounts
+// are never linked, so an account at the provider can't take one over.
+func (h *OIDCHandler) provisionUser(ctx context.Context, identity *oidc.Identity) (*types.User, error) {
+	userId, err := h.identityStore.GetIdentityUserId(ctx, identity.Issuer, identity.Subject)
+	if err == nil {
+		return h.userStore.GetUserById(ctx, userId)
+	}
+	if !errors.Is(err, db.ErrNotFound) {
+		return nil, err
+	}
+	if identity.Email == "" {
+		return nil, response.ErrSingleSignOnFailed()
+	}
+
+	user, err := h.userStore.GetUserByEmail(ctx, identity.Email)
+	switch {
+	case err == nil:
+		// anyone can claim an email they don't own at some providers
+		if !identity.EmailVerified {
+			return nil, response.ErrEmailInUse()
+		}
+		if user.Role != auth.RoleGuest {
+			return nil, response.ErrSingleSignOnNotAllowed()
+		}
+		if !user.EmailVerified {
+			if err := h.userStore.MarkEmailVerified(ctx, user.Id); err != nil {
+				return nil, err
+			}
+			user.EmailVerified = true
+		}
+	case errors.Is(err, db.ErrNotFound):
+		// users of the provider have no local password
+		user = &types.User{
+			FirstName:     identity.GivenName,
+			LastName:      identity.FamilyName,
+			Email:         identity.Email,
+			EmailVerified: identity.EmailVerified,
+			Role:          auth.RoleGuest,
+		}
+		if user, err = h.userStore.Create(ctx, user); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, err
+	}
+
+	if err := h.identityStore.Insert(ctx, identity.Issuer, identity.Subject, user.Id); err != nil {
+		return nil, err
+	}
+	return user, nil
+}
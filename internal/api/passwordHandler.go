@@ -0,0 +1,110 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/ctchen222/hotel-system/internal/auth"
+	"github.com/ctchen222/hotel-system/internal/db"
+	"github.com/ctchen222/hotel-system/internal/notify"
+	"github.com/ctchen222/hotel-system/internal/response"
+	"github.com/ctchen222/hotel-system/internal/types"
+	"github.com/gofiber/fiber/v2"
+)
+
+// forgotPasswordReply is sent whether or not the email belongs to an account,
+// so the endpoint can't be used to find out who has one.
+const forgotPasswordReply = "If the email belongs to an account, a password reset token has been sent to it"
+
+type PasswordHandler struct {
+	store    *db.Store
+	notifier notify.Notifier
+}
+
+func NewPasswordHandler(store *db.Store, notifier notify.Notifier) *PasswordHandler {
+	return &PasswordHandler{
+		store:    store,
+		notifier: notifier,
+	}
+}
+
+// HandleForgotPassword sends a single-use password reset token to the user.
+// Tokens sent before stop working.
+func (h *PasswordHandler) HandleForgotPassword(c *fiber.Ctx) error {
+	var params types.ForgotPasswordParams
+	if err := c.BodyParser(&params); err != nil {
+		return err
+	}
+
+	user, err := h.store.User.GetUserByEmail(c.Context(), params.Email)
+	if err != nil {
+		if errors.Is(err, db.ErrNotFound) {
+			return response.SuccessResponse(c, fiber.Map{"message": forgotPasswordReply})
+		}
+		return err
+	}
+
+	if err := h.store.Token.DeleteByUserId(c.Context(), user.Id, auth.PurposePasswordReset); err != nil {
+		return err
+	}
+	token, resetToken, err := auth.NewOneTimeToken(user.Id, auth.PurposePasswordReset, auth.PasswordResetTTL, time.Now())
+	if err != nil {
+		return err
+	}
+	if err := h.store.Token.Insert(c.Context(), token); err != nil {
+		return err
+	}
+	if err := sendPasswordReset(c.Context(), h.notifier, user.Email, resetToken); err != nil {
+		return err
+	}
+
+	return response.SuccessResponse(c, fiber.Map{"message": forgotPasswordReply})
+}
+
+// HandleResetPassword sets a new password with a reset token and logs the
+// user out everywhere.
+func (h *PasswordHandler) HandleResetPassword(c *fiber.Ctx) error {
+	var params types.ResetPasswordParams
+	if err := c.BodyParser(&params); err != nil {
+		return err
+	}
+	if validationErrors := params.Validate(); len(validationErrors) > 0 {
+		return response.ErrorResponse(c, validationErrors)
+	}
+
+	token, err := h.store.Token.Consume(c.Context(), auth.PurposePasswordReset, auth.HashToken(params.Token), time.Now())
+	if err != nil {
+		if errors.Is(err, db.ErrNotFound) {
+			return response.ErrInvalidResetToken()
+		}
+		return err
+	}
+
+	encpw, err := types.EncryptPassword(params.Password)
+	if err != nil {
+		return err
+	}
+	if err := h.store.User.UpdatePassword(c.Context(), token.UserId, encpw); err != nil {
+		if errors.Is(err, db.ErrNotFound) {
+			return response.ErrInvalidResetToken()
+		}
+		return err
+	}
+	if err := h.store.Session.RevokeByUserId(c.Context(), token.UserId); err != nil {
+		return err
+	}
+
+	return response.SuccessResponse(c, fiber.Map{"message": "password has been reset"})
+}
+
+func sendPasswordReset(ctx context.Context, notifier notify.Notifier, email, resetToken string) error {
+	return notifier.Notify(ctx, notify.Message{
+		To:      email,
+		Subject: "Reset your password",
+		Body: fmt.Sprintf("Use this token to reset your password: %s\n\n"+
+			"It expires in %s. If you didn't ask to reset your password, ignore this message.",
+			resetToken, auth.PasswordResetTTL),
+	})
+}
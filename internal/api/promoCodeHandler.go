@@ -0,0 +1,152 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/ctchen222/hotel-system/internal/auth"
+	"github.com/ctchen222/hotel-system/internal/db"
+	"github.com/ctchen222/hotel-system/internal/pricing"
+	"github.com/ctchen222/hotel-system/internal/response"
+	"github.com/ctchen222/hotel-system/internal/types"
+	"github.com/gofiber/fiber/v2"
+)
+
+type PromoCodeHandler struct {
+	store *db.Store
+}
+
+func NewPromoCodeHandler(store *db.Store) *PromoCodeHandler {
+	return &PromoCodeHandler{
+		store: store,
+	}
+}
+
+func (h *PromoCodeHandler) HandlePostPromoCode(c *fiber.Ctx) error {
+	var params types.CreatePromoCodeParams
+	if err := c.BodyParser(&params); err != nil {
+		return err
+	}
+	promotion, validationErrors := params.Promotion()
+	if len(validationErrors) > 0 {
+		return response.ErrorResponse(c, validationErrors)
+	}
+
+	promoCode := &types.PromoCode{Promotion: promotion}
+	if params.HotelId != "" {
+		if _, err := h.store.Hotel.GetHotelById(c.Context(), params.HotelId); err != nil {
+			return err
+		}
+		promoCode.HotelId = params.HotelId
+	}
+	if err := authorizePromoCode(c, promoCode.HotelId); err != nil {
+		return err
+	}
+
+	created, err := h.store.PromoCode.Insert(c.Context(), promoCode)
+	if err != nil {
+		return err
+	}
+
+	return response.SuccessResponse(c, created)
+}
+
+func (h *PromoCodeHandler) HandleGetPromoCodes(c *fiber.Ctx) error {
+	user, ok := c.Context().UserValue("user").(*types.User)
+	if !ok {
+		return response.ErrUnAuthenticated()
+	}
+
+	promoCodes, err := h.store.PromoCode.GetPromoCodes(c.Context())
+	if err != nil {
+		return err
+	}
+	if !user.Role.AtLeast(auth.RoleAdmin) {
+		promoCodes = slices.DeleteFunc(promoCodes, func(promoCode *types.PromoCode) bool {
+			return !slices.Contains(user.HotelIds, promoCode.HotelId)
+		})
+	}
+
+	return response.SuccessResponse(c, promoCodes)
+}
+
+func (h *PromoCodeHandler) HandleDeletePromoCode(c *fiber.Ctx) error {
+	promoCode, err := h.store.PromoCode.GetPromoCodeById(c.Context(), c.Params("id"))
+	if err != nil {
+		return err
+	}
+	if err := authorizePromoCode(c, promoCode.HotelId); err != nil {
+		return err
+	}
+
+	if err := h.store.PromoCode.Delete(c.Context(), c.Params("id")); err != nil {
+		return err
+	}
+
+	return response.SuccessResponse(c, "Promo code has been deleted.")
+}
+
+// authorizePromoCode checks that the authenticated user may manage a promo code
+// of the hotel. Codes that are valid at every hotel are left to admins.
+func authorizePromoCode(c *fiber.Ctx, hotelId string) error {
+	if hotelId != "" {
+		return authorizeHotel(c, hotelId)
+	}
+
+	user, ok := c.Context().UserValue("user").(*types.User)
+	if !ok {
+		return response.ErrUnAuthenticated()
+	}
+	if !user.Role.AtLeast(auth.RoleAdmin) {
+		return response.ErrUnAuthorized()
+	}
+	return nil
+}
+
+// findPromoCode loads the promo code a guest entered for a room of hotelId.
+func findPromoCode(ctx context.Context, store *db.Store, code string, hotelId string) (*types.PromoCode, error) {
+	code = pricing.NormalizeCode(code)
+	promoCode, err := store.PromoCode.GetPromoCodeByCode(ctx, code)
+	if err != nil {
+		if errors.Is(err, db.ErrNotFound) {
+			return nil, response.ErrInvalidPromoCode(fmt.Sprintf("promo code %q does not exist", code))
+		}
+		return nil, err
+	}
+	if !promoCode.AppliesTo(hotelId) {
+		return nil, response.ErrInvalidPromoCode(fmt.Sprintf("promo code %q is not valid at this hotel", code))
+	}
+	return promoCode, nil
+}
+
+// applyPromotion discounts price, turning a promotion that can't be used for
+// the stay into an API error.
+func applyPromotion(pricer *pricing.Engine, price pricing.Breakdown, promotion pricing.Promotion, now time.Time) (pricing.Breakdown, error) {
+	if err := promotion.Check(price.Nights, now); err != nil {
+		var promotionErr *pricing.PromotionError
+		if errors.As(err, &promotionErr) {
+			return pricing.Breakdown{}, response.ErrInvalidPromoCode(promotionErr.Error())
+		}
+		return pricing.Breakdown{}, err
+	}
+	return pricer.ApplyPromotion(price, promotion), nil
+}
+
+// keepPromotion carries the promo code of a booking over to its new price in
+// a room of hotelId. The code was redeemed when the booking was made, but it
+// is checked again like a new one, so a change can't take the discount to
+// another hotel or below the code's minimum stay.
+func keepPromotion(ctx context.Context, store *db.Store, pricer *pricing.Engine, hotelId string, previous, price pricing.Breakdown) (pricing.Breakdown, error) {
+	if previous.PromoCode == "" {
+		return price, nil
+	}
+
+	promoCode, err := findPromoCode(ctx, store, previous.PromoCode, hotelId)
+	if err != nil {
+		return pricing.Breakdown{}, err
+	}
+	return applyPromotion(pricer, price, promoCode.Promotion, time.Now())
+}
@@ -0,0 +1,155 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/ctchen222/hotel-system/internal/db"
+	"github.com/ctchen222/hotel-system/internal/pricing"
+	"github.com/ctchen222/hotel-system/internal/response"
+	"github.com/ctchen222/hotel-system/internal/types"
+	"github.com/gofiber/fiber/v2"
+)
+
+type RatePlanHandler struct {
+	store *db.Store
+}
+
+func NewRatePlanHandler(store *db.Store) *RatePlanHandler {
+	return &RatePlanHandler{
+		store: store,
+	}
+}
+
+func (h *RatePlanHandler) HandlePostRatePlan(c *fiber.Ctx) error {
+	roomId := c.Params("id")
+
+	var params pricing.RatePlanParams
+	if err := c.BodyParser(&params); err != nil {
+		return err
+	}
+	plan, validationErrors := params.RatePlan()
+	if len(validationErrors) > 0 {
+		return response.ErrorResponse(c, validationErrors)
+	}
+
+	if err := h.authorizeRoom(c, roomId); err != nil {
+		return err
+	}
+
+	ratePlan, err := h.store.RatePlan.Insert(c.Context(), &types.RatePlan{
+		RoomId:   roomId,
+		RatePlan: plan,
+	})
+	if err != nil {
+		return err
+	}
+
+	return response.SuccessResponse(c, ratePlan)
+}
+
+func (h *RatePlanHandler) HandleGetRatePlans(c *fiber.Ctx) error {
+	roomId := c.Params("id")
+	if err := h.authorizeRoom(c, roomId); err != nil {
+		return err
+	}
+
+	ratePlans, err := h.store.RatePlan.GetRatePlans(c.Context(), roomId)
+	if err != nil {
+		return err
+	}
+
+	return response.SuccessResponse(c, ratePlans)
+}
+
+func (h *RatePlanHandler) HandleGetRatePlan(c *fiber.Ctx) error {
+	ratePlan, err := h.getRatePlan(c)
+	if err != nil {
+		return err
+	}
+
+	return response.SuccessResponse(c, ratePlan)
+}
+
+// HandleUpdateRatePlan applies the fields present in the body to the rate
+// plan; missing fields keep their current value.
+func (h *RatePlanHandler) HandleUpdateRatePlan(c *fiber.Ctx) error {
+	ratePlan, err := h.getRatePlan(c)
+	if err != nil {
+		return err
+	}
+
+	params := pricing.NewRatePlanParams(ratePlan.RatePlan)
+	if err := c.BodyParser(&params); err != nil {
+		return err
+	}
+	plan, validationErrors := params.RatePlan()
+	if len(validationErrors) > 0 {
+		return response.ErrorResponse(c, validationErrors)
+	}
+
+	ratePlan.RatePlan = plan
+	if err := h.store.RatePlan.Update(c.Context(), ratePlan); err != nil {
+		return err
+	}
+
+	return response.SuccessResponse(c, ratePlan)
+}
+
+func (h *RatePlanHandler) HandleDeleteRatePlan(c *fiber.Ctx) error {
+	if _, err := h.getRatePlan(c); err != nil {
+		return err
+	}
+
+	if err := h.store.RatePlan.Delete(c.Context(), c.Params("id")); err != nil {
+		return err
+	}
+
+	return response.SuccessResponse(c, "Rate plan has been deleted.")
+}
+
+func (h *RatePlanHandler) getRatePlan(c *fiber.Ctx) (*types.RatePlan, error) {
+	ratePlan, err := h.store.RatePlan.GetRatePlanById(c.Context(), c.Params("id"))
+	if err != nil {
+		return nil, err
+	}
+	if err := h.authorizeRoom(c, ratePlan.RoomId); err != nil {
+		return nil, err
+	}
+	return ratePlan, nil
+}
+
+// authorizeRoom checks that the room exists and that the authenticated user
+// may manage its hotel.
+func (h *RatePlanHandler) authorizeRoom(c *fiber.Ctx, roomId string) error {
+	room, err := h.store.Room.GetRoomById(c.Context(), roomId)
+	if err != nil {
+		return err
+	}
+	return authorizeHotel(c, room.HotelId)
+}
+
+// quoteRoom prices a stay in room with the room's rate plans.
+func quoteRoom(ctx context.Context, store *db.Store, pricer *pricing.Engine, room *types.Room, from, to time.Time) (pricing.Breakdown, error) {
+	ratePlans, err := store.RatePlan.GetRatePlans(ctx, room.Id)
+	if err != nil {
+		return pricing.Breakdown{}, err
+	}
+
+	return quote(pricer, pricing.FromFloat(room.Price), types.Plans(ratePlans), from, to)
+}
+
+// quote prices a stay, turning a broken rate plan restriction into an API
+// error.
+func quote(pricer *pricing.Engine, baseRate pricing.Money, plans []pricing.RatePlan, from, to time.Time) (pricing.Breakdown, error) {
+	price, err := pricer.Quote(baseRate, plans, from, to)
+	if err != nil {
+		var restrictionErr *pricing.RestrictionError
+		if errors.As(err, &restrictionErr) {
+			return pricing.Breakdown{}, response.ErrRatePlanRestriction(restrictionErr.Error())
+		}
+		return pricing.Breakdown{}, err
+	}
+	return price, nil
+}
@@ -1,49 +1,107 @@
 package api
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"time"
 
 	"github.com/ctchen222/hotel-system/internal/db"
+	"github.com/ctchen222/hotel-system/internal/pricing"
 	"github.com/ctchen222/hotel-system/internal/response"
 	"github.com/ctchen222/hotel-system/internal/types"
+	"github.com/ctchen222/hotel-system/internal/utils"
 	"github.com/gofiber/fiber/v2"
-	"go.mongodb.org/mongo-driver/bson"
-	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
 type RoomHandler struct {
-	store *db.Store
+	store  *db.Store
+	pricer *pricing.Engine
 }
 
-func NewRoomHandler(store *db.Store) *RoomHandler {
+func NewRoomHandler(store *db.Store, pricer *pricing.Engine) *RoomHandler {
 	return &RoomHandler{
-		store: store,
+		store:  store,
+		pricer: pricer,
 	}
 }
 
+// HandlePostRoom adds a room to the hotel in the path.
+func (h *RoomHandler) HandlePostRoom(c *fiber.Ctx) error {
+	hotelId := c.Params("id")
+	if err := authorizeHotel(c, hotelId); err != nil {
+		return err
+	}
+
+	var params types.CreateRoomParams
+	if err := c.BodyParser(&params); err != nil {
+		return err
+	}
+	if validationErrors := params.Validate(); len(validationErrors) > 0 {
+		return response.ErrorResponse(c, validationErrors)
+	}
+
+	room, err := h.store.Room.Insert(c.Context(), &types.Room{
+		Size:     params.Size,
+		SeaSide:  params.SeaSide,
+		Price:    params.Price,
+		Capacity: params.Capacity,
+		HotelId:  hotelId,
+	})
+	if err != nil {
+		return err
+	}
+
+	return response.SuccessResponse(c, room)
+}
+
+func (h *RoomHandler) HandleGetRoom(c *fiber.Ctx) error {
+	room, err := h.store.Room.GetRoomById(c.Context(), c.Params("id"))
+	if err != nil {
+		return err
+	}
+
+	return response.SuccessResponse(c, room)
+}
+
+func (h *RoomHandler) HandleDeleteRoom(c *fiber.Ctx) error {
+	roomId := c.Params("id")
+	room, err := h.store.Room.GetRoomById(c.Context(), roomId)
+	if err != nil {
+		return err
+	}
+	if err := authorizeHotel(c, room.HotelId); err != nil {
+		return err
+	}
+
+	if err := h.store.Room.Delete(c.Context(), roomId); err != nil {
+		return err
+	}
+
+	return response.SuccessResponse(c, fiber.Map{"message": "room deleted"})
+}
+
 func (h *RoomHandler) HandleBookRoom(c *fiber.Ctx) error {
 	var rawParams types.BookingRawParams
 	if err := c.BodyParser(&rawParams); err != nil {
 		return err
 	}
 
-	loc, err := time.LoadLocation("Asia/Taipei")
-	if err != nil {
-		return response.ErrInvalidLocation()
-	}
+	return createBooking(c, h.store, h.pricer, c.Params("id"), rawParams, nil)
+}
 
-	from, err := time.Parse("2006-01-02", rawParams.From)
+// createBooking books the room for the authenticated user. With a non-nil
+// expiresAt the booking is a pending hold that blocks the room until then;
+// otherwise it is confirmed straight away.
+func createBooking(c *fiber.Ctx, store *db.Store, pricer *pricing.Engine, roomId string, rawParams types.BookingRawParams, expiresAt *time.Time) error {
+	from, err := utils.ParseDate(rawParams.From)
 	if err != nil {
 		return response.ErrInvalidDate()
 	}
-	from = from.In(loc)
-
-	to, err := time.Parse("2006-01-02", rawParams.To)
+	to, err := utils.ParseDate(rawParams.To)
 	if err != nil {
 		return response.ErrInvalidDate()
 	}
-	to = to.In(loc)
 
 	params := types.BookingParams{
 		From:      from,
@@ -54,31 +112,31 @@ func (h *RoomHandler) HandleBookRoom(c *fiber.Ctx) error {
 		return response.ErrorResponse(c, validationErrors)
 	}
 
-	roomId, err := primitive.ObjectIDFromHex(c.Params("id"))
-	if err != nil {
-		return err
-	}
-
 	user, ok := c.Context().UserValue("user").(*types.User)
 	if !ok {
 		return response.ErrUnAuthenticated()
 	}
 
-	filter := bson.M{
-		"roomId": roomId,
-		"from": bson.M{
-			"$gte": from,
-		},
-		"to": bson.M{
-			"$lte": to,
-		},
+	room, err := store.Room.GetRoomById(c.Context(), roomId)
+	if err != nil {
+		return err
+	}
+	if params.NumPerson > room.Capacity {
+		return response.ErrorResponse(c, map[string]string{"numPerson": roomCapacityMessage(room)})
 	}
-	bookings, err := h.store.Booking.GetBookings(c.Context(), filter)
+
+	price, err := quoteRoom(c.Context(), store, pricer, room, params.From, params.To)
 	if err != nil {
 		return err
 	}
-	if len(bookings) > 0 {
-		return response.ErrorResponse(c, fmt.Sprintf("Room %s is already booked", roomId.Hex()))
+	if rawParams.PromoCode != "" {
+		promoCode, err := findPromoCode(c.Context(), store, rawParams.PromoCode, room.HotelId)
+		if err != nil {
+			return err
+		}
+		if price, err = applyPromotion(pricer, price, promoCode.Promotion, time.Now()); err != nil {
+			return err
+		}
 	}
 
 	booking := types.Booking{
@@ -87,9 +145,15 @@ func (h *RoomHandler) HandleBookRoom(c *fiber.Ctx) error {
 		NumPerson: params.NumPerson,
 		From:      params.From,
 		To:        params.To,
+		Price:     price,
+		Status:    types.BookingConfirmed,
+	}
+	if expiresAt != nil {
+		booking.Status = types.BookingPending
+		booking.ExpiresAt = expiresAt
 	}
 
-	bookedRoom, err := h.store.Booking.InsertBookRoom(c.Context(), &booking)
+	bookedRoom, err := store.Booking.Insert(c.Context(), &booking)
 	if err != nil {
 		return err
 	}
@@ -97,16 +161,119 @@ func (h *RoomHandler) HandleBookRoom(c *fiber.Ctx) error {
 	return response.SuccessResponse(c, bookedRoom)
 }
 
+func roomCapacityMessage(room *types.Room) string {
+	return fmt.Sprintf("room sleeps at most %d guests", room.Capacity)
+}
+
 func (h *RoomHandler) HandleGetBookings(c *fiber.Ctx) error {
 	var query types.BookingQuery
 	if err := c.QueryParser(&query); err != nil {
 		return err
 	}
 
-	bookings, err := h.store.Booking.GetBookings(c.Context(), bson.M{})
+	bookings, err := h.store.Booking.GetBookings(c.Context(), types.BookingFilter{})
 	if err != nil {
 		return err
 	}
 
 	return response.SuccessResponse(c, bookings)
 }
+
+func (h *RoomHandler) HandleGetAvailability(c *fiber.Ctx) error {
+	var rawQuery types.AvailabilityRawQuery
+	if err := c.QueryParser(&rawQuery); err != nil {
+		return err
+	}
+
+	from, err := utils.ParseDate(rawQuery.From)
+	if err != nil {
+		return response.ErrInvalidDate()
+	}
+	to, err := utils.ParseDate(rawQuery.To)
+	if err != nil {
+		return response.ErrInvalidDate()
+	}
+
+	query := types.AvailabilityQuery{
+		From:     from,
+		To:       to,
+		Guests:   rawQuery.Guests,
+		Location: rawQuery.Location,
+		SeaSide:  rawQuery.SeaSide,
+		MaxPrice: rawQuery.MaxPrice,
+	}
+	if validationErrors := query.Validate(); len(validationErrors) > 0 {
+		return response.ErrorResponse(c, validationErrors)
+	}
+
+	availability, err := h.store.Room.GetAvailableRooms(c.Context(), query)
+	if err != nil {
+		return err
+	}
+	if availability, err = h.priceAvailability(c.Context(), availability, query); err != nil {
+		return err
+	}
+
+	return response.SuccessResponse(c, availability)
+}
+
+// priceAvailability quotes the stay for every available room and drops the
+// rooms whose rate plans don't allow it. With a max price it also drops the
+// rooms quoted above it for any night, as rate plans may price a night above
+// or below the room's price.
+func (h *RoomHandler) priceAvailability(ctx context.Context, availability []*types.HotelAvailability, query types.AvailabilityQuery) ([]*types.HotelAvailability, error) {
+	var roomIds []string
+	for _, hotel := range availability {
+		for _, room := range hotel.Rooms {
+			roomIds = append(roomIds, room.Id)
+		}
+	}
+	if len(roomIds) == 0 {
+		return []*types.HotelAvailability{}, nil
+	}
+
+	ratePlans, err := h.store.RatePlan.GetRatePlans(ctx, roomIds...)
+	if err != nil {
+		return nil, err
+	}
+	plansByRoom := map[string][]*types.RatePlan{}
+	for _, ratePlan := range ratePlans {
+		plansByRoom[ratePlan.RoomId] = append(plansByRoom[ratePlan.RoomId], ratePlan)
+	}
+
+	priced := []*types.HotelAvailability{}
+	for _, hotel := range availability {
+		var rooms []*types.AvailableRoom
+		for _, room := range hotel.Rooms {
+			price, err := h.pricer.Quote(pricing.FromFloat(room.Price), types.Plans(plansByRoom[room.Id]), query.From, query.To)
+			if err != nil {
+				var restrictionErr *pricing.RestrictionError
+				if errors.As(err, &restrictionErr) {
+					continue
+				}
+				return nil, err
+			}
+			if query.MaxPrice > 0 && exceedsNightlyRate(price, pricing.FromFloat(query.MaxPrice)) {
+				continue
+			}
+			room.Quote = price
+			rooms = append(rooms, room)
+		}
+		if len(rooms) > 0 {
+			hotel.Rooms = rooms
+			priced = append(priced, hotel)
+		}
+	}
+	return priced, nil
+}
+
+// exceedsNightlyRate reports whether any night of the quote costs more than
+// maxRate.
+func exceedsNightlyRate(price pricing.Breakdown, maxRate pricing.Money) bool {
+	for _, night := range price.NightlyRates {
+		if night.Rate > maxRate {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,213 @@
+package api
+
+import (
+	"errors"
+	"time"
+
+	"github.com/ctchen222/hotel-system/internal/auth"
+	"github.com/ctchen222/hotel-system/internal/db"
+	"github.com/ctchen222/hotel-system/internal/response"
+	"github.com/ctchen222/hotel-system/internal/types"
+	"github.com/gofiber/fiber/v2"
+)
+
+// twoFactorIssuer names the account in authenticator apps.
+const twoFactorIssuer = "Hotel System"
+
+type TwoFactorHandler struct {
+	store *db.Store
+	guard *auth.LoginGuard
+}
+
+func NewTwoFactorHandler(store *db.Store, guard *auth.LoginGuard) *TwoFactorHandler {
+	return &TwoFactorHandler{
+		store: store,
+		guard: guard,
+	}
+}
+
+// HandleEnroll starts a TOTP enrollment for the authenticated user and
+// returns the secret for their authenticator app. Two-factor authentication
+// is enabled once HandleConfirmEnrollment got a code for the secret.
+func (h *TwoFactorHandler) HandleEnroll(c *fiber.Ctx) error {
+	user, ok := c.Context().UserValue("user").(*types.User)
+	if !ok {
+		return response.ErrUnAuthenticated()
+	}
+
+	existing, err := h.store.TwoFactor.GetTwoFactor(c.Context(), user.Id)
+	if err != nil && !errors.Is(err, db.ErrNotFound) {
+		return err
+	}
+	if existing != nil && existing.Enabled {
+		return response.ErrTwoFactorAlreadyEnabled()
+	}
+
+	tf, err := auth.NewTwoFactor(user.Id)
+	if err != nil {
+		return err
+	}
+	if err := h.store.TwoFactor.Upsert(c.Context(), tf); err != nil {
+		return err
+	}
+
+	return response.SuccessResponse(c, types.TwoFactorEnrollment{
+		Secret:          tf.Secret,
+		ProvisioningURI: tf.ProvisioningURI(twoFactorIssuer, user.Email),
+	})
+}
+
+// HandleConfirmEnrollment enables two-factor authentication with a code from
+// the authenticator and returns the recovery codes. They are shown only
+// this once. The other sessions of the user were started without a code, so
+// they are revoked; the session that confirmed stays.
+func (h *TwoFactorHandler) HandleConfirmEnrollment(c *fiber.Ctx) error {
+	user, ok := c.Context().UserValue("user").(*types.User)
+	if !ok {
+		return response.ErrUnAuthenticated()
+	}
+	var params types.TwoFactorCodeParams
+	if err := c.BodyParser(&params); err != nil {
+		return err
+	}
+
+	tf, err := h.store.TwoFactor.GetTwoFactor(c.Context(), user.Id)
+	if err != nil {
+		if errors.Is(err, db.ErrNotFound) {
+			return response.ErrTwoFactorNotEnrolled()
+		}
+		return err
+	}
+	if tf.Enabled {
+		return response.ErrTwoFactorAlreadyEnabled()
+	}
+	step, ok := tf.ValidateTOTP(params.Code, time.Now())
+	if !ok {
+		return response.ErrInvalidTwoFactorCode()
+	}
+
+	codes, hashes, err := auth.NewRecoveryCodes()
+	if err != nil {
+		return err
+	}
+	if err := h.store.TwoFactor.Enable(c.Context(), tf.UserId, hashes, step); err != nil {
+		if errors.Is(err, db.ErrNotFound) {
+			return response.ErrTwoFactorAlreadyEnabled()
+		}
+		return err
+	}
+	var keepId string
+	if session, ok := c.Context().UserValue("session").(*auth.Session); ok {
+		keepId = session.Id
+	}
+	if err := h.store.Session.RevokeOthers(c.Context(), user.Id, keepId); err != nil {
+		return err
+	}
+
+	return response.SuccessResponse(c, types.RecoveryCodesResponse{RecoveryCodes: codes})
+}
+
+// HandleDisable turns off two-factor authentication after checking a code.
+// Users whose role requires it can't turn it off.
+func (h *TwoFactorHandler) HandleDisable(c *fiber.Ctx) error {
+	user, ok := c.Context().UserValue("user").(*types.User)
+	if !ok {
+		return response.ErrUnAuthenticated()
+	}
+	var params types.TwoFactorCodeParams
+	if err := c.BodyParser(&params); err != nil {
+		return err
+	}
+
+	requiredRoles, err := h.store.TwoFactor.GetRequiredRoles(c.Context())
+	if err != nil {
+		return err
+	}
+	if auth.TwoFactorRequired(requiredRoles, user.Role) {
+		return response.ErrTwoFactorEnrollmentRequired()
+	}
+
+	tf, err := h.store.TwoFactor.GetTwoFactor(c.Context(), user.Id)
+	if err != nil {
+		if errors.Is(err, db.ErrNotFound) {
+			return response.ErrTwoFactorNotEnrolled()
+		}
+		return err
+	}
+	if !tf.Enabled {
+		return response.ErrTwoFactorNotEnrolled()
+	}
+	if err := useTwoFactorCode(c, h.guard, h.store.TwoFactor, tf, user.Email, params.Code); err != nil {
+		return err
+	}
+	if err := h.store.TwoFactor.Delete(c.Context(), tf.UserId); err != nil {
+		return err
+	}
+
+	return response.SuccessResponse(c, fiber.Map{"message": "two-factor authentication disabled"})
+}
+
+// HandleResetTwoFactor removes the enrollment of a user who lost both their
+// authenticator and their recovery codes.
+func (h *TwoFactorHandler) HandleResetTwoFactor(c *fiber.Ctx) error {
+	if err := h.store.TwoFactor.Delete(c.Context(), c.Params("id")); err != nil {
+		return err
+	}
+
+	return response.SuccessResponse(c, fiber.Map{"message": "two-factor authentication reset"})
+}
+
+func (h *TwoFactorHandler) HandleGetPolicy(c *fiber.Ctx) error {
+	roles, err := h.store.TwoFactor.GetRequiredRoles(c.Context())
+	if err != nil {
+		return err
+	}
+	if roles == nil {
+		roles = []auth.Role{}
+	}
+
+	return response.SuccessResponse(c, types.TwoFactorPolicyParams{Roles: roles})
+}
+
+// HandleUpdatePolicy sets the roles that have to use two-factor
+// authentication.
+func (h *TwoFactorHandler) HandleUpdatePolicy(c *fiber.Ctx) error {
+	var params types.TwoFactorPolicyParams
+	if err := c.BodyParser(&params); err != nil {
+		return err
+	}
+	if validationErrors := params.Validate(); len(validationErrors) > 0 {
+		return response.ErrorResponse(c, validationErrors)
+	}
+
+	if err := h.store.TwoFactor.SetRequiredRoles(c.Context(), params.Roles); err != nil {
+		return err
+	}
+
+	return response.SuccessResponse(c, params)
+}
+
+// useTwoFactorCode accepts a TOTP code or an unused recovery code and records
+// its use, so it can't be used again. Wrong codes count as failed logins of
+// the account, so guessing them is throttled and ends in a lockout just like
+// guessing passwords.
+func useTwoFactorCode(c *fiber.Ctx, guard *auth.LoginGuard, store db.TwoFactorStore, tf *auth.TwoFactor, email, code string) error {
+	if err := checkLoginThrottle(c, guard, email); err != nil {
+		return err
+	}
+
+	now := time.Now()
+	if step, ok := tf.ValidateTOTP(code, now); ok {
+		err := store.UseStep(c.Context(), tf.UserId, step)
+		if !errors.Is(err, db.ErrNotFound) {
+			return err
+		}
+	} else if hash, ok := tf.HasRecoveryCode(code); ok {
+		err := store.UseRecoveryCode(c.Context(), tf.UserId, hash)
+		if !errors.Is(err, db.ErrNotFound) {
+			return err
+		}
+	}
+	guard.Failure(email, c.IP(), now)
+	return response.ErrInvalidTwoFactorCode()
+}
@@ -4,19 +4,21 @@ import (
 	"errors"
 
 	"github.com/ctchen222/hotel-system/internal/db"
+	"github.com/ctchen222/hotel-system/internal/notify"
 	"github.com/ctchen222/hotel-system/internal/response"
 	"github.com/ctchen222/hotel-system/internal/types"
 	"github.com/gofiber/fiber/v2"
-	"go.mongodb.org/mongo-driver/mongo"
 )
 
 type UserHandler struct {
-	store *db.Store
+	store    *db.Store
+	notifier notify.Notifier
 }
 
-func NewUserHandler(store *db.Store) *UserHandler {
+func NewUserHandler(store *db.Store, notifier notify.Notifier) *UserHandler {
 	return &UserHandler{
-		store: store,
+		store:    store,
+		notifier: notifier,
 	}
 }
 
@@ -27,9 +29,6 @@ func (h *UserHandler) HandleGetUser(c *fiber.Ctx) error {
 
 	user, err := h.store.User.GetUserById(c.Context(), id)
 	if err != nil {
-		if errors.Is(err, mongo.ErrNoDocuments) {
-			return response.ErrResourceNotFound()
-		}
 		return err
 	}
 
@@ -64,6 +63,9 @@ func (h *UserHandler) HandlePostUser(c *fiber.Ctx) error {
 	if err != nil {
 		return err
 	}
+	if err := sendEmailVerification(c.Context(), h.store.Token, h.notifier, createdUser); err != nil {
+		return err
+	}
 
 	return response.SuccessResponse(c, createdUser)
 }
@@ -73,6 +75,9 @@ func (h *UserHandler) HandleDeleteUser(c *fiber.Ctx) error {
 	if err := h.store.User.DeleteById(c.Context(), userId); err != nil {
 		return err
 	}
+	if err := h.store.Session.RevokeByUserId(c.Context(), userId); err != nil {
+		return err
+	}
 
 	return response.SuccessResponse(c, fiber.Map{"message": "user deleted"})
 }
@@ -91,3 +96,32 @@ func (h *UserHandler) HandleUpdateUser(c *fiber.Ctx) error {
 
 	return response.SuccessResponse(c, fiber.Map{"message": "user updated"})
 }
+
+// HandleUpdateRole sets the role of a user and, for hotel staff and managers,
+// the hotels they work for.
+func (h *UserHandler) HandleUpdateRole(c *fiber.Ctx) error {
+	userId := c.Params("id")
+
+	var params types.UpdateRoleParams
+	if err := c.BodyParser(&params); err != nil {
+		return err
+	}
+	if validationErrors := params.Validate(); len(validationErrors) > 0 {
+		return response.ErrorResponse(c, validationErrors)
+	}
+
+	for _, hotelId := range params.HotelIds {
+		if _, err := h.store.Hotel.GetHotelById(c.Context(), hotelId); err != nil {
+			if errors.Is(err, db.ErrNotFound) {
+				return response.ErrorResponse(c, map[string]string{"hotelIds": "unknown hotel " + hotelId})
+			}
+			return err
+		}
+	}
+
+	if err := h.store.User.UpdateRole(c.Context(), userId, params.Role, params.HotelIds); err != nil {
+		return err
+	}
+
+	return response.SuccessResponse(c, fiber.Map{"message": "user role updated"})
+}
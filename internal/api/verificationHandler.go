@@ -0,0 +1,106 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/ctchen222/hotel-system/internal/auth"
+	"github.com/ctchen222/hotel-system/internal/db"
+	"github.com/ctchen222/hotel-system/internal/notify"
+	"github.com/ctchen222/hotel-system/internal/response"
+	"github.com/ctchen222/hotel-system/internal/types"
+	"github.com/gofiber/fiber/v2"
+)
+
+// resendVerificationReply is sent whether or not the email belongs to an
+// unverified account, so the endpoint can't be used to find out who has one.
+const resendVerificationReply = "If the email belongs to an unverified account, a verification token has been sent to it"
+
+type VerificationHandler struct {
+	store    *db.Store
+	notifier notify.Notifier
+}
+
+func NewVerificationHandler(store *db.Store, notifier notify.Notifier) *VerificationHandler {
+	return &VerificationHandler{
+		store:    store,
+		notifier: notifier,
+	}
+}
+
+// HandleVerifyEmail marks the email of the user as verified with the token
+// sent on signup.
+func (h *VerificationHandler) HandleVerifyEmail(c *fiber.Ctx) error {
+	verifyToken := c.Query("token")
+	if verifyToken == "" {
+		return response.ErrInvalidVerificationToken()
+	}
+
+	token, err := h.store.Token.Consume(c.Context(), auth.PurposeEmailVerification, auth.HashToken(verifyToken), time.Now())
+	if err != nil {
+		if errors.Is(err, db.ErrNotFound) {
+			return response.ErrInvalidVerificationToken()
+		}
+		return err
+	}
+	if err := h.store.User.MarkEmailVerified(c.Context(), token.UserId); err != nil {
+		if errors.Is(err, db.ErrNotFound) {
+			return response.ErrInvalidVerificationToken()
+		}
+		return err
+	}
+
+	return response.SuccessResponse(c, fiber.Map{"message": "email has been verified"})
+}
+
+// HandleResendVerification sends a new verification token, e.g. when the
+// first one expired. Tokens sent before stop working.
+func (h *VerificationHandler) HandleResendVerification(c *fiber.Ctx) error {
+	var params types.ResendVerificationParams
+	if err := c.BodyParser(&params); err != nil {
+		return err
+	}
+
+	user, err := h.store.User.GetUserByEmail(c.Context(), params.Email)
+	if err != nil {
+		if errors.Is(err, db.ErrNotFound) {
+			return response.SuccessResponse(c, fiber.Map{"message": resendVerificationReply})
+		}
+		return err
+	}
+	if !user.EmailVerified {
+		if err := sendEmailVerification(c.Context(), h.store.Token, h.notifier, user); err != nil {
+			return err
+		}
+	}
+
+	return response.SuccessResponse(c, fiber.Map{"message": resendVerificationReply})
+}
+
+// sendEmailVerification replaces the pending verification token of the user
+// with a new one and sends it to their email.
+func sendEmailVerification(ctx context.Context, tokenStore db.TokenStore, notifier notify.Notifier, user *types.User) error {
+	if err := tokenStore.DeleteByUserId(ctx, user.Id, auth.PurposeEmailVerification); err != nil {
+		return err
+	}
+	token, verifyToken, err := auth.NewOneTimeToken(user.Id, auth.PurposeEmailVerification, auth.EmailVerificationTTL, time.Now())
+	if err != nil {
+		return err
+	}
+	if err := tokenStore.Insert(ctx, token); err != nil {
+		return err
+	}
+	return notifyEmailVerification(ctx, notifier, user.Email, verifyToken)
+}
+
+func notifyEmailVerification(ctx context.Context, notifier notify.Notifier, email, verifyToken string) error {
+	return notifier.Notify(ctx, notify.Message{
+		To:      email,
+		Subject: "Verify your email",
+		Body: fmt.Sprintf("Use this token to verify your email: %s\n\n"+
+			"It expires in %s. If you didn't sign up, ignore this message.",
+			verifyToken, auth.EmailVerificationTTL),
+	})
+}
@@ -0,0 +1,123 @@
+package auth
+
+import (
+	"crypto/subtle"
+	"errors"
+	"slices"
+	"strings"
+	"time"
+)
+
+// APIKeyPrefix starts every API key, which tells them apart from access
+// tokens in the Authorization header.
+const APIKeyPrefix = "hsk_"
+
+// APIKeyTouchInterval is how often the last use of a key is recorded, so a
+// busy integration doesn't write on every request.
+const APIKeyTouchInterval = time.Minute
+
+var ErrInvalidAPIKey = errors.New("invalid API key")
+
+// Permission is something an API key may do. Keys act as the user they
+// belong to, so a key can never do more than its user.
+type Permission string
+
+const (
+	PermHotelsRead      Permission = "hotels:read"
+	PermHotelsWrite     Permission = "hotels:write"
+	PermRoomsRead       Permission = "rooms:read"
+	PermRoomsWrite      Permission = "rooms:write"
+	PermBookingsRead    Permission = "bookings:read"
+	PermBookingsWrite   Permission = "bookings:write"
+	PermRatePlansRead   Permission = "rateplans:read"
+	PermRatePlansWrite  Permission = "rateplans:write"
+	PermPromoCodesRead  Permission = "promocodes:read"
+	PermPromoCodesWrite Permission = "promocodes:write"
+	PermUsersRead       Permission = "users:read"
+	PermUsersWrite      Permission = "users:write"
+)
+
+var Permissions = []Permission{
+	PermHotelsRead, PermHotelsWrite,
+	PermRoomsRead, PermRoomsWrite,
+	PermBookingsRead, PermBookingsWrite,
+	PermRatePlansRead, PermRatePlansWrite,
+	PermPromoCodesRead, PermPromoCodesWrite,
+	PermUsersRead, PermUsersWrite,
+}
+
+func (p Permission) Valid() bool {
+	return slices.Contains(Permissions, p)
+}
+
+// APIKey lets an integration call the API as a user, restricted to its
+// permissions. Only the hash of the key is stored.
+type APIKey struct {
+	Id          string       `bson:"_id" json:"id"`
+	UserId      string       `bson:"userId" json:"userId"`
+	Name        string       `bson:"name" json:"name"`
+	KeyHash     string       `bson:"keyHash" json:"-"`
+	Permissions []Permission `bson:"permissions" json:"permissions"`
+	CreatedAt   time.Time    `bson:"createdAt" json:"createdAt"`
+	ExpiresAt   *time.Time   `bson:"expiresAt,omitempty" json:"expiresAt,omitempty"`
+	LastUsedAt  *time.Time   `bson:"lastUsedAt,omitempty" json:"lastUsedAt,omitempty"`
+	RevokedAt   *time.Time   `bson:"revokedAt,omitempty" json:"revokedAt,omitempty"`
+}
+
+// NewAPIKey issues a key for the user and returns it along with the key to
+// hand out, which can't be recovered later. Keys without expiresAt don't
+// expire.
+func NewAPIKey(userId, name string, permissions []Permission, expiresAt *time.Time, now time.Time) (*APIKey, string, error) {
+	id, err := randomString(12)
+	if err != nil {
+		return nil, "", err
+	}
+	secret, err := randomString(32)
+	if err != nil {
+		return nil, "", err
+	}
+
+	key := APIKeyPrefix + id + "." + secret
+	return &APIKey{
+		Id:          id,
+		UserId:      userId,
+		Name:        name,
+		KeyHash:     HashToken(key),
+		Permissions: permissions,
+		CreatedAt:   now,
+		ExpiresAt:   expiresAt,
+	}, key, nil
+}
+
+// IsAPIKey reports whether a bearer token is an API key rather than an
+// access token.
+func IsAPIKey(token string) bool {
+	return strings.HasPrefix(token, APIKeyPrefix)
+}
+
+// APIKeyId returns the id of the key, to look it up by.
+func APIKeyId(key string) (string, error) {
+	id, secret, ok := strings.Cut(strings.TrimPrefix(key, APIKeyPrefix), ".")
+	if !IsAPIKey(key) || !ok || id == "" || secret == "" {
+		return "", ErrInvalidAPIKey
+	}
+	return id, nil
+}
+
+func (k *APIKey) Matches(key string) bool {
+	return subtle.ConstantTimeCompare([]byte(HashToken(key)), []byte(k.KeyHash)) == 1
+}
+
+func (k *APIKey) Active(now time.Time) bool {
+	return k.RevokedAt == nil && (k.ExpiresAt == nil || now.Before(*k.ExpiresAt))
+}
+
+func (k *APIKey) Allows(permission Permission) bool {
+	return slices.Contains(k.Permissions, permission)
+}
+
+// NeedsTouch reports whether the last use of the key is older than
+// APIKeyTouchInterval and should be recorded again.
+func (k *APIKey) NeedsTouch(now time.Time) bool {
+	return k.LastUsedAt == nil || now.Sub(*k.LastUsedAt) >= APIKeyTouchInterval
+}
@@ -0,0 +1,59 @@
+package auth
+
+import (
+	"testing"
+	"time"
+)
+
+func TestNewAPIKey(t *testing.T) {
+	now := time.Date(2026, time.January, 1, 0, 0, 0, 0, time.UTC)
+	apiKey, key, err := NewAPIKey("user", "reporting", []Permission{PermBookingsRead}, nil, now)
+	if err != nil {
+		t.Fatalf("NewAPIKey() error = %v", err)
+	}
+	if !IsAPIKey(key) {
+		t.Errorf("IsAPIKey(%q) = false", key)
+	}
+	if id, err := APIKeyId(key); err != nil || id != apiKey.Id {
+		t.Fatalf("APIKeyId() = %v, %v, want %v", id, err, apiKey.Id)
+	}
+	if !apiKey.Matches(key) {
+		t.Error("APIKey.Matches() = false for its key")
+	}
+	if apiKey.Matches(key + "x") {
+		t.Error("APIKey.Matches() = true for another key")
+	}
+	if !apiKey.Allows(PermBookingsRead) || apiKey.Allows(PermBookingsWrite) {
+		t.Errorf("APIKey.Allows() doesn't follow permissions %v", apiKey.Permissions)
+	}
+}
+
+func TestAPIKey_Active(t *testing.T) {
+	now := time.Date(2026, time.January, 1, 0, 0, 0, 0, time.UTC)
+	past, future := now.Add(-time.Minute), now.Add(time.Hour)
+	tests := []struct {
+		name   string
+		apiKey APIKey
+		want   bool
+	}{
+		{name: "No expiry", apiKey: APIKey{}, want: true},
+		{name: "Not expired", apiKey: APIKey{ExpiresAt: &future}, want: true},
+		{name: "Expired", apiKey: APIKey{ExpiresAt: &past}, want: false},
+		{name: "Revoked", apiKey: APIKey{RevokedAt: &past}, want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.apiKey.Active(now); got != tt.want {
+				t.Errorf("APIKey.Active() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAPIKeyId(t *testing.T) {
+	for _, key := range []string{"", "id.secret", "hsk_", "hsk_nodot", "hsk_.secret", "hsk_id."} {
+		if _, err := APIKeyId(key); err != ErrInvalidAPIKey {
+			t.Errorf("APIKeyId(%q) error = %v, want %v", key, err, ErrInvalidAPIKey)
+		}
+	}
+}
@@ -0,0 +1,194 @@
+package auth
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// MinRSAKeyBits is the smallest RSA key accepted for signing tokens.
+const MinRSAKeyBits = 2048
+
+var ErrNoSigningKeys = errors.New("no token signing keys configured")
+
+// KeySet holds the keys tokens are signed with, by key id. New tokens are
+// signed with the active key; tokens signed with any key of the set are
+// accepted, so a key can be rotated out once its tokens have expired.
+type KeySet struct {
+	active string
+	keys   map[string]crypto.Signer
+}
+
+// NewKeySet builds a key set from RSA and Ed25519 private keys. An empty
+// activeKid picks the last key id in lexical order, so naming keys after the
+// date they were created activates the newest one.
+func NewKeySet(activeKid string, keys map[string]crypto.Signer) (*KeySet, error) {
+	if len(keys) == 0 {
+		return nil, ErrNoSigningKeys
+	}
+	for kid, key := range keys {
+		if err := checkSigningKey(key); err != nil {
+			return nil, fmt.Errorf("key %q: %w", kid, err)
+		}
+	}
+
+	if activeKid == "" {
+		kids := make([]string, 0, len(keys))
+		for kid := range keys {
+			kids = append(kids, kid)
+		}
+		slices.Sort(kids)
+		activeKid = kids[len(kids)-1]
+	}
+	if _, ok := keys[activeKid]; !ok {
+		return nil, fmt.Errorf("active key %q is not configured", activeKid)
+	}
+
+	return &KeySet{
+		active: activeKid,
+		keys:   keys,
+	}, nil
+}
+
+// LoadKeySet reads PKCS #8 PEM private keys from dir. The key id of each key
+// is its file name without the .pem extension.
+func LoadKeySet(dir, activeKid string) (*KeySet, error) {
+	if dir == "" {
+		return nil, ErrNoSigningKeys
+	}
+	paths, err := filepath.Glob(filepath.Join(dir, "*.pem"))
+	if err != nil {
+		return nil, err
+	}
+
+	keys := map[string]crypto.Signer{}
+	for _, path := range paths {
+		key, err := readPrivateKey(path)
+		if err != nil {
+			return nil, err
+		}
+		keys[strings.TrimSuffix(filepath.Base(path), ".pem")] = key
+	}
+	return NewKeySet(activeKid, keys)
+}
+
+func readPrivateKey(path string) (crypto.Signer, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("%s: no PEM data", path)
+	}
+	key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	signer, ok := key.(crypto.Signer)
+	if !ok {
+		return nil, fmt.Errorf("%s: unsupported key type %T", path, key)
+	}
+	return signer, nil
+}
+
+func checkSigningKey(key crypto.Signer) error {
+	switch key := key.(type) {
+	case *rsa.PrivateKey:
+		if key.N.BitLen() < MinRSAKeyBits {
+			return fmt.Errorf("RSA key must be at least %d bits", MinRSAKeyBits)
+		}
+		return nil
+	case ed25519.PrivateKey:
+		return nil
+	}
+	return fmt.Errorf("unsupported key type %T, want RSA or Ed25519", key)
+}
+
+func signingMethod(key crypto.Signer) jwt.SigningMethod {
+	if _, ok := key.(ed25519.PrivateKey); ok {
+		return jwt.SigningMethodEdDSA
+	}
+	return jwt.SigningMethodRS256
+}
+
+// JWK is the public half of a signing key in JSON Web Key format.
+type JWK struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	// RSA
+	N string `json:"n,omitempty"`
+	E string `json:"e,omitempty"`
+	// Ed25519
+	Crv string `json:"crv,omitempty"`
+	X   string `json:"x,omitempty"`
+}
+
+type JWKSet struct {
+	Keys []JWK `json:"keys"`
+}
+
+// JWKS returns the public keys of the set, ordered by key id.
+func (ks *KeySet) JWKS() JWKSet {
+	set := JWKSet{Keys: []JWK{}}
+	for kid, key := range ks.keys {
+		jwk := JWK{Kid: kid, Use: "sig", Alg: signingMethod(key).Alg()}
+		switch public := key.Public().(type) {
+		case *rsa.PublicKey:
+			jwk.Kty = "RSA"
+			jwk.N = base64.RawURLEncoding.EncodeToString(public.N.Bytes())
+			jwk.E = base64.RawURLEncoding.EncodeToString(big.NewInt(int64(public.E)).Bytes())
+		case ed25519.PublicKey:
+			jwk.Kty = "OKP"
+			jwk.Crv = "Ed25519"
+			jwk.X = base64.RawURLEncoding.EncodeToString(public)
+		}
+		set.Keys = append(set.Keys, jwk)
+	}
+	slices.SortFunc(set.Keys, func(a, b JWK) int { return strings.Compare(a.Kid, b.Kid) })
+	return set
+}
+
+// PublicKey decodes an RSA or Ed25519 key, e.g. of an identity provider.
+func (k JWK) PublicKey() (crypto.PublicKey, error) {
+	switch k.Kty {
+	case "RSA":
+		n, err := base64.RawURLEncoding.DecodeString(k.N)
+		if err != nil {
+			return nil, fmt.Errorf("key %s: %w", k.Kid, err)
+		}
+		e, err := base64.RawURLEncoding.DecodeString(k.E)
+		if err != nil {
+			return nil, fmt.Errorf("key %s: %w", k.Kid, err)
+		}
+		key := &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(new(big.Int).SetBytes(e).Int64())}
+		if key.N.BitLen() < MinRSAKeyBits {
+			return nil, fmt.Errorf("key %s: RSA key of %d bits, want at least %d", k.Kid, key.N.BitLen(), MinRSAKeyBits)
+		}
+		return key, nil
+	case "OKP":
+		x, err := base64.RawURLEncoding.DecodeString(k.X)
+		if err != nil {
+			return nil, fmt.Errorf("key %s: %w", k.Kid, err)
+		}
+		if k.Crv != "Ed25519" || len(x) != ed25519.PublicKeySize {
+			return nil, fmt.Errorf("key %s: unsupported curve %q", k.Kid, k.Crv)
+		}
+		return ed25519.PublicKey(x), nil
+	}
+	return nil, fmt.Errorf("key %s: unsupported key type %q", k.Kid, k.Kty)
+}
@@ -0,0 +1,205 @@
+package auth
+
+import (
+	"strings"
+	"sync"
+	"time"
+)
+
+// LockoutPolicy decides how failed logins slow down further attempts. After
+// FreeAttempts failures every attempt has to wait BaseDelay, doubling with
+// each further failure up to MaxDelay. After LockoutAfter failures the key
+// is locked for LockoutDuration. Failures are forgotten once there was none
+// for ResetAfter.
+type LockoutPolicy struct {
+	FreeAttempts    int
+	BaseDelay       time.Duration
+	MaxDelay        time.Duration
+	LockoutAfter    int
+	LockoutDuration time.Duration
+	ResetAfter      time.Duration
+}
+
+var (
+	// DefaultAccountPolicy limits guesses at the password of one account.
+	DefaultAccountPolicy = LockoutPolicy{
+		FreeAttempts:    3,
+		BaseDelay:       time.Second,
+		MaxDelay:        time.Minute,
+		LockoutAfter:    10,
+		LockoutDuration: 15 * time.Minute,
+		ResetAfter:      time.Hour,
+	}
+	// DefaultIPPolicy limits guesses from one address, across accounts. It is
+	// looser than the account policy since many users may share an address.
+	DefaultIPPolicy = LockoutPolicy{
+		FreeAttempts:    20,
+		BaseDelay:       time.Second,
+		MaxDelay:        time.Minute,
+		LockoutAfter:    100,
+		LockoutDuration: 15 * time.Minute,
+		ResetAfter:      time.Hour,
+	}
+)
+
+// pruneThreshold is the number of tracked keys above which stale ones are
+// dropped.
+const pruneThreshold = 10_000
+
+type attempts struct {
+	failures    int
+	lastFailure time.Time
+	lockedUntil time.Time
+}
+
+// FailureTracker counts failed attempts per key. It keeps its state in
+// memory, so every instance of the API tracks the attempts it saw.
+type FailureTracker struct {
+	mu      sync.Mutex
+	policy  LockoutPolicy
+	entries map[string]*attempts
+}
+
+func NewFailureTracker(policy LockoutPolicy) *FailureTracker {
+	return &FailureTracker{
+		policy:  policy,
+		entries: map[string]*attempts{},
+	}
+}
+
+// Wait returns how long key has to wait before its next attempt, zero if it
+// may try now.
+func (t *FailureTracker) Wait(key string, now time.Time) time.Duration {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	entry := t.entry(key, now)
+	if entry == nil {
+		return 0
+	}
+	if now.Before(entry.lockedUntil) {
+		return entry.lockedUntil.Sub(now)
+	}
+	if next := entry.lastFailure.Add(t.delay(entry.failures)); now.Before(next) {
+		return next.Sub(now)
+	}
+	return 0
+}
+
+// Locked reports whether key is locked out, rather than only slowed down.
+func (t *FailureTracker) Locked(key string, now time.Time) bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	entry := t.entry(key, now)
+	return entry != nil && now.Before(entry.lockedUntil)
+}
+
+func (t *FailureTracker) Failure(key string, now time.Time) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	entry := t.entry(key, now)
+	if entry == nil {
+		if len(t.entries) >= pruneThreshold {
+			t.prune(now)
+		}
+		entry = &attempts{}
+		t.entries[key] = entry
+	}
+	entry.failures++
+	entry.lastFailure = now
+	if entry.failures >= t.policy.LockoutAfter {
+		entry.lockedUntil = now.Add(t.policy.LockoutDuration)
+	}
+}
+
+// Reset forgets the failures of key, after a successful attempt or when an
+// admin unlocks it.
+func (t *FailureTracker) Reset(key string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	delete(t.entries, key)
+}
+
+// entry returns the attempts of key, or nil if there are none worth
+// remembering.
+func (t *FailureTracker) entry(key string, now time.Time) *attempts {
+	entry, ok := t.entries[key]
+	if !ok {
+		return nil
+	}
+	if t.stale(entry, now) {
+		delete(t.entries, key)
+		return nil
+	}
+	return entry
+}
+
+func (t *FailureTracker) stale(entry *attempts, now time.Time) bool {
+	return !now.Before(entry.lockedUntil) && now.Sub(entry.lastFailure) >= t.policy.ResetAfter
+}
+
+func (t *FailureTracker) prune(now time.Time) {
+	for key, entry := range t.entries {
+		if t.stale(entry, now) {
+			delete(t.entries, key)
+		}
+	}
+}
+
+func (t *FailureTracker) delay(failures int) time.Duration {
+	if failures < t.policy.FreeAttempts {
+		return 0
+	}
+	delay := t.policy.BaseDelay
+	for i := t.policy.FreeAttempts; i < failures && delay < t.policy.MaxDelay; i++ {
+		delay *= 2
+	}
+	return min(delay, t.policy.MaxDelay)
+}
+
+// LoginGuard throttles password logins per account and per client address.
+type LoginGuard struct {
+	accounts *FailureTracker
+	ips      *FailureTracker
+}
+
+func NewLoginGuard(accountPolicy, ipPolicy LockoutPolicy) *LoginGuard {
+	return &LoginGuard{
+		accounts: NewFailureTracker(accountPolicy),
+		ips:      NewFailureTracker(ipPolicy),
+	}
+}
+
+// Wait returns how long a login to the account from ip has to wait, zero if
+// it may go ahead.
+func (g *LoginGuard) Wait(email, ip string, now time.Time) time.Duration {
+	return max(g.accounts.Wait(accountKey(email), now), g.ips.Wait(ip, now))
+}
+
+// Failure records a failed login. Unknown accounts count like known ones, so
+// throttling doesn't tell which accounts exist.
+func (g *LoginGuard) Failure(email, ip string, now time.Time) {
+	g.accounts.Failure(accountKey(email), now)
+	g.ips.Failure(ip, now)
+}
+
+// Success forgets the failures of the account. Those of the address stay, or
+// an attacker could reset them by logging into an account of their own.
+func (g *LoginGuard) Success(email string) {
+	g.accounts.Reset(accountKey(email))
+}
+
+// Unlock lifts the lockout of an account.
+func (g *LoginGuard) Unlock(email string) {
+	g.accounts.Reset(accountKey(email))
+}
+
+func (g *LoginGuard) Locked(email string, now time.Time) bool {
+	return g.accounts.Locked(accountKey(email), now)
+}
+
+func accountKey(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
+}
@@ -0,0 +1,94 @@
+package auth
+
+import (
+	"testing"
+	"time"
+)
+
+func TestFailureTracker(t *testing.T) {
+	policy := LockoutPolicy{
+		FreeAttempts:    2,
+		BaseDelay:       time.Second,
+		MaxDelay:        4 * time.Second,
+		LockoutAfter:    6,
+		LockoutDuration: time.Minute,
+		ResetAfter:      time.Hour,
+	}
+	now := time.Date(2026, 10, 1, 12, 0, 0, 0, time.UTC)
+	tracker := NewFailureTracker(policy)
+
+	wantWaits := []time.Duration{0, time.Second, 2 * time.Second, 4 * time.Second, 4 * time.Second}
+	for i, want := range wantWaits {
+		tracker.Failure("key", now)
+		if got := tracker.Wait("key", now); got != want {
+			t.Fatalf("after %d failures: wait %v, want %v", i+1, got, want)
+		}
+	}
+	if tracker.Locked("key", now) {
+		t.Fatal("locked before LockoutAfter failures")
+	}
+	if got := tracker.Wait("key", now.Add(4*time.Second)); got != 0 {
+		t.Fatalf("wait after backoff passed: %v", got)
+	}
+
+	tracker.Failure("key", now)
+	if !tracker.Locked("key", now) {
+		t.Fatal("not locked after LockoutAfter failures")
+	}
+	if got := tracker.Wait("key", now); got != time.Minute {
+		t.Fatalf("wait while locked: %v, want %v", got, time.Minute)
+	}
+	if got := tracker.Wait("other", now); got != 0 {
+		t.Fatalf("other key has to wait %v", got)
+	}
+
+	tracker.Reset("key")
+	if tracker.Locked("key", now) || tracker.Wait("key", now) != 0 {
+		t.Fatal("still throttled after reset")
+	}
+
+	tracker.Failure("key", now)
+	tracker.Failure("key", now)
+	if got := tracker.Wait("key", now.Add(policy.ResetAfter)); got != 0 {
+		t.Fatalf("failures not forgotten after ResetAfter: wait %v", got)
+	}
+}
+
+func TestLoginGuard(t *testing.T) {
+	account := LockoutPolicy{FreeAttempts: 1, BaseDelay: time.Second, MaxDelay: time.Second, LockoutAfter: 3, LockoutDuration: time.Minute, ResetAfter: time.Hour}
+	ip := LockoutPolicy{FreeAttempts: 3, BaseDelay: time.Second, MaxDelay: time.Second, LockoutAfter: 10, LockoutDuration: time.Minute, ResetAfter: time.Hour}
+	now := time.Date(2026, 10, 1, 12, 0, 0, 0, time.UTC)
+	guard := NewLoginGuard(account, ip)
+
+	guard.Failure("Guest@Example.com", "10.0.0.1", now)
+	if got := guard.Wait("guest@example.com ", "10.0.0.2", now); got != time.Second {
+		t.Fatalf("account wait ignores case of the email: %v", got)
+	}
+
+	guard.Success("guest@example.com")
+	if got := guard.Wait("guest@example.com", "10.0.0.2", now); got != 0 {
+		t.Fatalf("account still throttled after success: %v", got)
+	}
+
+	// Failures against different accounts add up for the address.
+	guard.Failure("a@example.com", "10.0.0.1", now)
+	guard.Failure("b@example.com", "10.0.0.1", now)
+	if got := guard.Wait("c@example.com", "10.0.0.1", now); got != time.Second {
+		t.Fatalf("address wait %v, want %v", got, time.Second)
+	}
+	guard.Success("c@example.com")
+	if got := guard.Wait("c@example.com", "10.0.0.1", now); got != time.Second {
+		t.Fatalf("success of an account reset the address: %v", got)
+	}
+
+	for range account.LockoutAfter {
+		guard.Failure("locked@example.com", "10.0.0.3", now)
+	}
+	if !guard.Locked("locked@example.com", now) {
+		t.Fatal("account not locked")
+	}
+	guard.Unlock("LOCKED@example.com")
+	if guard.Locked("locked@example.com", now) {
+		t.Fatal("account still locked after unlock")
+	}
+}
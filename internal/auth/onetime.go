@@ -0,0 +1,57 @@
+package auth
+
+import (
+	"time"
+)
+
+// Purpose is what a one-time token may be used for. A token only works for
+// the purpose it was issued for.
+type Purpose string
+
+const (
+	PurposePasswordReset     Purpose = "password_reset"
+	PurposeEmailVerification Purpose = "email_verification"
+	// PurposeLoginChallenge tokens stand for a correct password while the
+	// second factor of the login is outstanding.
+	PurposeLoginChallenge Purpose = "login_challenge"
+)
+
+const (
+	PasswordResetTTL     = time.Hour
+	EmailVerificationTTL = 48 * time.Hour
+	LoginChallengeTTL    = 5 * time.Minute
+)
+
+// OneTimeToken is a token mailed to a user that proves they control the
+// address, e.g. to reset their password. Only the hash of the token is
+// stored, and it identifies the token.
+type OneTimeToken struct {
+	TokenHash string     `bson:"_id" json:"-"`
+	UserId    string     `bson:"userId" json:"userId"`
+	Purpose   Purpose    `bson:"purpose" json:"purpose"`
+	CreatedAt time.Time  `bson:"createdAt" json:"createdAt"`
+	ExpiresAt time.Time  `bson:"expiresAt" json:"expiresAt"`
+	UsedAt    *time.Time `bson:"usedAt,omitempty" json:"usedAt,omitempty"`
+}
+
+// NewOneTimeToken issues a token for the user that is valid for ttl and
+// returns it along with the token to send.
+func NewOneTimeToken(userId string, purpose Purpose, ttl time.Duration, now time.Time) (*OneTimeToken, string, error) {
+	token, err := randomString(32)
+	if err != nil {
+		return nil, "", err
+	}
+
+	return &OneTimeToken{
+		TokenHash: HashToken(token),
+		UserId:    userId,
+		Purpose:   purpose,
+		CreatedAt: now,
+		ExpiresAt: now.Add(ttl),
+	}, token, nil
+}
+
+// Usable reports whether the token has neither been used nor expired.
+func (t *OneTimeToken) Usable(now time.Time) bool {
+	return t.UsedAt == nil && now.Before(t.ExpiresAt)
+}
@@ -0,0 +1,36 @@
+package auth
+
+import (
+	"testing"
+	"time"
+)
+
+func TestOneTimeToken_Usable(t *testing.T) {
+	now := time.Now()
+	token, secret, err := NewOneTimeToken("user", PurposePasswordReset, time.Hour, now)
+	if err != nil {
+		t.Fatalf("NewOneTimeToken() error = %v", err)
+	}
+	if token.TokenHash != HashToken(secret) {
+		t.Error("NewOneTimeToken() does not store the hash of the token")
+	}
+
+	usedAt := now
+	tests := []struct {
+		name  string
+		token OneTimeToken
+		now   time.Time
+		want  bool
+	}{
+		{name: "Fresh", token: *token, now: now, want: true},
+		{name: "Expired", token: *token, now: now.Add(time.Hour), want: false},
+		{name: "Used", token: func() OneTimeToken { t := *token; t.UsedAt = &usedAt; return t }(), now: now, want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.token.Usable(tt.now); got != tt.want {
+				t.Errorf("OneTimeToken.Usable() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,46 @@
+package auth
+
+import "slices"
+
+// Role decides what a user may do. Roles are ordered: every role may do
+// everything the roles below it may.
+type Role string
+
+const (
+	RoleGuest        Role = "guest"
+	RoleHotelStaff   Role = "hotel_staff"
+	RoleHotelManager Role = "hotel_manager"
+	RoleAdmin        Role = "admin"
+)
+
+var roleRanks = map[Role]int{
+	RoleGuest:        1,
+	RoleHotelStaff:   2,
+	RoleHotelManager: 3,
+	RoleAdmin:        4,
+}
+
+func (r Role) Valid() bool {
+	_, ok := roleRanks[r]
+	return ok
+}
+
+// AtLeast reports whether r may do everything required may. Users created
+// before roles existed have no role and count as guests.
+func (r Role) AtLeast(required Role) bool {
+	rank, ok := roleRanks[r]
+	if !ok {
+		rank = roleRanks[RoleGuest]
+	}
+	return rank >= roleRanks[required]
+}
+
+// CanManageHotel reports whether a user with role, assigned to hotelIds, may
+// manage hotelId. Admins manage every hotel, hotel staff and managers only the
+// hotels they are assigned to.
+func CanManageHotel[T comparable](role Role, hotelIds []T, hotelId T) bool {
+	if role.AtLeast(RoleAdmin) {
+		return true
+	}
+	return role.AtLeast(RoleHotelStaff) && slices.Contains(hotelIds, hotelId)
+}
@@ -0,0 +1,48 @@
+package auth
+
+import "testing"
+
+func TestRole_AtLeast(t *testing.T) {
+	tests := []struct {
+		name     string
+		role     Role
+		required Role
+		want     bool
+	}{
+		{name: "Same role", role: RoleHotelStaff, required: RoleHotelStaff, want: true},
+		{name: "Higher role", role: RoleAdmin, required: RoleHotelManager, want: true},
+		{name: "Lower role", role: RoleHotelStaff, required: RoleHotelManager, want: false},
+		{name: "Guest is not staff", role: RoleGuest, required: RoleHotelStaff, want: false},
+		{name: "No role counts as guest", role: "", required: RoleGuest, want: true},
+		{name: "Unknown role counts as guest", role: "owner", required: RoleHotelStaff, want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.role.AtLeast(tt.required); got != tt.want {
+				t.Errorf("Role.AtLeast() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCanManageHotel(t *testing.T) {
+	tests := []struct {
+		name     string
+		role     Role
+		hotelIds []int
+		hotelId  int
+		want     bool
+	}{
+		{name: "Admin manages every hotel", role: RoleAdmin, hotelId: 1, want: true},
+		{name: "Staff of the hotel", role: RoleHotelStaff, hotelIds: []int{1, 2}, hotelId: 2, want: true},
+		{name: "Manager of another hotel", role: RoleHotelManager, hotelIds: []int{1}, hotelId: 2, want: false},
+		{name: "Guest assigned to the hotel", role: RoleGuest, hotelIds: []int{1}, hotelId: 1, want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := CanManageHotel(tt.role, tt.hotelIds, tt.hotelId); got != tt.want {
+				t.Errorf("CanManageHotel() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,98 @@
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"strings"
+	"time"
+)
+
+const (
+	AccessTokenTTL  = 15 * time.Minute
+	RefreshTokenTTL = 30 * 24 * time.Hour
+)
+
+var ErrInvalidRefreshToken = errors.New("invalid refresh token")
+
+// Session is a login of a user. Access tokens name the session they belong to,
+// so revoking the session ends them as well. The session is renewed with a
+// refresh token that changes on every use; only its hash is stored.
+type Session struct {
+	Id        string     `bson:"_id" json:"id"`
+	UserId    string     `bson:"userId" json:"userId"`
+	TokenHash string     `bson:"tokenHash" json:"-"`
+	CreatedAt time.Time  `bson:"createdAt" json:"createdAt"`
+	ExpiresAt time.Time  `bson:"expiresAt" json:"expiresAt"`
+	RevokedAt *time.Time `bson:"revokedAt,omitempty" json:"revokedAt,omitempty"`
+}
+
+// NewSession starts a session for the user and returns its first refresh
+// token.
+func NewSession(userId string, now time.Time) (*Session, string, error) {
+	id, err := randomString(16)
+	if err != nil {
+		return nil, "", err
+	}
+
+	session := &Session{
+		Id:        id,
+		UserId:    userId,
+		CreatedAt: now,
+	}
+	token, err := session.Rotate(now)
+	if err != nil {
+		return nil, "", err
+	}
+	return session, token, nil
+}
+
+// Rotate replaces the refresh token of the session and extends the session.
+// It returns the new token; the previous one stops matching.
+func (s *Session) Rotate(now time.Time) (string, error) {
+	secret, err := randomString(32)
+	if err != nil {
+		return "", err
+	}
+
+	token := s.Id + "." + secret
+	s.TokenHash = HashToken(token)
+	s.ExpiresAt = now.Add(RefreshTokenTTL)
+	return token, nil
+}
+
+// Matches reports whether token is the current refresh token of the session.
+func (s *Session) Matches(token string) bool {
+	return subtle.ConstantTimeCompare([]byte(HashToken(token)), []byte(s.TokenHash)) == 1
+}
+
+func (s *Session) Active(now time.Time) bool {
+	return s.RevokedAt == nil && now.Before(s.ExpiresAt)
+}
+
+// SessionId returns the id of the session a refresh token belongs to.
+func SessionId(refreshToken string) (string, error) {
+	id, secret, ok := strings.Cut(refreshToken, ".")
+	if !ok || id == "" || secret == "" {
+		return "", ErrInvalidRefreshToken
+	}
+	return id, nil
+}
+
+// HashToken hashes a token for storage. Tokens are long random strings, so a
+// plain SHA-256 is enough.
+func HashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+func randomString(size int) (string, error) {
+	b := make([]byte, size)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
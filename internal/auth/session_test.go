@@ -0,0 +1,64 @@
+package auth
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSession_Rotate(t *testing.T) {
+	now := time.Date(2026, time.January, 1, 0, 0, 0, 0, time.UTC)
+	session, first, err := NewSession("user", now)
+	if err != nil {
+		t.Fatalf("NewSession() error = %v", err)
+	}
+	if id, err := SessionId(first); err != nil || id != session.Id {
+		t.Fatalf("SessionId() = %v, %v, want %v", id, err, session.Id)
+	}
+	if !session.Matches(first) {
+		t.Fatal("Session.Matches() = false for the first token")
+	}
+
+	later := now.Add(time.Hour)
+	second, err := session.Rotate(later)
+	if err != nil {
+		t.Fatalf("Session.Rotate() error = %v", err)
+	}
+	if session.Matches(first) {
+		t.Error("Session.Matches() = true for a rotated token")
+	}
+	if !session.Matches(second) {
+		t.Error("Session.Matches() = false for the new token")
+	}
+	if want := later.Add(RefreshTokenTTL); !session.ExpiresAt.Equal(want) {
+		t.Errorf("Session.ExpiresAt = %v, want %v", session.ExpiresAt, want)
+	}
+}
+
+func TestSession_Active(t *testing.T) {
+	now := time.Date(2026, time.January, 1, 0, 0, 0, 0, time.UTC)
+	revokedAt := now.Add(-time.Minute)
+	tests := []struct {
+		name    string
+		session Session
+		want    bool
+	}{
+		{name: "Active", session: Session{ExpiresAt: now.Add(time.Hour)}, want: true},
+		{name: "Expired", session: Session{ExpiresAt: now}, want: false},
+		{name: "Revoked", session: Session{ExpiresAt: now.Add(time.Hour), RevokedAt: &revokedAt}, want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.session.Active(now); got != tt.want {
+				t.Errorf("Session.Active() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSessionId(t *testing.T) {
+	for _, token := range []string{"", "nodot", ".secret", "id."} {
+		if _, err := SessionId(token); err != ErrInvalidRefreshToken {
+			t.Errorf("SessionId(%q) error = %v, want ErrInvalidRefreshToken", token, err)
+		}
+	}
+}
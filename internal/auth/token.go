@@ -0,0 +1,109 @@
+package auth
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const (
+	DefaultIssuer   = "hotel-system"
+	DefaultAudience = "hotel-system-api"
+)
+
+var ErrInvalidToken = errors.New("invalid access token")
+
+// Claims are the claims of an access token. The subject is the user id.
+type Claims struct {
+	jwt.RegisteredClaims
+	Email     string `json:"email"`
+	Role      Role   `json:"role"`
+	SessionId string `json:"sid"`
+}
+
+// Issuer signs and verifies access tokens.
+type Issuer struct {
+	keys     *KeySet
+	issuer   string
+	audience string
+}
+
+func NewIssuer(keys *KeySet, issuer, audience string) (*Issuer, error) {
+	if keys == nil {
+		return nil, ErrNoSigningKeys
+	}
+	return &Issuer{
+		keys:     keys,
+		issuer:   issuer,
+		audience: audience,
+	}, nil
+}
+
+// Issue signs an access token for the user's session with the active key.
+func (i *Issuer) Issue(userId, email string, role Role, sessionId string, now time.Time) (string, error) {
+	jti, err := randomString(16)
+	if err != nil {
+		return "", err
+	}
+
+	claims := Claims{
+		RegisteredClaims: jwt.RegisteredClaims{
+			Issuer:    i.issuer,
+			Subject:   userId,
+			Audience:  jwt.ClaimStrings{i.audience},
+			ExpiresAt: jwt.NewNumericDate(now.Add(AccessTokenTTL)),
+			NotBefore: jwt.NewNumericDate(now),
+			IssuedAt:  jwt.NewNumericDate(now),
+			ID:        jti,
+		},
+		Email:     email,
+		Role:      role,
+		SessionId: sessionId,
+	}
+
+	key := i.keys.keys[i.keys.active]
+	token := jwt.NewWithClaims(signingMethod(key), claims)
+	token.Header["kid"] = i.keys.active
+	return token.SignedString(key)
+}
+
+// Parse verifies the signature, lifetime, issuer and audience of an access
+// token and returns its claims.
+func (i *Issuer) Parse(tokenStr string) (*Claims, error) {
+	claims := &Claims{}
+	_, err := jwt.ParseWithClaims(tokenStr, claims, i.verificationKey,
+		jwt.WithValidMethods([]string{jwt.SigningMethodRS256.Alg(), jwt.SigningMethodEdDSA.Alg()}),
+		jwt.WithIssuer(i.issuer),
+		jwt.WithAudience(i.audience),
+		jwt.WithExpirationRequired(),
+		jwt.WithIssuedAt(),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidToken, err)
+	}
+	if claims.Subject == "" || claims.SessionId == "" {
+		return nil, ErrInvalidToken
+	}
+	return claims, nil
+}
+
+// verificationKey picks the public key named by the kid header. The algorithm
+// has to be the one the key signs with, so an RSA key can't be used to check
+// an EdDSA signature or the other way round.
+func (i *Issuer) verificationKey(token *jwt.Token) (any, error) {
+	kid, _ := token.Header["kid"].(string)
+	key, ok := i.keys.keys[kid]
+	if !ok {
+		return nil, fmt.Errorf("unknown key id %q", kid)
+	}
+	if token.Method.Alg() != signingMethod(key).Alg() {
+		return nil, fmt.Errorf("key %q does not sign with %s", kid, token.Method.Alg())
+	}
+	return key.Public(), nil
+}
+
+func (i *Issuer) JWKS() JWKSet {
+	return i.keys.JWKS()
+}
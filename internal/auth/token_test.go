@@ -0,0 +1,267 @@
+package auth
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+func newEd25519Key(t *testing.T) ed25519.PrivateKey {
+	t.Helper()
+	_, key, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return key
+}
+
+func newRSAKey(t *testing.T) *rsa.PrivateKey {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, MinRSAKeyBits)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return key
+}
+
+func newIssuer(t *testing.T, activeKid string, keys map[string]crypto.Signer) *Issuer {
+	t.Helper()
+	keySet, err := NewKeySet(activeKid, keys)
+	if err != nil {
+		t.Fatalf("NewKeySet() error = %v", err)
+	}
+	issuer, err := NewIssuer(keySet, DefaultIssuer, DefaultAudience)
+	if err != nil {
+		t.Fatalf("NewIssuer() error = %v", err)
+	}
+	return issuer
+}
+
+func TestIssuer_Issue(t *testing.T) {
+	tests := []struct {
+		name string
+		key  crypto.Signer
+		alg  string
+	}{
+		{name: "EdDSA", key: newEd25519Key(t), alg: "EdDSA"},
+		{name: "RS256", key: newRSAKey(t), alg: "RS256"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			issuer := newIssuer(t, "", map[string]crypto.Signer{"2026-01": tt.key})
+			now := time.Now()
+			tokenStr, err := issuer.Issue("user", "guest@hotel.com", RoleGuest, "session", now)
+			if err != nil {
+				t.Fatalf("Issuer.Issue() error = %v", err)
+			}
+
+			claims, err := issuer.Parse(tokenStr)
+			if err != nil {
+				t.Fatalf("Issuer.Parse() error = %v", err)
+			}
+			if claims.Subject != "user" || claims.SessionId != "session" || claims.Role != RoleGuest {
+				t.Errorf("Issuer.Parse() = %+v", claims)
+			}
+			if claims.ID == "" || claims.IssuedAt == nil || claims.NotBefore == nil {
+				t.Errorf("Issuer.Parse() is missing registered claims: %+v", claims.RegisteredClaims)
+			}
+			if want := now.Add(AccessTokenTTL).Truncate(time.Second); !claims.ExpiresAt.Time.Equal(want) {
+				t.Errorf("exp = %v, want %v", claims.ExpiresAt.Time, want)
+			}
+
+			token, _, err := jwt.NewParser().ParseUnverified(tokenStr, &Claims{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if token.Header["kid"] != "2026-01" || token.Header["alg"] != tt.alg {
+				t.Errorf("header = %v, want kid 2026-01 and alg %s", token.Header, tt.alg)
+			}
+		})
+	}
+}
+
+func TestIssuer_Parse_Rotation(t *testing.T) {
+	oldKey, newKey := newEd25519Key(t), newRSAKey(t)
+	before := newIssuer(t, "", map[string]crypto.Signer{"2026-01": oldKey})
+	after := newIssuer(t, "", map[string]crypto.Signer{"2026-01": oldKey, "2026-02": newKey})
+	retired := newIssuer(t, "", map[string]crypto.Signer{"2026-02": newKey})
+
+	tokenStr, err := before.Issue("user", "guest@hotel.com", RoleGuest, "session", time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := after.Parse(tokenStr); err != nil {
+		t.Errorf("token of the previous key rejected after rotation: %v", err)
+	}
+	if _, err := retired.Parse(tokenStr); !errors.Is(err, ErrInvalidToken) {
+		t.Errorf("token of a retired key: error = %v, want ErrInvalidToken", err)
+	}
+
+	tokenStr, err = after.Issue("user", "guest@hotel.com", RoleGuest, "session", time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+	token, _, _ := jwt.NewParser().ParseUnverified(tokenStr, &Claims{})
+	if token.Header["kid"] != "2026-02" {
+		t.Errorf("kid = %v, want the newest key 2026-02", token.Header["kid"])
+	}
+}
+
+func TestIssuer_Parse_Rejected(t *testing.T) {
+	key := newEd25519Key(t)
+	issuer := newIssuer(t, "", map[string]crypto.Signer{"k1": key})
+	now := time.Now()
+	valid := Claims{
+		RegisteredClaims: jwt.RegisteredClaims{
+			Issuer:    DefaultIssuer,
+			Subject:   "user",
+			Audience:  jwt.ClaimStrings{DefaultAudience},
+			ExpiresAt: jwt.NewNumericDate(now.Add(time.Minute)),
+			NotBefore: jwt.NewNumericDate(now),
+			IssuedAt:  jwt.NewNumericDate(now),
+			ID:        "jti",
+		},
+		SessionId: "session",
+	}
+	sign := func(method jwt.SigningMethod, signingKey any, kid string, claims Claims) string {
+		token := jwt.NewWithClaims(method, claims)
+		token.Header["kid"] = kid
+		tokenStr, err := token.SignedString(signingKey)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return tokenStr
+	}
+
+	tests := []struct {
+		name  string
+		token string
+	}{
+		{name: "Expired", token: sign(jwt.SigningMethodEdDSA, key, "k1", func() Claims {
+			c := valid
+			c.ExpiresAt = jwt.NewNumericDate(now.Add(-time.Minute))
+			return c
+		}())},
+		{name: "No expiry", token: sign(jwt.SigningMethodEdDSA, key, "k1", func() Claims {
+			c := valid
+			c.ExpiresAt = nil
+			return c
+		}())},
+		{name: "Not yet valid", token: sign(jwt.SigningMethodEdDSA, key, "k1", func() Claims {
+			c := valid
+			c.NotBefore = jwt.NewNumericDate(now.Add(time.Hour))
+			return c
+		}())},
+		{name: "Other audience", token: sign(jwt.SigningMethodEdDSA, key, "k1", func() Claims {
+			c := valid
+			c.Audience = jwt.ClaimStrings{"other-api"}
+			return c
+		}())},
+		{name: "Other issuer", token: sign(jwt.SigningMethodEdDSA, key, "k1", func() Claims {
+			c := valid
+			c.Issuer = "someone-else"
+			return c
+		}())},
+		{name: "Unknown key id", token: sign(jwt.SigningMethodEdDSA, key, "k2", valid)},
+		{name: "Signed by another key", token: sign(jwt.SigningMethodEdDSA, newEd25519Key(t), "k1", valid)},
+		{name: "HMAC with the public key", token: sign(jwt.SigningMethodHS256, []byte(key.Public().(ed25519.PublicKey)), "k1", valid)},
+		{name: "No session", token: sign(jwt.SigningMethodEdDSA, key, "k1", func() Claims {
+			c := valid
+			c.SessionId = ""
+			return c
+		}())},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := issuer.Parse(tt.token); !errors.Is(err, ErrInvalidToken) {
+				t.Errorf("Issuer.Parse() error = %v, want ErrInvalidToken", err)
+			}
+		})
+	}
+}
+
+func TestLoadKeySet(t *testing.T) {
+	dir := t.TempDir()
+	for kid, key := range map[string]crypto.Signer{"2026-01": newRSAKey(t), "2026-02": newEd25519Key(t)} {
+		der, err := x509.MarshalPKCS8PrivateKey(key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		data := pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der})
+		if err := os.WriteFile(filepath.Join(dir, kid+".pem"), data, 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	keySet, err := LoadKeySet(dir, "")
+	if err != nil {
+		t.Fatalf("LoadKeySet() error = %v", err)
+	}
+	if keySet.active != "2026-02" {
+		t.Errorf("active key = %q, want 2026-02", keySet.active)
+	}
+
+	jwks := keySet.JWKS()
+	if len(jwks.Keys) != 2 {
+		t.Fatalf("JWKS() has %d keys, want 2", len(jwks.Keys))
+	}
+	if k := jwks.Keys[0]; k.Kid != "2026-01" || k.Kty != "RSA" || k.Alg != "RS256" || k.N == "" || k.E != "AQAB" {
+		t.Errorf("JWKS() RSA key = %+v", k)
+	}
+	if k := jwks.Keys[1]; k.Kid != "2026-02" || k.Kty != "OKP" || k.Crv != "Ed25519" || k.Alg != "EdDSA" || k.X == "" {
+		t.Errorf("JWKS() Ed25519 key = %+v", k)
+	}
+
+	if _, err := LoadKeySet(dir, "2025-12"); err == nil {
+		t.Error("LoadKeySet() with an unknown active key succeeded")
+	}
+	if _, err := LoadKeySet(t.TempDir(), ""); !errors.Is(err, ErrNoSigningKeys) {
+		t.Errorf("LoadKeySet() of an empty directory error = %v, want ErrNoSigningKeys", err)
+	}
+	if _, err := LoadKeySet("", ""); !errors.Is(err, ErrNoSigningKeys) {
+		t.Errorf("LoadKeySet() without a directory error = %v, want ErrNoSigningKeys", err)
+	}
+}
+
+func TestNewKeySet_WeakKey(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 1024)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := NewKeySet("", map[string]crypto.Signer{"weak": key}); err == nil {
+		t.Error("NewKeySet() accepted a 1024 bit RSA key")
+	}
+}
+
+func TestJWK_PublicKey(t *testing.T) {
+	rsaKey, edKey := newRSAKey(t), newEd25519Key(t)
+	keySet, err := NewKeySet("", map[string]crypto.Signer{"rsa": rsaKey, "ed": edKey})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := map[string]crypto.PublicKey{"rsa": rsaKey.Public(), "ed": edKey.Public()}
+	for _, jwk := range keySet.JWKS().Keys {
+		got, err := jwk.PublicKey()
+		if err != nil {
+			t.Fatalf("JWK.PublicKey() error = %v", err)
+		}
+		if !got.(interface{ Equal(crypto.PublicKey) bool }).Equal(want[jwk.Kid]) {
+			t.Errorf("JWK.PublicKey() of %s doesn't round trip", jwk.Kid)
+		}
+	}
+
+	if _, err := (JWK{Kid: "ec", Kty: "EC", Crv: "P-256"}).PublicKey(); err == nil {
+		t.Error("JWK.PublicKey() accepted an unsupported key type")
+	}
+}
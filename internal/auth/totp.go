@@ -0,0 +1,158 @@
+package auth
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/subtle"
+	"encoding/base32"
+	"encoding/binary"
+	"fmt"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+)
+
+// TOTP parameters (RFC 6238). They are the defaults of authenticator apps,
+// which ignore anything else in the provisioning URI.
+const (
+	TOTPDigits  = 6
+	totpModulus = 1_000_000 // 10^TOTPDigits
+	TOTPPeriod  = 30 * time.Second
+	// TOTPSkew is how many periods a code may be off, to allow for clock
+	// drift and slow typing.
+	TOTPSkew = 1
+
+	RecoveryCodeCount = 10
+)
+
+var totpEncoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// TwoFactor is the TOTP enrollment of a user. The secret has to be kept in
+// the clear to compute codes; recovery codes are stored as hashes.
+type TwoFactor struct {
+	UserId  string `bson:"_id" json:"-"`
+	Secret  string `bson:"secret" json:"-"`
+	Enabled bool   `bson:"enabled" json:"enabled"`
+	// RecoveryCodes are the hashes of the unused recovery codes.
+	RecoveryCodes []string `bson:"recoveryCodes" json:"-"`
+	// LastStep is the time step of the last accepted code, so a code can't be
+	// used twice.
+	LastStep int64 `bson:"lastStep" json:"-"`
+}
+
+// NewTwoFactor starts a TOTP enrollment with a new secret. It is enabled once
+// the user proved they set up their authenticator by entering a code.
+func NewTwoFactor(userId string) (*TwoFactor, error) {
+	secret := make([]byte, 20)
+	if _, err := rand.Read(secret); err != nil {
+		return nil, err
+	}
+	return &TwoFactor{
+		UserId: userId,
+		Secret: totpEncoding.EncodeToString(secret),
+	}, nil
+}
+
+// TwoFactorRequired reports whether users with role have to use two-factor
+// authentication, given the roles an admin required it for. Users without a
+// role count as guests.
+func TwoFactorRequired(required []Role, role Role) bool {
+	if !role.Valid() {
+		role = RoleGuest
+	}
+	return slices.Contains(required, role)
+}
+
+// ProvisioningURI is the otpauth URI authenticator apps read, usually from a
+// QR code.
+func (tf *TwoFactor) ProvisioningURI(issuer, account string) string {
+	label := url.PathEscape(issuer + ":" + account)
+	query := url.Values{}
+	query.Set("secret", tf.Secret)
+	query.Set("issuer", issuer)
+	query.Set("algorithm", "SHA1")
+	query.Set("digits", fmt.Sprint(TOTPDigits))
+	query.Set("period", fmt.Sprint(int(TOTPPeriod.Seconds())))
+	return "otpauth://totp/" + label + "?" + query.Encode()
+}
+
+// ValidateTOTP checks a code against the secret and returns the time step it
+// belongs to. Codes of steps up to LastStep are rejected as replays.
+func (tf *TwoFactor) ValidateTOTP(code string, now time.Time) (int64, bool) {
+	current := now.Unix() / int64(TOTPPeriod.Seconds())
+	for step := current - TOTPSkew; step <= current+TOTPSkew; step++ {
+		if step <= tf.LastStep {
+			continue
+		}
+		want, err := totpCode(tf.Secret, step)
+		if err != nil {
+			return 0, false
+		}
+		if subtle.ConstantTimeCompare([]byte(want), []byte(code)) == 1 {
+			return step, true
+		}
+	}
+	return 0, false
+}
+
+// HasRecoveryCode reports whether code is one of the unused recovery codes
+// and returns its hash.
+func (tf *TwoFactor) HasRecoveryCode(code string) (string, bool) {
+	hash := HashRecoveryCode(code)
+	for _, stored := range tf.RecoveryCodes {
+		if subtle.ConstantTimeCompare([]byte(stored), []byte(hash)) == 1 {
+			return hash, true
+		}
+	}
+	return "", false
+}
+
+// TOTPCode returns the code for the secret at time t.
+func TOTPCode(secret string, t time.Time) (string, error) {
+	return totpCode(secret, t.Unix()/int64(TOTPPeriod.Seconds()))
+}
+
+func totpCode(secret string, step int64) (string, error) {
+	key, err := totpEncoding.DecodeString(secret)
+	if err != nil {
+		return "", err
+	}
+
+	var msg [8]byte
+	binary.BigEndian.PutUint64(msg[:], uint64(step))
+	mac := hmac.New(sha1.New, key)
+	mac.Write(msg[:])
+	sum := mac.Sum(nil)
+
+	// dynamic truncation, RFC 4226 section 5.3
+	offset := sum[len(sum)-1] & 0x0f
+	value := binary.BigEndian.Uint32(sum[offset:offset+4]) & 0x7fffffff
+	return fmt.Sprintf("%0*d", TOTPDigits, value%totpModulus), nil
+}
+
+// NewRecoveryCodes generates one-time recovery codes for when the
+// authenticator is lost. It returns the codes to show to the user once, and
+// their hashes to store.
+func NewRecoveryCodes() ([]string, []string, error) {
+	codes := make([]string, RecoveryCodeCount)
+	hashes := make([]string, RecoveryCodeCount)
+	for i := range codes {
+		b := make([]byte, 5)
+		if _, err := rand.Read(b); err != nil {
+			return nil, nil, err
+		}
+		code := strings.ToLower(totpEncoding.EncodeToString(b))
+		codes[i] = code[:4] + "-" + code[4:]
+		hashes[i] = HashRecoveryCode(codes[i])
+	}
+	return codes, hashes, nil
+}
+
+// HashRecoveryCode hashes a recovery code for storage, ignoring case and
+// separators the user may or may not type.
+func HashRecoveryCode(code string) string {
+	code = strings.ToLower(strings.NewReplacer("-", "", " ", "").Replace(code))
+	return HashToken(code)
+}
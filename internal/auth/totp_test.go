@@ -0,0 +1,107 @@
+package auth
+
+import (
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+// rfc6238Secret is the SHA-1 key of the RFC 6238 test vectors,
+// "12345678901234567890", in base32.
+const rfc6238Secret = "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+
+func TestTOTPCode(t *testing.T) {
+	// RFC 6238 appendix B, truncated to six digits
+	tests := []struct {
+		unix int64
+		want string
+	}{
+		{unix: 59, want: "287082"},
+		{unix: 1111111109, want: "081804"},
+		{unix: 1111111111, want: "050471"},
+		{unix: 1234567890, want: "005924"},
+		{unix: 2000000000, want: "279037"},
+	}
+	for _, tt := range tests {
+		got, err := TOTPCode(rfc6238Secret, time.Unix(tt.unix, 0))
+		if err != nil {
+			t.Fatalf("TOTPCode() error = %v", err)
+		}
+		if got != tt.want {
+			t.Errorf("TOTPCode(%d) = %s, want %s", tt.unix, got, tt.want)
+		}
+	}
+}
+
+func TestTwoFactor_ValidateTOTP(t *testing.T) {
+	now := time.Unix(1111111111, 0)
+	step := now.Unix() / int64(TOTPPeriod.Seconds())
+	code := func(t time.Time) string {
+		c, _ := TOTPCode(rfc6238Secret, t)
+		return c
+	}
+
+	tests := []struct {
+		name     string
+		code     string
+		lastStep int64
+		wantStep int64
+		wantOk   bool
+	}{
+		{name: "Current code", code: code(now), wantStep: step, wantOk: true},
+		{name: "Previous code", code: code(now.Add(-TOTPPeriod)), wantStep: step - 1, wantOk: true},
+		{name: "Next code", code: code(now.Add(TOTPPeriod)), wantStep: step + 1, wantOk: true},
+		{name: "Too old", code: code(now.Add(-2 * TOTPPeriod)), wantOk: false},
+		{name: "Replayed", code: code(now), lastStep: step, wantOk: false},
+		{name: "Wrong code", code: "000000", wantOk: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tf := &TwoFactor{Secret: rfc6238Secret, LastStep: tt.lastStep}
+			gotStep, gotOk := tf.ValidateTOTP(tt.code, now)
+			if gotOk != tt.wantOk || gotStep != tt.wantStep {
+				t.Errorf("TwoFactor.ValidateTOTP() = %d, %v, want %d, %v", gotStep, gotOk, tt.wantStep, tt.wantOk)
+			}
+		})
+	}
+}
+
+func TestTwoFactor_ProvisioningURI(t *testing.T) {
+	tf, err := NewTwoFactor("user")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	uri, err := url.Parse(tf.ProvisioningURI("Hotel System", "staff@hotel.com"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if uri.Scheme != "otpauth" || uri.Host != "totp" || uri.Path != "/Hotel System:staff@hotel.com" {
+		t.Errorf("ProvisioningURI() = %s", uri)
+	}
+	if got := uri.Query().Get("secret"); got != tf.Secret {
+		t.Errorf("secret = %s, want %s", got, tf.Secret)
+	}
+	if got := uri.Query().Get("issuer"); got != "Hotel System" {
+		t.Errorf("issuer = %s", got)
+	}
+}
+
+func TestNewRecoveryCodes(t *testing.T) {
+	codes, hashes, err := NewRecoveryCodes()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(codes) != RecoveryCodeCount || len(hashes) != RecoveryCodeCount {
+		t.Fatalf("NewRecoveryCodes() returned %d codes and %d hashes", len(codes), len(hashes))
+	}
+
+	tf := &TwoFactor{RecoveryCodes: hashes}
+	if _, ok := tf.HasRecoveryCode(strings.ToUpper(strings.ReplaceAll(codes[3], "-", ""))); !ok {
+		t.Error("recovery code typed without separator in upper case is not accepted")
+	}
+	if _, ok := tf.HasRecoveryCode("aaaa-aaaa"); ok {
+		t.Error("unknown recovery code is accepted")
+	}
+}
@@ -0,0 +1,42 @@
+package cancellation
+
+import (
+	"time"
+
+	"github.com/ctchen222/hotel-system/internal/pricing"
+)
+
+// Policy describes how much of a booking is refunded when a guest cancels.
+// Cancelling at least FreeCancellationDays before arrival is free; later
+// cancellations keep PenaltyPercent of the booking total.
+type Policy struct {
+	FreeCancellationDays int `bson:"freeCancellationDays" json:"freeCancellationDays"`
+	PenaltyPercent       int `bson:"penaltyPercent" json:"penaltyPercent"`
+}
+
+func (p Policy) Validate() map[string]string {
+	errors := map[string]string{}
+	if p.FreeCancellationDays < 0 {
+		errors["freeCancellationDays"] = "freeCancellationDays must not be negative"
+	}
+	if p.PenaltyPercent < 0 || p.PenaltyPercent > 100 {
+		errors["penaltyPercent"] = "penaltyPercent must be between 0 and 100"
+	}
+	return errors
+}
+
+// IsFree reports whether cancelling at now, for a stay starting at arrival,
+// falls inside the free cancellation window.
+func (p Policy) IsFree(arrival, now time.Time) bool {
+	deadline := arrival.AddDate(0, 0, -p.FreeCancellationDays)
+	return !now.After(deadline)
+}
+
+// Refund returns the part of total given back to the guest when cancelling at
+// now.
+func (p Policy) Refund(total pricing.Money, arrival, now time.Time) pricing.Money {
+	if p.IsFree(arrival, now) {
+		return total
+	}
+	return total - total.Percent(p.PenaltyPercent*100)
+}
@@ -0,0 +1,90 @@
+package cancellation
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ctchen222/hotel-system/internal/pricing"
+)
+
+func TestPolicy_Refund(t *testing.T) {
+	arrival := time.Date(2030, time.March, 20, 0, 0, 0, 0, time.UTC)
+	policy := Policy{FreeCancellationDays: 7, PenaltyPercent: 30}
+
+	tests := []struct {
+		name   string
+		policy Policy
+		now    time.Time
+		want   pricing.Money
+	}{
+		{
+			name:   "Well before the free window closes",
+			policy: policy,
+			now:    arrival.AddDate(0, 0, -30),
+			want:   30000,
+		},
+		{
+			name:   "Exactly at the deadline",
+			policy: policy,
+			now:    arrival.AddDate(0, 0, -7),
+			want:   30000,
+		},
+		{
+			name:   "Just after the deadline",
+			policy: policy,
+			now:    arrival.AddDate(0, 0, -7).Add(time.Minute),
+			want:   21000,
+		},
+		{
+			name:   "After arrival",
+			policy: policy,
+			now:    arrival.AddDate(0, 0, 1),
+			want:   21000,
+		},
+		{
+			name:   "Non-refundable",
+			policy: Policy{FreeCancellationDays: 0, PenaltyPercent: 100},
+			now:    arrival.Add(time.Hour),
+			want:   0,
+		},
+		{
+			name:   "Penalty is rounded to the cent",
+			policy: Policy{PenaltyPercent: 33},
+			now:    arrival.Add(time.Hour),
+			want:   20100,
+		},
+		{
+			name:   "Default policy is free until arrival",
+			policy: Policy{},
+			now:    arrival,
+			want:   30000,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.policy.Refund(30000, arrival, tt.now); got != tt.want {
+				t.Errorf("Policy.Refund() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPolicy_Validate(t *testing.T) {
+	tests := []struct {
+		name   string
+		policy Policy
+		want   int
+	}{
+		{name: "Valid policy", policy: Policy{FreeCancellationDays: 3, PenaltyPercent: 50}, want: 0},
+		{name: "Negative days", policy: Policy{FreeCancellationDays: -1}, want: 1},
+		{name: "Penalty above 100", policy: Policy{PenaltyPercent: 101}, want: 1},
+		{name: "Both invalid", policy: Policy{FreeCancellationDays: -1, PenaltyPercent: -1}, want: 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.policy.Validate(); len(got) != tt.want {
+				t.Errorf("Policy.Validate() = %v, want %d errors", got, tt.want)
+			}
+		})
+	}
+}
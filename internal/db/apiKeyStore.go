@@ -0,0 +1,69 @@
+package db
+
+import (
+	"context"
+	"time"
+
+	"github.com/ctchen222/hotel-system/internal/auth"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type MongoAPIKeyStore struct {
+	client *mongo.Client
+	coll   *mongo.Collection
+}
+
+func NewMongoAPIKeyStore(client *mongo.Client) *MongoAPIKeyStore {
+	return &MongoAPIKeyStore{
+		client: client,
+		coll:   client.Database(DBNAME).Collection(apiKeyColl),
+	}
+}
+
+func (s *MongoAPIKeyStore) Insert(ctx context.Context, apiKey *auth.APIKey) error {
+	_, err := s.coll.InsertOne(ctx, apiKey)
+	return writeError(err)
+}
+
+func (s *MongoAPIKeyStore) GetAPIKeyById(ctx context.Context, id string) (*auth.APIKey, error) {
+	var apiKey auth.APIKey
+	if err := s.coll.FindOne(ctx, bson.M{"_id": id}).Decode(&apiKey); err != nil {
+		return nil, notFound(err)
+	}
+	return &apiKey, nil
+}
+
+func (s *MongoAPIKeyStore) GetAPIKeys(ctx context.Context) ([]*auth.APIKey, error) {
+	cur, err := s.coll.Find(ctx, bson.M{}, options.Find().SetSort(bson.M{"createdAt": 1}))
+	if err != nil {
+		return nil, err
+	}
+
+	var apiKeys []*auth.APIKey
+	if err := cur.All(ctx, &apiKeys); err != nil {
+		return nil, err
+	}
+	return apiKeys, nil
+}
+
+// Revoke revokes an active key. It returns ErrNotFound if there is
+// no such key or it was revoked already.
+func (s *MongoAPIKeyStore) Revoke(ctx context.Context, id string) error {
+	filter := bson.M{"_id": id, "revokedAt": bson.M{"$exists": false}}
+	res, err := s.coll.UpdateOne(ctx, filter, bson.M{"$set": bson.M{"revokedAt": time.Now()}})
+	if err != nil {
+		return err
+	}
+	if res.MatchedCount == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// Touch records when the key was last used.
+func (s *MongoAPIKeyStore) Touch(ctx context.Context, id string, usedAt time.Time) error {
+	_, err := s.coll.UpdateOne(ctx, bson.M{"_id": id}, bson.M{"$set": bson.M{"lastUsedAt": usedAt}})
+	return err
+}
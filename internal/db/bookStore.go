@@ -2,51 +2,501 @@ package db
 
 import (
 	"context"
+	"errors"
+	"log"
+	"time"
 
+	"github.com/ctchen222/hotel-system/internal/pricing"
 	"github.com/ctchen222/hotel-system/internal/types"
+	"github.com/ctchen222/hotel-system/internal/utils"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
-type BookingStore interface {
-	InsertBookRoom(context.Context, *types.Booking) (*types.Booking, error)
-	GetBookings(ctx context.Context, filter bson.M) ([]*types.Booking, error)
+// bookingDoc is how a booking is stored in MongoDB.
+type bookingDoc struct {
+	Id           primitive.ObjectID  `bson:"_id,omitempty"`
+	UserId       primitive.ObjectID  `bson:"userId,omitempty"`
+	RoomId       primitive.ObjectID  `bson:"roomId,omitempty"`
+	NumPerson    int                 `bson:"numPerson,omitempty"`
+	From         time.Time           `bson:"from,omitempty"`
+	To           time.Time           `bson:"to,omitempty"`
+	Price        pricing.Breakdown   `bson:"price"`
+	Status       types.BookingStatus `bson:"status,omitempty"`
+	ExpiresAt    *time.Time          `bson:"expiresAt,omitempty"`
+	CancelledAt  *time.Time          `bson:"cancelledAt,omitempty"`
+	RefundAmount pricing.Money       `bson:"refundAmount,omitempty"`
+}
+
+func newBookingDoc(booking *types.Booking) (*bookingDoc, error) {
+	doc := &bookingDoc{
+		NumPerson:    booking.NumPerson,
+		From:         booking.From,
+		To:           booking.To,
+		Price:        booking.Price,
+		Status:       booking.Status,
+		ExpiresAt:    booking.ExpiresAt,
+		CancelledAt:  booking.CancelledAt,
+		RefundAmount: booking.RefundAmount,
+	}
+	var err error
+	if booking.Id != "" {
+		if doc.Id, err = objectId(booking.Id); err != nil {
+			return nil, err
+		}
+	}
+	if doc.UserId, err = objectId(booking.UserId); err != nil {
+		return nil, err
+	}
+	if doc.RoomId, err = objectId(booking.RoomId); err != nil {
+		return nil, err
+	}
+	return doc, nil
+}
+
+func (d *bookingDoc) booking() *types.Booking {
+	return &types.Booking{
+		Id:           d.Id.Hex(),
+		UserId:       d.UserId.Hex(),
+		RoomId:       d.RoomId.Hex(),
+		NumPerson:    d.NumPerson,
+		From:         d.From,
+		To:           d.To,
+		Price:        d.Price,
+		Status:       d.Status,
+		ExpiresAt:    d.ExpiresAt,
+		CancelledAt:  d.CancelledAt,
+		RefundAmount: d.RefundAmount,
+	}
+}
+
+// roomNight reserves a single night of a room for a booking. The unique index
+// on (roomId, night), created by EnsureSchema, is what makes concurrent
+// bookings of the same room safe: only one booking can ever hold a given
+// night.
+type roomNight struct {
+	RoomId    primitive.ObjectID `bson:"roomId"`
+	Night     time.Time          `bson:"night"`
+	BookingId primitive.ObjectID `bson:"bookingId"`
 }
 
 type MongoBookingStore struct {
-	client *mongo.Client
-	coll   *mongo.Collection
+	client    *mongo.Client
+	coll      *mongo.Collection
+	roomColl  *mongo.Collection
+	nightColl *mongo.Collection
+	promoColl *mongo.Collection
 }
 
 func NewMongoBookingStore(client *mongo.Client) *MongoBookingStore {
 	return &MongoBookingStore{
-		client: client,
-		coll:   client.Database(DBNAME).Collection(bookingColl),
+		client:    client,
+		coll:      client.Database(DBNAME).Collection(bookingColl),
+		roomColl:  client.Database(DBNAME).Collection(roomColl),
+		nightColl: client.Database(DBNAME).Collection(roomNightColl),
+		promoColl: client.Database(DBNAME).Collection(promoCodeColl),
 	}
 }
 
-func (s *MongoBookingStore) InsertBookRoom(ctx context.Context, booking *types.Booking) (*types.Booking, error) {
-	resp, err := s.coll.InsertOne(ctx, booking)
+// Insert redeems the booking's promo code and reserves every night of the
+// booking before inserting it. If the code is used up or any night is already
+// taken, whatever was reserved is given back and ErrPromoCodeExhausted or a
+// BookingConflictError is returned. A room that doesn't exist gives
+// ErrNotFound.
+func (s *MongoBookingStore) Insert(ctx context.Context, booking *types.Booking) (*types.Booking, error) {
+	doc, err := newBookingDoc(booking)
 	if err != nil {
 		return nil, err
 	}
+	doc.Id = primitive.NewObjectID()
 
-	booking.Id = resp.InsertedID.(primitive.ObjectID)
+	rooms, err := s.roomColl.CountDocuments(ctx, bson.M{"_id": doc.RoomId})
+	if err != nil {
+		return nil, err
+	}
+	if rooms == 0 {
+		return nil, ErrNotFound
+	}
+	if booking.Price.PromoCode != "" {
+		if err := s.redeemPromoCode(ctx, booking.Price.PromoCode); err != nil {
+			return nil, err
+		}
+	}
+	if err := s.reserveNights(ctx, doc); err != nil {
+		s.releasePromoCode(ctx, booking.Price.PromoCode)
+		return nil, err
+	}
+
+	if _, err := s.coll.InsertOne(ctx, doc); err != nil {
+		s.releaseNights(ctx, doc.Id)
+		s.releasePromoCode(ctx, booking.Price.PromoCode)
+		return nil, err
+	}
+
+	return doc.booking(), nil
+}
 
-	return booking, nil
+func (s *MongoBookingStore) GetBookings(ctx context.Context, filter types.BookingFilter) ([]*types.Booking, error) {
+	query := bson.M{}
+	if filter.UserId != "" {
+		userId, err := objectId(filter.UserId)
+		if err != nil {
+			return []*types.Booking{}, nil
+		}
+		query["userId"] = userId
+	}
+	return s.findBookings(ctx, query)
 }
 
-func (s *MongoBookingStore) GetBookings(ctx context.Context, filter bson.M) ([]*types.Booking, error) {
+func (s *MongoBookingStore) findBookings(ctx context.Context, filter bson.M) ([]*types.Booking, error) {
 	cur, err := s.coll.Find(ctx, filter)
 	if err != nil {
 		return nil, err
 	}
 
-	var bookings []*types.Booking
-	if err := cur.All(ctx, &bookings); err != nil {
+	var docs []*bookingDoc
+	if err := cur.All(ctx, &docs); err != nil {
 		return nil, err
 	}
 
+	bookings := make([]*types.Booking, 0, len(docs))
+	for _, doc := range docs {
+		bookings = append(bookings, doc.booking())
+	}
 	return bookings, nil
 }
+
+func (s *MongoBookingStore) GetBookingById(ctx context.Context, id string) (*types.Booking, error) {
+	oid, err := objectId(id)
+	if err != nil {
+		return nil, err
+	}
+
+	var booking bookingDoc
+	if err := s.coll.FindOne(ctx, bson.M{"_id": oid}).Decode(&booking); err != nil {
+		return nil, notFound(err)
+	}
+	return booking.booking(), nil
+}
+
+// CancelBooking marks a pending or confirmed booking as cancelled, records the
+// refund, releases its nights and gives back its promo code redemption. The
+// status check and the update happen in a single operation, so a booking can
+// only be cancelled (and refunded) once. Once the booking is cancelled the
+// cancellation stands, so failing to release the nights or the redemption is
+// logged rather than returned.
+func (s *MongoBookingStore) CancelBooking(ctx context.Context, id string, refundAmount pricing.Money) (*types.Booking, error) {
+	oid, err := objectId(id)
+	if err != nil {
+		return nil, err
+	}
+	filter := bson.M{
+		"_id":    oid,
+		"status": bson.M{"$in": bson.A{types.BookingPending, types.BookingConfirmed}},
+	}
+	update := bson.M{
+		"$set": bson.M{
+			"status":       types.BookingCancelled,
+			"cancelledAt":  time.Now(),
+			"refundAmount": refundAmount,
+		},
+	}
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+
+	var booking bookingDoc
+	if err := s.coll.FindOneAndUpdate(ctx, filter, update, opts).Decode(&booking); err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, ErrInvalidBookingTransition
+		}
+		return nil, err
+	}
+
+	if err := s.releaseNights(ctx, booking.Id); err != nil {
+		log.Printf("releasing the nights of cancelled booking %s: %v", booking.Id.Hex(), err)
+	}
+	if err := s.releasePromoCode(ctx, booking.Price.PromoCode); err != nil {
+		log.Printf("giving back promo code %s of cancelled booking %s: %v", booking.Price.PromoCode, booking.Id.Hex(), err)
+	}
+
+	return booking.booking(), nil
+}
+
+// ConfirmBooking turns an unexpired hold into a confirmed booking. It returns
+// ErrHoldExpired if the hold ran out, and ErrInvalidBookingTransition if the
+// booking is not a pending hold.
+func (s *MongoBookingStore) ConfirmBooking(ctx context.Context, id string) (*types.Booking, error) {
+	oid, err := objectId(id)
+	if err != nil {
+		return nil, err
+	}
+	filter := bson.M{
+		"_id":       oid,
+		"status":    types.BookingPending,
+		"expiresAt": bson.M{"$gt": time.Now()},
+	}
+	update := bson.M{
+		"$set":   bson.M{"status": types.BookingConfirmed},
+		"$unset": bson.M{"expiresAt": ""},
+	}
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+
+	var booking bookingDoc
+	err = s.coll.FindOneAndUpdate(ctx, filter, update, opts).Decode(&booking)
+	if err == nil {
+		return booking.booking(), nil
+	}
+	if !errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, err
+	}
+
+	current, err := s.GetBookingById(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if current.Status == types.BookingPending && current.ExpiresAt != nil {
+		return nil, ErrHoldExpired
+	}
+	return nil, ErrInvalidBookingTransition
+}
+
+// ReleaseExpiredHolds cancels the holds that expired before now, frees their
+// nights and gives back their promo code redemptions. It returns the number of
+// holds released.
+func (s *MongoBookingStore) ReleaseExpiredHolds(ctx context.Context, now time.Time) (int, error) {
+	expired := bson.M{
+		"status":    types.BookingPending,
+		"expiresAt": bson.M{"$lte": now},
+	}
+	cur, err := s.coll.Find(ctx, expired)
+	if err != nil {
+		return 0, err
+	}
+	var holds []*bookingDoc
+	if err := cur.All(ctx, &holds); err != nil {
+		return 0, err
+	}
+
+	released := 0
+	for _, hold := range holds {
+		// The hold may have been confirmed since it was read, so the expiry
+		// is checked again as part of the update.
+		filter := bson.M{"_id": hold.Id}
+		for key, value := range expired {
+			filter[key] = value
+		}
+		update := bson.M{
+			"$set": bson.M{
+				"status":      types.BookingCancelled,
+				"cancelledAt": now,
+			},
+		}
+		res, err := s.coll.UpdateOne(ctx, filter, update)
+		if err != nil {
+			return released, err
+		}
+		if res.ModifiedCount == 0 {
+			continue
+		}
+
+		if err := s.releaseNights(ctx, hold.Id); err != nil {
+			return released, err
+		}
+		if err := s.releasePromoCode(ctx, hold.Price.PromoCode); err != nil {
+			return released, err
+		}
+		released++
+	}
+
+	return released, nil
+}
+
+// UpdateBooking moves current to the room, dates, guest count and price of
+// updated. Nights gained by the change are reserved before the booking is
+// touched, so if any of them is taken the original booking is kept and a
+// BookingConflictError is returned. Nights the booking no longer needs are
+// released once the update has been applied.
+func (s *MongoBookingStore) UpdateBooking(ctx context.Context, current, updated *types.Booking) (*types.Booking, error) {
+	currentDoc, err := newBookingDoc(current)
+	if err != nil {
+		return nil, err
+	}
+	updated.Id = current.Id
+	updatedDoc, err := newBookingDoc(updated)
+	if err != nil {
+		return nil, err
+	}
+	oldNights := roomNights(currentDoc)
+	newNights := roomNights(updatedDoc)
+	gained := diffNights(oldNights, newNights)
+	released := diffNights(newNights, oldNights)
+
+	if len(gained) > 0 {
+		docs := make([]any, 0, len(gained))
+		for _, night := range gained {
+			docs = append(docs, night)
+		}
+		if _, err := s.nightColl.InsertMany(ctx, docs); err != nil {
+			s.deleteNights(ctx, gained)
+			if mongo.IsDuplicateKeyError(err) {
+				return nil, &BookingConflictError{RoomId: updated.RoomId, From: updated.From, To: updated.To}
+			}
+			return nil, err
+		}
+	}
+
+	// Only apply the change if nobody else changed or cancelled the booking
+	// since it was read.
+	filter := bson.M{
+		"_id":    currentDoc.Id,
+		"status": bson.M{"$in": bson.A{types.BookingPending, types.BookingConfirmed}},
+		"roomId": currentDoc.RoomId,
+		"from":   current.From,
+		"to":     current.To,
+	}
+	update := bson.M{
+		"$set": bson.M{
+			"roomId":    updatedDoc.RoomId,
+			"from":      updated.From,
+			"to":        updated.To,
+			"numPerson": updated.NumPerson,
+			"price":     updated.Price,
+		},
+	}
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+
+	var booking bookingDoc
+	if err := s.coll.FindOneAndUpdate(ctx, filter, update, opts).Decode(&booking); err != nil {
+		s.deleteNights(ctx, gained)
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, ErrBookingChanged
+		}
+		return nil, err
+	}
+
+	if err := s.deleteNights(ctx, released); err != nil {
+		return nil, err
+	}
+
+	return booking.booking(), nil
+}
+
+func (s *MongoBookingStore) reserveNights(ctx context.Context, booking *bookingDoc) error {
+	nights := roomNights(booking)
+	if len(nights) == 0 {
+		return nil
+	}
+
+	docs := make([]any, 0, len(nights))
+	for _, night := range nights {
+		docs = append(docs, night)
+	}
+
+	if _, err := s.nightColl.InsertMany(ctx, docs); err != nil {
+		s.deleteNights(ctx, nights)
+		if mongo.IsDuplicateKeyError(err) {
+			return &BookingConflictError{RoomId: booking.RoomId.Hex(), From: booking.From, To: booking.To}
+		}
+		return err
+	}
+
+	return nil
+}
+
+// redeemPromoCode counts one use of the code. The limit check and the
+// increment are a single update, so concurrent bookings can't redeem the code
+// more often than it allows. It returns ErrUnknownPromoCode if there is no
+// such code.
+func (s *MongoBookingStore) redeemPromoCode(ctx context.Context, code string) error {
+	filter := bson.M{
+		"code": code,
+		"$expr": bson.M{"$or": bson.A{
+			bson.M{"$eq": bson.A{"$maxRedemptions", 0}},
+			bson.M{"$lt": bson.A{"$redemptions", "$maxRedemptions"}},
+		}},
+	}
+	update := bson.M{"$inc": bson.M{"redemptions": 1}}
+
+	err := s.promoColl.FindOneAndUpdate(ctx, filter, update, options.FindOneAndUpdate().SetProjection(bson.M{"_id": 1})).Err()
+	if err != mongo.ErrNoDocuments {
+		return err
+	}
+	count, err := s.promoColl.CountDocuments(ctx, bson.M{"code": code}, options.Count().SetLimit(1))
+	if err != nil {
+		return err
+	}
+	if count == 0 {
+		return ErrUnknownPromoCode
+	}
+	return ErrPromoCodeExhausted
+}
+
+// releasePromoCode gives back a redemption of the code, if there is one.
+func (s *MongoBookingStore) releasePromoCode(ctx context.Context, code string) error {
+	if code == "" {
+		return nil
+	}
+	filter := bson.M{"code": code, "redemptions": bson.M{"$gt": 0}}
+	_, err := s.promoColl.UpdateOne(ctx, filter, bson.M{"$inc": bson.M{"redemptions": -1}})
+	return err
+}
+
+// deleteNights removes the given reservations, leaving any night held by a
+// different booking alone.
+func (s *MongoBookingStore) deleteNights(ctx context.Context, nights []roomNight) error {
+	if len(nights) == 0 {
+		return nil
+	}
+
+	var or bson.A
+	for _, night := range nights {
+		or = append(or, bson.M{
+			"roomId":    night.RoomId,
+			"night":     night.Night,
+			"bookingId": night.BookingId,
+		})
+	}
+	_, err := s.nightColl.DeleteMany(ctx, bson.M{"$or": or})
+	return err
+}
+
+func (s *MongoBookingStore) releaseNights(ctx context.Context, bookingId primitive.ObjectID) error {
+	_, err := s.nightColl.DeleteMany(ctx, bson.M{"bookingId": bookingId})
+	return err
+}
+
+// hasBookings reports whether any booking, cancelled or not, matches the
+// filter.
+func hasBookings(ctx context.Context, bookingColl *mongo.Collection, filter bson.M) (bool, error) {
+	count, err := bookingColl.CountDocuments(ctx, filter, options.Count().SetLimit(1))
+	return count > 0, err
+}
+
+func roomNights(booking *bookingDoc) []roomNight {
+	var nights []roomNight
+	for _, night := range utils.StayNights(booking.From, booking.To) {
+		nights = append(nights, roomNight{
+			RoomId:    booking.RoomId,
+			Night:     night,
+			BookingId: booking.Id,
+		})
+	}
+	return nights
+}
+
+// diffNights returns the nights in b that are not in a.
+func diffNights(a, b []roomNight) []roomNight {
+	held := map[roomNight]bool{}
+	for _, night := range a {
+		held[night] = true
+	}
+
+	var diff []roomNight
+	for _, night := range b {
+		if !held[night] {
+			diff = append(diff, night)
+		}
+	}
+	return diff
+}
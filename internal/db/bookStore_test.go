@@ -1,123 +1,48 @@
 package db
 
 import (
-	"context"
-	"fmt"
-	"reflect"
 	"testing"
 	"time"
 
-	"github.com/ctchen222/hotel-system/internal/db/mocks"
 	"github.com/ctchen222/hotel-system/internal/types"
-	"go.mongodb.org/mongo-driver/bson"
-	"go.mongodb.org/mongo-driver/bson/primitive"
-	"go.mongodb.org/mongo-driver/mongo"
-	"go.uber.org/mock/gomock"
 )
 
-func TestNewMongoBookingStore(t *testing.T) {
-	ctrl := gomock.NewController(t)
-	defer ctrl.Finish()
-
-	mockBookingStore := mocks.NewMockBookingStore(ctrl)
-
-	Id1 := primitive.NewObjectID()
-	Id2 := primitive.NewObjectID()
-
-	mockBookingStore.EXPECT().GetBookings(gomock.Any(), gomock.Any()).Return(
-		[]*types.Booking{
-			{
-				Id:        Id1,
-				UserId:    Id1,
-				RoomId:    Id1,
-				NumPerson: 1,
-				From:      time.Now(),
-				To:        time.Now().Add(time.Second * 10),
-			},
-			{
-				Id:        Id2,
-				UserId:    Id2,
-				RoomId:    Id2,
-				NumPerson: 2,
-				From:      time.Now(),
-				To:        time.Now().Add(time.Second * 20),
-			},
-		}, nil).Times(2)
-
-	result, _ := mockBookingStore.GetBookings(context.Background(), bson.M{})
-	fmt.Println(result)
-	result, _ = mockBookingStore.GetBookings(context.Background(), bson.M{})
-	fmt.Println(result)
-
-}
-
-func TestMongoBookingStore_InsertBookRoom(t *testing.T) {
-	type fields struct {
-		client *mongo.Client
-		coll   *mongo.Collection
-	}
-	type args struct {
-		ctx     context.Context
-		booking *types.Booking
-	}
-	tests := []struct {
-		name    string
-		fields  fields
-		args    args
-		want    *types.Booking
-		wantErr bool
-	}{
-		// TODO: Add test cases.
+// Two bookings can only both reserve their nights if they do not overlap, so
+// the night sets must intersect exactly when Booking.Overlaps says they do.
+func Test_roomNights_MatchesOverlaps(t *testing.T) {
+	day := func(d int) time.Time {
+		return time.Date(2030, time.January, d, 0, 0, 0, 0, time.UTC)
 	}
-	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			s := &MongoBookingStore{
-				client: tt.fields.client,
-				coll:   tt.fields.coll,
-			}
-			got, err := s.InsertBookRoom(tt.args.ctx, tt.args.booking)
-			if (err != nil) != tt.wantErr {
-				t.Errorf("MongoBookingStore.InsertBookRoom() error = %v, wantErr %v", err, tt.wantErr)
-				return
-			}
-			if !reflect.DeepEqual(got, tt.want) {
-				t.Errorf("MongoBookingStore.InsertBookRoom() = %v, want %v", got, tt.want)
-			}
-		})
-	}
-}
+	existing := &types.Booking{From: day(10), To: day(15)}
+	existingDoc := &bookingDoc{From: existing.From, To: existing.To}
 
-func TestMongoBookingStore_GetBookings(t *testing.T) {
-	type fields struct {
-		client *mongo.Client
-		coll   *mongo.Collection
-	}
-	type args struct {
-		ctx    context.Context
-		filter bson.M
-	}
 	tests := []struct {
-		name    string
-		fields  fields
-		args    args
-		want    []*types.Booking
-		wantErr bool
+		name string
+		from time.Time
+		to   time.Time
 	}{
-		// TODO: Add test cases.
+		{name: "identical range", from: day(10), to: day(15)},
+		{name: "inside existing", from: day(11), to: day(14)},
+		{name: "encloses existing", from: day(8), to: day(17)},
+		{name: "overlaps start", from: day(8), to: day(12)},
+		{name: "overlaps end", from: day(13), to: day(17)},
+		{name: "checks out on existing check-in", from: day(5), to: day(10)},
+		{name: "checks in on existing check-out", from: day(15), to: day(20)},
+		{name: "entirely before", from: day(1), to: day(5)},
+		{name: "entirely after", from: day(20), to: day(25)},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			s := &MongoBookingStore{
-				client: tt.fields.client,
-				coll:   tt.fields.coll,
+			taken := map[time.Time]bool{}
+			for _, night := range roomNights(existingDoc) {
+				taken[night.Night] = true
 			}
-			got, err := s.GetBookings(tt.args.ctx, tt.args.filter)
-			if (err != nil) != tt.wantErr {
-				t.Errorf("MongoBookingStore.GetBookings() error = %v, wantErr %v", err, tt.wantErr)
-				return
+			clash := false
+			for _, night := range roomNights(&bookingDoc{From: tt.from, To: tt.to}) {
+				clash = clash || taken[night.Night]
 			}
-			if !reflect.DeepEqual(got, tt.want) {
-				t.Errorf("MongoBookingStore.GetBookings() = %v, want %v", got, tt.want)
+			if want := existing.Overlaps(tt.from, tt.to); clash != want {
+				t.Errorf("night reservation clash = %v, Booking.Overlaps() = %v", clash, want)
 			}
 		})
 	}
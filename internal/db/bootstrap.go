@@ -0,0 +1,358 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"slices"
+	"strings"
+
+	"github.com/ctchen222/hotel-system/internal/types"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// collectionSchema is the validator and the indexes of a collection.
+type collectionSchema struct {
+	name      string
+	validator bson.M
+	indexes   []mongo.IndexModel
+}
+
+// collectionSchemas lists every collection the stores use. The unique indexes
+// are what keeps emails, hotel names, promo codes and booked room nights
+// unique; the stores turn the duplicate key errors into ErrEmailExists,
+// ErrHotelExists, ErrPromoCodeExists and BookingConflictError.
+var collectionSchemas = []collectionSchema{
+	{
+		name: userColl,
+		validator: jsonSchema([]string{"email", "encryptedPassword", "role"}, bson.M{
+			"firstName":         bsonType("string"),
+			"lastName":          bsonType("string"),
+			"email":             bsonType("string"),
+			"encryptedPassword": bsonType("string"),
+			"emailVerified":     bsonType("bool"),
+			"role":              bsonType("string"),
+			"hotelIds":          arrayOf("objectId"),
+		}),
+		indexes: []mongo.IndexModel{
+			{Keys: bson.D{{Key: "email", Value: 1}}, Options: options.Index().SetUnique(true)},
+		},
+	},
+	{
+		name: hotelColl,
+		validator: jsonSchema([]string{"name"}, bson.M{
+			"name":               bsonType("string"),
+			"location":           bsonType("string"),
+			"rating":             bsonType("number"),
+			"cancellationPolicy": bsonType("object"),
+		}),
+		indexes: []mongo.IndexModel{
+			{Keys: bson.D{{Key: "name", Value: 1}}, Options: options.Index().SetUnique(true)},
+		},
+	},
+	{
+		name: roomColl,
+		validator: jsonSchema([]string{"hotelId", "price"}, bson.M{
+			"hotelId":  bsonType("objectId"),
+			"size":     bsonType("string"),
+			"seaside":  bsonType("bool"),
+			"price":    bsonType("number"),
+			"capacity": bson.M{"bsonType": "number", "minimum": types.DefaultRoomCapacity},
+		}),
+		indexes: []mongo.IndexModel{
+			{Keys: bson.D{{Key: "hotelId", Value: 1}}},
+		},
+	},
+	{
+		name: bookingColl,
+		validator: jsonSchema([]string{"userId", "roomId", "from", "to"}, bson.M{
+			"userId":       bsonType("objectId"),
+			"roomId":       bsonType("objectId"),
+			"numPerson":    bsonType("number"),
+			"from":         bsonType("date"),
+			"to":           bsonType("date"),
+			"price":        bsonType("object"),
+			"status":       bsonType("string"),
+			"expiresAt":    bsonType("date"),
+			"cancelledAt":  bsonType("date"),
+			"refundAmount": bsonType("number"),
+		}),
+		indexes: []mongo.IndexModel{
+			{Keys: bson.D{{Key: "roomId", Value: 1}, {Key: "from", Value: 1}, {Key: "to", Value: 1}}},
+			{Keys: bson.D{{Key: "userId", Value: 1}}},
+			{Keys: bson.D{{Key: "status", Value: 1}, {Key: "expiresAt", Value: 1}}},
+		},
+	},
+	{
+		name: roomNightColl,
+		validator: jsonSchema([]string{"roomId", "night", "bookingId"}, bson.M{
+			"roomId":    bsonType("objectId"),
+			"night":     bsonType("date"),
+			"bookingId": bsonType("objectId"),
+		}),
+		indexes: []mongo.IndexModel{
+			{Keys: bson.D{{Key: "roomId", Value: 1}, {Key: "night", Value: 1}}, Options: options.Index().SetUnique(true)},
+			{Keys: bson.D{{Key: "bookingId", Value: 1}}},
+		},
+	},
+	{
+		name: ratePlanColl,
+		validator: jsonSchema([]string{"roomId", "validFrom", "validTo"}, bson.M{
+			"roomId":         bsonType("objectId"),
+			"name":           bsonType("string"),
+			"validFrom":      bsonType("date"),
+			"validTo":        bsonType("date"),
+			"priority":       bsonType("number"),
+			"nightlyRate":    bsonType("number"),
+			"weekdayPercent": bsonType("number"),
+			"weekendPercent": bsonType("number"),
+			"minStay":        bsonType("number"),
+		}),
+		indexes: []mongo.IndexModel{
+			{Keys: bson.D{{Key: "roomId", Value: 1}}},
+		},
+	},
+	{
+		name: promoCodeColl,
+		validator: jsonSchema([]string{"code", "kind", "redemptions"}, bson.M{
+			"code":           bsonType("string"),
+			"kind":           bsonType("string"),
+			"hotelId":        bsonType("objectId"),
+			"validFrom":      bsonType("date"),
+			"validTo":        bsonType("date"),
+			"maxRedemptions": bsonType("number"),
+			"redemptions":    bson.M{"bsonType": "number", "minimum": 0},
+		}),
+		indexes: []mongo.IndexModel{
+			{Keys: bson.D{{Key: "code", Value: 1}}, Options: options.Index().SetUnique(true)},
+		},
+	},
+	{
+		name: paymentColl,
+		validator: jsonSchema([]string{"bookingId", "provider", "status", "amount"}, bson.M{
+			"bookingId": bsonType("objectId"),
+			"provider":  bsonType("string"),
+			"reference": bsonType("string"),
+			"status":    bsonType("string"),
+			"amount":    bsonType("number"),
+			"captured":  bsonType("number"),
+			"refunded":  bsonType("number"),
+		}),
+		indexes: []mongo.IndexModel{
+			{Keys: bson.D{{Key: "bookingId", Value: 1}}},
+		},
+	},
+	{
+		name: sessionColl,
+		validator: jsonSchema([]string{"userId", "tokenHash", "expiresAt"}, bson.M{
+			"userId":    bsonType("string"),
+			"tokenHash": bsonType("string"),
+			"createdAt": bsonType("date"),
+			"expiresAt": bsonType("date"),
+			"revokedAt": bsonType("date"),
+		}),
+		indexes: []mongo.IndexModel{
+			{Keys: bson.D{{Key: "userId", Value: 1}}},
+		},
+	},
+	{
+		name: tokenColl,
+		validator: jsonSchema([]string{"userId", "purpose", "expiresAt"}, bson.M{
+			"userId":    bsonType("string"),
+			"purpose":   bsonType("string"),
+			"createdAt": bsonType("date"),
+			"expiresAt": bsonType("date"),
+			"usedAt":    bsonType("date"),
+		}),
+		indexes: []mongo.IndexModel{
+			{Keys: bson.D{{Key: "userId", Value: 1}, {Key: "purpose", Value: 1}}},
+			// Expired tokens can never be consumed, so MongoDB may drop them.
+			{Keys: bson.D{{Key: "expiresAt", Value: 1}}, Options: options.Index().SetExpireAfterSeconds(0)},
+		},
+	},
+	{
+		name: twoFactorColl,
+		validator: jsonSchema([]string{"secret", "enabled"}, bson.M{
+			"secret":        bsonType("string"),
+			"enabled":       bsonType("bool"),
+			"recoveryCodes": arrayOf("string"),
+			"lastStep":      bsonType("number"),
+		}),
+	},
+	{
+		name: settingsColl,
+		validator: jsonSchema(nil, bson.M{
+			"roles": arrayOf("string"),
+		}),
+	},
+	{
+		name: apiKeyColl,
+		validator: jsonSchema([]string{"userId", "name", "keyHash", "permissions"}, bson.M{
+			"userId":      bsonType("string"),
+			"name":        bsonType("string"),
+			"keyHash":     bsonType("string"),
+			"permissions": arrayOf("string"),
+			"createdAt":   bsonType("date"),
+			"expiresAt":   bsonType("date"),
+			"lastUsedAt":  bsonType("date"),
+			"revokedAt":   bsonType("date"),
+		}),
+		indexes: []mongo.IndexModel{
+			{Keys: bson.D{{Key: "userId", Value: 1}}},
+		},
+	},
+	{
+		name: identityColl,
+		validator: jsonSchema([]string{"issuer", "subject", "userId"}, bson.M{
+			"issuer":    bsonType("string"),
+			"subject":   bsonType("string"),
+			"userId":    bsonType("string"),
+			"createdAt": bsonType("date"),
+		}),
+		indexes: []mongo.IndexModel{
+			{Keys: bson.D{{Key: "issuer", Value: 1}, {Key: "subject", Value: 1}}, Options: options.Index().SetUnique(true)},
+			{Keys: bson.D{{Key: "userId", Value: 1}}},
+		},
+	},
+}
+
+// EnsureSchema creates the collections with their JSON schema validators and
+// indexes. It is safe to run on every start: existing collections get their
+// validator replaced and indexes that already exist are left as they are.
+//
+// Validators use the moderate level, so documents written before a
+// validator existed can still be updated; new documents must be valid.
+// Creating a unique index fails if the collection already holds duplicates,
+// which have to be cleaned up by hand. Rooms stored without a capacity get
+// the default one, and bookings stored before room nights were reserved get
+// their nights reserved too, see backfillRoomNights.
+func EnsureSchema(ctx context.Context, client *mongo.Client) error {
+	database := client.Database(DBNAME)
+	existing, err := database.ListCollectionNames(ctx, bson.M{})
+	if err != nil {
+		return err
+	}
+
+	for _, schema := range collectionSchemas {
+		if slices.Contains(existing, schema.name) {
+			err = database.RunCommand(ctx, bson.D{
+				{Key: "collMod", Value: schema.name},
+				{Key: "validator", Value: schema.validator},
+				{Key: "validationLevel", Value: "moderate"},
+			}).Err()
+		} else {
+			err = database.CreateCollection(ctx, schema.name, options.CreateCollection().
+				SetValidator(schema.validator).
+				SetValidationLevel("moderate"))
+		}
+		if err != nil {
+			return fmt.Errorf("setting the validator of %s: %w", schema.name, err)
+		}
+
+		if len(schema.indexes) == 0 {
+			continue
+		}
+		if _, err := database.Collection(schema.name).Indexes().CreateMany(ctx, schema.indexes); err != nil {
+			return fmt.Errorf("creating the indexes of %s: %w", schema.name, err)
+		}
+	}
+	if err := backfillRoomCapacity(ctx, database); err != nil {
+		return err
+	}
+	return backfillRoomNights(ctx, database)
+}
+
+// backfillRoomCapacity gives rooms stored without a capacity, which no search
+// for guests finds, the default capacity.
+func backfillRoomCapacity(ctx context.Context, database *mongo.Database) error {
+	filter := bson.M{"$or": bson.A{
+		bson.M{"capacity": bson.M{"$exists": false}},
+		bson.M{"capacity": bson.M{"$lt": types.DefaultRoomCapacity}},
+	}}
+	update := bson.M{"$set": bson.M{"capacity": types.DefaultRoomCapacity}}
+	if _, err := database.Collection(roomColl).UpdateMany(ctx, filter, update); err != nil {
+		return fmt.Errorf("setting the capacity of rooms: %w", err)
+	}
+	return nil
+}
+
+// backfillRoomNights reserves the nights of the bookings that aren't
+// cancelled and hold none, which are the bookings stored before nights were
+// reserved. Without their nights, new bookings could overlap them. Bookings
+// that overlap each other can't both hold their nights; the ones left without
+// are logged on every start until they are cleaned up by hand, but don't keep
+// the server from starting.
+func backfillRoomNights(ctx context.Context, database *mongo.Database) error {
+	nightColl := database.Collection(roomNightColl)
+	reserved, err := nightColl.Distinct(ctx, "bookingId", bson.M{})
+	if err != nil {
+		return err
+	}
+	cur, err := database.Collection(bookingColl).Find(ctx, bson.M{
+		"_id":    bson.M{"$nin": reserved},
+		"status": bson.M{"$ne": types.BookingCancelled},
+	})
+	if err != nil {
+		return err
+	}
+	var bookings []*bookingDoc
+	if err := cur.All(ctx, &bookings); err != nil {
+		return err
+	}
+
+	var overlapping []string
+	for _, booking := range bookings {
+		nights := roomNights(booking)
+		if len(nights) == 0 {
+			continue
+		}
+		models := make([]mongo.WriteModel, 0, len(nights))
+		for _, night := range nights {
+			models = append(models, mongo.NewUpdateOneModel().
+				SetFilter(bson.M{"roomId": night.RoomId, "night": night.Night}).
+				SetUpdate(bson.M{"$setOnInsert": bson.M{"bookingId": night.BookingId}}).
+				SetUpsert(true))
+		}
+		res, err := nightColl.BulkWrite(ctx, models, options.BulkWrite().SetOrdered(false))
+		if err != nil {
+			return fmt.Errorf("reserving the nights of booking %s: %w", booking.Id.Hex(), err)
+		}
+		if int(res.UpsertedCount) < len(nights) {
+			// Give back what was reserved, so the booking is backfilled
+			// again once the overlap is resolved.
+			if _, err := nightColl.DeleteMany(ctx, bson.M{"bookingId": booking.Id}); err != nil {
+				return err
+			}
+			overlapping = append(overlapping, booking.Id.Hex())
+		}
+	}
+	if len(overlapping) > 0 {
+		log.Printf("bookings %s overlap other bookings of their room; cancel them or move them to free nights", strings.Join(overlapping, ", "))
+	}
+	return nil
+}
+
+// jsonSchema returns a validator requiring the given fields and checking the
+// types of the given properties. Other fields are allowed.
+func jsonSchema(required []string, properties bson.M) bson.M {
+	schema := bson.M{
+		"bsonType":   "object",
+		"properties": properties,
+	}
+	if len(required) > 0 {
+		schema["required"] = required
+	}
+	return bson.M{"$jsonSchema": schema}
+}
+
+func bsonType(name string) bson.M {
+	return bson.M{"bsonType": name}
+}
+
+// arrayOf also allows null, which is how the driver stores a nil slice.
+func arrayOf(name string) bson.M {
+	return bson.M{"bsonType": bson.A{"array", "null"}, "items": bsonType(name)}
+}
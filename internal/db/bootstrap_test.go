@@ -0,0 +1,75 @@
+package db
+
+import (
+	"testing"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+func TestCollectionSchemas(t *testing.T) {
+	collections := []string{
+		userColl, hotelColl, roomColl, bookingColl, roomNightColl, ratePlanColl, promoCodeColl,
+		paymentColl, sessionColl, tokenColl, twoFactorColl, settingsColl, apiKeyColl, identityColl,
+	}
+	schemas := map[string]collectionSchema{}
+	for _, schema := range collectionSchemas {
+		if _, ok := schemas[schema.name]; ok {
+			t.Errorf("collection %s is listed twice", schema.name)
+		}
+		schemas[schema.name] = schema
+	}
+	for _, name := range collections {
+		if _, ok := schemas[name]; !ok {
+			t.Errorf("collection %s has no schema", name)
+		}
+	}
+
+	for _, schema := range collectionSchemas {
+		jsonSchema := schema.validator["$jsonSchema"].(bson.M)
+		properties := jsonSchema["properties"].(bson.M)
+		required, _ := jsonSchema["required"].([]string)
+		for _, field := range required {
+			if _, ok := properties[field]; !ok {
+				t.Errorf("collection %s requires %s, which has no type", schema.name, field)
+			}
+		}
+	}
+}
+
+func TestCollectionSchemas_UniqueIndexes(t *testing.T) {
+	unique := map[string]bson.D{
+		userColl:      {{Key: "email", Value: 1}},
+		hotelColl:     {{Key: "name", Value: 1}},
+		roomNightColl: {{Key: "roomId", Value: 1}, {Key: "night", Value: 1}},
+		promoCodeColl: {{Key: "code", Value: 1}},
+		identityColl:  {{Key: "issuer", Value: 1}, {Key: "subject", Value: 1}},
+	}
+	for _, schema := range collectionSchemas {
+		keys, ok := unique[schema.name]
+		if !ok {
+			continue
+		}
+		found := false
+		for _, index := range schema.indexes {
+			if index.Options != nil && index.Options.Unique != nil && *index.Options.Unique &&
+				equalKeys(index.Keys.(bson.D), keys) {
+				found = true
+			}
+		}
+		if !found {
+			t.Errorf("collection %s has no unique index on %v", schema.name, keys)
+		}
+	}
+}
+
+func equalKeys(a, b bson.D) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
@@ -2,6 +2,7 @@ package db
 
 import (
 	"context"
+	"errors"
 	"log"
 	"sync"
 
@@ -11,13 +12,23 @@ import (
 )
 
 const (
-	DBNAME      = "hotel-reservation"
-	DBTESTNAME  = "hotel-reservation-test"
-	MONGOURI    = "mongodb://localhost:27017"
-	userColl    = "users"
-	hotelColl   = "hotels"
-	roomColl    = "rooms"
-	bookingColl = "bookings"
+	DBNAME        = "hotel-reservation"
+	DBTESTNAME    = "hotel-reservation-test"
+	MONGOURI      = "mongodb://localhost:27017"
+	userColl      = "users"
+	hotelColl     = "hotels"
+	roomColl      = "rooms"
+	bookingColl   = "bookings"
+	roomNightColl = "roomNights"
+	ratePlanColl  = "ratePlans"
+	promoCodeColl = "promoCodes"
+	paymentColl   = "payments"
+	sessionColl   = "sessions"
+	tokenColl     = "oneTimeTokens"
+	twoFactorColl = "twoFactor"
+	settingsColl  = "settings"
+	apiKeyColl    = "apiKeys"
+	identityColl  = "oidcIdentities"
 )
 
 var (
@@ -26,13 +37,6 @@ var (
 	Ctx           = context.Background()
 )
 
-type Store struct {
-	User    UserStore
-	Hotel   HotelStore
-	Room    RoomStore
-	Booking BookingStore
-}
-
 func ToObjectId(id string) primitive.ObjectID {
 	objectId, err := primitive.ObjectIDFromHex(id)
 	if err != nil {
@@ -41,6 +45,65 @@ func ToObjectId(id string) primitive.ObjectID {
 	return objectId
 }
 
+// objectId parses the hex id of a document. An id that isn't an ObjectID
+// can't name a document, so it gives ErrNotFound.
+func objectId(id string) (primitive.ObjectID, error) {
+	oid, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return primitive.NilObjectID, ErrNotFound
+	}
+	return oid, nil
+}
+
+func objectIds(ids []string) ([]primitive.ObjectID, error) {
+	oids := make([]primitive.ObjectID, 0, len(ids))
+	for _, id := range ids {
+		oid, err := objectId(id)
+		if err != nil {
+			return nil, err
+		}
+		oids = append(oids, oid)
+	}
+	return oids, nil
+}
+
+func hexIds(oids []primitive.ObjectID) []string {
+	if oids == nil {
+		return nil
+	}
+	ids := make([]string, 0, len(oids))
+	for _, oid := range oids {
+		ids = append(ids, oid.Hex())
+	}
+	return ids
+}
+
+// notFound translates the driver's mongo.ErrNoDocuments to ErrNotFound.
+func notFound(err error) error {
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return ErrNotFound
+	}
+	return err
+}
+
+// documentValidationFailure is the code of the error MongoDB returns when a
+// document doesn't match the collection's validator.
+const documentValidationFailure = 121
+
+// writeError translates the errors of a write the indexes or validators set
+// up by EnsureSchema reject to ErrConflict and ErrValidation. Stores that have
+// a more specific error for a duplicate check for it first.
+func writeError(err error) error {
+	var serverErr mongo.ServerError
+	switch {
+	case mongo.IsDuplicateKeyError(err):
+		return newError(ErrConflict, "record already exists")
+	case errors.As(err, &serverErr) && serverErr.HasErrorCode(documentValidationFailure):
+		return newError(ErrValidation, "record does not match the collection schema")
+	}
+	return err
+}
+
 func NewMongoInstance(connString string) *mongo.Client {
 	mongoOnce.Do(func() {
 		client, err := mongo.Connect(Ctx, options.Client().ApplyURI(connString))
@@ -51,3 +114,22 @@ func NewMongoInstance(connString string) *mongo.Client {
 	})
 	return MongoInstance
 }
+
+// NewMongoStore returns the stores backed by the MongoDB client.
+func NewMongoStore(client *mongo.Client) *Store {
+	hotelStore := NewMongoHotelStore(client)
+	return &Store{
+		User:      NewMongoUserStore(client),
+		Hotel:     hotelStore,
+		Room:      NewMongoRoomStore(client, hotelStore),
+		Booking:   NewMongoBookingStore(client),
+		RatePlan:  NewMongoRatePlanStore(client),
+		PromoCode: NewMongoPromoCodeStore(client),
+		Payment:   NewMongoPaymentStore(client),
+		Session:   NewMongoSessionStore(client),
+		Token:     NewMongoTokenStore(client),
+		TwoFactor: NewMongoTwoFactorStore(client),
+		APIKey:    NewMongoAPIKeyStore(client),
+		Identity:  NewMongoIdentityStore(client),
+	}
+}
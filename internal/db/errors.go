@@ -0,0 +1,97 @@
+package db
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// The kinds of store errors. Every error a store returns on purpose is, or
+// wraps, one of them, so callers can tell with errors.Is what went wrong
+// without knowing every specific error.
+var (
+	// ErrNotFound is returned by every store when the requested record does
+	// not exist.
+	ErrNotFound = errors.New("not found")
+	// ErrConflict is the kind of errors caused by the current state of the
+	// data, such as a duplicate or a record that is still referenced.
+	ErrConflict = errors.New("conflict")
+	// ErrValidation is the kind of errors caused by a record the database
+	// refuses to store as it is.
+	ErrValidation = errors.New("invalid")
+	// ErrForbidden is the kind of errors caused by a change that is never
+	// allowed, whatever the data.
+	ErrForbidden = errors.New("forbidden")
+)
+
+// Error is a store error of one of the kinds above.
+type Error struct {
+	Kind    error
+	Message string
+}
+
+func (e *Error) Error() string {
+	return e.Message
+}
+
+// Unwrap returns the kind, so errors.Is(err, ErrConflict) holds for every
+// conflict.
+func (e *Error) Unwrap() error {
+	return e.Kind
+}
+
+func newError(kind error, message string) *Error {
+	return &Error{Kind: kind, Message: message}
+}
+
+// ErrHasBookings is returned when deleting a user, hotel or room that
+// bookings, cancelled or not, still refer to.
+var ErrHasBookings = newError(ErrConflict, "record is still referenced by bookings")
+
+// ErrEmailExists is returned when creating a user with an email that is
+// already registered.
+var ErrEmailExists = newError(ErrConflict, "email is already registered")
+
+// ErrHotelExists is returned when creating or renaming a hotel to a name that
+// another hotel already has.
+var ErrHotelExists = newError(ErrConflict, "hotel already exists")
+
+// ErrInvalidBookingTransition is returned when a booking is not in a status
+// that allows the requested change, e.g. cancelling a cancelled booking.
+var ErrInvalidBookingTransition = newError(ErrConflict, "booking cannot transition to the requested status")
+
+// ErrBookingChanged is returned when a booking was modified or cancelled by
+// another request while it was being updated.
+var ErrBookingChanged = newError(ErrConflict, "booking was changed by another request")
+
+// ErrHoldExpired is returned when confirming a hold after it expired.
+var ErrHoldExpired = newError(ErrConflict, "booking hold has expired")
+
+// BookingConflictError is returned when a booking overlaps an existing booking
+// of the same room.
+type BookingConflictError struct {
+	RoomId string
+	From   time.Time
+	To     time.Time
+}
+
+func (e *BookingConflictError) Error() string {
+	return fmt.Sprintf("room %s is already booked between %s and %s",
+		e.RoomId, e.From.Format("2006-01-02"), e.To.Format("2006-01-02"))
+}
+
+func (e *BookingConflictError) Unwrap() error {
+	return ErrConflict
+}
+
+// ErrPromoCodeExhausted is returned when a promo code has reached its
+// maximum number of redemptions.
+var ErrPromoCodeExhausted = newError(ErrConflict, "promo code has no redemptions left")
+
+// ErrUnknownPromoCode is returned when a booking redeems a promo code that
+// doesn't exist.
+var ErrUnknownPromoCode = newError(ErrValidation, "promo code does not exist")
+
+// ErrPromoCodeExists is returned when creating a promo code whose code is
+// already taken.
+var ErrPromoCodeExists = newError(ErrConflict, "promo code already exists")
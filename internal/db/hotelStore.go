@@ -2,63 +2,77 @@ package db
 
 import (
 	"context"
-	"fmt"
 
+	"github.com/ctchen222/hotel-system/internal/cancellation"
 	"github.com/ctchen222/hotel-system/internal/types"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
 )
 
-type HotelStore interface {
-	Create(context.Context, *types.Hotel) (*types.Hotel, error)
-	Insert(context.Context, *types.Hotel) (*types.Hotel, error)
-	Update(ctx context.Context, params types.HotelUpdateParams, id string) error
-	GetHotels(ctx context.Context, filter bson.M) ([]*types.Hotel, error)
-	GetHotelById(context.Context, string) (*types.HotelEmbed, error)
-}
+// hotelDoc is how a hotel is stored in MongoDB.
+type hotelDoc struct {
+	Id       primitive.ObjectID `bson:"_id,omitempty"`
+	Name     string             `bson:"name"`
+	Location string             `bson:"location"`
+	Rating   int                `bson:"rating"`
 
-type MongoHotelStore struct {
-	client *mongo.Client
-	coll   *mongo.Collection
+	CancellationPolicy cancellation.Policy `bson:"cancellationPolicy"`
 }
 
-func NewMongoHotelStore(client *mongo.Client) *MongoHotelStore {
-	return &MongoHotelStore{
-		client: client,
-		coll:   client.Database(DBNAME).Collection(hotelColl),
+func newHotelDoc(hotel *types.Hotel) *hotelDoc {
+	return &hotelDoc{
+		Name:               hotel.Name,
+		Location:           hotel.Location,
+		Rating:             hotel.Rating,
+		CancellationPolicy: hotel.CancellationPolicy,
 	}
 }
 
-func (s *MongoHotelStore) Create(ctx context.Context, hotel *types.Hotel) (*types.Hotel, error) {
-	var existedHotel types.Hotel
-	err := s.coll.FindOne(ctx, bson.M{"name": hotel.Name}).Decode(&existedHotel)
-	if err == nil {
-		return nil, fmt.Errorf("hotel already exists")
-	}
-	if err != mongo.ErrNoDocuments {
-		return nil, err
+func (d *hotelDoc) hotel() *types.Hotel {
+	return &types.Hotel{
+		Id:                 d.Id.Hex(),
+		Name:               d.Name,
+		Location:           d.Location,
+		Rating:             d.Rating,
+		CancellationPolicy: d.CancellationPolicy,
 	}
+}
 
-	res, err := s.coll.InsertOne(ctx, hotel)
-	if err != nil {
-		return nil, err
+type MongoHotelStore struct {
+	client        *mongo.Client
+	coll          *mongo.Collection
+	roomColl      *mongo.Collection
+	bookingColl   *mongo.Collection
+	ratePlanColl  *mongo.Collection
+	promoCodeColl *mongo.Collection
+}
+
+func NewMongoHotelStore(client *mongo.Client) *MongoHotelStore {
+	return &MongoHotelStore{
+		client:        client,
+		coll:          client.Database(DBNAME).Collection(hotelColl),
+		roomColl:      client.Database(DBNAME).Collection(roomColl),
+		bookingColl:   client.Database(DBNAME).Collection(bookingColl),
+		ratePlanColl:  client.Database(DBNAME).Collection(ratePlanColl),
+		promoCodeColl: client.Database(DBNAME).Collection(promoCodeColl),
 	}
-	hotel.Id = res.InsertedID.(primitive.ObjectID)
-	return hotel, nil
 }
 
 func (s *MongoHotelStore) Insert(ctx context.Context, hotel *types.Hotel) (*types.Hotel, error) {
-	resp, err := s.coll.InsertOne(ctx, hotel)
+	res, err := s.coll.InsertOne(ctx, newHotelDoc(hotel))
 	if err != nil {
-		return nil, err
+		if mongo.IsDuplicateKeyError(err) {
+			return nil, ErrHotelExists
+		}
+		return nil, writeError(err)
 	}
-	hotel.Id = resp.InsertedID.(primitive.ObjectID)
+	hotel.Id = res.InsertedID.(primitive.ObjectID).Hex()
 	return hotel, nil
 }
 
 func (s *MongoHotelStore) Update(ctx context.Context, params types.HotelUpdateParams, id string) error {
-	oid, err := primitive.ObjectIDFromHex(id)
+	oid, err := objectId(id)
 	if err != nil {
 		return err
 	}
@@ -68,58 +82,89 @@ func (s *MongoHotelStore) Update(ctx context.Context, params types.HotelUpdatePa
 			"name":     params.Name,
 			"location": params.Location,
 			"rating":   params.Rating,
+
+			"cancellationPolicy": params.CancellationPolicy,
 		},
 	}
 
-	_, err = s.coll.UpdateOne(ctx, filter, update)
+	res, err := s.coll.UpdateOne(ctx, filter, update)
 	if err != nil {
-		return err
+		if mongo.IsDuplicateKeyError(err) {
+			return ErrHotelExists
+		}
+		return writeError(err)
+	}
+	if res.MatchedCount == 0 {
+		return ErrNotFound
 	}
 
-	return err
+	return nil
 }
 
-func (s *MongoHotelStore) GetHotels(ctx context.Context, filter bson.M) ([]*types.Hotel, error) {
-	cur, err := s.coll.Find(ctx, filter)
+func (s *MongoHotelStore) GetHotels(ctx context.Context) ([]*types.Hotel, error) {
+	cur, err := s.coll.Find(ctx, bson.M{})
 	if err != nil {
 		return nil, err
 	}
 
-	var hotels []*types.Hotel
-	if err := cur.All(ctx, &hotels); err != nil {
+	var docs []*hotelDoc
+	if err := cur.All(ctx, &docs); err != nil {
 		return nil, err
 	}
+
+	hotels := make([]*types.Hotel, 0, len(docs))
+	for _, doc := range docs {
+		hotels = append(hotels, doc.hotel())
+	}
 	return hotels, nil
 }
 
-func (s *MongoHotelStore) GetHotelById(ctx context.Context, id string) (*types.HotelEmbed, error) {
-	oid, err := primitive.ObjectIDFromHex(id)
+func (s *MongoHotelStore) GetHotelById(ctx context.Context, id string) (*types.Hotel, error) {
+	oid, err := objectId(id)
 	if err != nil {
 		return nil, err
 	}
 
-	// var hotel []*types.Hotel
-	var hotel *types.HotelEmbed
-	pipeline := mongo.Pipeline{
-		bson.D{{Key: "$match", Value: bson.M{"_id": oid}}},
-		bson.D{{Key: "$lookup", Value: bson.D{
-			{Key: "from", Value: "rooms"},
-			{Key: "localField", Value: "_id"},
-			{Key: "foreignField", Value: "hotelId"},
-			{Key: "as", Value: "rooms"},
-		}}},
-		bson.D{{Key: "$match", Value: bson.M{"rooms": bson.M{"$ne": bson.A{}}}}},
+	var hotel hotelDoc
+	if err := s.coll.FindOne(ctx, bson.M{"_id": oid}).Decode(&hotel); err != nil {
+		return nil, notFound(err)
 	}
-	cur, err := s.coll.Aggregate(ctx, pipeline)
+	return hotel.hotel(), nil
+}
+
+// Delete removes the hotel with its rooms, their rate plans and the promo
+// codes of the hotel, unless any of the rooms has bookings.
+func (s *MongoHotelStore) Delete(ctx context.Context, id string) error {
+	oid, err := objectId(id)
 	if err != nil {
-		return nil, err
+		return err
+	}
+	roomIds, err := s.roomColl.Distinct(ctx, "_id", bson.M{"hotelId": oid})
+	if err != nil {
+		return err
+	}
+	booked, err := hasBookings(ctx, s.bookingColl, bson.M{"roomId": bson.M{"$in": roomIds}})
+	if err != nil {
+		return err
+	}
+	if booked {
+		return ErrHasBookings
 	}
 
-	if cur.Next(ctx) {
-		if err := cur.Decode(&hotel); err != nil {
-			return nil, err
-		}
+	res, err := s.coll.DeleteOne(ctx, bson.M{"_id": oid})
+	if err != nil {
+		return err
+	}
+	if res.DeletedCount == 0 {
+		return ErrNotFound
 	}
 
-	return hotel, nil
+	if _, err := s.ratePlanColl.DeleteMany(ctx, bson.M{"roomId": bson.M{"$in": roomIds}}); err != nil {
+		return err
+	}
+	if _, err := s.promoCodeColl.DeleteMany(ctx, bson.M{"hotelId": oid}); err != nil {
+		return err
+	}
+	_, err = s.roomColl.DeleteMany(ctx, bson.M{"hotelId": oid})
+	return err
 }
@@ -0,0 +1,47 @@
+package db
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// identityDoc links the subject of a single sign-on provider to a user.
+type identityDoc struct {
+	Issuer    string    `bson:"issuer"`
+	Subject   string    `bson:"subject"`
+	UserId    string    `bson:"userId"`
+	CreatedAt time.Time `bson:"createdAt"`
+}
+
+type MongoIdentityStore struct {
+	client *mongo.Client
+	coll   *mongo.Collection
+}
+
+func NewMongoIdentityStore(client *mongo.Client) *MongoIdentityStore {
+	return &MongoIdentityStore{
+		client: client,
+		coll:   client.Database(DBNAME).Collection(identityColl),
+	}
+}
+
+func (s *MongoIdentityStore) GetIdentityUserId(ctx context.Context, issuer, subject string) (string, error) {
+	var identity identityDoc
+	if err := s.coll.FindOne(ctx, bson.M{"issuer": issuer, "subject": subject}).Decode(&identity); err != nil {
+		return "", notFound(err)
+	}
+	return identity.UserId, nil
+}
+
+func (s *MongoIdentityStore) Insert(ctx context.Context, issuer, subject, userId string) error {
+	_, err := s.coll.InsertOne(ctx, &identityDoc{
+		Issuer:    issuer,
+		Subject:   subject,
+		UserId:    userId,
+		CreatedAt: time.Now(),
+	})
+	return writeError(err)
+}
@@ -1,182 +0,0 @@
-// Code generated by MockGen. DO NOT EDIT.
-// Source: ./internal/db/userStore.go
-//
-// Generated by this command:
-//
-//	mockgen -package mocks -destination ./internal/db/mocks/mock_userStore.go -source ./internal/db/userStore.go
-//
-
-// Package mocks is a generated GoMock package.
-package mocks
-
-import (
-	context "context"
-	reflect "reflect"
-
-	types "github.com/ctchen222/hotel-system/internal/types"
-	gomock "go.uber.org/mock/gomock"
-)
-
-// MockDropper is a mock of Dropper interface.
-type MockDropper struct {
-	ctrl     *gomock.Controller
-	recorder *MockDropperMockRecorder
-	isgomock struct{}
-}
-
-// MockDropperMockRecorder is the mock recorder for MockDropper.
-type MockDropperMockRecorder struct {
-	mock *MockDropper
-}
-
-// NewMockDropper creates a new mock instance.
-func NewMockDropper(ctrl *gomock.Controller) *MockDropper {
-	mock := &MockDropper{ctrl: ctrl}
-	mock.recorder = &MockDropperMockRecorder{mock}
-	return mock
-}
-
-// EXPECT returns an object that allows the caller to indicate expected use.
-func (m *MockDropper) EXPECT() *MockDropperMockRecorder {
-	return m.recorder
-}
-
-// Drop mocks base method.
-func (m *MockDropper) Drop(arg0 context.Context) error {
-	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "Drop", arg0)
-	ret0, _ := ret[0].(error)
-	return ret0
-}
-
-// Drop indicates an expected call of Drop.
-func (mr *MockDropperMockRecorder) Drop(arg0 any) *gomock.Call {
-	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Drop", reflect.TypeOf((*MockDropper)(nil).Drop), arg0)
-}
-
-// MockUserStore is a mock of UserStore interface.
-type MockUserStore struct {
-	ctrl     *gomock.Controller
-	recorder *MockUserStoreMockRecorder
-	isgomock struct{}
-}
-
-// MockUserStoreMockRecorder is the mock recorder for MockUserStore.
-type MockUserStoreMockRecorder struct {
-	mock *MockUserStore
-}
-
-// NewMockUserStore creates a new mock instance.
-func NewMockUserStore(ctrl *gomock.Controller) *MockUserStore {
-	mock := &MockUserStore{ctrl: ctrl}
-	mock.recorder = &MockUserStoreMockRecorder{mock}
-	return mock
-}
-
-// EXPECT returns an object that allows the caller to indicate expected use.
-func (m *MockUserStore) EXPECT() *MockUserStoreMockRecorder {
-	return m.recorder
-}
-
-// Create mocks base method.
-func (m *MockUserStore) Create(arg0 context.Context, arg1 *types.User) (*types.User, error) {
-	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "Create", arg0, arg1)
-	ret0, _ := ret[0].(*types.User)
-	ret1, _ := ret[1].(error)
-	return ret0, ret1
-}
-
-// Create indicates an expected call of Create.
-func (mr *MockUserStoreMockRecorder) Create(arg0, arg1 any) *gomock.Call {
-	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Create", reflect.TypeOf((*MockUserStore)(nil).Create), arg0, arg1)
-}
-
-// DeleteById mocks base method.
-func (m *MockUserStore) DeleteById(arg0 context.Context, arg1 string) error {
-	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "DeleteById", arg0, arg1)
-	ret0, _ := ret[0].(error)
-	return ret0
-}
-
-// DeleteById indicates an expected call of DeleteById.
-func (mr *MockUserStoreMockRecorder) DeleteById(arg0, arg1 any) *gomock.Call {
-	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteById", reflect.TypeOf((*MockUserStore)(nil).DeleteById), arg0, arg1)
-}
-
-// Drop mocks base method.
-func (m *MockUserStore) Drop(arg0 context.Context) error {
-	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "Drop", arg0)
-	ret0, _ := ret[0].(error)
-	return ret0
-}
-
-// Drop indicates an expected call of Drop.
-func (mr *MockUserStoreMockRecorder) Drop(arg0 any) *gomock.Call {
-	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Drop", reflect.TypeOf((*MockUserStore)(nil).Drop), arg0)
-}
-
-// GetUserByEmail mocks base method.
-func (m *MockUserStore) GetUserByEmail(arg0 context.Context, arg1 string) (*types.User, error) {
-	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetUserByEmail", arg0, arg1)
-	ret0, _ := ret[0].(*types.User)
-	ret1, _ := ret[1].(error)
-	return ret0, ret1
-}
-
-// GetUserByEmail indicates an expected call of GetUserByEmail.
-func (mr *MockUserStoreMockRecorder) GetUserByEmail(arg0, arg1 any) *gomock.Call {
-	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUserByEmail", reflect.TypeOf((*MockUserStore)(nil).GetUserByEmail), arg0, arg1)
-}
-
-// GetUserById mocks base method.
-func (m *MockUserStore) GetUserById(arg0 context.Context, arg1 string) (*types.User, error) {
-	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetUserById", arg0, arg1)
-	ret0, _ := ret[0].(*types.User)
-	ret1, _ := ret[1].(error)
-	return ret0, ret1
-}
-
-// GetUserById indicates an expected call of GetUserById.
-func (mr *MockUserStoreMockRecorder) GetUserById(arg0, arg1 any) *gomock.Call {
-	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUserById", reflect.TypeOf((*MockUserStore)(nil).GetUserById), arg0, arg1)
-}
-
-// GetUsers mocks base method.
-func (m *MockUserStore) GetUsers(arg0 context.Context) ([]*types.User, error) {
-	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetUsers", arg0)
-	ret0, _ := ret[0].([]*types.User)
-	ret1, _ := ret[1].(error)
-	return ret0, ret1
-}
-
-// GetUsers indicates an expected call of GetUsers.
-func (mr *MockUserStoreMockRecorder) GetUsers(arg0 any) *gomock.Call {
-	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUsers", reflect.TypeOf((*MockUserStore)(nil).GetUsers), arg0)
-}
-
-// Update mocks base method.
-func (m *MockUserStore) Update(ctx context.Context, params types.UserUpdateParams, id string) error {
-	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "Update", ctx, params, id)
-	ret0, _ := ret[0].(error)
-	return ret0
-}
-
-// Update indicates an expected call of Update.
-func (mr *MockUserStoreMockRecorder) Update(ctx, params, id any) *gomock.Call {
-	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Update", reflect.TypeOf((*MockUserStore)(nil).Update), ctx, params, id)
-}
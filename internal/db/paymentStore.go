@@ -0,0 +1,97 @@
+package db
+
+import (
+	"context"
+
+	"github.com/ctchen222/hotel-system/internal/payments"
+	"github.com/ctchen222/hotel-system/internal/types"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// paymentDoc is how a payment is stored in MongoDB.
+type paymentDoc struct {
+	Id              primitive.ObjectID `bson:"_id,omitempty"`
+	BookingId       primitive.ObjectID `bson:"bookingId"`
+	payments.Record `bson:",inline"`
+}
+
+func (d *paymentDoc) payment() *types.Payment {
+	return &types.Payment{
+		Id:        d.Id.Hex(),
+		BookingId: d.BookingId.Hex(),
+		Record:    d.Record,
+	}
+}
+
+type MongoPaymentStore struct {
+	client *mongo.Client
+	coll   *mongo.Collection
+}
+
+func NewMongoPaymentStore(client *mongo.Client) *MongoPaymentStore {
+	return &MongoPaymentStore{
+		client: client,
+		coll:   client.Database(DBNAME).Collection(paymentColl),
+	}
+}
+
+func (s *MongoPaymentStore) Insert(ctx context.Context, payment *types.Payment) (*types.Payment, error) {
+	bookingId, err := objectId(payment.BookingId)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := s.coll.InsertOne(ctx, &paymentDoc{BookingId: bookingId, Record: payment.Record})
+	if err != nil {
+		return nil, writeError(err)
+	}
+	payment.Id = resp.InsertedID.(primitive.ObjectID).Hex()
+	return payment, nil
+}
+
+func (s *MongoPaymentStore) Update(ctx context.Context, payment *types.Payment) error {
+	oid, err := objectId(payment.Id)
+	if err != nil {
+		return err
+	}
+	bookingId, err := objectId(payment.BookingId)
+	if err != nil {
+		return err
+	}
+
+	doc := &paymentDoc{Id: oid, BookingId: bookingId, Record: payment.Record}
+	res, err := s.coll.ReplaceOne(ctx, bson.M{"_id": oid}, doc)
+	if err != nil {
+		return writeError(err)
+	}
+	if res.MatchedCount == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func (s *MongoPaymentStore) GetPaymentsByBookingId(ctx context.Context, bookingId string) ([]*types.Payment, error) {
+	oid, err := objectId(bookingId)
+	if err != nil {
+		return []*types.Payment{}, nil
+	}
+
+	opts := options.Find().SetSort(bson.D{{Key: "createdAt", Value: 1}})
+	cur, err := s.coll.Find(ctx, bson.M{"bookingId": oid}, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	var docs []*paymentDoc
+	if err := cur.All(ctx, &docs); err != nil {
+		return nil, err
+	}
+
+	found := make([]*types.Payment, 0, len(docs))
+	for _, doc := range docs {
+		found = append(found, doc.payment())
+	}
+	return found, nil
+}
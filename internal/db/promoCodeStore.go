@@ -0,0 +1,118 @@
+package db
+
+import (
+	"context"
+
+	"github.com/ctchen222/hotel-system/internal/pricing"
+	"github.com/ctchen222/hotel-system/internal/types"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// promoCodeDoc is how a promo code is stored in MongoDB.
+type promoCodeDoc struct {
+	Id                primitive.ObjectID `bson:"_id,omitempty"`
+	pricing.Promotion `bson:",inline"`
+	HotelId           *primitive.ObjectID `bson:"hotelId,omitempty"`
+	Redemptions       int                 `bson:"redemptions"`
+}
+
+func (d *promoCodeDoc) promoCode() *types.PromoCode {
+	promoCode := &types.PromoCode{
+		Id:          d.Id.Hex(),
+		Promotion:   d.Promotion,
+		Redemptions: d.Redemptions,
+	}
+	if d.HotelId != nil {
+		promoCode.HotelId = d.HotelId.Hex()
+	}
+	return promoCode
+}
+
+type MongoPromoCodeStore struct {
+	client *mongo.Client
+	coll   *mongo.Collection
+}
+
+func NewMongoPromoCodeStore(client *mongo.Client) *MongoPromoCodeStore {
+	return &MongoPromoCodeStore{
+		client: client,
+		coll:   client.Database(DBNAME).Collection(promoCodeColl),
+	}
+}
+
+func (s *MongoPromoCodeStore) Insert(ctx context.Context, promoCode *types.PromoCode) (*types.PromoCode, error) {
+	doc := &promoCodeDoc{Promotion: promoCode.Promotion}
+	if promoCode.HotelId != "" {
+		hotelId, err := objectId(promoCode.HotelId)
+		if err != nil {
+			return nil, err
+		}
+		doc.HotelId = &hotelId
+	}
+	resp, err := s.coll.InsertOne(ctx, doc)
+	if err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			return nil, ErrPromoCodeExists
+		}
+		return nil, writeError(err)
+	}
+	promoCode.Id = resp.InsertedID.(primitive.ObjectID).Hex()
+	promoCode.Redemptions = 0
+	return promoCode, nil
+}
+
+func (s *MongoPromoCodeStore) GetPromoCodes(ctx context.Context) ([]*types.PromoCode, error) {
+	cur, err := s.coll.Find(ctx, bson.M{})
+	if err != nil {
+		return nil, err
+	}
+
+	var docs []*promoCodeDoc
+	if err := cur.All(ctx, &docs); err != nil {
+		return nil, err
+	}
+
+	promoCodes := make([]*types.PromoCode, 0, len(docs))
+	for _, doc := range docs {
+		promoCodes = append(promoCodes, doc.promoCode())
+	}
+	return promoCodes, nil
+}
+
+func (s *MongoPromoCodeStore) GetPromoCodeById(ctx context.Context, id string) (*types.PromoCode, error) {
+	oid, err := objectId(id)
+	if err != nil {
+		return nil, err
+	}
+	return s.findPromoCode(ctx, bson.M{"_id": oid})
+}
+
+func (s *MongoPromoCodeStore) GetPromoCodeByCode(ctx context.Context, code string) (*types.PromoCode, error) {
+	return s.findPromoCode(ctx, bson.M{"code": code})
+}
+
+func (s *MongoPromoCodeStore) findPromoCode(ctx context.Context, filter bson.M) (*types.PromoCode, error) {
+	var promoCode promoCodeDoc
+	if err := s.coll.FindOne(ctx, filter).Decode(&promoCode); err != nil {
+		return nil, notFound(err)
+	}
+	return promoCode.promoCode(), nil
+}
+
+func (s *MongoPromoCodeStore) Delete(ctx context.Context, id string) error {
+	oid, err := objectId(id)
+	if err != nil {
+		return err
+	}
+
+	res, err := s.coll.DeleteOne(ctx, bson.M{"_id": oid})
+	if err != nil {
+		return err
+	}
+	if res.DeletedCount == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
@@ -0,0 +1,138 @@
+package db
+
+import (
+	"context"
+
+	"github.com/ctchen222/hotel-system/internal/pricing"
+	"github.com/ctchen222/hotel-system/internal/types"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// ratePlanDoc is how a rate plan is stored in MongoDB.
+type ratePlanDoc struct {
+	Id               primitive.ObjectID `bson:"_id,omitempty"`
+	RoomId           primitive.ObjectID `bson:"roomId"`
+	pricing.RatePlan `bson:",inline"`
+}
+
+func newRatePlanDoc(ratePlan *types.RatePlan) (*ratePlanDoc, error) {
+	roomId, err := objectId(ratePlan.RoomId)
+	if err != nil {
+		return nil, err
+	}
+	return &ratePlanDoc{RoomId: roomId, RatePlan: ratePlan.RatePlan}, nil
+}
+
+func (d *ratePlanDoc) ratePlan() *types.RatePlan {
+	return &types.RatePlan{
+		Id:       d.Id.Hex(),
+		RoomId:   d.RoomId.Hex(),
+		RatePlan: d.RatePlan,
+	}
+}
+
+type MongoRatePlanStore struct {
+	client *mongo.Client
+	coll   *mongo.Collection
+}
+
+func NewMongoRatePlanStore(client *mongo.Client) *MongoRatePlanStore {
+	return &MongoRatePlanStore{
+		client: client,
+		coll:   client.Database(DBNAME).Collection(ratePlanColl),
+	}
+}
+
+func (s *MongoRatePlanStore) Insert(ctx context.Context, ratePlan *types.RatePlan) (*types.RatePlan, error) {
+	doc, err := newRatePlanDoc(ratePlan)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := s.coll.InsertOne(ctx, doc)
+	if err != nil {
+		return nil, writeError(err)
+	}
+	ratePlan.Id = resp.InsertedID.(primitive.ObjectID).Hex()
+	return ratePlan, nil
+}
+
+func (s *MongoRatePlanStore) GetRatePlans(ctx context.Context, roomIds ...string) ([]*types.RatePlan, error) {
+	filter := bson.M{}
+	if len(roomIds) > 0 {
+		oids := make([]primitive.ObjectID, 0, len(roomIds))
+		for _, roomId := range roomIds {
+			if oid, err := objectId(roomId); err == nil {
+				oids = append(oids, oid)
+			}
+		}
+		filter["roomId"] = bson.M{"$in": oids}
+	}
+
+	cur, err := s.coll.Find(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	var docs []*ratePlanDoc
+	if err := cur.All(ctx, &docs); err != nil {
+		return nil, err
+	}
+
+	ratePlans := make([]*types.RatePlan, 0, len(docs))
+	for _, doc := range docs {
+		ratePlans = append(ratePlans, doc.ratePlan())
+	}
+	return ratePlans, nil
+}
+
+func (s *MongoRatePlanStore) GetRatePlanById(ctx context.Context, id string) (*types.RatePlan, error) {
+	oid, err := objectId(id)
+	if err != nil {
+		return nil, err
+	}
+
+	var ratePlan ratePlanDoc
+	if err := s.coll.FindOne(ctx, bson.M{"_id": oid}).Decode(&ratePlan); err != nil {
+		return nil, notFound(err)
+	}
+	return ratePlan.ratePlan(), nil
+}
+
+func (s *MongoRatePlanStore) Update(ctx context.Context, ratePlan *types.RatePlan) error {
+	oid, err := objectId(ratePlan.Id)
+	if err != nil {
+		return err
+	}
+	doc, err := newRatePlanDoc(ratePlan)
+	if err != nil {
+		return err
+	}
+	doc.Id = oid
+
+	res, err := s.coll.ReplaceOne(ctx, bson.M{"_id": oid}, doc)
+	if err != nil {
+		return writeError(err)
+	}
+	if res.MatchedCount == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func (s *MongoRatePlanStore) Delete(ctx context.Context, id string) error {
+	oid, err := objectId(id)
+	if err != nil {
+		return err
+	}
+
+	res, err := s.coll.DeleteOne(ctx, bson.M{"_id": oid})
+	if err != nil {
+		return err
+	}
+	if res.DeletedCount == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
@@ -2,6 +2,8 @@ package db
 
 import (
 	"context"
+	"regexp"
+	"time"
 
 	"github.com/ctchen222/hotel-system/internal/types"
 	"go.mongodb.org/mongo-driver/bson"
@@ -9,52 +11,222 @@ import (
 	"go.mongodb.org/mongo-driver/mongo"
 )
 
-type RoomStore interface {
-	Insert(context.Context, *types.Room) (*types.Room, error)
-	GetRooms(ctx context.Context, filter bson.M) ([]*types.Room, error)
+// roomDoc is how a room is stored in MongoDB.
+type roomDoc struct {
+	Id       primitive.ObjectID `bson:"_id,omitempty"`
+	Size     string             `bson:"size"`
+	SeaSide  bool               `bson:"seaside"`
+	Price    float64            `bson:"price"`
+	Capacity int                `bson:"capacity"`
+	HotelId  primitive.ObjectID `bson:"hotelId"`
+}
+
+func (d *roomDoc) room() *types.Room {
+	return &types.Room{
+		Id:       d.Id.Hex(),
+		Size:     d.Size,
+		SeaSide:  d.SeaSide,
+		Price:    d.Price,
+		Capacity: d.Capacity,
+		HotelId:  d.HotelId.Hex(),
+	}
+}
+
+func rooms(docs []*roomDoc) []*types.Room {
+	rooms := make([]*types.Room, 0, len(docs))
+	for _, doc := range docs {
+		rooms = append(rooms, doc.room())
+	}
+	return rooms
 }
 
 type MongoRoomStore struct {
-	client *mongo.Client
-	coll   *mongo.Collection
+	client       *mongo.Client
+	coll         *mongo.Collection
+	bookingColl  *mongo.Collection
+	ratePlanColl *mongo.Collection
 
-	HotelStore
+	hotelStore HotelStore
 }
 
 func NewMongoRoomStore(client *mongo.Client, hotelStore HotelStore) *MongoRoomStore {
 	return &MongoRoomStore{
-		client: client,
-		coll:   client.Database(DBNAME).Collection(roomColl),
+		client:       client,
+		coll:         client.Database(DBNAME).Collection(roomColl),
+		bookingColl:  client.Database(DBNAME).Collection(bookingColl),
+		ratePlanColl: client.Database(DBNAME).Collection(ratePlanColl),
 
-		HotelStore: hotelStore,
+		hotelStore: hotelStore,
 	}
 }
 
 func (s *MongoRoomStore) Insert(ctx context.Context, room *types.Room) (*types.Room, error) {
-	resp, err := s.coll.InsertOne(ctx, room)
+	if _, err := s.hotelStore.GetHotelById(ctx, room.HotelId); err != nil {
+		return nil, err
+	}
+	hotelId, err := objectId(room.HotelId)
 	if err != nil {
 		return nil, err
 	}
-	room.Id = resp.InsertedID.(primitive.ObjectID)
+	if room.Capacity == 0 {
+		room.Capacity = types.DefaultRoomCapacity
+	}
 
-	// filter := bson.M{"_id": room.HotelId}
-	// update := bson.M{"$push": bson.M{"rooms": room.Id}}
-	// if err := s.HotelStore.Update(ctx, filter, update); err != nil {
-	// 	return nil, err
-	// }
+	resp, err := s.coll.InsertOne(ctx, &roomDoc{
+		Size:     room.Size,
+		SeaSide:  room.SeaSide,
+		Price:    room.Price,
+		Capacity: room.Capacity,
+		HotelId:  hotelId,
+	})
+	if err != nil {
+		return nil, writeError(err)
+	}
+	room.Id = resp.InsertedID.(primitive.ObjectID).Hex()
 
 	return room, nil
 }
 
-func (s *MongoRoomStore) GetRooms(ctx context.Context, filter bson.M) ([]*types.Room, error) {
-	cur, err := s.coll.Find(ctx, filter)
+func (s *MongoRoomStore) GetRooms(ctx context.Context, hotelId string) ([]*types.Room, error) {
+	oid, err := objectId(hotelId)
+	if err != nil {
+		return nil, err
+	}
+
+	cur, err := s.coll.Find(ctx, bson.M{"hotelId": oid})
 	if err != nil {
 		return nil, err
 	}
 
-	var room []*types.Room
-	if err := cur.All(ctx, &room); err != nil {
+	var docs []*roomDoc
+	if err := cur.All(ctx, &docs); err != nil {
 		return nil, err
 	}
-	return room, nil
+	return rooms(docs), nil
+}
+
+func (s *MongoRoomStore) GetRoomById(ctx context.Context, id string) (*types.Room, error) {
+	oid, err := objectId(id)
+	if err != nil {
+		return nil, err
+	}
+
+	var room roomDoc
+	if err := s.coll.FindOne(ctx, bson.M{"_id": oid}).Decode(&room); err != nil {
+		return nil, notFound(err)
+	}
+	return room.room(), nil
+}
+
+// Delete removes the room with its rate plans, unless it has bookings.
+func (s *MongoRoomStore) Delete(ctx context.Context, id string) error {
+	oid, err := objectId(id)
+	if err != nil {
+		return err
+	}
+	booked, err := hasBookings(ctx, s.bookingColl, bson.M{"roomId": oid})
+	if err != nil {
+		return err
+	}
+	if booked {
+		return ErrHasBookings
+	}
+
+	res, err := s.coll.DeleteOne(ctx, bson.M{"_id": oid})
+	if err != nil {
+		return err
+	}
+	if res.DeletedCount == 0 {
+		return ErrNotFound
+	}
+
+	_, err = s.ratePlanColl.DeleteMany(ctx, bson.M{"roomId": oid})
+	return err
+}
+
+// GetAvailableRooms returns the rooms matching the query that have no
+// booking overlapping [From, To), grouped by hotel.
+func (s *MongoRoomStore) GetAvailableRooms(ctx context.Context, query types.AvailabilityQuery) ([]*types.HotelAvailability, error) {
+	bookedRoomIds, err := s.bookedRoomIds(ctx, query.From, query.To)
+	if err != nil {
+		return nil, err
+	}
+	roomFilter := bson.M{
+		"_id":      bson.M{"$nin": bookedRoomIds},
+		"capacity": bson.M{"$gte": query.Guests},
+	}
+	if query.SeaSide != nil {
+		roomFilter["seaside"] = *query.SeaSide
+	}
+
+	pipeline := mongo.Pipeline{
+		bson.D{{Key: "$match", Value: roomFilter}},
+		bson.D{{Key: "$sort", Value: bson.D{{Key: "price", Value: 1}}}},
+		bson.D{{Key: "$lookup", Value: bson.D{
+			{Key: "from", Value: hotelColl},
+			{Key: "localField", Value: "hotelId"},
+			{Key: "foreignField", Value: "_id"},
+			{Key: "as", Value: "hotel"},
+		}}},
+		bson.D{{Key: "$unwind", Value: "$hotel"}},
+	}
+	if query.Location != "" {
+		pipeline = append(pipeline, bson.D{{Key: "$match", Value: bson.M{
+			"hotel.location": primitive.Regex{Pattern: regexp.QuoteMeta(query.Location), Options: "i"},
+		}}})
+	}
+	pipeline = append(pipeline,
+		bson.D{{Key: "$group", Value: bson.D{
+			{Key: "_id", Value: "$hotelId"},
+			{Key: "hotel", Value: bson.M{"$first": "$hotel"}},
+			{Key: "rooms", Value: bson.M{"$push": "$$ROOT"}},
+		}}},
+		bson.D{{Key: "$sort", Value: bson.D{{Key: "hotel.name", Value: 1}}}},
+	)
+
+	cur, err := s.coll.Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, err
+	}
+
+	var docs []struct {
+		Hotel hotelDoc   `bson:"hotel"`
+		Rooms []*roomDoc `bson:"rooms"`
+	}
+	if err := cur.All(ctx, &docs); err != nil {
+		return nil, err
+	}
+
+	availability := make([]*types.HotelAvailability, 0, len(docs))
+	for _, doc := range docs {
+		entry := &types.HotelAvailability{Hotel: doc.Hotel.hotel()}
+		for _, room := range doc.Rooms {
+			entry.Rooms = append(entry.Rooms, &types.AvailableRoom{Room: *room.room()})
+		}
+		availability = append(availability, entry)
+	}
+	return availability, nil
+}
+
+// bookedRoomIds returns the ids of every room with a booking that overlaps
+// [from, to).
+func (s *MongoRoomStore) bookedRoomIds(ctx context.Context, from, to time.Time) ([]primitive.ObjectID, error) {
+	filter := bson.M{
+		"from":   bson.M{"$lt": to},
+		"to":     bson.M{"$gt": from},
+		"status": bson.M{"$ne": types.BookingCancelled},
+	}
+	values, err := s.bookingColl.Distinct(ctx, "roomId", filter)
+	if err != nil {
+		return nil, err
+	}
+
+	roomIds := make([]primitive.ObjectID, 0, len(values))
+	for _, value := range values {
+		if roomId, ok := value.(primitive.ObjectID); ok {
+			roomIds = append(roomIds, roomId)
+		}
+	}
+
+	return roomIds, nil
 }
@@ -0,0 +1,78 @@
+package db
+
+import (
+	"context"
+	"time"
+
+	"github.com/ctchen222/hotel-system/internal/auth"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+type MongoSessionStore struct {
+	client *mongo.Client
+	coll   *mongo.Collection
+}
+
+func NewMongoSessionStore(client *mongo.Client) *MongoSessionStore {
+	return &MongoSessionStore{
+		client: client,
+		coll:   client.Database(DBNAME).Collection(sessionColl),
+	}
+}
+
+func (s *MongoSessionStore) Insert(ctx context.Context, session *auth.Session) error {
+	_, err := s.coll.InsertOne(ctx, session)
+	return err
+}
+
+func (s *MongoSessionStore) GetSessionById(ctx context.Context, id string) (*auth.Session, error) {
+	var session auth.Session
+	if err := s.coll.FindOne(ctx, bson.M{"_id": id}).Decode(&session); err != nil {
+		return nil, notFound(err)
+	}
+	return &session, nil
+}
+
+// Rotate stores the new refresh token of an active session, provided its
+// token is still previousHash. Of two requests using the same refresh token
+// only one can rotate it; the other gets ErrNotFound.
+func (s *MongoSessionStore) Rotate(ctx context.Context, session *auth.Session, previousHash string) error {
+	filter := bson.M{
+		"_id":       session.Id,
+		"tokenHash": previousHash,
+		"revokedAt": bson.M{"$exists": false},
+	}
+	update := bson.M{
+		"$set": bson.M{
+			"tokenHash": session.TokenHash,
+			"expiresAt": session.ExpiresAt,
+		},
+	}
+	res, err := s.coll.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return err
+	}
+	if res.MatchedCount == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func (s *MongoSessionStore) Revoke(ctx context.Context, id string) error {
+	filter := bson.M{"_id": id, "revokedAt": bson.M{"$exists": false}}
+	_, err := s.coll.UpdateOne(ctx, filter, bson.M{"$set": bson.M{"revokedAt": time.Now()}})
+	return err
+}
+
+func (s *MongoSessionStore) RevokeByUserId(ctx context.Context, userId string) error {
+	filter := bson.M{"userId": userId, "revokedAt": bson.M{"$exists": false}}
+	_, err := s.coll.UpdateMany(ctx, filter, bson.M{"$set": bson.M{"revokedAt": time.Now()}})
+	return err
+}
+
+func (s *MongoSessionStore) RevokeOthers(ctx context.Context, userId, keepId string) error {
+	filter := bson.M{"userId": userId, "_id": bson.M{"$ne": keepId}, "revokedAt": bson.M{"$exists": false}}
+	_, err := s.coll.UpdateMany(ctx, filter, bson.M{"$set": bson.M{"revokedAt": time.Now()}})
+	return err
+}
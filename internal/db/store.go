@@ -0,0 +1,163 @@
+package db
+
+import (
+	"context"
+	"time"
+
+	"github.com/ctchen222/hotel-system/internal/auth"
+	"github.com/ctchen222/hotel-system/internal/pricing"
+	"github.com/ctchen222/hotel-system/internal/types"
+)
+
+// Store holds the stores of one backend. The handlers only use these
+// interfaces, so MongoDB and PostgreSQL can be swapped by configuration.
+//
+// Every store returns ErrNotFound when the record it is asked for does not
+// exist, including when the id isn't valid for the backend.
+type Store struct {
+	User      UserStore
+	Hotel     HotelStore
+	Room      RoomStore
+	Booking   BookingStore
+	RatePlan  RatePlanStore
+	PromoCode PromoCodeStore
+	Payment   PaymentStore
+	Session   SessionStore
+	Token     TokenStore
+	TwoFactor TwoFactorStore
+	APIKey    APIKeyStore
+	Identity  IdentityStore
+}
+
+type UserStore interface {
+	GetUserById(context.Context, string) (*types.User, error)
+	GetUserByEmail(context.Context, string) (*types.User, error)
+	GetUsers(context.Context) ([]*types.User, error)
+	// Create returns ErrEmailExists if the email is already registered.
+	Create(context.Context, *types.User) (*types.User, error)
+	// DeleteById, Update and the other changes of a user return ErrNotFound
+	// if there is no such user. DeleteById returns ErrHasBookings if the user
+	// has bookings.
+	DeleteById(context.Context, string) error
+	Update(ctx context.Context, params types.UserUpdateParams, id string) error
+	UpdateRole(ctx context.Context, id string, role auth.Role, hotelIds []string) error
+	UpdatePassword(ctx context.Context, id string, encryptedPassword string) error
+	MarkEmailVerified(ctx context.Context, id string) error
+}
+
+type HotelStore interface {
+	// Insert returns ErrHotelExists if another hotel has the same name.
+	Insert(context.Context, *types.Hotel) (*types.Hotel, error)
+	// Update returns ErrHotelExists if another hotel has the new name.
+	Update(ctx context.Context, params types.HotelUpdateParams, id string) error
+	GetHotels(context.Context) ([]*types.Hotel, error)
+	GetHotelById(context.Context, string) (*types.Hotel, error)
+	// Delete removes the hotel with its rooms. It returns ErrHasBookings if
+	// any of the rooms has bookings.
+	Delete(ctx context.Context, id string) error
+}
+
+type RoomStore interface {
+	// Insert returns ErrNotFound if the room's hotel does not exist.
+	Insert(context.Context, *types.Room) (*types.Room, error)
+	GetRooms(ctx context.Context, hotelId string) ([]*types.Room, error)
+	GetRoomById(ctx context.Context, id string) (*types.Room, error)
+	// Delete returns ErrHasBookings if the room has bookings.
+	Delete(ctx context.Context, id string) error
+	// GetAvailableRooms returns the rooms matching the query that have no
+	// booking overlapping the stay, grouped by hotel.
+	GetAvailableRooms(context.Context, types.AvailabilityQuery) ([]*types.HotelAvailability, error)
+}
+
+type BookingStore interface {
+	// Insert stores the booking and redeems its promo code. It returns a
+	// BookingConflictError if the room is taken for any night of the stay,
+	// ErrUnknownPromoCode if the code doesn't exist and ErrPromoCodeExhausted
+	// if it has no redemptions left; in each case nothing is stored.
+	Insert(context.Context, *types.Booking) (*types.Booking, error)
+	GetBookings(context.Context, types.BookingFilter) ([]*types.Booking, error)
+	GetBookingById(ctx context.Context, id string) (*types.Booking, error)
+	// CancelBooking cancels a pending or confirmed booking, records the
+	// refund and gives back its promo code redemption.
+	CancelBooking(ctx context.Context, id string, refundAmount pricing.Money) (*types.Booking, error)
+	UpdateBooking(ctx context.Context, current, updated *types.Booking) (*types.Booking, error)
+	ConfirmBooking(ctx context.Context, id string) (*types.Booking, error)
+	ReleaseExpiredHolds(ctx context.Context, now time.Time) (int, error)
+}
+
+type RatePlanStore interface {
+	Insert(context.Context, *types.RatePlan) (*types.RatePlan, error)
+	// GetRatePlans returns the rate plans of the given rooms, or every rate
+	// plan if no room is given.
+	GetRatePlans(ctx context.Context, roomIds ...string) ([]*types.RatePlan, error)
+	GetRatePlanById(ctx context.Context, id string) (*types.RatePlan, error)
+	Update(context.Context, *types.RatePlan) error
+	Delete(ctx context.Context, id string) error
+}
+
+type PromoCodeStore interface {
+	// Insert returns ErrPromoCodeExists if the code is already taken.
+	Insert(context.Context, *types.PromoCode) (*types.PromoCode, error)
+	GetPromoCodes(context.Context) ([]*types.PromoCode, error)
+	GetPromoCodeById(ctx context.Context, id string) (*types.PromoCode, error)
+	GetPromoCodeByCode(ctx context.Context, code string) (*types.PromoCode, error)
+	Delete(ctx context.Context, id string) error
+}
+
+type PaymentStore interface {
+	Insert(context.Context, *types.Payment) (*types.Payment, error)
+	Update(context.Context, *types.Payment) error
+	GetPaymentsByBookingId(ctx context.Context, bookingId string) ([]*types.Payment, error)
+}
+
+type SessionStore interface {
+	Insert(context.Context, *auth.Session) error
+	GetSessionById(ctx context.Context, id string) (*auth.Session, error)
+	// Rotate replaces the token hash of an active session, but only if it
+	// is still previousHash; otherwise it returns ErrNotFound.
+	Rotate(ctx context.Context, session *auth.Session, previousHash string) error
+	Revoke(ctx context.Context, id string) error
+	RevokeByUserId(ctx context.Context, userId string) error
+	// RevokeOthers revokes every session of the user except keepId.
+	RevokeOthers(ctx context.Context, userId, keepId string) error
+}
+
+type TokenStore interface {
+	Insert(context.Context, *auth.OneTimeToken) error
+	// Consume marks an unused, unexpired token as used and returns it. Any
+	// other token gives ErrNotFound.
+	Consume(ctx context.Context, purpose auth.Purpose, tokenHash string, now time.Time) (*auth.OneTimeToken, error)
+	DeleteByUserId(ctx context.Context, userId string, purpose auth.Purpose) error
+}
+
+type TwoFactorStore interface {
+	GetTwoFactor(ctx context.Context, userId string) (*auth.TwoFactor, error)
+	Upsert(context.Context, *auth.TwoFactor) error
+	Enable(ctx context.Context, userId string, recoveryCodes []string, step int64) error
+	// UseStep records step as the last TOTP step used. It returns
+	// ErrNotFound if that step or a later one was used already.
+	UseStep(ctx context.Context, userId string, step int64) error
+	// UseRecoveryCode removes a recovery code, returning ErrNotFound if the
+	// user doesn't have it.
+	UseRecoveryCode(ctx context.Context, userId string, codeHash string) error
+	Delete(ctx context.Context, userId string) error
+
+	GetRequiredRoles(context.Context) ([]auth.Role, error)
+	SetRequiredRoles(context.Context, []auth.Role) error
+}
+
+type APIKeyStore interface {
+	Insert(context.Context, *auth.APIKey) error
+	GetAPIKeyById(ctx context.Context, id string) (*auth.APIKey, error)
+	GetAPIKeys(context.Context) ([]*auth.APIKey, error)
+	// Revoke revokes an active key, returning ErrNotFound if there is none.
+	Revoke(ctx context.Context, id string) error
+	Touch(ctx context.Context, id string, usedAt time.Time) error
+}
+
+// IdentityStore links the identities of users at single sign-on providers
+// to their accounts.
+type IdentityStore interface {
+	GetIdentityUserId(ctx context.Context, issuer, subject string) (string, error)
+	Insert(ctx context.Context, issuer, subject, userId string) error
+}
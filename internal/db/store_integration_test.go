@@ -0,0 +1,105 @@
+//go:build integration
+
+package db_test
+
+import (
+	"context"
+	"errors"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/ctchen222/hotel-system/internal/db"
+	"github.com/ctchen222/hotel-system/internal/db/storetest"
+	"github.com/ctchen222/hotel-system/internal/types"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// TestMongoStore runs against the MongoDB server at MONGO_TEST_URI. It drops
+// the database before every check, so never point it at a database in use.
+func TestMongoStore(t *testing.T) {
+	uri := os.Getenv("MONGO_TEST_URI")
+	if uri == "" {
+		t.Skip("MONGO_TEST_URI is not set")
+	}
+	storetest.Run(t, func(t *testing.T) *db.Store {
+		return newMongoStore(t, uri)
+	})
+}
+
+// newMongoStore drops the test database and sets up its schema again.
+func newMongoStore(t *testing.T, uri string) *db.Store {
+	ctx := context.Background()
+	client := db.NewMongoInstance(uri)
+	if err := client.Database(db.DBNAME).Drop(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if err := db.EnsureSchema(ctx, client); err != nil {
+		t.Fatal(err)
+	}
+	return db.NewMongoStore(client)
+}
+
+// Bookings stored before room nights were reserved must get their nights
+// once the schema is ensured, or new bookings could overlap them.
+func TestEnsureSchema_BackfillsRoomNights(t *testing.T) {
+	uri := os.Getenv("MONGO_TEST_URI")
+	if uri == "" {
+		t.Skip("MONGO_TEST_URI is not set")
+	}
+	ctx := context.Background()
+	store := newMongoStore(t, uri)
+	client := db.NewMongoInstance(uri)
+	day := func(n int) time.Time {
+		return time.Date(2030, time.January, n, 0, 0, 0, 0, time.UTC)
+	}
+
+	hotel, err := store.Hotel.Insert(ctx, &types.Hotel{Name: "Hotel 1", Location: "Taipei"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	room, err := store.Room.Insert(ctx, &types.Room{Price: 100, HotelId: hotel.Id})
+	if err != nil {
+		t.Fatal(err)
+	}
+	roomId, err := primitive.ObjectIDFromHex(room.Id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	legacy := bson.M{"userId": primitive.NewObjectID(), "roomId": roomId, "numPerson": 1, "from": day(10), "to": day(12), "status": types.BookingConfirmed}
+	cancelled := bson.M{"userId": primitive.NewObjectID(), "roomId": roomId, "numPerson": 1, "from": day(14), "to": day(16), "status": types.BookingCancelled}
+	// double bookings made before nights were reserved are logged, and
+	// don't keep the server from starting
+	doubleRoomId := primitive.NewObjectID()
+	double := bson.M{"userId": primitive.NewObjectID(), "roomId": doubleRoomId, "numPerson": 1, "from": day(20), "to": day(22), "status": types.BookingConfirmed}
+	overlapping := bson.M{"userId": primitive.NewObjectID(), "roomId": doubleRoomId, "numPerson": 1, "from": day(21), "to": day(23), "status": types.BookingConfirmed}
+	if _, err := client.Database(db.DBNAME).Collection("bookings").InsertMany(ctx, bson.A{legacy, cancelled, double, overlapping}); err != nil {
+		t.Fatal(err)
+	}
+	if err := db.EnsureSchema(ctx, client); err != nil {
+		t.Fatalf("EnsureSchema() with overlapping legacy bookings error = %v, want nil", err)
+	}
+
+	booking := func(from, to time.Time) *types.Booking {
+		return &types.Booking{
+			UserId:    primitive.NewObjectID().Hex(),
+			RoomId:    roomId.Hex(),
+			NumPerson: 1,
+			From:      from,
+			To:        to,
+			Status:    types.BookingConfirmed,
+		}
+	}
+	var conflict *db.BookingConflictError
+	if _, err := store.Booking.Insert(ctx, booking(day(11), day(13))); !errors.As(err, &conflict) {
+		t.Errorf("Insert() over a legacy booking error = %v, want a BookingConflictError", err)
+	}
+	if _, err := store.Booking.Insert(ctx, booking(day(14), day(16))); err != nil {
+		t.Errorf("Insert() over a cancelled legacy booking error = %v, want nil", err)
+	}
+	// running it again leaves the reserved nights alone
+	if err := db.EnsureSchema(ctx, client); err != nil {
+		t.Fatal(err)
+	}
+}
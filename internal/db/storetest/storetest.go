@@ -0,0 +1,622 @@
+// Package storetest checks that the stores of a backend behave the way the
+// handlers rely on, whichever database they use. Every backend runs it from
+// its own tests; MongoDB and PostgreSQL do so in integration tests, built
+// with -tags integration.
+package storetest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ctchen222/hotel-system/internal/auth"
+	"github.com/ctchen222/hotel-system/internal/db"
+	"github.com/ctchen222/hotel-system/internal/pricing"
+	"github.com/ctchen222/hotel-system/internal/types"
+)
+
+// Run runs every check against the stores newStore returns. newStore is
+// called once per check and must return stores without any records.
+func Run(t *testing.T, newStore func(t *testing.T) *db.Store) {
+	checks := []struct {
+		name  string
+		check func(t *testing.T, f *fixture)
+	}{
+		{"BookingOverlap", testBookingOverlap},
+		{"BookingOfMissingRoom", testBookingOfMissingRoom},
+		{"ConcurrentBookings", testConcurrentBookings},
+		{"UpdateBooking", testUpdateBooking},
+		{"ConcurrentPromoCodeRedemptions", testConcurrentPromoCodeRedemptions},
+		{"PromoCodeRedemption", testPromoCodeRedemption},
+		{"DefaultRoomCapacity", testDefaultRoomCapacity},
+		{"AvailabilityLocation", testAvailabilityLocation},
+		{"MissingUser", testMissingUser},
+		{"DeleteWithBookings", testDeleteWithBookings},
+		{"DeleteRemovesPricing", testDeleteRemovesPricing},
+		{"RevokeOtherSessions", testRevokeOtherSessions},
+	}
+	for _, c := range checks {
+		t.Run(c.name, func(t *testing.T) {
+			c.check(t, &fixture{t: t, ctx: context.Background(), store: newStore(t)})
+		})
+	}
+}
+
+func day(n int) time.Time {
+	return time.Date(2030, time.January, n, 0, 0, 0, 0, time.UTC)
+}
+
+// fixture creates the records a check needs through the stores themselves,
+// so the ids are valid for the backend.
+type fixture struct {
+	t     *testing.T
+	ctx   context.Context
+	store *db.Store
+	n     int
+}
+
+func (f *fixture) user() *types.User {
+	f.t.Helper()
+	f.n++
+	user, err := f.store.User.Create(f.ctx, &types.User{
+		FirstName:         "Test",
+		LastName:          "Guest",
+		Email:             fmt.Sprintf("guest%d@example.com", f.n),
+		EncryptedPassword: "-",
+		Role:              auth.RoleGuest,
+	})
+	if err != nil {
+		f.t.Fatal(err)
+	}
+	return user
+}
+
+func (f *fixture) hotel() *types.Hotel {
+	f.t.Helper()
+	f.n++
+	hotel, err := f.store.Hotel.Insert(f.ctx, &types.Hotel{Name: fmt.Sprintf("Hotel %d", f.n), Location: "Taipei"})
+	if err != nil {
+		f.t.Fatal(err)
+	}
+	return hotel
+}
+
+func (f *fixture) room(hotelId string) *types.Room {
+	f.t.Helper()
+	room, err := f.store.Room.Insert(f.ctx, &types.Room{Size: "double", Price: 100, Capacity: 2, HotelId: hotelId})
+	if err != nil {
+		f.t.Fatal(err)
+	}
+	return room
+}
+
+func (f *fixture) promoCode(code string, maxRedemptions int) *types.PromoCode {
+	f.t.Helper()
+	promoCode, err := f.store.PromoCode.Insert(f.ctx, &types.PromoCode{Promotion: pricing.Promotion{
+		Code:           code,
+		Kind:           pricing.PromotionPercentage,
+		Percent:        10,
+		ValidFrom:      day(1),
+		ValidTo:        day(31),
+		MaxRedemptions: maxRedemptions,
+	}})
+	if err != nil {
+		f.t.Fatal(err)
+	}
+	return promoCode
+}
+
+// newBooking returns a confirmed booking of the room, which isn't stored.
+func newBooking(user *types.User, room *types.Room, from, to time.Time) *types.Booking {
+	return &types.Booking{
+		UserId:    user.Id,
+		RoomId:    room.Id,
+		NumPerson: 1,
+		From:      from,
+		To:        to,
+		Price:     pricing.Breakdown{Currency: "TWD"},
+		Status:    types.BookingConfirmed,
+	}
+}
+
+func (f *fixture) booking(booking *types.Booking) *types.Booking {
+	f.t.Helper()
+	booking, err := f.store.Booking.Insert(f.ctx, booking)
+	if err != nil {
+		f.t.Fatal(err)
+	}
+	return booking
+}
+
+// roomBookings returns the bookings of the room that aren't cancelled.
+func (f *fixture) roomBookings(roomId string) []*types.Booking {
+	f.t.Helper()
+	bookings, err := f.store.Booking.GetBookings(f.ctx, types.BookingFilter{})
+	if err != nil {
+		f.t.Fatal(err)
+	}
+	var active []*types.Booking
+	for _, booking := range bookings {
+		if booking.RoomId == roomId && booking.Status != types.BookingCancelled {
+			active = append(active, booking)
+		}
+	}
+	return active
+}
+
+func isConflict(err error) bool {
+	var conflict *db.BookingConflictError
+	return errors.As(err, &conflict)
+}
+
+func testBookingOverlap(t *testing.T, f *fixture) {
+	user := f.user()
+	hotel := f.hotel()
+	existing := &types.Booking{From: day(10), To: day(15)}
+
+	tests := []struct {
+		name string
+		from time.Time
+		to   time.Time
+		// cancelled cancels the existing booking first
+		cancelled bool
+	}{
+		{name: "Identical stay", from: day(10), to: day(15)},
+		{name: "Inside the existing stay", from: day(11), to: day(14)},
+		{name: "Around the existing stay", from: day(8), to: day(17)},
+		{name: "Overlapping the check-in", from: day(8), to: day(12)},
+		{name: "Overlapping the check-out", from: day(13), to: day(17)},
+		{name: "Checking out on the check-in day", from: day(5), to: day(10)},
+		{name: "Checking in on the check-out day", from: day(15), to: day(20)},
+		{name: "Entirely before", from: day(1), to: day(5)},
+		{name: "Entirely after", from: day(20), to: day(25)},
+		{name: "Identical stay after a cancellation", from: day(10), to: day(15), cancelled: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			room := f.room(hotel.Id)
+			booked := f.booking(newBooking(user, room, existing.From, existing.To))
+			if tt.cancelled {
+				if _, err := f.store.Booking.CancelBooking(f.ctx, booked.Id, 0); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			_, err := f.store.Booking.Insert(f.ctx, newBooking(user, room, tt.from, tt.to))
+			wantConflict := !tt.cancelled && existing.Overlaps(tt.from, tt.to)
+			switch {
+			case wantConflict && !isConflict(err):
+				t.Errorf("Insert() error = %v, want a BookingConflictError", err)
+			case !wantConflict && err != nil:
+				t.Errorf("Insert() error = %v, want nil", err)
+			}
+		})
+	}
+}
+
+// testBookingOfMissingRoom checks that a room that no longer exists can't be
+// booked.
+func testBookingOfMissingRoom(t *testing.T, f *fixture) {
+	room := f.room(f.hotel().Id)
+	if err := f.store.Room.Delete(f.ctx, room.Id); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := f.store.Booking.Insert(f.ctx, newBooking(f.user(), room, day(10), day(12))); !errors.Is(err, db.ErrNotFound) {
+		t.Errorf("Insert() error = %v, want %v", err, db.ErrNotFound)
+	}
+	if bookings := f.roomBookings(room.Id); len(bookings) != 0 {
+		t.Errorf("stored %d bookings of a deleted room, want 0", len(bookings))
+	}
+}
+
+// testConcurrentBookings books overlapping stays of one room at the same
+// time. However the requests interleave, no two stored bookings may overlap.
+func testConcurrentBookings(t *testing.T, f *fixture) {
+	user := f.user()
+	room := f.room(f.hotel().Id)
+
+	const attempts = 20
+	var (
+		wg       sync.WaitGroup
+		mu       sync.Mutex
+		booked   int
+		failures []error
+	)
+	for i := range attempts {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			from := day(10 + i%5)
+			_, err := f.store.Booking.Insert(f.ctx, newBooking(user, room, from, from.AddDate(0, 0, 2)))
+			mu.Lock()
+			defer mu.Unlock()
+			switch {
+			case err == nil:
+				booked++
+			case !isConflict(err):
+				failures = append(failures, err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	for _, err := range failures {
+		t.Errorf("Insert() error = %v, want nil or a BookingConflictError", err)
+	}
+	if booked == 0 {
+		t.Fatal("no concurrent booking succeeded")
+	}
+	bookings := f.roomBookings(room.Id)
+	if len(bookings) != booked {
+		t.Errorf("stored %d bookings, want the %d that succeeded", len(bookings), booked)
+	}
+	for i, a := range bookings {
+		for _, b := range bookings[i+1:] {
+			if a.Overlaps(b.From, b.To) {
+				t.Errorf("bookings %s and %s overlap", a.Id, b.Id)
+			}
+		}
+	}
+}
+
+// testUpdateBooking checks that a change that clashes with another booking
+// leaves the booking as it was, and that the nights a booking gives up can be
+// booked again.
+func testUpdateBooking(t *testing.T, f *fixture) {
+	user := f.user()
+	room := f.room(f.hotel().Id)
+	booking := f.booking(newBooking(user, room, day(10), day(12)))
+	f.booking(newBooking(user, room, day(14), day(16)))
+
+	clashing := *booking
+	clashing.To = day(15)
+	if _, err := f.store.Booking.UpdateBooking(f.ctx, booking, &clashing); !isConflict(err) {
+		t.Fatalf("UpdateBooking() into another booking error = %v, want a BookingConflictError", err)
+	}
+	stored, err := f.store.Booking.GetBookingById(f.ctx, booking.Id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !stored.From.Equal(day(10)) || !stored.To.Equal(day(12)) {
+		t.Errorf("booking after a rejected update = %v to %v, want it unchanged", stored.From, stored.To)
+	}
+
+	moved := *stored
+	moved.From = day(11)
+	moved.To = day(14)
+	updated, err := f.store.Booking.UpdateBooking(f.ctx, stored, &moved)
+	if err != nil {
+		t.Fatalf("UpdateBooking() up to the next check-in error = %v, want nil", err)
+	}
+	if !updated.From.Equal(day(11)) || !updated.To.Equal(day(14)) {
+		t.Errorf("UpdateBooking() = %v to %v, want %v to %v", updated.From, updated.To, day(11), day(14))
+	}
+	if _, err := f.store.Booking.UpdateBooking(f.ctx, stored, &moved); !errors.Is(err, db.ErrBookingChanged) && !isConflict(err) {
+		t.Errorf("UpdateBooking() of a stale booking error = %v, want a conflict", err)
+	}
+
+	// the night given up is free again
+	if _, err := f.store.Booking.Insert(f.ctx, newBooking(user, room, day(10), day(11))); err != nil {
+		t.Errorf("Insert() of a released night error = %v, want nil", err)
+	}
+}
+
+// testConcurrentPromoCodeRedemptions redeems a limited code from more
+// bookings at once than it allows.
+func testConcurrentPromoCodeRedemptions(t *testing.T, f *fixture) {
+	const maxRedemptions = 3
+	user := f.user()
+	hotel := f.hotel()
+	promoCode := f.promoCode("LIMITED", maxRedemptions)
+
+	const attempts = 10
+	rooms := make([]*types.Room, attempts)
+	for i := range rooms {
+		rooms[i] = f.room(hotel.Id)
+	}
+
+	var (
+		wg       sync.WaitGroup
+		mu       sync.Mutex
+		redeemed int
+		failures []error
+	)
+	for _, room := range rooms {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			booking := newBooking(user, room, day(10), day(12))
+			booking.Price.PromoCode = promoCode.Code
+			_, err := f.store.Booking.Insert(f.ctx, booking)
+			mu.Lock()
+			defer mu.Unlock()
+			switch {
+			case err == nil:
+				redeemed++
+			case !errors.Is(err, db.ErrPromoCodeExhausted):
+				failures = append(failures, err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	for _, err := range failures {
+		t.Errorf("Insert() error = %v, want nil or %v", err, db.ErrPromoCodeExhausted)
+	}
+	if redeemed != maxRedemptions {
+		t.Errorf("%d bookings redeemed the code, want %d", redeemed, maxRedemptions)
+	}
+	stored, err := f.store.PromoCode.GetPromoCodeById(f.ctx, promoCode.Id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stored.Redemptions != maxRedemptions {
+		t.Errorf("Redemptions = %d, want %d", stored.Redemptions, maxRedemptions)
+	}
+}
+
+// testPromoCodeRedemption checks that a cancelled booking gives its
+// redemption back and that a code that doesn't exist is told apart from one
+// that is used up.
+func testPromoCodeRedemption(t *testing.T, f *fixture) {
+	user := f.user()
+	hotel := f.hotel()
+	promoCode := f.promoCode("ONCE", 1)
+	withCode := func(code string) *types.Booking {
+		booking := newBooking(user, f.room(hotel.Id), day(10), day(12))
+		booking.Price.PromoCode = code
+		return booking
+	}
+
+	booking := f.booking(withCode(promoCode.Code))
+	if _, err := f.store.Booking.Insert(f.ctx, withCode(promoCode.Code)); !errors.Is(err, db.ErrPromoCodeExhausted) {
+		t.Errorf("Insert() with a used up code error = %v, want %v", err, db.ErrPromoCodeExhausted)
+	}
+	if _, err := f.store.Booking.Insert(f.ctx, withCode("MISSING")); !errors.Is(err, db.ErrUnknownPromoCode) {
+		t.Errorf("Insert() with an unknown code error = %v, want %v", err, db.ErrUnknownPromoCode)
+	}
+
+	if _, err := f.store.Booking.CancelBooking(f.ctx, booking.Id, 0); err != nil {
+		t.Fatal(err)
+	}
+	stored, err := f.store.PromoCode.GetPromoCodeById(f.ctx, promoCode.Id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stored.Redemptions != 0 {
+		t.Errorf("Redemptions after the cancellation = %d, want 0", stored.Redemptions)
+	}
+	if _, err := f.store.Booking.Insert(f.ctx, withCode(promoCode.Code)); err != nil {
+		t.Errorf("Insert() with a code given back error = %v, want nil", err)
+	}
+}
+
+// testDefaultRoomCapacity checks that a room stored without a capacity sleeps
+// the default number of guests and is found by searches for them.
+func testDefaultRoomCapacity(t *testing.T, f *fixture) {
+	hotel := f.hotel()
+	room, err := f.store.Room.Insert(f.ctx, &types.Room{Size: "single", Price: 100, HotelId: hotel.Id})
+	if err != nil {
+		t.Fatal(err)
+	}
+	stored, err := f.store.Room.GetRoomById(f.ctx, room.Id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stored.Capacity != types.DefaultRoomCapacity {
+		t.Errorf("Capacity = %d, want %d", stored.Capacity, types.DefaultRoomCapacity)
+	}
+
+	availability, err := f.store.Room.GetAvailableRooms(f.ctx, types.AvailabilityQuery{From: day(10), To: day(12), Guests: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(availability) != 1 || len(availability[0].Rooms) != 1 || availability[0].Rooms[0].Id != room.Id {
+		t.Errorf("GetAvailableRooms() for one guest = %v, want the room", availability)
+	}
+}
+
+// testAvailabilityLocation checks that the location of an availability
+// search is matched case-insensitively but otherwise literally, so characters
+// that are wildcards to a database match only themselves.
+func testAvailabilityLocation(t *testing.T, f *fixture) {
+	for _, location := range []string{"Taipei", "Tainan 100%"} {
+		hotel, err := f.store.Hotel.Insert(f.ctx, &types.Hotel{Name: location, Location: location})
+		if err != nil {
+			t.Fatal(err)
+		}
+		f.room(hotel.Id)
+	}
+
+	tests := []struct {
+		location string
+		want     []string
+	}{
+		{location: "taipei", want: []string{"Taipei"}},
+		{location: "Tai", want: []string{"Tainan 100%", "Taipei"}},
+		{location: "%", want: []string{"Tainan 100%"}},
+		{location: "T_i", want: nil},
+		{location: `\`, want: nil},
+	}
+	for _, tt := range tests {
+		availability, err := f.store.Room.GetAvailableRooms(f.ctx, types.AvailabilityQuery{From: day(10), To: day(12), Location: tt.location})
+		if err != nil {
+			t.Fatal(err)
+		}
+		var got []string
+		for _, entry := range availability {
+			got = append(got, entry.Hotel.Name)
+		}
+		if !slices.Equal(got, tt.want) {
+			t.Errorf("GetAvailableRooms(%q) hotels = %q, want %q", tt.location, got, tt.want)
+		}
+	}
+}
+
+// testMissingUser checks that every change of a user that doesn't exist, or
+// no longer does, returns ErrNotFound.
+func testMissingUser(t *testing.T, f *fixture) {
+	deleted := f.user()
+	if err := f.store.User.DeleteById(f.ctx, deleted.Id); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, id := range []string{deleted.Id, "missing"} {
+		changes := map[string]func() error{
+			"DeleteById": func() error { return f.store.User.DeleteById(f.ctx, id) },
+			"Update": func() error {
+				return f.store.User.Update(f.ctx, types.UserUpdateParams{FirstName: "Jane", LastName: "Doe"}, id)
+			},
+			"UpdateRole":        func() error { return f.store.User.UpdateRole(f.ctx, id, auth.RoleAdmin, nil) },
+			"UpdatePassword":    func() error { return f.store.User.UpdatePassword(f.ctx, id, "-") },
+			"MarkEmailVerified": func() error { return f.store.User.MarkEmailVerified(f.ctx, id) },
+		}
+		for name, change := range changes {
+			if err := change(); !errors.Is(err, db.ErrNotFound) {
+				t.Errorf("%s(%q) error = %v, want %v", name, id, err, db.ErrNotFound)
+			}
+		}
+	}
+}
+
+// testDeleteWithBookings checks that users, hotels and rooms with bookings,
+// even cancelled ones, can't be deleted, and that those without can.
+func testDeleteWithBookings(t *testing.T, f *fixture) {
+	user := f.user()
+	hotel := f.hotel()
+	room := f.room(hotel.Id)
+	booking := f.booking(newBooking(user, room, day(10), day(12)))
+	if _, err := f.store.Booking.CancelBooking(f.ctx, booking.Id, 0); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := f.store.User.DeleteById(f.ctx, user.Id); !errors.Is(err, db.ErrHasBookings) {
+		t.Errorf("User.DeleteById() error = %v, want %v", err, db.ErrHasBookings)
+	}
+	if err := f.store.Hotel.Delete(f.ctx, hotel.Id); !errors.Is(err, db.ErrHasBookings) {
+		t.Errorf("Hotel.Delete() error = %v, want %v", err, db.ErrHasBookings)
+	}
+	if err := f.store.Room.Delete(f.ctx, room.Id); !errors.Is(err, db.ErrHasBookings) {
+		t.Errorf("Room.Delete() error = %v, want %v", err, db.ErrHasBookings)
+	}
+	if _, err := f.store.User.GetUserById(f.ctx, user.Id); err != nil {
+		t.Errorf("GetUserById() after a refused delete error = %v, want nil", err)
+	}
+	if _, err := f.store.Room.GetRoomById(f.ctx, room.Id); err != nil {
+		t.Errorf("GetRoomById() after a refused delete error = %v, want nil", err)
+	}
+
+	unbooked := f.room(hotel.Id)
+	if err := f.store.Room.Delete(f.ctx, unbooked.Id); err != nil {
+		t.Errorf("Room.Delete() without bookings error = %v, want nil", err)
+	}
+	emptyHotel := f.hotel()
+	f.room(emptyHotel.Id)
+	if err := f.store.Hotel.Delete(f.ctx, emptyHotel.Id); err != nil {
+		t.Errorf("Hotel.Delete() without bookings error = %v, want nil", err)
+	}
+	if err := f.store.User.DeleteById(f.ctx, f.user().Id); err != nil {
+		t.Errorf("User.DeleteById() without bookings error = %v, want nil", err)
+	}
+}
+
+// testDeleteRemovesPricing checks that deleting a room removes its rate
+// plans, and deleting a hotel those of its rooms and the hotel's promo codes,
+// while the promo codes of every hotel stay.
+func testDeleteRemovesPricing(t *testing.T, f *fixture) {
+	ratePlan := func(roomId string) *types.RatePlan {
+		t.Helper()
+		ratePlan, err := f.store.RatePlan.Insert(f.ctx, &types.RatePlan{RoomId: roomId, RatePlan: pricing.RatePlan{
+			Name:        "Winter",
+			ValidFrom:   day(1),
+			ValidTo:     day(31),
+			NightlyRate: 12000,
+		}})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return ratePlan
+	}
+	hotel := f.hotel()
+	room := f.room(hotel.Id)
+	other := f.room(hotel.Id)
+	ratePlan(room.Id)
+	otherPlan := ratePlan(other.Id)
+	scoped, err := f.store.PromoCode.Insert(f.ctx, &types.PromoCode{
+		Promotion: pricing.Promotion{Code: "HOTEL", Kind: pricing.PromotionPercentage, Percent: 10, ValidFrom: day(1), ValidTo: day(31)},
+		HotelId:   hotel.Id,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	everywhere := f.promoCode("EVERYWHERE", 0)
+
+	if err := f.store.Room.Delete(f.ctx, room.Id); err != nil {
+		t.Fatal(err)
+	}
+	if plans, err := f.store.RatePlan.GetRatePlans(f.ctx, room.Id); err != nil || len(plans) != 0 {
+		t.Errorf("GetRatePlans() of a deleted room = %d plans, %v, want none", len(plans), err)
+	}
+	if _, err := f.store.RatePlan.GetRatePlanById(f.ctx, otherPlan.Id); err != nil {
+		t.Errorf("GetRatePlanById() of another room error = %v, want nil", err)
+	}
+
+	if err := f.store.Hotel.Delete(f.ctx, hotel.Id); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := f.store.RatePlan.GetRatePlanById(f.ctx, otherPlan.Id); !errors.Is(err, db.ErrNotFound) {
+		t.Errorf("GetRatePlanById() of a deleted hotel's room error = %v, want %v", err, db.ErrNotFound)
+	}
+	if _, err := f.store.PromoCode.GetPromoCodeById(f.ctx, scoped.Id); !errors.Is(err, db.ErrNotFound) {
+		t.Errorf("GetPromoCodeById() of a deleted hotel error = %v, want %v", err, db.ErrNotFound)
+	}
+	if _, err := f.store.PromoCode.GetPromoCodeById(f.ctx, everywhere.Id); err != nil {
+		t.Errorf("GetPromoCodeById() of a code for every hotel error = %v, want nil", err)
+	}
+}
+
+// testRevokeOtherSessions checks that RevokeOthers ends every session of the
+// user but the one kept, and leaves the sessions of other users alone.
+func testRevokeOtherSessions(t *testing.T, f *fixture) {
+	user := f.user()
+	stranger := f.user()
+	now := time.Now()
+	newSession := func(userId string) *auth.Session {
+		t.Helper()
+		session, _, err := auth.NewSession(userId, now)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := f.store.Session.Insert(f.ctx, session); err != nil {
+			t.Fatal(err)
+		}
+		return session
+	}
+	kept, other, strangers := newSession(user.Id), newSession(user.Id), newSession(stranger.Id)
+
+	if err := f.store.Session.RevokeOthers(f.ctx, user.Id, kept.Id); err != nil {
+		t.Fatal(err)
+	}
+	for _, tt := range []struct {
+		name    string
+		session *auth.Session
+		active  bool
+	}{
+		{"kept", kept, true},
+		{"other", other, false},
+		{"another user's", strangers, true},
+	} {
+		session, err := f.store.Session.GetSessionById(f.ctx, tt.session.Id)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := session.Active(now); got != tt.active {
+			t.Errorf("%s session Active() = %v, want %v", tt.name, got, tt.active)
+		}
+	}
+}
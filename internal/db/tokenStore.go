@@ -0,0 +1,56 @@
+package db
+
+import (
+	"context"
+	"time"
+
+	"github.com/ctchen222/hotel-system/internal/auth"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type MongoTokenStore struct {
+	client *mongo.Client
+	coll   *mongo.Collection
+}
+
+func NewMongoTokenStore(client *mongo.Client) *MongoTokenStore {
+	return &MongoTokenStore{
+		client: client,
+		coll:   client.Database(DBNAME).Collection(tokenColl),
+	}
+}
+
+func (s *MongoTokenStore) Insert(ctx context.Context, token *auth.OneTimeToken) error {
+	_, err := s.coll.InsertOne(ctx, token)
+	return err
+}
+
+// Consume marks a usable token as used and returns it. A token can be
+// consumed once; afterwards, or once it expired, Consume returns
+// ErrNotFound.
+func (s *MongoTokenStore) Consume(ctx context.Context, purpose auth.Purpose, tokenHash string, now time.Time) (*auth.OneTimeToken, error) {
+	filter := bson.M{
+		"_id":       tokenHash,
+		"purpose":   purpose,
+		"usedAt":    bson.M{"$exists": false},
+		"expiresAt": bson.M{"$gt": now},
+	}
+	update := bson.M{"$set": bson.M{"usedAt": now}}
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+
+	var token auth.OneTimeToken
+	if err := s.coll.FindOneAndUpdate(ctx, filter, update, opts).Decode(&token); err != nil {
+		return nil, notFound(err)
+	}
+	return &token, nil
+}
+
+// DeleteByUserId drops the unused tokens of the user for purpose, so only the
+// most recently sent token works.
+func (s *MongoTokenStore) DeleteByUserId(ctx context.Context, userId string, purpose auth.Purpose) error {
+	filter := bson.M{"userId": userId, "purpose": purpose, "usedAt": bson.M{"$exists": false}}
+	_, err := s.coll.DeleteMany(ctx, filter)
+	return err
+}
@@ -0,0 +1,119 @@
+package db
+
+import (
+	"context"
+	"errors"
+
+	"github.com/ctchen222/hotel-system/internal/auth"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// twoFactorPolicyId is the id of the settings document holding the roles
+// that have to use two-factor authentication.
+const twoFactorPolicyId = "twoFactor"
+
+type MongoTwoFactorStore struct {
+	client   *mongo.Client
+	coll     *mongo.Collection
+	settings *mongo.Collection
+}
+
+func NewMongoTwoFactorStore(client *mongo.Client) *MongoTwoFactorStore {
+	return &MongoTwoFactorStore{
+		client:   client,
+		coll:     client.Database(DBNAME).Collection(twoFactorColl),
+		settings: client.Database(DBNAME).Collection(settingsColl),
+	}
+}
+
+func (s *MongoTwoFactorStore) GetTwoFactor(ctx context.Context, userId string) (*auth.TwoFactor, error) {
+	var tf auth.TwoFactor
+	if err := s.coll.FindOne(ctx, bson.M{"_id": userId}).Decode(&tf); err != nil {
+		return nil, notFound(err)
+	}
+	return &tf, nil
+}
+
+// Upsert replaces the enrollment of the user, e.g. to start over with a new
+// secret.
+func (s *MongoTwoFactorStore) Upsert(ctx context.Context, tf *auth.TwoFactor) error {
+	opts := options.Replace().SetUpsert(true)
+	_, err := s.coll.ReplaceOne(ctx, bson.M{"_id": tf.UserId}, tf, opts)
+	return err
+}
+
+// Enable turns on a pending enrollment. step is the time step of the code
+// the user confirmed it with.
+func (s *MongoTwoFactorStore) Enable(ctx context.Context, userId string, recoveryCodes []string, step int64) error {
+	filter := bson.M{"_id": userId, "enabled": false}
+	update := bson.M{
+		"$set": bson.M{
+			"enabled":       true,
+			"recoveryCodes": recoveryCodes,
+			"lastStep":      step,
+		},
+	}
+	res, err := s.coll.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return err
+	}
+	if res.MatchedCount == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// UseStep records the time step of an accepted code. A code of the same or
+// an earlier step was used already, so UseStep returns ErrNotFound.
+func (s *MongoTwoFactorStore) UseStep(ctx context.Context, userId string, step int64) error {
+	filter := bson.M{"_id": userId, "enabled": true, "lastStep": bson.M{"$lt": step}}
+	res, err := s.coll.UpdateOne(ctx, filter, bson.M{"$set": bson.M{"lastStep": step}})
+	if err != nil {
+		return err
+	}
+	if res.MatchedCount == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// UseRecoveryCode removes a recovery code. It returns ErrNotFound if
+// the code was used already.
+func (s *MongoTwoFactorStore) UseRecoveryCode(ctx context.Context, userId string, codeHash string) error {
+	filter := bson.M{"_id": userId, "enabled": true, "recoveryCodes": codeHash}
+	res, err := s.coll.UpdateOne(ctx, filter, bson.M{"$pull": bson.M{"recoveryCodes": codeHash}})
+	if err != nil {
+		return err
+	}
+	if res.MatchedCount == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func (s *MongoTwoFactorStore) Delete(ctx context.Context, userId string) error {
+	_, err := s.coll.DeleteOne(ctx, bson.M{"_id": userId})
+	return err
+}
+
+func (s *MongoTwoFactorStore) GetRequiredRoles(ctx context.Context) ([]auth.Role, error) {
+	var policy struct {
+		Roles []auth.Role `bson:"roles"`
+	}
+	err := s.settings.FindOne(ctx, bson.M{"_id": twoFactorPolicyId}).Decode(&policy)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return policy.Roles, nil
+}
+
+func (s *MongoTwoFactorStore) SetRequiredRoles(ctx context.Context, roles []auth.Role) error {
+	opts := options.Update().SetUpsert(true)
+	_, err := s.settings.UpdateOne(ctx, bson.M{"_id": twoFactorPolicyId}, bson.M{"$set": bson.M{"roles": roles}}, opts)
+	return err
+}
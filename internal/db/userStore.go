@@ -2,63 +2,91 @@ package db
 
 import (
 	"context"
-	"fmt"
 
+	"github.com/ctchen222/hotel-system/internal/auth"
 	"github.com/ctchen222/hotel-system/internal/types"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
 )
 
-type Dropper interface {
-	Drop(context.Context) error
+// userDoc is how a user is stored in MongoDB.
+type userDoc struct {
+	Id                primitive.ObjectID   `bson:"_id,omitempty"`
+	FirstName         string               `bson:"firstName"`
+	LastName          string               `bson:"lastName"`
+	Email             string               `bson:"email"`
+	EncryptedPassword string               `bson:"encryptedPassword"`
+	EmailVerified     bool                 `bson:"emailVerified"`
+	Role              auth.Role            `bson:"role"`
+	HotelIds          []primitive.ObjectID `bson:"hotelIds,omitempty"`
 }
 
-type UserStore interface {
-	Dropper
+func newUserDoc(user *types.User) (*userDoc, error) {
+	hotelIds, err := objectIds(user.HotelIds)
+	if err != nil {
+		return nil, err
+	}
+	return &userDoc{
+		FirstName:         user.FirstName,
+		LastName:          user.LastName,
+		Email:             user.Email,
+		EncryptedPassword: user.EncryptedPassword,
+		EmailVerified:     user.EmailVerified,
+		Role:              user.Role,
+		HotelIds:          hotelIds,
+	}, nil
+}
 
-	GetUserById(context.Context, string) (*types.User, error)
-	GetUserByEmail(context.Context, string) (*types.User, error)
-	GetUsers(context.Context) ([]*types.User, error)
-	Create(context.Context, *types.User) (*types.User, error)
-	DeleteById(context.Context, string) error
-	Update(ctx context.Context, params types.UserUpdateParams, id string) error
+func (d *userDoc) user() *types.User {
+	return &types.User{
+		Id:                d.Id.Hex(),
+		FirstName:         d.FirstName,
+		LastName:          d.LastName,
+		Email:             d.Email,
+		EncryptedPassword: d.EncryptedPassword,
+		EmailVerified:     d.EmailVerified,
+		Role:              d.Role,
+		HotelIds:          hexIds(d.HotelIds),
+	}
 }
 
 type MongoUserStore struct {
-	client *mongo.Client
-	coll   *mongo.Collection
+	client      *mongo.Client
+	coll        *mongo.Collection
+	bookingColl *mongo.Collection
 }
 
 func NewMongoUserStore(client *mongo.Client) *MongoUserStore {
 	return &MongoUserStore{
-		client: client,
-		coll:   client.Database(DBNAME).Collection(userColl),
+		client:      client,
+		coll:        client.Database(DBNAME).Collection(userColl),
+		bookingColl: client.Database(DBNAME).Collection(bookingColl),
 	}
 }
 
 func (s *MongoUserStore) GetUserById(ctx context.Context, id string) (*types.User, error) {
 	// validate the correctness of the id
-	oid, err := primitive.ObjectIDFromHex(id)
+	oid, err := objectId(id)
 	if err != nil {
 		return nil, err
 	}
 
 	// find the user by id
-	var user types.User
+	var user userDoc
 	if err := s.coll.FindOne(ctx, bson.M{"_id": oid}).Decode(&user); err != nil {
-		return nil, err
+		return nil, notFound(err)
 	}
-	return &user, nil
+	return user.user(), nil
 }
 
 func (s *MongoUserStore) GetUserByEmail(ctx context.Context, email string) (*types.User, error) {
 	// find the user by email
-	var user types.User
+	var user userDoc
 	if err := s.coll.FindOne(ctx, bson.M{"email": email}).Decode(&user); err != nil {
-		return nil, err
+		return nil, notFound(err)
 	}
-	return &user, nil
+	return user.user(), nil
 }
 
 func (s *MongoUserStore) GetUsers(ctx context.Context) ([]*types.User, error) {
@@ -67,52 +95,64 @@ func (s *MongoUserStore) GetUsers(ctx context.Context) ([]*types.User, error) {
 		return nil, err
 	}
 
-	var users []*types.User
-	if err := cur.All(ctx, &users); err != nil {
+	var docs []*userDoc
+	if err := cur.All(ctx, &docs); err != nil {
 		return nil, err
 	}
 
+	users := make([]*types.User, 0, len(docs))
+	for _, doc := range docs {
+		users = append(users, doc.user())
+	}
 	return users, nil
 }
 
 func (s *MongoUserStore) Create(ctx context.Context, user *types.User) (*types.User, error) {
-	// First check if a user with the same email exists
-	var existedUser types.User
-	err := s.coll.FindOne(ctx, bson.M{"email": user.Email}).Decode(&existedUser)
-	if err == nil {
-		return nil, fmt.Errorf("user with email %s already exists", existedUser.Email)
-	}
-	if err != mongo.ErrNoDocuments {
+	doc, err := newUserDoc(user)
+	if err != nil {
 		return nil, err
 	}
-
-	// If no user with same email exists, proceed with insertion
-	res, err := s.coll.InsertOne(ctx, user)
+	// The unique index on email rejects a second account with the same email,
+	// even when both are created at the same time.
+	res, err := s.coll.InsertOne(ctx, doc)
 	if err != nil {
-		return nil, err
+		if mongo.IsDuplicateKeyError(err) {
+			return nil, ErrEmailExists
+		}
+		return nil, writeError(err)
 	}
 
-	user.Id = res.InsertedID.(primitive.ObjectID)
+	user.Id = res.InsertedID.(primitive.ObjectID).Hex()
 	return user, nil
 }
 
 func (s *MongoUserStore) DeleteById(ctx context.Context, id string) error {
 	// validate the correctness of the id
-	oid, err := primitive.ObjectIDFromHex(id)
+	oid, err := objectId(id)
 	if err != nil {
 		return err
 	}
+	booked, err := hasBookings(ctx, s.bookingColl, bson.M{"userId": oid})
+	if err != nil {
+		return err
+	}
+	if booked {
+		return ErrHasBookings
+	}
 
-	_, err = s.coll.DeleteOne(ctx, bson.M{"_id": oid})
+	res, err := s.coll.DeleteOne(ctx, bson.M{"_id": oid})
 	if err != nil {
 		return err
 	}
+	if res.DeletedCount == 0 {
+		return ErrNotFound
+	}
 
 	return nil
 }
 
 func (s *MongoUserStore) Update(ctx context.Context, params types.UserUpdateParams, id string) error {
-	oid, err := primitive.ObjectIDFromHex(id)
+	oid, err := objectId(id)
 	if err != nil {
 		return err
 	}
@@ -127,14 +167,85 @@ func (s *MongoUserStore) Update(ctx context.Context, params types.UserUpdatePara
 		},
 	}
 
-	if _, err := s.coll.UpdateOne(ctx, filter, update); err != nil {
+	res, err := s.coll.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return writeError(err)
+	}
+	if res.MatchedCount == 0 {
+		return ErrNotFound
+	}
+
+	return nil
+}
+
+// UpdateRole sets the role of the user and the hotels they work for.
+func (s *MongoUserStore) UpdateRole(ctx context.Context, id string, role auth.Role, hotelIds []string) error {
+	oid, err := objectId(id)
+	if err != nil {
+		return err
+	}
+	hotelOids, err := objectIds(hotelIds)
+	if err != nil {
+		return err
+	}
+
+	update := bson.M{
+		"$set": bson.M{
+			"role":     role,
+			"hotelIds": hotelOids,
+		},
+	}
+	res, err := s.coll.UpdateOne(ctx, bson.M{"_id": oid}, update)
+	if err != nil {
+		return writeError(err)
+	}
+	if res.MatchedCount == 0 {
+		return ErrNotFound
+	}
+
+	return nil
+}
+
+func (s *MongoUserStore) UpdatePassword(ctx context.Context, id string, encryptedPassword string) error {
+	oid, err := objectId(id)
+	if err != nil {
 		return err
 	}
 
+	update := bson.M{
+		"$set": bson.M{
+			"encryptedPassword": encryptedPassword,
+		},
+	}
+	res, err := s.coll.UpdateOne(ctx, bson.M{"_id": oid}, update)
+	if err != nil {
+		return writeError(err)
+	}
+	if res.MatchedCount == 0 {
+		return ErrNotFound
+	}
+
 	return nil
 }
 
-func (s *MongoUserStore) Drop(ctx context.Context) error {
-	fmt.Println("---Dropping User collection---")
-	return s.coll.Drop(ctx)
+func (s *MongoUserStore) MarkEmailVerified(ctx context.Context, id string) error {
+	oid, err := objectId(id)
+	if err != nil {
+		return err
+	}
+
+	update := bson.M{
+		"$set": bson.M{
+			"emailVerified": true,
+		},
+	}
+	res, err := s.coll.UpdateOne(ctx, bson.M{"_id": oid}, update)
+	if err != nil {
+		return writeError(err)
+	}
+	if res.MatchedCount == 0 {
+		return ErrNotFound
+	}
+
+	return nil
 }
@@ -0,0 +1,54 @@
+package holds
+
+import (
+	"context"
+	"log"
+	"time"
+)
+
+const (
+	// DefaultDuration is how long a hold blocks a room before it must be
+	// confirmed.
+	DefaultDuration = 15 * time.Minute
+	// DefaultSweepInterval is how often expired holds are released.
+	DefaultSweepInterval = time.Minute
+)
+
+// Releaser releases the holds that expired before now and reports how many it
+// released. Every booking store implements it.
+type Releaser interface {
+	ReleaseExpiredHolds(ctx context.Context, now time.Time) (int, error)
+}
+
+// Sweep releases expired holds from every releaser once per interval until ctx
+// is done. Errors are logged and retried on the next tick.
+func Sweep(ctx context.Context, interval time.Duration, releasers ...Releaser) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			SweepOnce(ctx, now, releasers...)
+		}
+	}
+}
+
+// SweepOnce releases the holds that expired before now and returns how many
+// were released.
+func SweepOnce(ctx context.Context, now time.Time, releasers ...Releaser) int {
+	total := 0
+	for _, releaser := range releasers {
+		released, err := releaser.ReleaseExpiredHolds(ctx, now)
+		if err != nil {
+			log.Printf("releasing expired holds: %v", err)
+		}
+		total += released
+	}
+	if total > 0 {
+		log.Printf("released %d expired holds", total)
+	}
+	return total
+}
@@ -0,0 +1,68 @@
+package holds
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+type releaserFunc func(ctx context.Context, now time.Time) (int, error)
+
+func (f releaserFunc) ReleaseExpiredHolds(ctx context.Context, now time.Time) (int, error) {
+	return f(ctx, now)
+}
+
+func TestSweepOnce(t *testing.T) {
+	now := time.Date(2026, time.January, 1, 12, 0, 0, 0, time.UTC)
+	var seen []time.Time
+	record := func(released int, err error) Releaser {
+		return releaserFunc(func(_ context.Context, at time.Time) (int, error) {
+			seen = append(seen, at)
+			return released, err
+		})
+	}
+
+	got := SweepOnce(context.Background(), now, record(2, nil), record(0, errors.New("down")), record(1, nil))
+	if got != 3 {
+		t.Errorf("SweepOnce() = %d, want 3", got)
+	}
+	if len(seen) != 3 {
+		t.Fatalf("SweepOnce() called %d releasers, want 3", len(seen))
+	}
+	for _, at := range seen {
+		if !at.Equal(now) {
+			t.Errorf("releaser called with %v, want %v", at, now)
+		}
+	}
+}
+
+func TestSweep_StopsWithContext(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	swept := make(chan struct{}, 1)
+	releaser := releaserFunc(func(context.Context, time.Time) (int, error) {
+		select {
+		case swept <- struct{}{}:
+		default:
+		}
+		return 0, nil
+	})
+
+	done := make(chan struct{})
+	go func() {
+		Sweep(ctx, time.Millisecond, releaser)
+		close(done)
+	}()
+
+	select {
+	case <-swept:
+	case <-time.After(time.Second):
+		t.Fatal("Sweep() never released holds")
+	}
+	cancel()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("Sweep() didn't stop after the context was cancelled")
+	}
+}
@@ -0,0 +1,73 @@
+package memory
+
+import (
+	"context"
+	"slices"
+	"time"
+
+	"github.com/ctchen222/hotel-system/internal/auth"
+	"github.com/ctchen222/hotel-system/internal/db"
+)
+
+type APIKeyStore struct {
+	*data
+}
+
+func (s *APIKeyStore) Insert(ctx context.Context, apiKey *auth.APIKey) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.apiKeys[apiKey.Id] = clone(apiKey)
+	return nil
+}
+
+func (s *APIKeyStore) GetAPIKeyById(ctx context.Context, id string) (*auth.APIKey, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	apiKey, ok := s.apiKeys[id]
+	if !ok {
+		return nil, db.ErrNotFound
+	}
+	return clone(apiKey), nil
+}
+
+// GetAPIKeys returns every key, oldest first.
+func (s *APIKeyStore) GetAPIKeys(ctx context.Context) ([]*auth.APIKey, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	apiKeys := make([]*auth.APIKey, 0, len(s.apiKeys))
+	for _, apiKey := range s.apiKeys {
+		apiKeys = append(apiKeys, clone(apiKey))
+	}
+	slices.SortFunc(apiKeys, func(a, b *auth.APIKey) int {
+		return a.CreatedAt.Compare(b.CreatedAt)
+	})
+	return apiKeys, nil
+}
+
+// Revoke revokes an active key. It returns ErrNotFound if there is
+// none.
+func (s *APIKeyStore) Revoke(ctx context.Context, id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	apiKey, ok := s.apiKeys[id]
+	if !ok || apiKey.RevokedAt != nil {
+		return db.ErrNotFound
+	}
+	now := time.Now()
+	apiKey.RevokedAt = &now
+	return nil
+}
+
+func (s *APIKeyStore) Touch(ctx context.Context, id string, usedAt time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if apiKey, ok := s.apiKeys[id]; ok {
+		apiKey.LastUsedAt = &usedAt
+	}
+	return nil
+}
@@ -0,0 +1,180 @@
+package memory
+
+import (
+	"context"
+	"time"
+
+	"github.com/ctchen222/hotel-system/internal/db"
+	"github.com/ctchen222/hotel-system/internal/pricing"
+	"github.com/ctchen222/hotel-system/internal/types"
+)
+
+type BookingStore struct {
+	*data
+}
+
+// Insert redeems the booking's promo code and stores the booking, unless the
+// room doesn't exist, the code is used up or the room is already booked for
+// any night of the stay.
+func (s *BookingStore) Insert(ctx context.Context, booking *types.Booking) (*types.Booking, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.rooms[booking.RoomId]; !ok {
+		return nil, db.ErrNotFound
+	}
+	promoCode := s.promoCodeByCode(booking.Price.PromoCode)
+	if booking.Price.PromoCode != "" {
+		if promoCode == nil {
+			return nil, db.ErrUnknownPromoCode
+		}
+		if promoCode.MaxRedemptions > 0 && promoCode.Redemptions >= promoCode.MaxRedemptions {
+			return nil, db.ErrPromoCodeExhausted
+		}
+	}
+	if s.isBooked(booking.RoomId, booking.From, booking.To, "") {
+		return nil, &db.BookingConflictError{RoomId: booking.RoomId, From: booking.From, To: booking.To}
+	}
+	if promoCode != nil {
+		promoCode.Redemptions++
+	}
+
+	booking.Id = s.newId()
+	s.bookings[booking.Id] = clone(booking)
+	return booking, nil
+}
+
+func (s *BookingStore) GetBookings(ctx context.Context, filter types.BookingFilter) ([]*types.Booking, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return byId(s.bookings, func(booking *types.Booking) bool {
+		return filter.UserId == "" || booking.UserId == filter.UserId
+	}), nil
+}
+
+func (s *BookingStore) GetBookingById(ctx context.Context, id string) (*types.Booking, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	booking, ok := s.bookings[id]
+	if !ok {
+		return nil, db.ErrNotFound
+	}
+	return clone(booking), nil
+}
+
+// CancelBooking marks a pending or confirmed booking as cancelled, records the
+// refund and gives back its promo code redemption.
+func (s *BookingStore) CancelBooking(ctx context.Context, id string, refundAmount pricing.Money) (*types.Booking, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	booking, ok := s.bookings[id]
+	if !ok {
+		return nil, db.ErrNotFound
+	}
+	if !booking.Status.CanTransitionTo(types.BookingCancelled) {
+		return nil, db.ErrInvalidBookingTransition
+	}
+	now := time.Now()
+	booking.Status = types.BookingCancelled
+	booking.CancelledAt = &now
+	booking.RefundAmount = refundAmount
+	s.releasePromoCode(booking.Price.PromoCode)
+	return clone(booking), nil
+}
+
+// ConfirmBooking turns an unexpired hold into a confirmed booking. It returns
+// ErrHoldExpired if the hold ran out, and ErrInvalidBookingTransition if the
+// booking is not a pending hold.
+func (s *BookingStore) ConfirmBooking(ctx context.Context, id string) (*types.Booking, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	booking, ok := s.bookings[id]
+	if !ok {
+		return nil, db.ErrNotFound
+	}
+	if booking.Status != types.BookingPending || booking.ExpiresAt == nil {
+		return nil, db.ErrInvalidBookingTransition
+	}
+	if !booking.ExpiresAt.After(time.Now()) {
+		return nil, db.ErrHoldExpired
+	}
+	booking.Status = types.BookingConfirmed
+	booking.ExpiresAt = nil
+	return clone(booking), nil
+}
+
+// ReleaseExpiredHolds cancels the holds that expired before now and gives
+// back their promo code redemptions. It returns the number of holds released.
+func (s *BookingStore) ReleaseExpiredHolds(ctx context.Context, now time.Time) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	released := 0
+	for _, booking := range s.bookings {
+		if booking.Status != types.BookingPending || booking.ExpiresAt == nil || booking.ExpiresAt.After(now) {
+			continue
+		}
+		cancelledAt := now
+		booking.Status = types.BookingCancelled
+		booking.CancelledAt = &cancelledAt
+		s.releasePromoCode(booking.Price.PromoCode)
+		released++
+	}
+	return released, nil
+}
+
+// UpdateBooking moves current to the room, dates, guest count and price of
+// updated. It returns a BookingConflictError if another booking holds any of
+// the new nights, and ErrBookingChanged if the booking was changed or
+// cancelled since current was read.
+func (s *BookingStore) UpdateBooking(ctx context.Context, current, updated *types.Booking) (*types.Booking, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.isBooked(updated.RoomId, updated.From, updated.To, current.Id) {
+		return nil, &db.BookingConflictError{RoomId: updated.RoomId, From: updated.From, To: updated.To}
+	}
+	booking, ok := s.bookings[current.Id]
+	if !ok ||
+		!booking.Status.CanTransitionTo(types.BookingCancelled) ||
+		booking.RoomId != current.RoomId ||
+		!booking.From.Equal(current.From) ||
+		!booking.To.Equal(current.To) {
+		return nil, db.ErrBookingChanged
+	}
+	updated = clone(updated)
+	booking.RoomId = updated.RoomId
+	booking.From = updated.From
+	booking.To = updated.To
+	booking.NumPerson = updated.NumPerson
+	booking.Price = updated.Price
+	return clone(booking), nil
+}
+
+// hasBookings reports whether any booking, cancelled or not, matches.
+func (d *data) hasBookings(match func(*types.Booking) bool) bool {
+	for _, booking := range d.bookings {
+		if match(booking) {
+			return true
+		}
+	}
+	return false
+}
+
+// isBooked reports whether a booking other than exceptId, that isn't
+// cancelled, holds the room for any night of [from, to).
+func (d *data) isBooked(roomId string, from, to time.Time, exceptId string) bool {
+	for _, booking := range d.bookings {
+		if booking.RoomId == roomId &&
+			booking.Id != exceptId &&
+			booking.Status != types.BookingCancelled &&
+			booking.Overlaps(from, to) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,188 @@
+package memory
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/ctchen222/hotel-system/internal/db"
+	"github.com/ctchen222/hotel-system/internal/pricing"
+	"github.com/ctchen222/hotel-system/internal/types"
+)
+
+func day(n int) time.Time {
+	return time.Date(2030, time.January, n, 0, 0, 0, 0, time.UTC)
+}
+
+// newRooms adds a hotel with n rooms to the store and returns their ids.
+func newRooms(t *testing.T, store *db.Store, n int) []string {
+	t.Helper()
+	hotel, err := store.Hotel.Insert(context.Background(), &types.Hotel{Name: "Hotel 1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ids := make([]string, 0, n)
+	for range n {
+		room, err := store.Room.Insert(context.Background(), &types.Room{HotelId: hotel.Id})
+		if err != nil {
+			t.Fatal(err)
+		}
+		ids = append(ids, room.Id)
+	}
+	return ids
+}
+
+func TestBookingStore_Insert(t *testing.T) {
+	ctx := context.Background()
+	store := NewStore()
+	rooms := newRooms(t, store, 4)
+	if _, err := store.PromoCode.Insert(ctx, &types.PromoCode{Promotion: pricing.Promotion{Code: "ONCE", MaxRedemptions: 1}}); err != nil {
+		t.Fatal(err)
+	}
+	existing, err := store.Booking.Insert(ctx, &types.Booking{RoomId: rooms[0], From: day(10), To: day(12), Status: types.BookingConfirmed})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		booking *types.Booking
+		wantErr error
+	}{
+		{
+			name:    "Overlapping stay",
+			booking: &types.Booking{RoomId: rooms[0], From: day(11), To: day(13)},
+			wantErr: &db.BookingConflictError{},
+		},
+		{
+			name:    "Stay ending on the check-in day",
+			booking: &types.Booking{RoomId: rooms[0], From: day(8), To: day(10)},
+		},
+		{
+			name:    "Same nights in another room",
+			booking: &types.Booking{RoomId: rooms[1], From: day(10), To: day(12)},
+		},
+		{
+			name:    "Promo code",
+			booking: &types.Booking{RoomId: rooms[2], From: day(10), To: day(12), Price: pricing.Breakdown{PromoCode: "ONCE"}},
+		},
+		{
+			name:    "Promo code used up",
+			booking: &types.Booking{RoomId: rooms[3], From: day(10), To: day(12), Price: pricing.Breakdown{PromoCode: "ONCE"}},
+			wantErr: db.ErrPromoCodeExhausted,
+		},
+		{
+			name:    "Room that does not exist",
+			booking: &types.Booking{RoomId: "missing", From: day(10), To: day(12)},
+			wantErr: db.ErrNotFound,
+		},
+		{
+			name:    "Promo code that does not exist",
+			booking: &types.Booking{RoomId: rooms[3], From: day(10), To: day(12), Price: pricing.Breakdown{PromoCode: "NONE"}},
+			wantErr: db.ErrUnknownPromoCode,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := store.Booking.Insert(ctx, tt.booking)
+			var conflict *db.BookingConflictError
+			switch {
+			case tt.wantErr == nil && err != nil:
+				t.Errorf("Insert() error = %v, want nil", err)
+			case errors.As(tt.wantErr, &conflict):
+				if !errors.As(err, &conflict) {
+					t.Errorf("Insert() error = %v, want a BookingConflictError", err)
+				}
+			case tt.wantErr != nil && !errors.Is(err, tt.wantErr):
+				t.Errorf("Insert() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+
+	if _, err := store.Booking.CancelBooking(ctx, existing.Id, 0); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := store.Booking.CancelBooking(ctx, existing.Id, 0); !errors.Is(err, db.ErrInvalidBookingTransition) {
+		t.Errorf("CancelBooking() twice error = %v, want %v", err, db.ErrInvalidBookingTransition)
+	}
+	if _, err := store.Booking.Insert(ctx, &types.Booking{RoomId: rooms[0], From: day(11), To: day(13)}); err != nil {
+		t.Errorf("Insert() over a cancelled booking error = %v, want nil", err)
+	}
+}
+
+func TestBookingStore_UpdateBooking(t *testing.T) {
+	ctx := context.Background()
+	store := NewStore()
+	rooms := newRooms(t, store, 4)
+	booking, err := store.Booking.Insert(ctx, &types.Booking{RoomId: rooms[0], From: day(10), To: day(12), Status: types.BookingConfirmed})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := store.Booking.Insert(ctx, &types.Booking{RoomId: rooms[0], From: day(14), To: day(16), Status: types.BookingConfirmed}); err != nil {
+		t.Fatal(err)
+	}
+
+	extended := *booking
+	extended.To = day(13)
+	updated, err := store.Booking.UpdateBooking(ctx, booking, &extended)
+	if err != nil {
+		t.Fatalf("UpdateBooking() error = %v, want nil", err)
+	}
+	if !updated.To.Equal(day(13)) {
+		t.Errorf("UpdateBooking() To = %v, want %v", updated.To, day(13))
+	}
+
+	if _, err := store.Booking.UpdateBooking(ctx, booking, &extended); !errors.Is(err, db.ErrBookingChanged) {
+		t.Errorf("UpdateBooking() of a stale booking error = %v, want %v", err, db.ErrBookingChanged)
+	}
+
+	overlapping := *updated
+	overlapping.To = day(15)
+	var conflict *db.BookingConflictError
+	if _, err := store.Booking.UpdateBooking(ctx, updated, &overlapping); !errors.As(err, &conflict) {
+		t.Errorf("UpdateBooking() into another booking error = %v, want a BookingConflictError", err)
+	}
+}
+
+func TestBookingStore_ReleaseExpiredHolds(t *testing.T) {
+	ctx := context.Background()
+	store := NewStore()
+	rooms := newRooms(t, store, 4)
+	promoCode, err := store.PromoCode.Insert(ctx, &types.PromoCode{Promotion: pricing.Promotion{Code: "ONCE", MaxRedemptions: 1}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	now := time.Now()
+	expiresAt := now.Add(-time.Minute)
+	hold, err := store.Booking.Insert(ctx, &types.Booking{
+		RoomId:    rooms[0],
+		From:      day(10),
+		To:        day(12),
+		Price:     pricing.Breakdown{PromoCode: "ONCE"},
+		Status:    types.BookingPending,
+		ExpiresAt: &expiresAt,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := store.Booking.ConfirmBooking(ctx, hold.Id); !errors.Is(err, db.ErrHoldExpired) {
+		t.Errorf("ConfirmBooking() error = %v, want %v", err, db.ErrHoldExpired)
+	}
+
+	released, err := store.Booking.ReleaseExpiredHolds(ctx, now)
+	if err != nil || released != 1 {
+		t.Fatalf("ReleaseExpiredHolds() = %d, %v, want 1, nil", released, err)
+	}
+	promoCode, err = store.PromoCode.GetPromoCodeById(ctx, promoCode.Id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if promoCode.Redemptions != 0 {
+		t.Errorf("Redemptions = %d, want 0", promoCode.Redemptions)
+	}
+	if _, err := store.Booking.Insert(ctx, &types.Booking{RoomId: rooms[0], From: day(10), To: day(12)}); err != nil {
+		t.Errorf("Insert() over a released hold error = %v, want nil", err)
+	}
+}
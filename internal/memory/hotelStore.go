@@ -0,0 +1,99 @@
+package memory
+
+import (
+	"context"
+
+	"github.com/ctchen222/hotel-system/internal/db"
+	"github.com/ctchen222/hotel-system/internal/types"
+)
+
+type HotelStore struct {
+	*data
+}
+
+func (s *HotelStore) Insert(ctx context.Context, hotel *types.Hotel) (*types.Hotel, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.hotelByName(hotel.Name) != nil {
+		return nil, db.ErrHotelExists
+	}
+	hotel.Id = s.newId()
+	s.hotels[hotel.Id] = clone(hotel)
+	return hotel, nil
+}
+
+func (s *HotelStore) Update(ctx context.Context, params types.HotelUpdateParams, id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	hotel, ok := s.hotels[id]
+	if !ok {
+		return db.ErrNotFound
+	}
+	if existing := s.hotelByName(params.Name); existing != nil && existing.Id != id {
+		return db.ErrHotelExists
+	}
+	params = *clone(&params)
+	hotel.Name = params.Name
+	hotel.Location = params.Location
+	hotel.Rating = params.Rating
+	hotel.CancellationPolicy = params.CancellationPolicy
+	return nil
+}
+
+func (s *HotelStore) GetHotels(ctx context.Context) ([]*types.Hotel, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return byId(s.hotels, all), nil
+}
+
+func (s *HotelStore) GetHotelById(ctx context.Context, id string) (*types.Hotel, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	hotel, ok := s.hotels[id]
+	if !ok {
+		return nil, db.ErrNotFound
+	}
+	return clone(hotel), nil
+}
+
+// Delete removes the hotel with its rooms, their rate plans and the promo
+// codes of the hotel, unless any of the rooms has bookings.
+func (s *HotelStore) Delete(ctx context.Context, id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.hotels[id]; !ok {
+		return db.ErrNotFound
+	}
+	if s.hasBookings(func(booking *types.Booking) bool {
+		room, ok := s.rooms[booking.RoomId]
+		return ok && room.HotelId == id
+	}) {
+		return db.ErrHasBookings
+	}
+	delete(s.hotels, id)
+	for roomId, room := range s.rooms {
+		if room.HotelId == id {
+			s.deleteRoom(roomId)
+		}
+	}
+	for promoCodeId, promoCode := range s.promoCodes {
+		if promoCode.HotelId == id {
+			delete(s.promoCodes, promoCodeId)
+		}
+	}
+	return nil
+}
+
+func (s *HotelStore) hotelByName(name string) *types.Hotel {
+	for _, hotel := range s.hotels {
+		if hotel.Name == name {
+			return hotel
+		}
+	}
+	return nil
+}
@@ -0,0 +1,30 @@
+package memory
+
+import (
+	"context"
+
+	"github.com/ctchen222/hotel-system/internal/db"
+)
+
+type IdentityStore struct {
+	*data
+}
+
+func (s *IdentityStore) GetIdentityUserId(ctx context.Context, issuer, subject string) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	userId, ok := s.identities[identity{issuer, subject}]
+	if !ok {
+		return "", db.ErrNotFound
+	}
+	return userId, nil
+}
+
+func (s *IdentityStore) Insert(ctx context.Context, issuer, subject, userId string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.identities[identity{issuer, subject}] = userId
+	return nil
+}
@@ -0,0 +1,41 @@
+package memory
+
+import (
+	"context"
+
+	"github.com/ctchen222/hotel-system/internal/db"
+	"github.com/ctchen222/hotel-system/internal/types"
+)
+
+type PaymentStore struct {
+	*data
+}
+
+func (s *PaymentStore) Insert(ctx context.Context, payment *types.Payment) (*types.Payment, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	payment.Id = s.newId()
+	s.payments[payment.Id] = clone(payment)
+	return payment, nil
+}
+
+func (s *PaymentStore) Update(ctx context.Context, payment *types.Payment) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.payments[payment.Id]; !ok {
+		return db.ErrNotFound
+	}
+	s.payments[payment.Id] = clone(payment)
+	return nil
+}
+
+func (s *PaymentStore) GetPaymentsByBookingId(ctx context.Context, bookingId string) ([]*types.Payment, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return byId(s.payments, func(payment *types.Payment) bool {
+		return payment.BookingId == bookingId
+	}), nil
+}
@@ -0,0 +1,83 @@
+package memory
+
+import (
+	"context"
+
+	"github.com/ctchen222/hotel-system/internal/db"
+	"github.com/ctchen222/hotel-system/internal/types"
+)
+
+type PromoCodeStore struct {
+	*data
+}
+
+func (s *PromoCodeStore) Insert(ctx context.Context, promoCode *types.PromoCode) (*types.PromoCode, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.promoCodeByCode(promoCode.Code) != nil {
+		return nil, db.ErrPromoCodeExists
+	}
+	promoCode.Id = s.newId()
+	s.promoCodes[promoCode.Id] = clone(promoCode)
+	return promoCode, nil
+}
+
+func (s *PromoCodeStore) GetPromoCodes(ctx context.Context) ([]*types.PromoCode, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return byId(s.promoCodes, all), nil
+}
+
+func (s *PromoCodeStore) GetPromoCodeById(ctx context.Context, id string) (*types.PromoCode, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	promoCode, ok := s.promoCodes[id]
+	if !ok {
+		return nil, db.ErrNotFound
+	}
+	return clone(promoCode), nil
+}
+
+func (s *PromoCodeStore) GetPromoCodeByCode(ctx context.Context, code string) (*types.PromoCode, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	promoCode := s.promoCodeByCode(code)
+	if promoCode == nil {
+		return nil, db.ErrNotFound
+	}
+	return clone(promoCode), nil
+}
+
+func (s *PromoCodeStore) Delete(ctx context.Context, id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.promoCodes[id]; !ok {
+		return db.ErrNotFound
+	}
+	delete(s.promoCodes, id)
+	return nil
+}
+
+func (d *data) promoCodeByCode(code string) *types.PromoCode {
+	if code == "" {
+		return nil
+	}
+	for _, promoCode := range d.promoCodes {
+		if promoCode.Code == code {
+			return promoCode
+		}
+	}
+	return nil
+}
+
+// releasePromoCode gives back a redemption of the code, if there is one.
+func (d *data) releasePromoCode(code string) {
+	if promoCode := d.promoCodeByCode(code); promoCode != nil && promoCode.Redemptions > 0 {
+		promoCode.Redemptions--
+	}
+}
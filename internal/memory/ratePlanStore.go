@@ -0,0 +1,64 @@
+package memory
+
+import (
+	"context"
+	"slices"
+
+	"github.com/ctchen222/hotel-system/internal/db"
+	"github.com/ctchen222/hotel-system/internal/types"
+)
+
+type RatePlanStore struct {
+	*data
+}
+
+func (s *RatePlanStore) Insert(ctx context.Context, ratePlan *types.RatePlan) (*types.RatePlan, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	ratePlan.Id = s.newId()
+	s.ratePlans[ratePlan.Id] = clone(ratePlan)
+	return ratePlan, nil
+}
+
+func (s *RatePlanStore) GetRatePlans(ctx context.Context, roomIds ...string) ([]*types.RatePlan, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return byId(s.ratePlans, func(ratePlan *types.RatePlan) bool {
+		return len(roomIds) == 0 || slices.Contains(roomIds, ratePlan.RoomId)
+	}), nil
+}
+
+func (s *RatePlanStore) GetRatePlanById(ctx context.Context, id string) (*types.RatePlan, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	ratePlan, ok := s.ratePlans[id]
+	if !ok {
+		return nil, db.ErrNotFound
+	}
+	return clone(ratePlan), nil
+}
+
+func (s *RatePlanStore) Update(ctx context.Context, ratePlan *types.RatePlan) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.ratePlans[ratePlan.Id]; !ok {
+		return db.ErrNotFound
+	}
+	s.ratePlans[ratePlan.Id] = clone(ratePlan)
+	return nil
+}
+
+func (s *RatePlanStore) Delete(ctx context.Context, id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.ratePlans[id]; !ok {
+		return db.ErrNotFound
+	}
+	delete(s.ratePlans, id)
+	return nil
+}
@@ -0,0 +1,118 @@
+package memory
+
+import (
+	"context"
+	"slices"
+	"strings"
+
+	"github.com/ctchen222/hotel-system/internal/db"
+	"github.com/ctchen222/hotel-system/internal/types"
+)
+
+type RoomStore struct {
+	*data
+}
+
+func (s *RoomStore) Insert(ctx context.Context, room *types.Room) (*types.Room, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.hotels[room.HotelId]; !ok {
+		return nil, db.ErrNotFound
+	}
+	if room.Capacity == 0 {
+		room.Capacity = types.DefaultRoomCapacity
+	}
+	room.Id = s.newId()
+	s.rooms[room.Id] = clone(room)
+	return room, nil
+}
+
+func (s *RoomStore) GetRooms(ctx context.Context, hotelId string) ([]*types.Room, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return byId(s.rooms, func(room *types.Room) bool {
+		return room.HotelId == hotelId
+	}), nil
+}
+
+func (s *RoomStore) GetRoomById(ctx context.Context, id string) (*types.Room, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	room, ok := s.rooms[id]
+	if !ok {
+		return nil, db.ErrNotFound
+	}
+	return clone(room), nil
+}
+
+func (s *RoomStore) Delete(ctx context.Context, id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.rooms[id]; !ok {
+		return db.ErrNotFound
+	}
+	if s.hasBookings(func(booking *types.Booking) bool { return booking.RoomId == id }) {
+		return db.ErrHasBookings
+	}
+	s.deleteRoom(id)
+	return nil
+}
+
+// deleteRoom removes the room with its rate plans, as the database stores do.
+func (d *data) deleteRoom(id string) {
+	delete(d.rooms, id)
+	for ratePlanId, ratePlan := range d.ratePlans {
+		if ratePlan.RoomId == id {
+			delete(d.ratePlans, ratePlanId)
+		}
+	}
+}
+
+// GetAvailableRooms returns the rooms matching the query that have no
+// booking overlapping [From, To), grouped by hotel. As with the database
+// stores, the hotels are sorted by name and their rooms by price.
+func (s *RoomStore) GetAvailableRooms(ctx context.Context, query types.AvailabilityQuery) ([]*types.HotelAvailability, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	rooms := byId(s.rooms, func(room *types.Room) bool {
+		hotel, ok := s.hotels[room.HotelId]
+		switch {
+		case !ok,
+			room.Capacity < query.Guests,
+			query.SeaSide != nil && room.SeaSide != *query.SeaSide,
+			!strings.Contains(strings.ToLower(hotel.Location), strings.ToLower(query.Location)):
+			return false
+		}
+		return !s.isBooked(room.Id, query.From, query.To, "")
+	})
+	slices.SortStableFunc(rooms, func(a, b *types.Room) int {
+		switch {
+		case a.Price < b.Price:
+			return -1
+		case a.Price > b.Price:
+			return 1
+		}
+		return 0
+	})
+
+	byHotel := map[string]*types.HotelAvailability{}
+	availability := []*types.HotelAvailability{}
+	for _, room := range rooms {
+		entry, ok := byHotel[room.HotelId]
+		if !ok {
+			entry = &types.HotelAvailability{Hotel: clone(s.hotels[room.HotelId])}
+			byHotel[room.HotelId] = entry
+			availability = append(availability, entry)
+		}
+		entry.Rooms = append(entry.Rooms, &types.AvailableRoom{Room: *room})
+	}
+	slices.SortStableFunc(availability, func(a, b *types.HotelAvailability) int {
+		return strings.Compare(a.Hotel.Name, b.Hotel.Name)
+	})
+	return availability, nil
+}
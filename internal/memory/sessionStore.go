@@ -0,0 +1,88 @@
+package memory
+
+import (
+	"context"
+	"time"
+
+	"github.com/ctchen222/hotel-system/internal/auth"
+	"github.com/ctchen222/hotel-system/internal/db"
+)
+
+type SessionStore struct {
+	*data
+}
+
+func (s *SessionStore) Insert(ctx context.Context, session *auth.Session) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.sessions[session.Id] = clone(session)
+	return nil
+}
+
+func (s *SessionStore) GetSessionById(ctx context.Context, id string) (*auth.Session, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	session, ok := s.sessions[id]
+	if !ok {
+		return nil, db.ErrNotFound
+	}
+	return clone(session), nil
+}
+
+// Rotate replaces the token hash and expiry of an active session, provided
+// its token hash is still previousHash.
+func (s *SessionStore) Rotate(ctx context.Context, session *auth.Session, previousHash string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	stored, ok := s.sessions[session.Id]
+	if !ok || stored.TokenHash != previousHash || stored.RevokedAt != nil {
+		return db.ErrNotFound
+	}
+	stored.TokenHash = session.TokenHash
+	stored.ExpiresAt = session.ExpiresAt
+	return nil
+}
+
+func (s *SessionStore) Revoke(ctx context.Context, id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if session, ok := s.sessions[id]; ok {
+		revoke(session)
+	}
+	return nil
+}
+
+func (s *SessionStore) RevokeByUserId(ctx context.Context, userId string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, session := range s.sessions {
+		if session.UserId == userId {
+			revoke(session)
+		}
+	}
+	return nil
+}
+
+func (s *SessionStore) RevokeOthers(ctx context.Context, userId, keepId string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, session := range s.sessions {
+		if session.UserId == userId && session.Id != keepId {
+			revoke(session)
+		}
+	}
+	return nil
+}
+
+func revoke(session *auth.Session) {
+	if session.RevokedAt == nil {
+		now := time.Now()
+		session.RevokedAt = &now
+	}
+}
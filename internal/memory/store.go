@@ -0,0 +1,127 @@
+// Package memory implements the stores in memory. It needs no database, so
+// it is meant for tests and local development; everything is lost when the
+// process exits.
+package memory
+
+import (
+	"bytes"
+	"encoding/gob"
+	"slices"
+	"strconv"
+	"sync"
+
+	"github.com/ctchen222/hotel-system/internal/auth"
+	"github.com/ctchen222/hotel-system/internal/db"
+	"github.com/ctchen222/hotel-system/internal/types"
+)
+
+// data holds the records of every store. The stores share it, and its lock,
+// because some changes span several of them: booking a room redeems a promo
+// code and deleting a hotel deletes its rooms.
+type data struct {
+	mu     sync.Mutex
+	lastId int
+
+	users      map[string]*types.User
+	hotels     map[string]*types.Hotel
+	rooms      map[string]*types.Room
+	bookings   map[string]*types.Booking
+	ratePlans  map[string]*types.RatePlan
+	promoCodes map[string]*types.PromoCode
+	payments   map[string]*types.Payment
+	sessions   map[string]*auth.Session
+	tokens     map[string]*auth.OneTimeToken
+	twoFactors map[string]*auth.TwoFactor
+	apiKeys    map[string]*auth.APIKey
+	identities map[identity]string
+
+	requiredRoles []auth.Role
+}
+
+// identity is an account at a single sign-on provider.
+type identity struct {
+	issuer  string
+	subject string
+}
+
+// NewStore returns empty stores that keep their records in memory.
+func NewStore() *db.Store {
+	d := &data{
+		users:      map[string]*types.User{},
+		hotels:     map[string]*types.Hotel{},
+		rooms:      map[string]*types.Room{},
+		bookings:   map[string]*types.Booking{},
+		ratePlans:  map[string]*types.RatePlan{},
+		promoCodes: map[string]*types.PromoCode{},
+		payments:   map[string]*types.Payment{},
+		sessions:   map[string]*auth.Session{},
+		tokens:     map[string]*auth.OneTimeToken{},
+		twoFactors: map[string]*auth.TwoFactor{},
+		apiKeys:    map[string]*auth.APIKey{},
+		identities: map[identity]string{},
+	}
+	return &db.Store{
+		User:      &UserStore{d},
+		Hotel:     &HotelStore{d},
+		Room:      &RoomStore{d},
+		Booking:   &BookingStore{d},
+		RatePlan:  &RatePlanStore{d},
+		PromoCode: &PromoCodeStore{d},
+		Payment:   &PaymentStore{d},
+		Session:   &SessionStore{d},
+		Token:     &TokenStore{d},
+		TwoFactor: &TwoFactorStore{d},
+		APIKey:    &APIKeyStore{d},
+		Identity:  &IdentityStore{d},
+	}
+}
+
+// newId returns the next id. Ids are sequential numbers, like the
+// PostgreSQL ones, and are shared by all records.
+func (d *data) newId() string {
+	d.lastId++
+	return strconv.Itoa(d.lastId)
+}
+
+// byId returns copies of the records of m for which keep returns true, in
+// the order they were created.
+func byId[T any](m map[string]*T, keep func(*T) bool) []*T {
+	ids := make([]string, 0, len(m))
+	for id, record := range m {
+		if keep(record) {
+			ids = append(ids, id)
+		}
+	}
+	slices.SortFunc(ids, func(a, b string) int {
+		x, _ := strconv.Atoi(a)
+		y, _ := strconv.Atoi(b)
+		return x - y
+	})
+
+	records := make([]*T, 0, len(ids))
+	for _, id := range ids {
+		records = append(records, clone(m[id]))
+	}
+	return records
+}
+
+func all[T any](*T) bool {
+	return true
+}
+
+// clone returns a deep copy of record. Records are copied both when they
+// are stored and when they are returned, so that callers can't change what
+// is stored. Copying on the way in matters too: handlers pass strings such
+// as path parameters that point into buffers fiber reuses for the next
+// request.
+func clone[T any](record *T) *T {
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(record); err != nil {
+		panic(err)
+	}
+	c := new(T)
+	if err := gob.NewDecoder(&buf).Decode(c); err != nil {
+		panic(err)
+	}
+	return c
+}
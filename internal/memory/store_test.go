@@ -0,0 +1,15 @@
+package memory_test
+
+import (
+	"testing"
+
+	"github.com/ctchen222/hotel-system/internal/db"
+	"github.com/ctchen222/hotel-system/internal/db/storetest"
+	"github.com/ctchen222/hotel-system/internal/memory"
+)
+
+func TestStore(t *testing.T) {
+	storetest.Run(t, func(t *testing.T) *db.Store {
+		return memory.NewStore()
+	})
+}
@@ -0,0 +1,50 @@
+package memory
+
+import (
+	"context"
+	"time"
+
+	"github.com/ctchen222/hotel-system/internal/auth"
+	"github.com/ctchen222/hotel-system/internal/db"
+)
+
+type TokenStore struct {
+	*data
+}
+
+func (s *TokenStore) Insert(ctx context.Context, token *auth.OneTimeToken) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.tokens[token.TokenHash] = clone(token)
+	return nil
+}
+
+// Consume marks a usable token as used and returns it. A token can be
+// consumed once; afterwards, or once it expired, Consume returns
+// ErrNotFound.
+func (s *TokenStore) Consume(ctx context.Context, purpose auth.Purpose, tokenHash string, now time.Time) (*auth.OneTimeToken, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	token, ok := s.tokens[tokenHash]
+	if !ok || token.Purpose != purpose || token.UsedAt != nil || !token.ExpiresAt.After(now) {
+		return nil, db.ErrNotFound
+	}
+	usedAt := now
+	token.UsedAt = &usedAt
+	return clone(token), nil
+}
+
+// DeleteByUserId deletes the user's unused tokens for purpose.
+func (s *TokenStore) DeleteByUserId(ctx context.Context, userId string, purpose auth.Purpose) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for tokenHash, token := range s.tokens {
+		if token.UserId == userId && token.Purpose == purpose && token.UsedAt == nil {
+			delete(s.tokens, tokenHash)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,100 @@
+package memory
+
+import (
+	"context"
+	"slices"
+
+	"github.com/ctchen222/hotel-system/internal/auth"
+	"github.com/ctchen222/hotel-system/internal/db"
+)
+
+type TwoFactorStore struct {
+	*data
+}
+
+func (s *TwoFactorStore) GetTwoFactor(ctx context.Context, userId string) (*auth.TwoFactor, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	tf, ok := s.twoFactors[userId]
+	if !ok {
+		return nil, db.ErrNotFound
+	}
+	return clone(tf), nil
+}
+
+func (s *TwoFactorStore) Upsert(ctx context.Context, tf *auth.TwoFactor) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.twoFactors[tf.UserId] = clone(tf)
+	return nil
+}
+
+// Enable turns on two-factor authentication if it isn't on yet.
+func (s *TwoFactorStore) Enable(ctx context.Context, userId string, recoveryCodes []string, step int64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	tf, ok := s.twoFactors[userId]
+	if !ok || tf.Enabled {
+		return db.ErrNotFound
+	}
+	tf.Enabled = true
+	tf.RecoveryCodes = slices.Clone(recoveryCodes)
+	tf.LastStep = step
+	return nil
+}
+
+// UseStep records step as the last TOTP step used. If step or a later one
+// was used already, UseStep returns ErrNotFound.
+func (s *TwoFactorStore) UseStep(ctx context.Context, userId string, step int64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	tf, ok := s.twoFactors[userId]
+	if !ok || !tf.Enabled || tf.LastStep >= step {
+		return db.ErrNotFound
+	}
+	tf.LastStep = step
+	return nil
+}
+
+// UseRecoveryCode removes a recovery code. It returns ErrNotFound if
+// the user doesn't have it.
+func (s *TwoFactorStore) UseRecoveryCode(ctx context.Context, userId string, codeHash string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	tf, ok := s.twoFactors[userId]
+	if !ok || !tf.Enabled || !slices.Contains(tf.RecoveryCodes, codeHash) {
+		return db.ErrNotFound
+	}
+	tf.RecoveryCodes = slices.DeleteFunc(tf.RecoveryCodes, func(code string) bool {
+		return code == codeHash
+	})
+	return nil
+}
+
+func (s *TwoFactorStore) Delete(ctx context.Context, userId string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.twoFactors, userId)
+	return nil
+}
+
+func (s *TwoFactorStore) GetRequiredRoles(ctx context.Context) ([]auth.Role, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return slices.Clone(s.requiredRoles), nil
+}
+
+func (s *TwoFactorStore) SetRequiredRoles(ctx context.Context, roles []auth.Role) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.requiredRoles = slices.Clone(roles)
+	return nil
+}
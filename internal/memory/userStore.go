@@ -0,0 +1,131 @@
+package memory
+
+import (
+	"context"
+
+	"github.com/ctchen222/hotel-system/internal/auth"
+	"github.com/ctchen222/hotel-system/internal/db"
+	"github.com/ctchen222/hotel-system/internal/types"
+)
+
+type UserStore struct {
+	*data
+}
+
+func (s *UserStore) GetUserById(ctx context.Context, id string) (*types.User, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	user, ok := s.users[id]
+	if !ok {
+		return nil, db.ErrNotFound
+	}
+	return clone(user), nil
+}
+
+func (s *UserStore) GetUserByEmail(ctx context.Context, email string) (*types.User, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	user := s.userByEmail(email)
+	if user == nil {
+		return nil, db.ErrNotFound
+	}
+	return clone(user), nil
+}
+
+func (s *UserStore) GetUsers(ctx context.Context) ([]*types.User, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return byId(s.users, all), nil
+}
+
+func (s *UserStore) Create(ctx context.Context, user *types.User) (*types.User, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.userByEmail(user.Email) != nil {
+		return nil, db.ErrEmailExists
+	}
+	if user.Role == "" {
+		user.Role = auth.RoleGuest
+	}
+	user.Id = s.newId()
+	s.users[user.Id] = clone(user)
+	return user, nil
+}
+
+func (s *UserStore) DeleteById(ctx context.Context, id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.users[id]; !ok {
+		return db.ErrNotFound
+	}
+	if s.hasBookings(func(booking *types.Booking) bool { return booking.UserId == id }) {
+		return db.ErrHasBookings
+	}
+	delete(s.users, id)
+	return nil
+}
+
+func (s *UserStore) Update(ctx context.Context, params types.UserUpdateParams, id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	user, ok := s.users[id]
+	if !ok {
+		return db.ErrNotFound
+	}
+	params = *clone(&params)
+	user.FirstName = params.FirstName
+	user.LastName = params.LastName
+	return nil
+}
+
+func (s *UserStore) UpdateRole(ctx context.Context, id string, role auth.Role, hotelIds []string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	user, ok := s.users[id]
+	if !ok {
+		return db.ErrNotFound
+	}
+	user.Role = role
+	user.HotelIds = *clone(&hotelIds)
+	return nil
+}
+
+func (s *UserStore) UpdatePassword(ctx context.Context, id string, encryptedPassword string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	user, ok := s.users[id]
+	if !ok {
+		return db.ErrNotFound
+	}
+	user.EncryptedPassword = encryptedPassword
+	return nil
+}
+
+func (s *UserStore) MarkEmailVerified(ctx context.Context, id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	user, ok := s.users[id]
+	if !ok {
+		return db.ErrNotFound
+	}
+	user.EmailVerified = true
+	return nil
+}
+
+func (s *UserStore) userByEmail(email string) *types.User {
+	for _, user := range s.users {
+		if user.Email == email {
+			return user
+		}
+	}
+	return nil
+}
@@ -0,0 +1,40 @@
+package notify
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+)
+
+// LogNotifier writes messages to a writer instead of delivering them, so
+// tokens can be picked up from the log or a file during development and in
+// tests.
+type LogNotifier struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+func NewLogNotifier(w io.Writer) *LogNotifier {
+	return &LogNotifier{
+		w: w,
+	}
+}
+
+// NewFileNotifier appends messages to the file at path, creating it if
+// needed.
+func NewFileNotifier(path string) (*LogNotifier, error) {
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+	if err != nil {
+		return nil, err
+	}
+	return NewLogNotifier(f), nil
+}
+
+func (n *LogNotifier) Notify(ctx context.Context, msg Message) error {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	_, err := fmt.Fprintf(n.w, "To: %s\nSubject: %s\n\n%s\n\n", msg.To, msg.Subject, msg.Body)
+	return err
+}
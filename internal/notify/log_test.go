@@ -0,0 +1,39 @@
+package notify
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestFileNotifier(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "mail.log")
+	notifier, err := NewFileNotifier(path)
+	if err != nil {
+		t.Fatalf("NewFileNotifier() error = %v", err)
+	}
+
+	messages := []Message{
+		{To: "a@hotel.com", Subject: "Verify your email", Body: "token one"},
+		{To: "b@hotel.com", Subject: "Reset your password", Body: "token two"},
+	}
+	for _, msg := range messages {
+		if err := notifier.Notify(context.Background(), msg); err != nil {
+			t.Fatalf("Notify() error = %v", err)
+		}
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, msg := range messages {
+		for _, want := range []string{"To: " + msg.To, "Subject: " + msg.Subject, msg.Body} {
+			if !strings.Contains(string(data), want) {
+				t.Errorf("file is missing %q:\n%s", want, data)
+			}
+		}
+	}
+}
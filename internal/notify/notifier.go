@@ -0,0 +1,17 @@
+package notify
+
+import "context"
+
+// Message is a notification for a user, e.g. an email with a password reset
+// token.
+type Message struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+// Notifier delivers messages to users. Implementations decide the channel,
+// e.g. email or a log for development.
+type Notifier interface {
+	Notify(ctx context.Context, msg Message) error
+}
@@ -0,0 +1,31 @@
+package notify
+
+import (
+	"context"
+	"sync"
+)
+
+// Outbox is an in-process Notifier that keeps the messages instead of
+// delivering them, for development and tests.
+type Outbox struct {
+	mu       sync.Mutex
+	messages []Message
+}
+
+func NewOutbox() *Outbox {
+	return &Outbox{}
+}
+
+func (o *Outbox) Notify(ctx context.Context, msg Message) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.messages = append(o.messages, msg)
+	return nil
+}
+
+// Messages returns the messages sent so far, oldest first.
+func (o *Outbox) Messages() []Message {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	return append([]Message(nil), o.messages...)
+}
@@ -0,0 +1,61 @@
+package oidc
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"strings"
+	"time"
+)
+
+// LoginTTL is how long a user has to log in at the provider.
+const LoginTTL = 10 * time.Minute
+
+var ErrInvalidLogin = errors.New("invalid login state")
+
+// Login is a login in progress. State ties the callback to the browser that
+// started the login, Nonce ties the ID token to it, and Verifier is the PKCE
+// secret (RFC 7636) only we know.
+type Login struct {
+	State    string
+	Nonce    string
+	Verifier string
+}
+
+func NewLogin() (*Login, error) {
+	var values [3]string
+	for i := range values {
+		b := make([]byte, 32)
+		if _, err := rand.Read(b); err != nil {
+			return nil, err
+		}
+		values[i] = base64.RawURLEncoding.EncodeToString(b)
+	}
+	return &Login{State: values[0], Nonce: values[1], Verifier: values[2]}, nil
+}
+
+// Challenge is the S256 code challenge of the verifier.
+func (l *Login) Challenge() string {
+	sum := sha256.Sum256([]byte(l.Verifier))
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
+
+// Encode packs the login into a cookie value.
+func (l *Login) Encode() string {
+	return l.State + "." + l.Nonce + "." + l.Verifier
+}
+
+// DecodeLogin unpacks the login from a cookie and checks it is the one state
+// belongs to.
+func DecodeLogin(value, state string) (*Login, error) {
+	parts := strings.Split(value, ".")
+	if len(parts) != 3 || parts[0] == "" || parts[1] == "" || parts[2] == "" {
+		return nil, ErrInvalidLogin
+	}
+	if subtle.ConstantTimeCompare([]byte(parts[0]), []byte(state)) != 1 {
+		return nil, ErrInvalidLogin
+	}
+	return &Login{State: parts[0], Nonce: parts[1], Verifier: parts[2]}, nil
+}
@@ -0,0 +1,224 @@
+// Package oidctest runs an in-process OpenID Connect provider for tests.
+package oidctest
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/ctchen222/hotel-system/internal/auth"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// User is who logs in at the provider.
+type User struct {
+	Subject       string
+	Email         string
+	EmailVerified bool
+	GivenName     string
+	FamilyName    string
+}
+
+type grant struct {
+	user        User
+	redirectURI string
+	challenge   string
+	nonce       string
+}
+
+// Server is a provider that logs in its current user without asking,
+// following the authorization code flow with PKCE.
+type Server struct {
+	*httptest.Server
+	ClientId     string
+	ClientSecret string
+	// Claims, if set, may change the claims of ID tokens before they are
+	// signed, to test how bad tokens are handled.
+	Claims func(jwt.MapClaims)
+
+	key  ed25519.PrivateKey
+	jwks auth.JWKSet
+
+	mu     sync.Mutex
+	user   User
+	grants map[string]grant
+}
+
+func NewServer(clientId, clientSecret string) *Server {
+	_, key, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		panic(err)
+	}
+	keySet, err := auth.NewKeySet("", map[string]crypto.Signer{"oidctest": key})
+	if err != nil {
+		panic(err)
+	}
+
+	s := &Server{
+		ClientId:     clientId,
+		ClientSecret: clientSecret,
+		key:          key,
+		jwks:         keySet.JWKS(),
+		grants:       map[string]grant{},
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /.well-known/openid-configuration", s.handleDiscovery)
+	mux.HandleFunc("GET /authorize", s.handleAuthorize)
+	mux.HandleFunc("POST /token", s.handleToken)
+	mux.HandleFunc("GET /jwks", s.handleJWKS)
+	s.Server = httptest.NewServer(mux)
+	return s
+}
+
+// Issuer is the issuer URL to configure clients with.
+func (s *Server) Issuer() string {
+	return s.URL
+}
+
+// SetUser sets who logs in next.
+func (s *Server) SetUser(user User) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.user = user
+}
+
+// Authorize follows an authorization URL like a browser and returns the
+// callback URL the provider redirects to.
+func (s *Server) Authorize(authURL string) (*url.URL, error) {
+	client := &http.Client{
+		CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+	resp, err := client.Get(authURL)
+	if err != nil {
+		return nil, err
+	}
+	resp.Body.Close()
+	return resp.Location()
+}
+
+func (s *Server) handleDiscovery(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]any{
+		"issuer":                                s.URL,
+		"authorization_endpoint":                s.URL + "/authorize",
+		"token_endpoint":                        s.URL + "/token",
+		"jwks_uri":                              s.URL + "/jwks",
+		"response_types_supported":              []string{"code"},
+		"subject_types_supported":               []string{"public"},
+		"id_token_signing_alg_values_supported": []string{"EdDSA"},
+		"code_challenge_methods_supported":      []string{"S256"},
+	})
+}
+
+func (s *Server) handleAuthorize(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	redirectURI, err := url.Parse(query.Get("redirect_uri"))
+	if err != nil || query.Get("redirect_uri") == "" {
+		http.Error(w, "invalid redirect_uri", http.StatusBadRequest)
+		return
+	}
+	if query.Get("client_id") != s.ClientId || query.Get("response_type") != "code" ||
+		query.Get("code_challenge_method") != "S256" || query.Get("code_challenge") == "" {
+		http.Error(w, "invalid authorization request", http.StatusBadRequest)
+		return
+	}
+
+	code := randomString()
+	s.mu.Lock()
+	s.grants[code] = grant{
+		user:        s.user,
+		redirectURI: query.Get("redirect_uri"),
+		challenge:   query.Get("code_challenge"),
+		nonce:       query.Get("nonce"),
+	}
+	s.mu.Unlock()
+
+	callback := redirectURI.Query()
+	callback.Set("code", code)
+	callback.Set("state", query.Get("state"))
+	redirectURI.RawQuery = callback.Encode()
+	http.Redirect(w, r, redirectURI.String(), http.StatusFound)
+}
+
+func (s *Server) handleToken(w http.ResponseWriter, r *http.Request) {
+	clientId, clientSecret, _ := r.BasicAuth()
+	clientId, _ = url.QueryUnescape(clientId)
+	clientSecret, _ = url.QueryUnescape(clientSecret)
+	if clientId != s.ClientId || clientSecret != s.ClientSecret {
+		writeJSON(w, http.StatusUnauthorized, map[string]string{"error": "invalid_client"})
+		return
+	}
+
+	s.mu.Lock()
+	code := r.PostFormValue("code")
+	g, ok := s.grants[code]
+	// codes can be used once
+	delete(s.grants, code)
+	s.mu.Unlock()
+
+	sum := sha256.Sum256([]byte(r.PostFormValue("code_verifier")))
+	if !ok || r.PostFormValue("grant_type") != "authorization_code" ||
+		r.PostFormValue("redirect_uri") != g.redirectURI ||
+		base64.RawURLEncoding.EncodeToString(sum[:]) != g.challenge {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid_grant"})
+		return
+	}
+
+	now := time.Now()
+	claims := jwt.MapClaims{
+		"iss":            s.URL,
+		"sub":            g.user.Subject,
+		"aud":            s.ClientId,
+		"exp":            now.Add(5 * time.Minute).Unix(),
+		"iat":            now.Unix(),
+		"nonce":          g.nonce,
+		"email":          g.user.Email,
+		"email_verified": g.user.EmailVerified,
+		"given_name":     g.user.GivenName,
+		"family_name":    g.user.FamilyName,
+	}
+	if s.Claims != nil {
+		s.Claims(claims)
+	}
+	token := jwt.NewWithClaims(jwt.SigningMethodEdDSA, claims)
+	token.Header["kid"] = "oidctest"
+	idToken, err := token.SignedString(s.key)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]any{
+		"access_token": randomString(),
+		"token_type":   "Bearer",
+		"expires_in":   300,
+		"id_token":     idToken,
+	})
+}
+
+func (s *Server) handleJWKS(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, s.jwks)
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+func randomString() string {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		panic(err)
+	}
+	return base64.RawURLEncoding.EncodeToString(b)
+}
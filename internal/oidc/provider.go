@@ -0,0 +1,274 @@
+package oidc
+
+import (
+	"context"
+	"crypto"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ctchen222/hotel-system/internal/auth"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+var (
+	// ErrInvalidIDToken is returned when the ID token of a login doesn't
+	// verify: a bad signature, a token for another client or login, or an
+	// expired one.
+	ErrInvalidIDToken = errors.New("invalid ID token")
+	// ErrExchangeFailed is returned when the provider doesn't trade the
+	// authorization code for tokens.
+	ErrExchangeFailed = errors.New("authorization code exchange failed")
+)
+
+// keyRefreshInterval limits how often the keys of the provider are fetched
+// again for an unknown key id, so tokens with made up key ids can't make us
+// hammer the provider.
+const keyRefreshInterval = time.Minute
+
+// IdentityProvider logs users in with an authorization code flow.
+type IdentityProvider interface {
+	// AuthCodeURL is where the user is sent to log in at the provider.
+	AuthCodeURL(login *Login) string
+	// Exchange trades the code the provider sent the user back with for
+	// the identity of the user.
+	Exchange(ctx context.Context, code string, login *Login) (*Identity, error)
+}
+
+// Identity is a user as the provider knows them. Issuer and Subject identify
+// the user; the email may change.
+type Identity struct {
+	Issuer        string
+	Subject       string
+	Email         string
+	EmailVerified bool
+	GivenName     string
+	FamilyName    string
+}
+
+type Config struct {
+	// Issuer is the URL of the provider, where its discovery document is
+	// found under /.well-known/openid-configuration.
+	Issuer       string
+	ClientId     string
+	ClientSecret string
+	// RedirectURL is the callback the provider sends users back to.
+	RedirectURL string
+	// Scopes are requested in addition to openid, email and profile by
+	// default.
+	Scopes []string
+}
+
+type metadata struct {
+	Issuer                string `json:"issuer"`
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+	JWKSURI               string `json:"jwks_uri"`
+}
+
+// Provider is an OpenID Connect provider, configured from its discovery
+// document.
+type Provider struct {
+	config   Config
+	client   *http.Client
+	metadata metadata
+
+	mu            sync.Mutex
+	keys          map[string]crypto.PublicKey
+	keysFetchedAt time.Time
+}
+
+// NewProvider reads the discovery document of the provider. A nil client
+// uses http.DefaultClient.
+func NewProvider(ctx context.Context, config Config, client *http.Client) (*Provider, error) {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	if len(config.Scopes) == 0 {
+		config.Scopes = []string{"email", "profile"}
+	}
+
+	p := &Provider{config: config, client: client}
+	discoveryURL := strings.TrimSuffix(config.Issuer, "/") + "/.well-known/openid-configuration"
+	if err := p.getJSON(ctx, discoveryURL, &p.metadata); err != nil {
+		return nil, fmt.Errorf("oidc discovery: %w", err)
+	}
+	// the issuer in the document has to be the one we were configured with,
+	// or tokens of another provider would verify
+	if p.metadata.Issuer != config.Issuer {
+		return nil, fmt.Errorf("oidc discovery: issuer %q doesn't match %q", p.metadata.Issuer, config.Issuer)
+	}
+	if p.metadata.AuthorizationEndpoint == "" || p.metadata.TokenEndpoint == "" || p.metadata.JWKSURI == "" {
+		return nil, errors.New("oidc discovery: document lacks endpoints")
+	}
+	return p, nil
+}
+
+func (p *Provider) AuthCodeURL(login *Login) string {
+	query := url.Values{}
+	query.Set("response_type", "code")
+	query.Set("client_id", p.config.ClientId)
+	query.Set("redirect_uri", p.config.RedirectURL)
+	query.Set("scope", strings.Join(append([]string{"openid"}, p.config.Scopes...), " "))
+	query.Set("state", login.State)
+	query.Set("nonce", login.Nonce)
+	query.Set("code_challenge", login.Challenge())
+	query.Set("code_challenge_method", "S256")
+
+	sep := "?"
+	if strings.Contains(p.metadata.AuthorizationEndpoint, "?") {
+		sep = "&"
+	}
+	return p.metadata.AuthorizationEndpoint + sep + query.Encode()
+}
+
+type tokenResponse struct {
+	IdToken          string `json:"id_token"`
+	Error            string `json:"error"`
+	ErrorDescription string `json:"error_description"`
+}
+
+func (p *Provider) Exchange(ctx context.Context, code string, login *Login) (*Identity, error) {
+	form := url.Values{}
+	form.Set("grant_type", "authorization_code")
+	form.Set("code", code)
+	form.Set("redirect_uri", p.config.RedirectURL)
+	form.Set("code_verifier", login.Verifier)
+	form.Set("client_id", p.config.ClientId)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.metadata.TokenEndpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	if p.config.ClientSecret != "" {
+		req.SetBasicAuth(url.QueryEscape(p.config.ClientId), url.QueryEscape(p.config.ClientSecret))
+	}
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	var token tokenResponse
+	if err := json.NewDecoder(io.LimitReader(resp.Body, 1<<20)).Decode(&token); err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrExchangeFailed, resp.Status)
+	}
+	if resp.StatusCode != http.StatusOK || token.IdToken == "" {
+		return nil, fmt.Errorf("%w: %s %s", ErrExchangeFailed, token.Error, token.ErrorDescription)
+	}
+
+	return p.verify(ctx, token.IdToken, login.Nonce)
+}
+
+type idTokenClaims struct {
+	jwt.RegisteredClaims
+	Nonce           string `json:"nonce"`
+	AuthorizedParty string `json:"azp"`
+	Email           string `json:"email"`
+	EmailVerified   bool   `json:"email_verified"`
+	GivenName       string `json:"given_name"`
+	FamilyName      string `json:"family_name"`
+}
+
+// verify checks the ID token of a login, as in section 3.1.3.7 of OpenID
+// Connect Core.
+func (p *Provider) verify(ctx context.Context, idToken, nonce string) (*Identity, error) {
+	claims := &idTokenClaims{}
+	_, err := jwt.ParseWithClaims(idToken, claims,
+		func(token *jwt.Token) (any, error) {
+			kid, _ := token.Header["kid"].(string)
+			return p.key(ctx, kid)
+		},
+		jwt.WithValidMethods([]string{jwt.SigningMethodRS256.Alg(), jwt.SigningMethodEdDSA.Alg()}),
+		jwt.WithIssuer(p.metadata.Issuer),
+		jwt.WithAudience(p.config.ClientId),
+		jwt.WithExpirationRequired(),
+		jwt.WithIssuedAt(),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidIDToken, err)
+	}
+	if claims.Nonce == "" || claims.Nonce != nonce {
+		return nil, fmt.Errorf("%w: nonce doesn't match the login", ErrInvalidIDToken)
+	}
+	if len(claims.Audience) > 1 && claims.AuthorizedParty != p.config.ClientId {
+		return nil, fmt.Errorf("%w: issued to %q", ErrInvalidIDToken, claims.AuthorizedParty)
+	}
+	if claims.Subject == "" {
+		return nil, fmt.Errorf("%w: no subject", ErrInvalidIDToken)
+	}
+
+	return &Identity{
+		Issuer:        claims.Issuer,
+		Subject:       claims.Subject,
+		Email:         claims.Email,
+		EmailVerified: claims.EmailVerified,
+		GivenName:     claims.GivenName,
+		FamilyName:    claims.FamilyName,
+	}, nil
+}
+
+// key returns the signing key of the provider with kid, fetching the keys
+// again if it's unknown, e.g. after the provider rotated its keys.
+func (p *Provider) key(ctx context.Context, kid string) (crypto.PublicKey, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if key, ok := p.keys[kid]; ok {
+		return key, nil
+	}
+	if time.Since(p.keysFetchedAt) < keyRefreshInterval {
+		return nil, fmt.Errorf("unknown key %q", kid)
+	}
+
+	var set auth.JWKSet
+	if err := p.getJSON(ctx, p.metadata.JWKSURI, &set); err != nil {
+		return nil, fmt.Errorf("fetching keys: %w", err)
+	}
+	keys := map[string]crypto.PublicKey{}
+	for _, jwk := range set.Keys {
+		if jwk.Use != "" && jwk.Use != "sig" {
+			continue
+		}
+		// keys of types we don't verify with are skipped, the provider may
+		// sign our tokens with another one
+		if key, err := jwk.PublicKey(); err == nil {
+			keys[jwk.Kid] = key
+		}
+	}
+	p.keys = keys
+	p.keysFetchedAt = time.Now()
+
+	if key, ok := p.keys[kid]; ok {
+		return key, nil
+	}
+	return nil, fmt.Errorf("unknown key %q", kid)
+}
+
+func (p *Provider) getJSON(ctx context.Context, url string, v any) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("GET %s: %s", url, resp.Status)
+	}
+	return json.NewDecoder(io.LimitReader(resp.Body, 1<<20)).Decode(v)
+}
@@ -0,0 +1,138 @@
+package oidc_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/ctchen222/hotel-system/internal/oidc"
+	"github.com/ctchen222/hotel-system/internal/oidc/oidctest"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const redirectURL = "http://hotel.test/api/pg/oidc/callback"
+
+func newProvider(t *testing.T, idp *oidctest.Server) *oidc.Provider {
+	t.Helper()
+	provider, err := oidc.NewProvider(context.Background(), oidc.Config{
+		Issuer:       idp.Issuer(),
+		ClientId:     idp.ClientId,
+		ClientSecret: idp.ClientSecret,
+		RedirectURL:  redirectURL,
+	}, idp.Client())
+	if err != nil {
+		t.Fatalf("NewProvider() error = %v", err)
+	}
+	return provider
+}
+
+// login runs the flow up to the callback and returns the code the provider
+// sent back.
+func login(t *testing.T, idp *oidctest.Server, provider *oidc.Provider, l *oidc.Login) string {
+	t.Helper()
+	callback, err := idp.Authorize(provider.AuthCodeURL(l))
+	if err != nil {
+		t.Fatalf("Authorize() error = %v", err)
+	}
+	if got := callback.Query().Get("state"); got != l.State {
+		t.Fatalf("callback state = %q, want %q", got, l.State)
+	}
+	return callback.Query().Get("code")
+}
+
+func TestProvider_Exchange(t *testing.T) {
+	idp := oidctest.NewServer("hotel-system", "secret")
+	defer idp.Close()
+	idp.SetUser(oidctest.User{Subject: "alice", Email: "alice@corp.example", EmailVerified: true, GivenName: "Alice", FamilyName: "Chen"})
+	provider := newProvider(t, idp)
+
+	l, err := oidc.NewLogin()
+	if err != nil {
+		t.Fatal(err)
+	}
+	identity, err := provider.Exchange(context.Background(), login(t, idp, provider, l), l)
+	if err != nil {
+		t.Fatalf("Exchange() error = %v", err)
+	}
+	want := oidc.Identity{Issuer: idp.Issuer(), Subject: "alice", Email: "alice@corp.example", EmailVerified: true, GivenName: "Alice", FamilyName: "Chen"}
+	if *identity != want {
+		t.Errorf("Exchange() = %+v, want %+v", *identity, want)
+	}
+
+	// codes can be used once
+	if _, err := provider.Exchange(context.Background(), "used", l); !errors.Is(err, oidc.ErrExchangeFailed) {
+		t.Errorf("Exchange() of an unknown code error = %v, want %v", err, oidc.ErrExchangeFailed)
+	}
+}
+
+func TestProvider_Exchange_Rejected(t *testing.T) {
+	tests := []struct {
+		name   string
+		claims func(jwt.MapClaims)
+		login  func(*oidc.Login)
+		want   error
+	}{
+		{name: "Wrong verifier", login: func(l *oidc.Login) { l.Verifier = "guessed" }, want: oidc.ErrExchangeFailed},
+		{name: "Wrong nonce", login: func(l *oidc.Login) { l.Nonce = "other" }, want: oidc.ErrInvalidIDToken},
+		{name: "Other audience", claims: func(c jwt.MapClaims) { c["aud"] = "other-client" }, want: oidc.ErrInvalidIDToken},
+		{name: "Other issuer", claims: func(c jwt.MapClaims) { c["iss"] = "https://evil.example" }, want: oidc.ErrInvalidIDToken},
+		{name: "Expired", claims: func(c jwt.MapClaims) { c["exp"] = 1 }, want: oidc.ErrInvalidIDToken},
+		{name: "Other authorized party", claims: func(c jwt.MapClaims) {
+			c["aud"] = []string{"hotel-system", "other-client"}
+			c["azp"] = "other-client"
+		}, want: oidc.ErrInvalidIDToken},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			idp := oidctest.NewServer("hotel-system", "secret")
+			defer idp.Close()
+			idp.SetUser(oidctest.User{Subject: "alice", Email: "alice@corp.example"})
+			idp.Claims = tt.claims
+			provider := newProvider(t, idp)
+
+			l, err := oidc.NewLogin()
+			if err != nil {
+				t.Fatal(err)
+			}
+			code := login(t, idp, provider, l)
+			if tt.login != nil {
+				tt.login(l)
+			}
+			if _, err := provider.Exchange(context.Background(), code, l); !errors.Is(err, tt.want) {
+				t.Errorf("Exchange() error = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestNewProvider_IssuerMismatch(t *testing.T) {
+	idp := oidctest.NewServer("hotel-system", "secret")
+	defer idp.Close()
+
+	_, err := oidc.NewProvider(context.Background(), oidc.Config{Issuer: idp.Issuer() + "/"}, idp.Client())
+	if err == nil {
+		t.Error("NewProvider() accepted a discovery document of another issuer")
+	}
+}
+
+func TestDecodeLogin(t *testing.T) {
+	l, err := oidc.NewLogin()
+	if err != nil {
+		t.Fatal(err)
+	}
+	decoded, err := oidc.DecodeLogin(l.Encode(), l.State)
+	if err != nil || *decoded != *l {
+		t.Fatalf("DecodeLogin() = %+v, %v, want %+v", decoded, err, l)
+	}
+
+	for _, tt := range []struct{ value, state string }{
+		{value: l.Encode(), state: "other"},
+		{value: "", state: ""},
+		{value: "state.nonce", state: "state"},
+		{value: "state..verifier", state: "state"},
+	} {
+		if _, err := oidc.DecodeLogin(tt.value, tt.state); err != oidc.ErrInvalidLogin {
+			t.Errorf("DecodeLogin(%q, %q) error = %v, want %v", tt.value, tt.state, err, oidc.ErrInvalidLogin)
+		}
+	}
+}
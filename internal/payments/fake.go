@@ -0,0 +1,108 @@
+package payments
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/ctchen222/hotel-system/internal/pricing"
+)
+
+// DeclinedSource is a payment source the fake provider always declines.
+const DeclinedSource = "tok_declined"
+
+type fakePayment struct {
+	amount   pricing.Money
+	captured pricing.Money
+	refunded pricing.Money
+	voided   bool
+}
+
+// FakeProvider is an in-process PaymentProvider for development and tests. It
+// accepts any source except DeclinedSource and enforces the same state rules
+// a real gateway would.
+type FakeProvider struct {
+	// CaptureErr and RefundErr, if set, are returned by every Capture and
+	// Refund, like a gateway that is down would.
+	CaptureErr error
+	RefundErr  error
+
+	mu       sync.Mutex
+	next     int
+	payments map[string]*fakePayment
+}
+
+func NewFakeProvider() *FakeProvider {
+	return &FakeProvider{
+		payments: map[string]*fakePayment{},
+	}
+}
+
+func (p *FakeProvider) Name() string {
+	return "fake"
+}
+
+func (p *FakeProvider) Authorize(ctx context.Context, amount pricing.Money, currency, source string) (string, error) {
+	if source == "" || source == DeclinedSource || amount <= 0 {
+		return "", ErrDeclined
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.next++
+	reference := fmt.Sprintf("fake_%d", p.next)
+	p.payments[reference] = &fakePayment{amount: amount}
+	return reference, nil
+}
+
+func (p *FakeProvider) Capture(ctx context.Context, reference string, amount pricing.Money) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.CaptureErr != nil {
+		return p.CaptureErr
+	}
+	payment, ok := p.payments[reference]
+	if !ok {
+		return ErrUnknownPayment
+	}
+	if payment.voided || payment.captured > 0 || amount <= 0 || amount > payment.amount {
+		return ErrInvalidState
+	}
+	payment.captured = amount
+	return nil
+}
+
+func (p *FakeProvider) Refund(ctx context.Context, reference string, amount pricing.Money) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.RefundErr != nil {
+		return p.RefundErr
+	}
+	payment, ok := p.payments[reference]
+	if !ok {
+		return ErrUnknownPayment
+	}
+	if amount <= 0 || payment.refunded+amount > payment.captured {
+		return ErrInvalidState
+	}
+	payment.refunded += amount
+	return nil
+}
+
+func (p *FakeProvider) Void(ctx context.Context, reference string) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	payment, ok := p.payments[reference]
+	if !ok {
+		return ErrUnknownPayment
+	}
+	if payment.voided || payment.captured > 0 {
+		return ErrInvalidState
+	}
+	payment.voided = true
+	return nil
+}
@@ -0,0 +1,128 @@
+package payments
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/ctchen222/hotel-system/internal/pricing"
+)
+
+type Status string
+
+const (
+	StatusAuthorized Status = "authorized"
+	StatusCaptured   Status = "captured"
+	StatusVoided     Status = "voided"
+	StatusRefunded   Status = "refunded"
+)
+
+// Record is what we keep of a payment. The stores link it to a booking.
+type Record struct {
+	Provider  string        `bson:"provider" json:"provider"`
+	Reference string        `bson:"reference" json:"reference"`
+	Status    Status        `bson:"status" json:"status"`
+	Amount    pricing.Money `bson:"amount" json:"amount"`
+	Currency  string        `bson:"currency" json:"currency"`
+	Captured  pricing.Money `bson:"captured" json:"captured"`
+	Refunded  pricing.Money `bson:"refunded" json:"refunded"`
+	CreatedAt time.Time     `bson:"createdAt" json:"createdAt"`
+	UpdatedAt time.Time     `bson:"updatedAt" json:"updatedAt"`
+}
+
+// Processor runs payments through a PaymentProvider and keeps their records
+// up to date.
+type Processor struct {
+	provider PaymentProvider
+}
+
+func NewProcessor(provider PaymentProvider) *Processor {
+	return &Processor{
+		provider: provider,
+	}
+}
+
+// Pay charges amount to source around confirm. The amount is authorized
+// first, confirm runs next, and the authorization is captured only if confirm
+// succeeds; otherwise it is voided and confirm's error is returned. The record
+// is returned whenever an authorization was made, so it can be stored even if
+// the payment didn't go through.
+//
+// A capture that fails after confirm succeeded doesn't undo the confirmation:
+// the record is returned authorized, pending capture, with an error wrapping
+// ErrCaptureFailed. Capture retries it.
+func (p *Processor) Pay(ctx context.Context, amount pricing.Money, currency, source string, confirm func() error) (*Record, error) {
+	reference, err := p.provider.Authorize(ctx, amount, currency, source)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	record := &Record{
+		Provider:  p.provider.Name(),
+		Reference: reference,
+		Status:    StatusAuthorized,
+		Amount:    amount,
+		Currency:  currency,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+
+	if err := confirm(); err != nil {
+		if voidErr := p.provider.Void(ctx, reference); voidErr == nil {
+			record.Status = StatusVoided
+			record.UpdatedAt = time.Now()
+		}
+		return record, err
+	}
+
+	if err := p.Capture(ctx, record); err != nil {
+		return record, err
+	}
+	return record, nil
+}
+
+// Capture takes the full amount of a payment that was only authorized. It
+// returns an error wrapping ErrCaptureFailed if the provider doesn't capture
+// it, leaving the record as it was.
+func (p *Processor) Capture(ctx context.Context, record *Record) error {
+	if record.Status != StatusAuthorized {
+		return ErrInvalidState
+	}
+	if err := p.provider.Capture(ctx, record.Reference, record.Amount); err != nil {
+		return fmt.Errorf("%w: %w", ErrCaptureFailed, err)
+	}
+	record.Status = StatusCaptured
+	record.Captured = record.Amount
+	record.UpdatedAt = time.Now()
+	return nil
+}
+
+// Refund gives back up to amount of a captured payment, or voids a payment
+// that was only authorized. It returns the amount refunded.
+func (p *Processor) Refund(ctx context.Context, record *Record, amount pricing.Money) (pricing.Money, error) {
+	switch record.Status {
+	case StatusAuthorized:
+		if err := p.provider.Void(ctx, record.Reference); err != nil {
+			return 0, err
+		}
+		record.Status = StatusVoided
+		record.UpdatedAt = time.Now()
+		return 0, nil
+	case StatusCaptured:
+		amount = min(amount, record.Captured-record.Refunded)
+		if amount <= 0 {
+			return 0, nil
+		}
+		if err := p.provider.Refund(ctx, record.Reference, amount); err != nil {
+			return 0, err
+		}
+		record.Refunded += amount
+		if record.Refunded == record.Captured {
+			record.Status = StatusRefunded
+		}
+		record.UpdatedAt = time.Now()
+		return amount, nil
+	}
+	return 0, nil
+}
@@ -0,0 +1,129 @@
+package payments
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestProcessor_Pay(t *testing.T) {
+	confirmErr := errors.New("hold expired")
+
+	tests := []struct {
+		name       string
+		source     string
+		confirmErr error
+		captureErr error
+		wantErr    error
+		wantStatus Status
+	}{
+		{name: "Captured after confirmation", source: "tok_visa", wantStatus: StatusCaptured},
+		{name: "Declined before confirmation", source: DeclinedSource, wantErr: ErrDeclined},
+		{name: "Voided when confirmation fails", source: "tok_visa", confirmErr: confirmErr, wantErr: confirmErr, wantStatus: StatusVoided},
+		{name: "Pending capture when capture fails", source: "tok_visa", captureErr: ErrUnavailable, wantErr: ErrCaptureFailed, wantStatus: StatusAuthorized},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			provider := NewFakeProvider()
+			provider.CaptureErr = tt.captureErr
+			processor := NewProcessor(provider)
+			confirmed := false
+			record, err := processor.Pay(context.Background(), 23000, "TWD", tt.source, func() error {
+				confirmed = true
+				return tt.confirmErr
+			})
+
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Processor.Pay() error = %v, want %v", err, tt.wantErr)
+			}
+			if tt.wantErr == ErrDeclined {
+				if confirmed || record != nil {
+					t.Errorf("Processor.Pay() confirmed a declined payment")
+				}
+				return
+			}
+			if record.Status != tt.wantStatus {
+				t.Errorf("Processor.Pay() status = %v, want %v", record.Status, tt.wantStatus)
+			}
+		})
+	}
+}
+
+func TestProcessor_Capture(t *testing.T) {
+	ctx := context.Background()
+	provider := NewFakeProvider()
+	provider.CaptureErr = ErrUnavailable
+	processor := NewProcessor(provider)
+	record, err := processor.Pay(ctx, 10000, "TWD", "tok_visa", func() error { return nil })
+	if !errors.Is(err, ErrCaptureFailed) {
+		t.Fatalf("Processor.Pay() error = %v, want %v", err, ErrCaptureFailed)
+	}
+
+	if err := processor.Capture(ctx, record); !errors.Is(err, ErrUnavailable) || record.Status != StatusAuthorized {
+		t.Fatalf("Processor.Capture() while unavailable = %v, status %v", err, record.Status)
+	}
+
+	provider.CaptureErr = nil
+	if err := processor.Capture(ctx, record); err != nil {
+		t.Fatal(err)
+	}
+	if record.Status != StatusCaptured || record.Captured != 10000 {
+		t.Errorf("captured record = %+v", record)
+	}
+	if err := processor.Capture(ctx, record); !errors.Is(err, ErrInvalidState) {
+		t.Errorf("second capture error = %v, want %v", err, ErrInvalidState)
+	}
+}
+
+func TestProcessor_Refund(t *testing.T) {
+	ctx := context.Background()
+	processor := NewProcessor(NewFakeProvider())
+	record, err := processor.Pay(ctx, 10000, "TWD", "tok_visa", func() error { return nil })
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if refunded, err := processor.Refund(ctx, record, 4000); err != nil || refunded != 4000 {
+		t.Fatalf("Processor.Refund() = %v, %v, want 4000", refunded, err)
+	}
+	if record.Status != StatusCaptured {
+		t.Errorf("partial refund status = %v, want %v", record.Status, StatusCaptured)
+	}
+
+	// only what is left of the capture can be refunded
+	if refunded, err := processor.Refund(ctx, record, 9000); err != nil || refunded != 6000 {
+		t.Fatalf("Processor.Refund() = %v, %v, want 6000", refunded, err)
+	}
+	if record.Status != StatusRefunded || record.Refunded != 10000 {
+		t.Errorf("full refund record = %+v", record)
+	}
+
+	if refunded, err := processor.Refund(ctx, record, 1000); err != nil || refunded != 0 {
+		t.Errorf("Processor.Refund() of a refunded payment = %v, %v, want 0", refunded, err)
+	}
+}
+
+func TestFakeProvider_StateRules(t *testing.T) {
+	ctx := context.Background()
+	provider := NewFakeProvider()
+	reference, err := provider.Authorize(ctx, 5000, "TWD", "tok_visa")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := provider.Refund(ctx, reference, 1000); !errors.Is(err, ErrInvalidState) {
+		t.Errorf("refund before capture error = %v, want %v", err, ErrInvalidState)
+	}
+	if err := provider.Capture(ctx, reference, 6000); !errors.Is(err, ErrInvalidState) {
+		t.Errorf("capture above the authorization error = %v, want %v", err, ErrInvalidState)
+	}
+	if err := provider.Void(ctx, reference); err != nil {
+		t.Fatal(err)
+	}
+	if err := provider.Capture(ctx, reference, 5000); !errors.Is(err, ErrInvalidState) {
+		t.Errorf("capture after void error = %v, want %v", err, ErrInvalidState)
+	}
+	if err := provider.Void(ctx, "fake_404"); !errors.Is(err, ErrUnknownPayment) {
+		t.Errorf("void of an unknown payment error = %v, want %v", err, ErrUnknownPayment)
+	}
+}
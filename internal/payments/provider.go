@@ -0,0 +1,38 @@
+package payments
+
+import (
+	"context"
+	"errors"
+
+	"github.com/ctchen222/hotel-system/internal/pricing"
+)
+
+var (
+	// ErrDeclined is returned when the provider refuses to authorize a charge.
+	ErrDeclined = errors.New("payment declined")
+	// ErrUnknownPayment is returned for a reference the provider never issued.
+	ErrUnknownPayment = errors.New("unknown payment")
+	// ErrInvalidState is returned when an operation doesn't fit the state of
+	// the payment, e.g. capturing a voided authorization or refunding more
+	// than was captured.
+	ErrInvalidState = errors.New("payment is not in a state that allows the operation")
+	// ErrUnavailable is returned when the provider can't be reached.
+	ErrUnavailable = errors.New("payment provider unavailable")
+	// ErrCaptureFailed is returned when an authorized payment could not be
+	// captured. The authorization stands, so the capture can be retried.
+	ErrCaptureFailed = errors.New("payment could not be captured")
+)
+
+// PaymentProvider is a payment gateway. A charge is authorized first, which
+// reserves the amount, and then either captured or voided. Captured money can
+// be refunded, in full or in part.
+type PaymentProvider interface {
+	// Name identifies the provider on payment records.
+	Name() string
+	// Authorize reserves amount on the payment source, e.g. a card token, and
+	// returns the provider's reference for the payment.
+	Authorize(ctx context.Context, amount pricing.Money, currency, source string) (string, error)
+	Capture(ctx context.Context, reference string, amount pricing.Money) error
+	Refund(ctx context.Context, reference string, amount pricing.Money) error
+	Void(ctx context.Context, reference string) error
+}
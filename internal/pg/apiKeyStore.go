@@ -0,0 +1,97 @@
+package models
+
+import (
+	"context"
+	"github.com/ctchen222/hotel-system/internal/db"
+	"time"
+
+	"github.com/ctchen222/hotel-system/internal/auth"
+	"github.com/jackc/pgx/v5"
+)
+
+type PostgresAPIKeyStore struct {
+	pool *PostgresInstance
+}
+
+func NewPostgresAPIKeyStore(pool *PostgresInstance) *PostgresAPIKeyStore {
+	return &PostgresAPIKeyStore{
+		pool: pool,
+	}
+}
+
+const apiKeyColumns = `id, userid, name, key_hash, permissions, created_at, expires_at, last_used_at, revoked_at`
+
+func (s *PostgresAPIKeyStore) Insert(ctx context.Context, apiKey *auth.APIKey) error {
+	query := `INSERT INTO api_keys(id, userid, name, key_hash, permissions, created_at, expires_at)
+		VALUES($1, $2, $3, $4, $5, $6, $7)`
+
+	_, err := s.pool.DB.Exec(ctx, query,
+		apiKey.Id,
+		apiKey.UserId,
+		apiKey.Name,
+		apiKey.KeyHash,
+		apiKey.Permissions,
+		apiKey.CreatedAt,
+		apiKey.ExpiresAt)
+	return err
+}
+
+func (s *PostgresAPIKeyStore) GetAPIKeyById(ctx context.Context, id string) (*auth.APIKey, error) {
+	row := s.pool.DB.QueryRow(ctx, `SELECT `+apiKeyColumns+` FROM api_keys WHERE id = $1`, id)
+
+	var apiKey auth.APIKey
+	if err := scanAPIKey(row, &apiKey); err != nil {
+		return nil, storeError(err)
+	}
+	return &apiKey, nil
+}
+
+func (s *PostgresAPIKeyStore) GetAPIKeys(ctx context.Context) ([]*auth.APIKey, error) {
+	rows, err := s.pool.DB.Query(ctx, `SELECT `+apiKeyColumns+` FROM api_keys ORDER BY created_at`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var apiKeys []*auth.APIKey
+	for rows.Next() {
+		var apiKey auth.APIKey
+		if err := scanAPIKey(rows, &apiKey); err != nil {
+			return nil, err
+		}
+		apiKeys = append(apiKeys, &apiKey)
+	}
+	return apiKeys, rows.Err()
+}
+
+// Revoke revokes an active key. It returns db.ErrNotFound if there is no
+// such key or it was revoked already.
+func (s *PostgresAPIKeyStore) Revoke(ctx context.Context, id string) error {
+	tag, err := s.pool.DB.Exec(ctx, `UPDATE api_keys SET revoked_at = now() WHERE id = $1 AND revoked_at IS NULL`, id)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return db.ErrNotFound
+	}
+	return nil
+}
+
+// Touch records when the key was last used.
+func (s *PostgresAPIKeyStore) Touch(ctx context.Context, id string, usedAt time.Time) error {
+	_, err := s.pool.DB.Exec(ctx, `UPDATE api_keys SET last_used_at = $2 WHERE id = $1`, id, usedAt)
+	return err
+}
+
+func scanAPIKey(row pgx.Row, apiKey *auth.APIKey) error {
+	return row.Scan(
+		&apiKey.Id,
+		&apiKey.UserId,
+		&apiKey.Name,
+		&apiKey.KeyHash,
+		&apiKey.Permissions,
+		&apiKey.CreatedAt,
+		&apiKey.ExpiresAt,
+		&apiKey.LastUsedAt,
+		&apiKey.RevokedAt)
+}
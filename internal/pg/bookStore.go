@@ -2,14 +2,17 @@ package models
 
 import (
 	"context"
+	"errors"
+	"time"
 
-	"github.com/ctchen222/hotel-system/internal/pgtypes"
+	"github.com/ctchen222/hotel-system/internal/db"
+	"github.com/ctchen222/hotel-system/internal/pricing"
+	"github.com/ctchen222/hotel-system/internal/types"
+	"github.com/jackc/pgx/v5"
 )
 
-type BookingStore interface {
-	CreateBooking(context.Context, *pgtypes.Booking) error
-	GetBookingByUserId(ctx context.Context, userId string) ([]*pgtypes.BookingInfo, error)
-}
+const bookingColumns = `id, userid, roomid, numperson, fromdate, todate, price_breakdown, status,
+	expires_at, cancelled_at, refund_amount`
 
 type PostgresBookingStore struct {
 	pool *PostgresInstance
@@ -21,45 +24,303 @@ func NewPostgresBookingStore(pool *PostgresInstance) *PostgresBookingStore {
 	}
 }
 
-func (s *PostgresBookingStore) CreateBooking(ctx context.Context, booking *pgtypes.Booking) error {
+// Insert inserts the booking unless it overlaps an existing booking of
+// the same room. Bookings are half-open ranges, so a guest may check in on the
+// day the previous guest checks out. The room row is locked for the duration of
+// the transaction to serialise concurrent bookings of the same room. A promo
+// code on the price breakdown is redeemed in the same transaction, so the
+// redemption is only counted if the booking is stored.
+func (s *PostgresBookingStore) Insert(ctx context.Context, booking *types.Booking) (*types.Booking, error) {
+	tx, err := s.pool.DB.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	var roomId int
+	row := tx.QueryRow(ctx, `SELECT id FROM rooms WHERE id = $1 FOR UPDATE`, booking.RoomId)
+	if err := row.Scan(&roomId); err != nil {
+		return nil, storeError(err)
+	}
+
+	overlapQuery := `SELECT EXISTS (
+		SELECT 1 FROM bookings
+		WHERE roomid = $1 AND status <> 'cancelled'
+			AND fromdate < $3 AND todate > $2)`
+
+	var overlapping bool
+	row = tx.QueryRow(ctx, overlapQuery, booking.RoomId, booking.From, booking.To)
+	if err := row.Scan(&overlapping); err != nil {
+		return nil, err
+	}
+	if overlapping {
+		return nil, newBookingConflictError(booking)
+	}
+
+	if booking.Price.PromoCode != "" {
+		if err := redeemPromoCode(ctx, tx, booking.Price.PromoCode); err != nil {
+			return nil, err
+		}
+	}
+
 	query := `INSERT INTO 
-		bookings (userid, roomid, numperson, fromdate, todate) 
-		VALUES ($1, $2, $3, $4, $5)`
+		bookings (userid, roomid, numperson, fromdate, todate, total_price, price_breakdown, status, expires_at) 
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+		RETURNING id`
 
-	_, err := s.pool.DB.Exec(ctx, query,
+	row = tx.QueryRow(ctx, query,
 		booking.UserId,
 		booking.RoomId,
 		booking.NumPerson,
-		booking.FromDate,
-		booking.ToDate)
-	if err != nil {
-		return err
+		booking.From,
+		booking.To,
+		booking.Price.Total,
+		booking.Price,
+		booking.Status,
+		booking.ExpiresAt)
+	if err := row.Scan(&booking.Id); err != nil {
+		if isExclusionViolation(err) {
+			return nil, newBookingConflictError(booking)
+		}
+		return nil, err
 	}
 
-	return nil
+	if err := tx.Commit(ctx); err != nil {
+		return nil, err
+	}
+	return booking, nil
 }
 
-func (s *PostgresBookingStore) GetBookingByUserId(ctx context.Context, userId string) ([]*pgtypes.BookingInfo, error) {
-	query := `SELECT u.id, u.firstname, u.email, b.numperson
-		FROM users u
-		LEFT JOIN bookings b
-		ON u.id = b.userid
-		WHERE u.id = $1`
+func (s *PostgresBookingStore) GetBookings(ctx context.Context, filter types.BookingFilter) ([]*types.Booking, error) {
+	query := `SELECT ` + bookingColumns + ` FROM bookings
+		WHERE ($1::text = '' OR userid::text = $1::text)
+		ORDER BY id`
 
-	rows, err := s.pool.DB.Query(ctx, query, userId)
+	rows, err := s.pool.DB.Query(ctx, query, filter.UserId)
 	if err != nil {
 		return nil, err
 	}
+	defer rows.Close()
 
-	var bookingInfos []*pgtypes.BookingInfo
+	bookings := []*types.Booking{}
 	for rows.Next() {
-		var bookingInfo pgtypes.BookingInfo
-		if err := rows.Scan(&bookingInfo.Id, &bookingInfo.Firstname, &bookingInfo.Email, &bookingInfo.NumPerson); err != nil {
+		var booking types.Booking
+		if err := scanBooking(rows, &booking); err != nil {
 			return nil, err
 		}
+		bookings = append(bookings, &booking)
+	}
+
+	return bookings, rows.Err()
+}
+
+func (s *PostgresBookingStore) GetBookingById(ctx context.Context, id string) (*types.Booking, error) {
+	query := `SELECT ` + bookingColumns + ` FROM bookings WHERE id = $1`
+
+	var booking types.Booking
+	row := s.pool.DB.QueryRow(ctx, query, id)
+	if err := scanBooking(row, &booking); err != nil {
+		return nil, storeError(err)
+	}
+
+	return &booking, nil
+}
+
+// CancelBooking marks a pending or confirmed booking as cancelled, records
+// the refund and gives back its promo code redemption, in one transaction. The
+// status check is part of the UPDATE, so a booking can only be cancelled (and
+// refunded) once.
+func (s *PostgresBookingStore) CancelBooking(ctx context.Context, id string, refundAmount pricing.Money) (*types.Booking, error) {
+	tx, err := s.pool.DB.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	query := `UPDATE bookings
+		SET status = 'cancelled', cancelled_at = now(), refund_amount = $2
+		WHERE id = $1 AND status IN ('pending', 'confirmed')
+		RETURNING ` + bookingColumns
+
+	var booking types.Booking
+	row := tx.QueryRow(ctx, query, id, refundAmount)
+	if err := scanBooking(row, &booking); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, db.ErrInvalidBookingTransition
+		}
+		return nil, storeError(err)
+	}
+	if err := releasePromoCode(ctx, tx, booking.Price.PromoCode); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, err
+	}
+	return &booking, nil
+}
+
+// ConfirmBooking turns an unexpired hold into a confirmed booking. It returns
+// ErrHoldExpired if the hold ran out, and ErrInvalidBookingTransition if the
+// booking is not a pending hold.
+func (s *PostgresBookingStore) ConfirmBooking(ctx context.Context, id string) (*types.Booking, error) {
+	query := `UPDATE bookings
+		SET status = 'confirmed', expires_at = NULL
+		WHERE id = $1 AND status = 'pending' AND expires_at > now()
+		RETURNING ` + bookingColumns
+
+	var booking types.Booking
+	err := scanBooking(s.pool.DB.QueryRow(ctx, query, id), &booking)
+	if err == nil {
+		return &booking, nil
+	}
+	if !errors.Is(err, pgx.ErrNoRows) {
+		return nil, storeError(err)
+	}
+
+	current, err := s.GetBookingById(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if current.Status == types.BookingPending && current.ExpiresAt != nil {
+		return nil, db.ErrHoldExpired
+	}
+	return nil, db.ErrInvalidBookingTransition
+}
+
+// ReleaseExpiredHolds cancels the holds that expired before now and gives back
+// their promo code redemptions, in one transaction. It returns the number of
+// holds released.
+func (s *PostgresBookingStore) ReleaseExpiredHolds(ctx context.Context, now time.Time) (int, error) {
+	tx, err := s.pool.DB.Begin(ctx)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback(ctx)
+
+	query := `UPDATE bookings
+		SET status = 'cancelled', cancelled_at = $1
+		WHERE status = 'pending' AND expires_at <= $1
+		RETURNING price_breakdown->>'promoCode'`
+
+	rows, err := tx.Query(ctx, query, now)
+	if err != nil {
+		return 0, err
+	}
+	var (
+		released   int
+		promoCodes []string
+	)
+	for rows.Next() {
+		var promoCode *string
+		if err := rows.Scan(&promoCode); err != nil {
+			rows.Close()
+			return 0, err
+		}
+		if promoCode != nil && *promoCode != "" {
+			promoCodes = append(promoCodes, *promoCode)
+		}
+		released++
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, err
+	}
+
+	for _, code := range promoCodes {
+		if err := releasePromoCode(ctx, tx, code); err != nil {
+			return 0, err
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return 0, err
+	}
+	return released, nil
+}
+
+// UpdateBooking moves current to the room, dates, guest count and price of
+// updated. The booking row and the target room are locked, and the change is
+// rejected with a BookingConflictError if it would overlap another booking,
+// leaving the original booking untouched.
+func (s *PostgresBookingStore) UpdateBooking(ctx context.Context, current, updated *types.Booking) (*types.Booking, error) {
+	booking := *updated
+	booking.Id = current.Id
+
+	tx, err := s.pool.DB.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	var status types.BookingStatus
+	row := tx.QueryRow(ctx, `SELECT status FROM bookings WHERE id = $1 FOR UPDATE`, booking.Id)
+	if err := row.Scan(&status); err != nil {
+		return nil, storeError(err)
+	}
+	if status != types.BookingPending && status != types.BookingConfirmed {
+		return nil, db.ErrInvalidBookingTransition
+	}
+
+	var roomId int
+	row = tx.QueryRow(ctx, `SELECT id FROM rooms WHERE id = $1 FOR UPDATE`, booking.RoomId)
+	if err := row.Scan(&roomId); err != nil {
+		return nil, storeError(err)
+	}
+
+	overlapQuery := `SELECT EXISTS (
+		SELECT 1 FROM bookings
+		WHERE roomid = $1 AND id <> $4 AND status <> 'cancelled'
+			AND fromdate < $3 AND todate > $2)`
+
+	var overlapping bool
+	row = tx.QueryRow(ctx, overlapQuery, booking.RoomId, booking.From, booking.To, booking.Id)
+	if err := row.Scan(&overlapping); err != nil {
+		return nil, err
+	}
+	if overlapping {
+		return nil, newBookingConflictError(&booking)
+	}
+
+	query := `UPDATE bookings
+		SET roomid = $2, numperson = $3, fromdate = $4, todate = $5, total_price = $6, price_breakdown = $7
+		WHERE id = $1
+		RETURNING ` + bookingColumns
+
+	var result types.Booking
+	row = tx.QueryRow(ctx, query,
+		booking.Id,
+		booking.RoomId,
+		booking.NumPerson,
+		booking.From,
+		booking.To,
+		booking.Price.Total,
+		booking.Price)
+	if err := scanBooking(row, &result); err != nil {
+		if isExclusionViolation(err) {
+			return nil, newBookingConflictError(&booking)
+		}
+		return nil, err
+	}
 
-		bookingInfos = append(bookingInfos, &bookingInfo)
+	if err := tx.Commit(ctx); err != nil {
+		return nil, err
 	}
 
-	return bookingInfos, nil
+	return &result, nil
+}
+
+func scanBooking(row pgx.Row, booking *types.Booking) error {
+	return row.Scan(
+		&booking.Id,
+		&booking.UserId,
+		&booking.RoomId,
+		&booking.NumPerson,
+		&booking.From,
+		&booking.To,
+		&booking.Price,
+		&booking.Status,
+		&booking.ExpiresAt,
+		&booking.CancelledAt,
+		&booking.RefundAmount)
 }
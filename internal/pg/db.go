@@ -5,6 +5,7 @@ import (
 	"log"
 	"sync"
 
+	"github.com/ctchen222/hotel-system/internal/db"
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
@@ -43,3 +44,21 @@ func NewPostgresInstance(ctx context.Context, connString string) *PostgresInstan
 	})
 	return PGInstance
 }
+
+// NewStore returns the stores backed by the PostgreSQL pool.
+func NewStore(pool *PostgresInstance) *db.Store {
+	return &db.Store{
+		User:      NewPostgresUserStore(pool),
+		Hotel:     NewPostgresHotelStore(pool),
+		Room:      NewPostgresRoomStore(pool),
+		Booking:   NewPostgresBookingStore(pool),
+		RatePlan:  NewPostgresRatePlanStore(pool),
+		PromoCode: NewPostgresPromoCodeStore(pool),
+		Payment:   NewPostgresPaymentStore(pool),
+		Session:   NewPostgresSessionStore(pool),
+		Token:     NewPostgresTokenStore(pool),
+		TwoFactor: NewPostgresTwoFactorStore(pool),
+		APIKey:    NewPostgresAPIKeyStore(pool),
+		Identity:  NewPostgresIdentityStore(pool),
+	}
+}
@@ -0,0 +1,78 @@
+package models
+
+import (
+	"errors"
+
+	"github.com/ctchen222/hotel-system/internal/db"
+	"github.com/ctchen222/hotel-system/internal/types"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+const (
+	// exclusionViolation is the SQLSTATE raised when an exclusion constraint
+	// rejects a row.
+	exclusionViolation = "23P01"
+	// uniqueViolation is the SQLSTATE raised when a unique constraint rejects
+	// a row.
+	uniqueViolation = "23505"
+	// foreignKeyViolation is the SQLSTATE raised when a row references a row
+	// that doesn't exist, or a referenced row is deleted.
+	foreignKeyViolation = "23503"
+	// checkViolation is the SQLSTATE raised when a check constraint rejects a
+	// row.
+	checkViolation = "23514"
+	// invalidTextRepresentation is the SQLSTATE raised when a parameter can't
+	// be parsed, e.g. an id that isn't a number.
+	invalidTextRepresentation = "22P02"
+)
+
+// storeError translates pgx.ErrNoRows, ids that can't be parsed and so can't
+// name a row, and references to rows that don't exist to db.ErrNotFound.
+// Rows rejected by a unique or check constraint become db.ErrConflict and
+// db.ErrValidation errors. Stores that have a more specific error for a
+// constraint check for it first.
+func storeError(err error) error {
+	switch {
+	case errors.Is(err, pgx.ErrNoRows), hasCode(err, invalidTextRepresentation), hasCode(err, foreignKeyViolation):
+		return db.ErrNotFound
+	case isUniqueViolation(err):
+		return &db.Error{Kind: db.ErrConflict, Message: "record already exists"}
+	case hasCode(err, checkViolation):
+		return &db.Error{Kind: db.ErrValidation, Message: "record violates a constraint"}
+	}
+	return err
+}
+
+// deleteError is storeError for deletes, where a foreign key violation means
+// that bookings still reference the row; every other reference to users,
+// hotels and rooms cascades.
+func deleteError(err error) error {
+	if hasCode(err, foreignKeyViolation) {
+		return db.ErrHasBookings
+	}
+	return storeError(err)
+}
+
+// isExclusionViolation reports whether err was raised by the bookings
+// exclusion constraint rejecting an overlapping date range.
+func isExclusionViolation(err error) bool {
+	return hasCode(err, exclusionViolation)
+}
+
+func isUniqueViolation(err error) bool {
+	return hasCode(err, uniqueViolation)
+}
+
+func hasCode(err error, code string) bool {
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && pgErr.Code == code
+}
+
+func newBookingConflictError(booking *types.Booking) *db.BookingConflictError {
+	return &db.BookingConflictError{
+		RoomId: booking.RoomId,
+		From:   booking.From,
+		To:     booking.To,
+	}
+}
@@ -0,0 +1,35 @@
+package models
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/ctchen222/hotel-system/internal/db"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+func TestStoreError(t *testing.T) {
+	tests := []struct {
+		name       string
+		err        error
+		wantKind   error
+		wantDelete error
+	}{
+		{name: "No rows", err: pgx.ErrNoRows, wantKind: db.ErrNotFound, wantDelete: db.ErrNotFound},
+		{name: "Id that isn't a number", err: &pgconn.PgError{Code: invalidTextRepresentation}, wantKind: db.ErrNotFound, wantDelete: db.ErrNotFound},
+		{name: "Foreign key", err: &pgconn.PgError{Code: foreignKeyViolation}, wantKind: db.ErrNotFound, wantDelete: db.ErrHasBookings},
+		{name: "Unique", err: &pgconn.PgError{Code: uniqueViolation}, wantKind: db.ErrConflict, wantDelete: db.ErrConflict},
+		{name: "Check", err: &pgconn.PgError{Code: checkViolation}, wantKind: db.ErrValidation, wantDelete: db.ErrValidation},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := storeError(tt.err); !errors.Is(err, tt.wantKind) {
+				t.Errorf("storeError() = %v, want %v", err, tt.wantKind)
+			}
+			if err := deleteError(tt.err); !errors.Is(err, tt.wantDelete) {
+				t.Errorf("deleteError() = %v, want %v", err, tt.wantDelete)
+			}
+		})
+	}
+}
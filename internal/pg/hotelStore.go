@@ -3,16 +3,12 @@ package models
 import (
 	"context"
 
-	"github.com/ctchen222/hotel-system/internal/pgtypes"
+	"github.com/ctchen222/hotel-system/internal/db"
+	"github.com/ctchen222/hotel-system/internal/types"
+	"github.com/jackc/pgx/v5"
 )
 
-type PgHotelStore interface {
-	CreateHotel(context.Context, *pgtypes.Hotel) error
-	GetHotels(context.Context) ([]*pgtypes.Hotel, error)
-	GetHotelById(ctx context.Context, id string) (*pgtypes.Hotel, error)
-	UpdateHotel(ctx context.Context, hotel *pgtypes.UpdateHotelParams, id string) error
-	DeleteHotel(ctx context.Context, id string) error
-}
+const hotelColumns = `id, name, location, rating, free_cancellation_days, cancellation_penalty_percent`
 
 type PostgresHotelStore struct {
 	pool *PostgresInstance
@@ -24,30 +20,38 @@ func NewPostgresHotelStore(pool *PostgresInstance) *PostgresHotelStore {
 	}
 }
 
-func (s *PostgresHotelStore) CreateHotel(ctx context.Context, hotel *pgtypes.Hotel) error {
-	query := `INSERT INTO hotels(name, location, rating) VALUES($1, $2, $3)`
-
-	_, err := s.pool.DB.Exec(ctx, query, hotel.Name, hotel.Location, hotel.Rating)
+func (s *PostgresHotelStore) Insert(ctx context.Context, hotel *types.Hotel) (*types.Hotel, error) {
+	query := `INSERT INTO hotels(name, location, rating, free_cancellation_days, cancellation_penalty_percent)
+		VALUES($1, $2, $3, $4, $5)
+		RETURNING id`
+
+	err := s.pool.DB.QueryRow(ctx, query,
+		hotel.Name,
+		hotel.Location,
+		hotel.Rating,
+		hotel.CancellationPolicy.FreeCancellationDays,
+		hotel.CancellationPolicy.PenaltyPercent).Scan(&hotel.Id)
 	if err != nil {
-		return err
+		if isUniqueViolation(err) {
+			return nil, db.ErrHotelExists
+		}
+		return nil, storeError(err)
 	}
 
-	return nil
+	return hotel, nil
 }
 
-func (s *PostgresHotelStore) GetHotels(ctx context.Context) ([]*pgtypes.Hotel, error) {
-	query := `SELECT * FROM hotels`
-
-	rows, err := s.pool.DB.Query(ctx, query)
+func (s *PostgresHotelStore) GetHotels(ctx context.Context) ([]*types.Hotel, error) {
+	rows, err := s.pool.DB.Query(ctx, `SELECT `+hotelColumns+` FROM hotels`)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
 
-	var hotels []*pgtypes.Hotel
+	var hotels []*types.Hotel
 	for rows.Next() {
-		var hotel pgtypes.Hotel
-		if err := rows.Scan(&hotel.Id, &hotel.Name, &hotel.Location, &hotel.Rating); err != nil {
+		var hotel types.Hotel
+		if err := scanHotel(rows, &hotel); err != nil {
 			return nil, err
 		}
 		hotels = append(hotels, &hotel)
@@ -56,41 +60,60 @@ func (s *PostgresHotelStore) GetHotels(ctx context.Context) ([]*pgtypes.Hotel, e
 	return hotels, nil
 }
 
-func (s *PostgresHotelStore) GetHotelById(ctx context.Context, id string) (*pgtypes.Hotel, error) {
-	query := `SELECT * FROM hotels WHERE id = $1`
-
-	var hotel pgtypes.Hotel
-	row := s.pool.DB.QueryRow(ctx, query, id)
-	if err := row.Scan(&hotel.Id, &hotel.Name, &hotel.Location, &hotel.Rating); err != nil {
-		return nil, err
+func (s *PostgresHotelStore) GetHotelById(ctx context.Context, id string) (*types.Hotel, error) {
+	var hotel types.Hotel
+	row := s.pool.DB.QueryRow(ctx, `SELECT `+hotelColumns+` FROM hotels WHERE id = $1`, id)
+	if err := scanHotel(row, &hotel); err != nil {
+		return nil, storeError(err)
 	}
 
 	return &hotel, nil
 }
 
-func (s *PostgresHotelStore) UpdateHotel(ctx context.Context, hotel *pgtypes.UpdateHotelParams, id string) error {
-	var hotelId string
-	row := s.pool.DB.QueryRow(ctx, `SELECT id FROM hotels WHERE id = $1`, id)
-	if err := row.Scan(&hotelId); err != nil {
-		return err
-	}
-
-	query := `UPDATE hotels SET name = $1, location = $2, rating = $3 WHERE id = $4`
-	_, err := s.pool.DB.Exec(ctx, query, hotel.Name, hotel.Location, hotel.Rating, id)
+func (s *PostgresHotelStore) Update(ctx context.Context, params types.HotelUpdateParams, id string) error {
+	query := `UPDATE hotels
+		SET name = $1, location = $2, rating = $3, free_cancellation_days = $4, cancellation_penalty_percent = $5
+		WHERE id = $6`
+	tag, err := s.pool.DB.Exec(ctx, query,
+		params.Name,
+		params.Location,
+		params.Rating,
+		params.CancellationPolicy.FreeCancellationDays,
+		params.CancellationPolicy.PenaltyPercent,
+		id)
 	if err != nil {
-		return err
+		if isUniqueViolation(err) {
+			return db.ErrHotelExists
+		}
+		return storeError(err)
+	}
+	if tag.RowsAffected() == 0 {
+		return db.ErrNotFound
 	}
 
 	return nil
 }
 
-func (s *PostgresHotelStore) DeleteHotel(ctx context.Context, id string) error {
+func (s *PostgresHotelStore) Delete(ctx context.Context, id string) error {
 	query := `DELETE FROM hotels WHERE id = $1`
 
-	_, err := s.pool.DB.Exec(ctx, query, id)
+	tag, err := s.pool.DB.Exec(ctx, query, id)
 	if err != nil {
-		return err
+		return deleteError(err)
+	}
+	if tag.RowsAffected() == 0 {
+		return db.ErrNotFound
 	}
 
 	return nil
 }
+
+func scanHotel(row pgx.Row, hotel *types.Hotel) error {
+	return row.Scan(
+		&hotel.Id,
+		&hotel.Name,
+		&hotel.Location,
+		&hotel.Rating,
+		&hotel.CancellationPolicy.FreeCancellationDays,
+		&hotel.CancellationPolicy.PenaltyPercent)
+}
@@ -0,0 +1,32 @@
+package models
+
+import (
+	"context"
+)
+
+type PostgresIdentityStore struct {
+	pool *PostgresInstance
+}
+
+func NewPostgresIdentityStore(pool *PostgresInstance) *PostgresIdentityStore {
+	return &PostgresIdentityStore{
+		pool: pool,
+	}
+}
+
+func (s *PostgresIdentityStore) GetIdentityUserId(ctx context.Context, issuer, subject string) (string, error) {
+	query := `SELECT userid FROM oidc_identities WHERE issuer = $1 AND subject = $2`
+
+	var userId string
+	if err := s.pool.DB.QueryRow(ctx, query, issuer, subject).Scan(&userId); err != nil {
+		return "", storeError(err)
+	}
+	return userId, nil
+}
+
+func (s *PostgresIdentityStore) Insert(ctx context.Context, issuer, subject, userId string) error {
+	query := `INSERT INTO oidc_identities(issuer, subject, userid, created_at) VALUES($1, $2, $3, now())`
+
+	_, err := s.pool.DB.Exec(ctx, query, issuer, subject, userId)
+	return storeError(err)
+}
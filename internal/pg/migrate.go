@@ -0,0 +1,192 @@
+package models
+
+import (
+	"context"
+	"embed"
+	"fmt"
+	"io/fs"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// migrationFiles holds the schema migrations. Each version has an up and a
+// down file, named <version>_<name>.up.sql and <version>_<name>.down.sql,
+// with versions numbered from 1 without gaps.
+//
+//go:embed migrations/*.sql
+var migrationFiles embed.FS
+
+// migrationLockId is the advisory lock held while migrating, so that
+// instances started at the same time don't migrate concurrently.
+const migrationLockId = 7_412_305
+
+type migration struct {
+	version int
+	name    string
+	up      string
+	down    string
+}
+
+// loadMigrations reads the embedded migrations, ordered by version.
+func loadMigrations() ([]migration, error) {
+	files, err := fs.Glob(migrationFiles, "migrations/*.sql")
+	if err != nil {
+		return nil, err
+	}
+
+	byVersion := map[int]*migration{}
+	for _, file := range files {
+		base := path.Base(file)
+		name, direction, ok := strings.Cut(strings.TrimSuffix(base, ".sql"), ".")
+		if !ok || (direction != "up" && direction != "down") {
+			return nil, fmt.Errorf("migration %s: name must end in .up.sql or .down.sql", base)
+		}
+		prefix, _, _ := strings.Cut(name, "_")
+		version, err := strconv.Atoi(prefix)
+		if err != nil || version < 1 {
+			return nil, fmt.Errorf("migration %s: name must start with a version number", base)
+		}
+
+		sql, err := migrationFiles.ReadFile(file)
+		if err != nil {
+			return nil, err
+		}
+		m, ok := byVersion[version]
+		if !ok {
+			m = &migration{version: version, name: name}
+			byVersion[version] = m
+		}
+		if m.name != name {
+			return nil, fmt.Errorf("migration %s: version %d is also used by %s", base, version, m.name)
+		}
+		if direction == "up" {
+			m.up = string(sql)
+		} else {
+			m.down = string(sql)
+		}
+	}
+
+	migrations := make([]migration, 0, len(byVersion))
+	for _, m := range byVersion {
+		if m.up == "" || m.down == "" {
+			return nil, fmt.Errorf("migration %s: needs both an up and a down file", m.name)
+		}
+		migrations = append(migrations, *m)
+	}
+	sort.Slice(migrations, func(i, j int) bool {
+		return migrations[i].version < migrations[j].version
+	})
+	for i, m := range migrations {
+		if m.version != i+1 {
+			return nil, fmt.Errorf("migration %s: expected version %d", m.name, i+1)
+		}
+	}
+	return migrations, nil
+}
+
+// LatestSchemaVersion returns the version of the newest migration.
+func LatestSchemaVersion() (int, error) {
+	migrations, err := loadMigrations()
+	if err != nil {
+		return 0, err
+	}
+	return len(migrations), nil
+}
+
+// SchemaVersion returns the version the database was migrated to, 0 if it
+// was never migrated.
+func (pg *PostgresInstance) SchemaVersion(ctx context.Context) (int, error) {
+	if err := createMigrationsTable(ctx, pg.DB); err != nil {
+		return 0, err
+	}
+	return schemaVersion(ctx, pg.DB)
+}
+
+// Migrate applies or rolls back migrations until the database is at the given
+// version. Every migration runs in its own transaction together with the
+// update of the schema_migrations table, so a failed migration leaves the
+// database at the previous version.
+func (pg *PostgresInstance) Migrate(ctx context.Context, version int) error {
+	migrations, err := loadMigrations()
+	if err != nil {
+		return err
+	}
+	if version < 0 || version > len(migrations) {
+		return fmt.Errorf("unknown schema version %d, want 0 to %d", version, len(migrations))
+	}
+
+	conn, err := pg.DB.Acquire(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Release()
+
+	if _, err := conn.Exec(ctx, `SELECT pg_advisory_lock($1)`, migrationLockId); err != nil {
+		return err
+	}
+	defer conn.Exec(context.Background(), `SELECT pg_advisory_unlock($1)`, migrationLockId)
+
+	if err := createMigrationsTable(ctx, conn); err != nil {
+		return err
+	}
+	current, err := schemaVersion(ctx, conn)
+	if err != nil {
+		return err
+	}
+
+	for current < version {
+		m := migrations[current]
+		err := pgx.BeginFunc(ctx, conn, func(tx pgx.Tx) error {
+			if _, err := tx.Exec(ctx, m.up); err != nil {
+				return err
+			}
+			_, err := tx.Exec(ctx, `INSERT INTO schema_migrations(version, name) VALUES($1, $2)`, m.version, m.name)
+			return err
+		})
+		if err != nil {
+			return fmt.Errorf("applying migration %s: %w", m.name, err)
+		}
+		current++
+	}
+	for current > version {
+		m := migrations[current-1]
+		err := pgx.BeginFunc(ctx, conn, func(tx pgx.Tx) error {
+			if _, err := tx.Exec(ctx, m.down); err != nil {
+				return err
+			}
+			_, err := tx.Exec(ctx, `DELETE FROM schema_migrations WHERE version = $1`, m.version)
+			return err
+		})
+		if err != nil {
+			return fmt.Errorf("rolling back migration %s: %w", m.name, err)
+		}
+		current--
+	}
+
+	return nil
+}
+
+type execQuerier interface {
+	Exec(ctx context.Context, sql string, arguments ...any) (pgconn.CommandTag, error)
+	QueryRow(ctx context.Context, sql string, args ...any) pgx.Row
+}
+
+func createMigrationsTable(ctx context.Context, conn execQuerier) error {
+	_, err := conn.Exec(ctx, `CREATE TABLE IF NOT EXISTS schema_migrations (
+		version    INT PRIMARY KEY,
+		name       TEXT NOT NULL,
+		applied_at TIMESTAMPTZ NOT NULL DEFAULT now()
+	)`)
+	return err
+}
+
+func schemaVersion(ctx context.Context, conn execQuerier) (int, error) {
+	var version int
+	err := conn.QueryRow(ctx, `SELECT COALESCE(MAX(version), 0) FROM schema_migrations`).Scan(&version)
+	return version, err
+}
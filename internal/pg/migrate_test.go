@@ -0,0 +1,57 @@
+package models
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestLoadMigrations(t *testing.T) {
+	migrations, err := loadMigrations()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(migrations) == 0 {
+		t.Fatal("no migrations embedded")
+	}
+
+	for i, m := range migrations {
+		if m.version != i+1 {
+			t.Errorf("migration %s has version %d, want %d", m.name, m.version, i+1)
+		}
+		if strings.TrimSpace(m.up) == "" || strings.TrimSpace(m.down) == "" {
+			t.Errorf("migration %s has an empty up or down file", m.name)
+		}
+	}
+
+	latest, err := LatestSchemaVersion()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if latest != len(migrations) {
+		t.Errorf("LatestSchemaVersion() = %d, want %d", latest, len(migrations))
+	}
+}
+
+// TestMigrationsCreateStoreTables checks that every table the stores query
+// is created by a migration.
+func TestMigrationsCreateStoreTables(t *testing.T) {
+	migrations, err := loadMigrations()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var up strings.Builder
+	for _, m := range migrations {
+		up.WriteString(m.up)
+	}
+
+	tables := []string{
+		"users", "hotels", "rooms", "bookings", "rate_plans", "promo_codes", "payments",
+		"sessions", "one_time_tokens", "two_factor", "two_factor_roles", "api_keys", "oidc_identities",
+	}
+	for _, table := range tables {
+		if !strings.Contains(up.String(), "CREATE TABLE IF NOT EXISTS "+table+" (") &&
+			!strings.Contains(up.String(), "CREATE TABLE "+table+" (") {
+			t.Errorf("no migration creates table %s", table)
+		}
+	}
+}
@@ -0,0 +1,39 @@
+-- The tables the first version of the PostgreSQL backend used. They may
+-- have been created by hand before there were migrations, so existing ones
+-- are kept.
+CREATE TABLE IF NOT EXISTS users (
+    id                 SERIAL PRIMARY KEY,
+    firstname          TEXT NOT NULL,
+    lastname           TEXT NOT NULL,
+    email              TEXT NOT NULL,
+    encrypted_password TEXT NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS hotels (
+    id       SERIAL PRIMARY KEY,
+    name     TEXT NOT NULL,
+    location TEXT NOT NULL,
+    rating   INT NOT NULL DEFAULT 0
+);
+
+CREATE TABLE IF NOT EXISTS rooms (
+    id      SERIAL PRIMARY KEY,
+    size    TEXT NOT NULL,
+    seaside BOOLEAN NOT NULL DEFAULT false,
+    price   DOUBLE PRECISION NOT NULL,
+    hotelid INT NOT NULL REFERENCES hotels(id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS rooms_hotelid_idx ON rooms (hotelid);
+
+CREATE TABLE IF NOT EXISTS bookings (
+    id        SERIAL PRIMARY KEY,
+    userid    INT NOT NULL REFERENCES users(id),
+    roomid    INT NOT NULL REFERENCES rooms(id),
+    numperson INT NOT NULL,
+    fromdate  TIMESTAMPTZ NOT NULL,
+    todate    TIMESTAMPTZ NOT NULL,
+    CHECK (todate > fromdate)
+);
+
+CREATE INDEX IF NOT EXISTS bookings_userid_idx ON bookings (userid);
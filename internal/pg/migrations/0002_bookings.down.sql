@@ -0,0 +1,17 @@
+DROP INDEX IF EXISTS bookings_expires_at_idx;
+
+ALTER TABLE bookings DROP CONSTRAINT IF EXISTS bookings_no_overlap;
+
+ALTER TABLE bookings
+    DROP COLUMN IF EXISTS expires_at,
+    DROP COLUMN IF EXISTS price_breakdown,
+    DROP COLUMN IF EXISTS total_price,
+    DROP COLUMN IF EXISTS refund_amount,
+    DROP COLUMN IF EXISTS cancelled_at,
+    DROP COLUMN IF EXISTS status;
+
+ALTER TABLE hotels
+    DROP COLUMN IF EXISTS cancellation_penalty_percent,
+    DROP COLUMN IF EXISTS free_cancellation_days;
+
+ALTER TABLE rooms DROP COLUMN IF EXISTS capacity;
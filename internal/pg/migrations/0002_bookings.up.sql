@@ -0,0 +1,50 @@
+ALTER TABLE rooms ADD COLUMN IF NOT EXISTS capacity INT NOT NULL DEFAULT 1;
+
+ALTER TABLE hotels
+    ADD COLUMN IF NOT EXISTS free_cancellation_days INT NOT NULL DEFAULT 0,
+    ADD COLUMN IF NOT EXISTS cancellation_penalty_percent INT NOT NULL DEFAULT 0;
+
+ALTER TABLE bookings
+    ADD COLUMN IF NOT EXISTS status TEXT NOT NULL DEFAULT 'confirmed'
+        CHECK (status IN ('pending', 'confirmed', 'cancelled')),
+    ADD COLUMN IF NOT EXISTS cancelled_at TIMESTAMPTZ,
+    ADD COLUMN IF NOT EXISTS refund_amount BIGINT NOT NULL DEFAULT 0,
+    ADD COLUMN IF NOT EXISTS total_price BIGINT NOT NULL DEFAULT 0,
+    ADD COLUMN IF NOT EXISTS price_breakdown JSONB NOT NULL DEFAULT '{}',
+    ADD COLUMN IF NOT EXISTS expires_at TIMESTAMPTZ;
+
+-- Bookings are half-open ranges, so a stay may start on the day the
+-- previous one ends. The booking store checks for overlaps itself; the
+-- constraint catches the bookings that race past that check, which the
+-- store reports as a conflict.
+CREATE EXTENSION IF NOT EXISTS btree_gist;
+
+-- Bookings made before the constraint may already overlap, and the
+-- constraint can't be added over them. Name them, so one of each pair can be
+-- moved or deleted before migrating again; the migration is rolled back, so
+-- there is no status to cancel them with yet.
+DO $$
+DECLARE
+    overlapping TEXT;
+BEGIN
+    SELECT string_agg(format('%s and %s in room %s', a.id, b.id, a.roomid), ', ' ORDER BY a.id, b.id)
+    INTO overlapping
+    FROM bookings a
+    JOIN bookings b ON a.roomid = b.roomid AND a.id < b.id
+        AND tstzrange(a.fromdate, a.todate) && tstzrange(b.fromdate, b.todate)
+    WHERE a.status <> 'cancelled' AND b.status <> 'cancelled';
+
+    IF overlapping IS NOT NULL THEN
+        RAISE EXCEPTION 'bookings overlap: %', overlapping
+            USING HINT = 'Move or delete one booking of each pair and migrate again.';
+    END IF;
+END
+$$;
+
+ALTER TABLE bookings ADD CONSTRAINT bookings_no_overlap
+    EXCLUDE USING gist (roomid WITH =, tstzrange(fromdate, todate) WITH &&)
+    WHERE (status <> 'cancelled');
+
+-- The hold sweeper looks for expired holds.
+CREATE INDEX IF NOT EXISTS bookings_expires_at_idx ON bookings (expires_at)
+    WHERE status = 'pending';
@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS payments;
+DROP TABLE IF EXISTS promo_codes;
+DROP TABLE IF EXISTS rate_plans;
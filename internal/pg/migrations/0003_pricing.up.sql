@@ -0,0 +1,47 @@
+CREATE TABLE rate_plans (
+    id                SERIAL PRIMARY KEY,
+    roomid            INT NOT NULL REFERENCES rooms(id) ON DELETE CASCADE,
+    name              TEXT NOT NULL,
+    valid_from        TIMESTAMPTZ NOT NULL,
+    valid_to          TIMESTAMPTZ NOT NULL,
+    priority          INT NOT NULL DEFAULT 0,
+    nightly_rate      BIGINT NOT NULL DEFAULT 0,
+    weekday_percent   INT NOT NULL DEFAULT 0,
+    weekend_percent   INT NOT NULL DEFAULT 0,
+    min_stay          INT NOT NULL DEFAULT 0,
+    closed_to_arrival INT[] NOT NULL DEFAULT '{}'
+);
+
+CREATE INDEX rate_plans_roomid_idx ON rate_plans (roomid, valid_from);
+
+CREATE TABLE promo_codes (
+    id              SERIAL PRIMARY KEY,
+    code            TEXT NOT NULL UNIQUE,
+    kind            TEXT NOT NULL,
+    percent         INT NOT NULL DEFAULT 0,
+    amount          BIGINT NOT NULL DEFAULT 0,
+    valid_from      TIMESTAMPTZ NOT NULL,
+    valid_to        TIMESTAMPTZ NOT NULL,
+    min_nights      INT NOT NULL DEFAULT 0,
+    -- Zero means the code can be redeemed any number of times.
+    max_redemptions INT NOT NULL DEFAULT 0,
+    redemptions     INT NOT NULL DEFAULT 0 CHECK (redemptions >= 0),
+    -- NULL means the code applies to every hotel.
+    hotelid         INT REFERENCES hotels(id) ON DELETE CASCADE
+);
+
+CREATE TABLE payments (
+    id         SERIAL PRIMARY KEY,
+    bookingid  INT NOT NULL REFERENCES bookings(id) ON DELETE CASCADE,
+    provider   TEXT NOT NULL,
+    reference  TEXT NOT NULL,
+    status     TEXT NOT NULL,
+    amount     BIGINT NOT NULL,
+    currency   TEXT NOT NULL,
+    captured   BIGINT NOT NULL DEFAULT 0,
+    refunded   BIGINT NOT NULL DEFAULT 0,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX payments_bookingid_idx ON payments (bookingid, created_at);
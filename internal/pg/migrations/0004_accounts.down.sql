@@ -0,0 +1,13 @@
+DROP TABLE IF EXISTS oidc_identities;
+DROP TABLE IF EXISTS api_keys;
+DROP TABLE IF EXISTS two_factor_roles;
+DROP TABLE IF EXISTS two_factor;
+DROP TABLE IF EXISTS one_time_tokens;
+DROP TABLE IF EXISTS sessions;
+
+ALTER TABLE users
+    DROP COLUMN IF EXISTS email_verified,
+    DROP COLUMN IF EXISTS hotel_ids,
+    DROP COLUMN IF EXISTS role;
+
+DROP INDEX IF EXISTS users_email_key;
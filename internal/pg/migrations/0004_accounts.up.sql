@@ -0,0 +1,65 @@
+CREATE UNIQUE INDEX IF NOT EXISTS users_email_key ON users (email);
+
+ALTER TABLE users
+    ADD COLUMN IF NOT EXISTS role TEXT NOT NULL DEFAULT 'guest',
+    -- The hotels a hotel staff member or manager works for.
+    ADD COLUMN IF NOT EXISTS hotel_ids INT[],
+    ADD COLUMN IF NOT EXISTS email_verified BOOLEAN NOT NULL DEFAULT false;
+
+-- Accounts that existed before email verification keep working.
+UPDATE users SET email_verified = true;
+
+CREATE TABLE sessions (
+    id         TEXT PRIMARY KEY,
+    userid     INT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    token_hash TEXT NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL,
+    expires_at TIMESTAMPTZ NOT NULL,
+    revoked_at TIMESTAMPTZ
+);
+
+CREATE INDEX sessions_userid_idx ON sessions (userid);
+
+CREATE TABLE one_time_tokens (
+    token_hash TEXT PRIMARY KEY,
+    userid     INT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    purpose    TEXT NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL,
+    expires_at TIMESTAMPTZ NOT NULL,
+    used_at    TIMESTAMPTZ
+);
+
+CREATE INDEX one_time_tokens_userid_idx ON one_time_tokens (userid, purpose);
+
+CREATE TABLE two_factor (
+    userid         INT PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+    secret         TEXT NOT NULL,
+    enabled        BOOLEAN NOT NULL DEFAULT false,
+    recovery_codes TEXT[] NOT NULL DEFAULT '{}',
+    last_step      BIGINT NOT NULL DEFAULT 0
+);
+
+-- The roles that have to use two-factor authentication.
+CREATE TABLE two_factor_roles (
+    role TEXT PRIMARY KEY
+);
+
+CREATE TABLE api_keys (
+    id           TEXT PRIMARY KEY,
+    userid       INT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    name         TEXT NOT NULL,
+    key_hash     TEXT NOT NULL,
+    permissions  TEXT[] NOT NULL DEFAULT '{}',
+    created_at   TIMESTAMPTZ NOT NULL,
+    expires_at   TIMESTAMPTZ,
+    last_used_at TIMESTAMPTZ,
+    revoked_at   TIMESTAMPTZ
+);
+
+CREATE TABLE oidc_identities (
+    issuer     TEXT NOT NULL,
+    subject    TEXT NOT NULL,
+    userid     INT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    PRIMARY KEY (issuer, subject)
+);
@@ -0,0 +1 @@
+DROP INDEX IF EXISTS hotels_name_key;
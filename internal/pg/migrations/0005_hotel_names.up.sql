@@ -0,0 +1,2 @@
+-- Hotel names are unique, as they already are in the other stores.
+CREATE UNIQUE INDEX IF NOT EXISTS hotels_name_key ON hotels (name);
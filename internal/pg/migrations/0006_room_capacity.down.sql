@@ -0,0 +1 @@
+ALTER TABLE rooms DROP CONSTRAINT IF EXISTS rooms_capacity_check;
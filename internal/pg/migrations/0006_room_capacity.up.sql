@@ -0,0 +1,3 @@
+-- Rooms created with an explicit capacity of 0 could never be booked.
+UPDATE rooms SET capacity = 1 WHERE capacity < 1;
+ALTER TABLE rooms ADD CONSTRAINT rooms_capacity_check CHECK (capacity >= 1);
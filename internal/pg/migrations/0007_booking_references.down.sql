@@ -0,0 +1,5 @@
+ALTER TABLE bookings
+    DROP CONSTRAINT IF EXISTS bookings_userid_fkey,
+    DROP CONSTRAINT IF EXISTS bookings_roomid_fkey,
+    ADD CONSTRAINT bookings_userid_fkey FOREIGN KEY (userid) REFERENCES users(id),
+    ADD CONSTRAINT bookings_roomid_fkey FOREIGN KEY (roomid) REFERENCES rooms(id);
@@ -0,0 +1,8 @@
+-- Users and rooms with bookings can't be deleted, so the bookings are never
+-- left without their guest or room. Deleting a hotel deletes its rooms, so it
+-- fails too if any of them has bookings.
+ALTER TABLE bookings
+    DROP CONSTRAINT IF EXISTS bookings_userid_fkey,
+    DROP CONSTRAINT IF EXISTS bookings_roomid_fkey,
+    ADD CONSTRAINT bookings_userid_fkey FOREIGN KEY (userid) REFERENCES users(id) ON DELETE RESTRICT,
+    ADD CONSTRAINT bookings_roomid_fkey FOREIGN KEY (roomid) REFERENCES rooms(id) ON DELETE RESTRICT;
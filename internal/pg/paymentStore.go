@@ -0,0 +1,98 @@
+package models
+
+import (
+	"context"
+
+	"github.com/ctchen222/hotel-system/internal/db"
+	"github.com/ctchen222/hotel-system/internal/types"
+)
+
+type PostgresPaymentStore struct {
+	pool *PostgresInstance
+}
+
+func NewPostgresPaymentStore(pool *PostgresInstance) *PostgresPaymentStore {
+	return &PostgresPaymentStore{
+		pool: pool,
+	}
+}
+
+func (s *PostgresPaymentStore) Insert(ctx context.Context, payment *types.Payment) (*types.Payment, error) {
+	query := `INSERT INTO payments(bookingid, provider, reference, status, amount, currency,
+			captured, refunded, created_at, updated_at)
+		VALUES($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+		RETURNING id`
+
+	err := s.pool.DB.QueryRow(ctx, query,
+		payment.BookingId,
+		payment.Provider,
+		payment.Reference,
+		payment.Status,
+		payment.Amount,
+		payment.Currency,
+		payment.Captured,
+		payment.Refunded,
+		payment.CreatedAt,
+		payment.UpdatedAt).Scan(&payment.Id)
+	if err != nil {
+		return nil, storeError(err)
+	}
+	return payment, nil
+}
+
+func (s *PostgresPaymentStore) Update(ctx context.Context, payment *types.Payment) error {
+	query := `UPDATE payments SET status = $2, captured = $3, refunded = $4, updated_at = $5
+		WHERE id = $1`
+
+	tag, err := s.pool.DB.Exec(ctx, query,
+		payment.Id,
+		payment.Status,
+		payment.Captured,
+		payment.Refunded,
+		payment.UpdatedAt)
+	if err != nil {
+		return storeError(err)
+	}
+	if tag.RowsAffected() == 0 {
+		return db.ErrNotFound
+	}
+	return nil
+}
+
+func (s *PostgresPaymentStore) GetPaymentsByBookingId(ctx context.Context, bookingId string) ([]*types.Payment, error) {
+	query := `SELECT id, bookingid, provider, reference, status, amount, currency,
+			captured, refunded, created_at, updated_at
+		FROM payments WHERE bookingid = $1 ORDER BY created_at`
+
+	rows, err := s.pool.DB.Query(ctx, query, bookingId)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	payments := []*types.Payment{}
+	for rows.Next() {
+		var payment types.Payment
+		err := rows.Scan(
+			&payment.Id,
+			&payment.BookingId,
+			&payment.Provider,
+			&payment.Reference,
+			&payment.Status,
+			&payment.Amount,
+			&payment.Currency,
+			&payment.Captured,
+			&payment.Refunded,
+			&payment.CreatedAt,
+			&payment.UpdatedAt)
+		if err != nil {
+			return nil, err
+		}
+		payments = append(payments, &payment)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return payments, nil
+}
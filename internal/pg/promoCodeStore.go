@@ -0,0 +1,164 @@
+package models
+
+import (
+	"context"
+	"strconv"
+
+	"github.com/ctchen222/hotel-system/internal/db"
+	"github.com/ctchen222/hotel-system/internal/types"
+	"github.com/jackc/pgx/v5"
+)
+
+type PostgresPromoCodeStore struct {
+	pool *PostgresInstance
+}
+
+func NewPostgresPromoCodeStore(pool *PostgresInstance) *PostgresPromoCodeStore {
+	return &PostgresPromoCodeStore{
+		pool: pool,
+	}
+}
+
+const promoCodeColumns = `id, code, kind, percent, amount, valid_from, valid_to,
+	min_nights, max_redemptions, redemptions, hotelid`
+
+func (s *PostgresPromoCodeStore) Insert(ctx context.Context, promoCode *types.PromoCode) (*types.PromoCode, error) {
+	var hotelId *string
+	if promoCode.HotelId != "" {
+		hotelId = &promoCode.HotelId
+	}
+
+	query := `INSERT INTO promo_codes(code, kind, percent, amount, valid_from, valid_to,
+			min_nights, max_redemptions, hotelid)
+		VALUES($1, $2, $3, $4, $5, $6, $7, $8, $9)
+		RETURNING id, redemptions`
+
+	err := s.pool.DB.QueryRow(ctx, query,
+		promoCode.Code,
+		promoCode.Kind,
+		promoCode.Percent,
+		promoCode.Amount,
+		promoCode.ValidFrom,
+		promoCode.ValidTo,
+		promoCode.MinNights,
+		promoCode.MaxRedemptions,
+		hotelId).Scan(&promoCode.Id, &promoCode.Redemptions)
+	if err != nil {
+		if isUniqueViolation(err) {
+			return nil, db.ErrPromoCodeExists
+		}
+		return nil, storeError(err)
+	}
+	return promoCode, nil
+}
+
+func (s *PostgresPromoCodeStore) GetPromoCodes(ctx context.Context) ([]*types.PromoCode, error) {
+	rows, err := s.pool.DB.Query(ctx, `SELECT `+promoCodeColumns+` FROM promo_codes ORDER BY id`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var promoCodes []*types.PromoCode
+	for rows.Next() {
+		var promoCode types.PromoCode
+		if err := scanPromoCode(rows, &promoCode); err != nil {
+			return nil, err
+		}
+		promoCodes = append(promoCodes, &promoCode)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return promoCodes, nil
+}
+
+func (s *PostgresPromoCodeStore) GetPromoCodeByCode(ctx context.Context, code string) (*types.PromoCode, error) {
+	query := `SELECT ` + promoCodeColumns + ` FROM promo_codes WHERE code = $1`
+
+	var promoCode types.PromoCode
+	if err := scanPromoCode(s.pool.DB.QueryRow(ctx, query, code), &promoCode); err != nil {
+		return nil, storeError(err)
+	}
+	return &promoCode, nil
+}
+
+func (s *PostgresPromoCodeStore) GetPromoCodeById(ctx context.Context, id string) (*types.PromoCode, error) {
+	query := `SELECT ` + promoCodeColumns + ` FROM promo_codes WHERE id = $1`
+
+	var promoCode types.PromoCode
+	if err := scanPromoCode(s.pool.DB.QueryRow(ctx, query, id), &promoCode); err != nil {
+		return nil, storeError(err)
+	}
+	return &promoCode, nil
+}
+
+func (s *PostgresPromoCodeStore) Delete(ctx context.Context, id string) error {
+	tag, err := s.pool.DB.Exec(ctx, `DELETE FROM promo_codes WHERE id = $1`, id)
+	if err != nil {
+		return storeError(err)
+	}
+	if tag.RowsAffected() == 0 {
+		return db.ErrNotFound
+	}
+	return nil
+}
+
+// redeemPromoCode counts one use of the code within tx. The limit check and the
+// increment are a single statement, and the row stays locked until tx ends, so
+// concurrent bookings can't redeem the code more often than it allows.
+func redeemPromoCode(ctx context.Context, tx pgx.Tx, code string) error {
+	query := `UPDATE promo_codes SET redemptions = redemptions + 1
+		WHERE code = $1 AND (max_redemptions = 0 OR redemptions < max_redemptions)`
+
+	tag, err := tx.Exec(ctx, query, code)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() > 0 {
+		return nil
+	}
+	var exists bool
+	if err := tx.QueryRow(ctx, `SELECT EXISTS (SELECT 1 FROM promo_codes WHERE code = $1)`, code).Scan(&exists); err != nil {
+		return err
+	}
+	if !exists {
+		return db.ErrUnknownPromoCode
+	}
+	return db.ErrPromoCodeExhausted
+}
+
+// releasePromoCode gives back a redemption of the code, if there is one.
+func releasePromoCode(ctx context.Context, tx pgx.Tx, code string) error {
+	if code == "" {
+		return nil
+	}
+	_, err := tx.Exec(ctx, `UPDATE promo_codes SET redemptions = redemptions - 1 WHERE code = $1 AND redemptions > 0`, code)
+	return err
+}
+
+func scanPromoCode(row pgx.Row, promoCode *types.PromoCode) error {
+	var hotelId *int
+	err := row.Scan(
+		&promoCode.Id,
+		&promoCode.Code,
+		&promoCode.Kind,
+		&promoCode.Percent,
+		&promoCode.Amount,
+		&promoCode.ValidFrom,
+		&promoCode.ValidTo,
+		&promoCode.MinNights,
+		&promoCode.MaxRedemptions,
+		&promoCode.Redemptions,
+		&hotelId)
+	if err != nil {
+		return err
+	}
+
+	promoCode.HotelId = ""
+	if hotelId != nil {
+		promoCode.HotelId = strconv.Itoa(*hotelId)
+	}
+	return nil
+}
@@ -0,0 +1,161 @@
+package models
+
+import (
+	"context"
+	"time"
+
+	"github.com/ctchen222/hotel-system/internal/db"
+	"github.com/ctchen222/hotel-system/internal/types"
+	"github.com/jackc/pgx/v5"
+)
+
+type PostgresRatePlanStore struct {
+	pool *PostgresInstance
+}
+
+func NewPostgresRatePlanStore(pool *PostgresInstance) *PostgresRatePlanStore {
+	return &PostgresRatePlanStore{
+		pool: pool,
+	}
+}
+
+const ratePlanColumns = `id, roomid, name, valid_from, valid_to, priority, nightly_rate,
+	weekday_percent, weekend_percent, min_stay, closed_to_arrival`
+
+func (s *PostgresRatePlanStore) Insert(ctx context.Context, ratePlan *types.RatePlan) (*types.RatePlan, error) {
+	query := `INSERT INTO rate_plans(roomid, name, valid_from, valid_to, priority, nightly_rate,
+			weekday_percent, weekend_percent, min_stay, closed_to_arrival)
+		VALUES($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+		RETURNING id`
+
+	err := s.pool.DB.QueryRow(ctx, query,
+		ratePlan.RoomId,
+		ratePlan.Name,
+		ratePlan.ValidFrom,
+		ratePlan.ValidTo,
+		ratePlan.Priority,
+		ratePlan.NightlyRate,
+		ratePlan.WeekdayPercent,
+		ratePlan.WeekendPercent,
+		ratePlan.MinStay,
+		weekdaysToInts(ratePlan.ClosedToArrival)).Scan(&ratePlan.Id)
+	if err != nil {
+		return nil, storeError(err)
+	}
+	return ratePlan, nil
+}
+
+// GetRatePlans returns the rate plans of the given rooms, or every rate plan
+// if no room is given.
+func (s *PostgresRatePlanStore) GetRatePlans(ctx context.Context, roomIds ...string) ([]*types.RatePlan, error) {
+	query := `SELECT ` + ratePlanColumns + ` FROM rate_plans ORDER BY roomid, valid_from`
+	var args []any
+	if len(roomIds) > 0 {
+		ids, err := intIds(roomIds)
+		if err != nil {
+			return []*types.RatePlan{}, nil
+		}
+		query = `SELECT ` + ratePlanColumns + ` FROM rate_plans WHERE roomid = ANY($1) ORDER BY roomid, valid_from`
+		args = append(args, ids)
+	}
+
+	rows, err := s.pool.DB.Query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var ratePlans []*types.RatePlan
+	for rows.Next() {
+		var ratePlan types.RatePlan
+		if err := scanRatePlan(rows, &ratePlan); err != nil {
+			return nil, err
+		}
+		ratePlans = append(ratePlans, &ratePlan)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return ratePlans, nil
+}
+
+func (s *PostgresRatePlanStore) GetRatePlanById(ctx context.Context, id string) (*types.RatePlan, error) {
+	query := `SELECT ` + ratePlanColumns + ` FROM rate_plans WHERE id = $1`
+
+	var ratePlan types.RatePlan
+	if err := scanRatePlan(s.pool.DB.QueryRow(ctx, query, id), &ratePlan); err != nil {
+		return nil, storeError(err)
+	}
+	return &ratePlan, nil
+}
+
+func (s *PostgresRatePlanStore) Update(ctx context.Context, ratePlan *types.RatePlan) error {
+	query := `UPDATE rate_plans SET name = $2, valid_from = $3, valid_to = $4, priority = $5,
+			nightly_rate = $6, weekday_percent = $7, weekend_percent = $8, min_stay = $9,
+			closed_to_arrival = $10
+		WHERE id = $1`
+
+	tag, err := s.pool.DB.Exec(ctx, query,
+		ratePlan.Id,
+		ratePlan.Name,
+		ratePlan.ValidFrom,
+		ratePlan.ValidTo,
+		ratePlan.Priority,
+		ratePlan.NightlyRate,
+		ratePlan.WeekdayPercent,
+		ratePlan.WeekendPercent,
+		ratePlan.MinStay,
+		weekdaysToInts(ratePlan.ClosedToArrival))
+	if err != nil {
+		return storeError(err)
+	}
+	if tag.RowsAffected() == 0 {
+		return db.ErrNotFound
+	}
+	return nil
+}
+
+func (s *PostgresRatePlanStore) Delete(ctx context.Context, id string) error {
+	tag, err := s.pool.DB.Exec(ctx, `DELETE FROM rate_plans WHERE id = $1`, id)
+	if err != nil {
+		return storeError(err)
+	}
+	if tag.RowsAffected() == 0 {
+		return db.ErrNotFound
+	}
+	return nil
+}
+
+func scanRatePlan(row pgx.Row, ratePlan *types.RatePlan) error {
+	var closedToArrival []int32
+	err := row.Scan(
+		&ratePlan.Id,
+		&ratePlan.RoomId,
+		&ratePlan.Name,
+		&ratePlan.ValidFrom,
+		&ratePlan.ValidTo,
+		&ratePlan.Priority,
+		&ratePlan.NightlyRate,
+		&ratePlan.WeekdayPercent,
+		&ratePlan.WeekendPercent,
+		&ratePlan.MinStay,
+		&closedToArrival)
+	if err != nil {
+		return err
+	}
+
+	ratePlan.ClosedToArrival = nil
+	for _, day := range closedToArrival {
+		ratePlan.ClosedToArrival = append(ratePlan.ClosedToArrival, time.Weekday(day))
+	}
+	return nil
+}
+
+func weekdaysToInts(days []time.Weekday) []int32 {
+	ints := make([]int32, 0, len(days))
+	for _, day := range days {
+		ints = append(ints, int32(day))
+	}
+	return ints
+}
@@ -2,16 +2,14 @@ package models
 
 import (
 	"context"
+	"strings"
 
-	"github.com/ctchen222/hotel-system/internal/pgtypes"
+	"github.com/ctchen222/hotel-system/internal/db"
+	"github.com/ctchen222/hotel-system/internal/types"
+	"github.com/jackc/pgx/v5"
 )
 
-type PgRoomStore interface {
-	CreateRoom(ctx context.Context, room pgtypes.CreateRoomParams, hotelId string) error
-	GetRooms(ctx context.Context, hotelId string) ([]*pgtypes.Room, error)
-	GetRoomById(ctx context.Context, roomId string) (*pgtypes.Room, error)
-	DeleteRoom(ctx context.Context, roomId string) error
-}
+const roomColumns = `id, size, seaside, price, capacity, hotelid`
 
 type PostgresRoomStore struct {
 	pool *PostgresInstance
@@ -23,61 +21,131 @@ func NewPostgresRoomStore(pool *PostgresInstance) *PostgresRoomStore {
 	}
 }
 
-func (s *PostgresRoomStore) CreateRoom(ctx context.Context, room pgtypes.CreateRoomParams, hotelId string) error {
-	var rowId string
-	row := s.pool.DB.QueryRow(ctx, `SELECT id FROM hotels WHERE id = $1`, hotelId)
-	if err := row.Scan(&rowId); err != nil {
-		return err
+func (s *PostgresRoomStore) Insert(ctx context.Context, room *types.Room) (*types.Room, error) {
+	var hotelId string
+	row := s.pool.DB.QueryRow(ctx, `SELECT id FROM hotels WHERE id = $1`, room.HotelId)
+	if err := row.Scan(&hotelId); err != nil {
+		return nil, storeError(err)
 	}
 
-	query := `INSERT INTO rooms(size, seaside, price, hotelid) VALUES($1, $2, $3, $4)`
+	if room.Capacity == 0 {
+		room.Capacity = types.DefaultRoomCapacity
+	}
+	query := `INSERT INTO rooms(size, seaside, price, capacity, hotelid) VALUES($1, $2, $3, $4, $5) RETURNING id`
 
-	_, err := s.pool.DB.Exec(ctx, query, room.Size, room.SeaSide, room.Price, hotelId)
+	err := s.pool.DB.QueryRow(ctx, query, room.Size, room.SeaSide, room.Price, room.Capacity, room.HotelId).Scan(&room.Id)
 	if err != nil {
-		return err
+		return nil, storeError(err)
 	}
-	return nil
+	return room, nil
 }
 
-func (s *PostgresRoomStore) GetRooms(ctx context.Context, hotelId string) ([]*pgtypes.Room, error) {
-	query := `SELECT * FROM rooms WHERE hotelId = $1`
-
-	rows, err := s.pool.DB.Query(ctx, query, hotelId)
+func (s *PostgresRoomStore) GetRooms(ctx context.Context, hotelId string) ([]*types.Room, error) {
+	rows, err := s.pool.DB.Query(ctx, `SELECT `+roomColumns+` FROM rooms WHERE hotelid = $1`, hotelId)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
 
-	var rooms []*pgtypes.Room
+	var rooms []*types.Room
 	for rows.Next() {
-		var room pgtypes.Room
-		err := rows.Scan(&room.Id, &room.Size, &room.SeaSide, &room.Price, &room.HotelId)
-		if err != nil {
+		var room types.Room
+		if err := scanRoom(rows, &room); err != nil {
 			return nil, err
 		}
 		rooms = append(rooms, &room)
 	}
 
-	return rooms, nil
+	return rooms, rows.Err()
 }
-func (s *PostgresRoomStore) GetRoomById(ctx context.Context, roomId string) (*pgtypes.Room, error) {
-	query := `SELECT * FROM rooms WHERE id = $1`
-	row := s.pool.DB.QueryRow(ctx, query, roomId)
 
-	var room pgtypes.Room
-	err := row.Scan(&room.Id, &room.Size, &room.SeaSide, &room.Price, &room.HotelId)
-	if err != nil {
-		return nil, err
+func (s *PostgresRoomStore) GetRoomById(ctx context.Context, id string) (*types.Room, error) {
+	row := s.pool.DB.QueryRow(ctx, `SELECT `+roomColumns+` FROM rooms WHERE id = $1`, id)
+
+	var room types.Room
+	if err := scanRoom(row, &room); err != nil {
+		return nil, storeError(err)
 	}
 
 	return &room, nil
 }
 
-func (s *PostgresRoomStore) DeleteRoom(ctx context.Context, roomId string) error {
-	query := `DELETE FROM rooms WHERE id = $1`
-	_, err := s.pool.DB.Exec(ctx, query, roomId)
+func (s *PostgresRoomStore) Delete(ctx context.Context, id string) error {
+	tag, err := s.pool.DB.Exec(ctx, `DELETE FROM rooms WHERE id = $1`, id)
 	if err != nil {
-		return err
+		return deleteError(err)
+	}
+	if tag.RowsAffected() == 0 {
+		return db.ErrNotFound
 	}
 	return nil
 }
+
+// GetAvailableRooms returns the rooms matching the query that have no
+// booking overlapping [From, To), grouped by hotel.
+func (s *PostgresRoomStore) GetAvailableRooms(ctx context.Context, query types.AvailabilityQuery) ([]*types.HotelAvailability, error) {
+	sql := `SELECT h.id, h.name, h.location, h.rating,
+			h.free_cancellation_days, h.cancellation_penalty_percent,
+			r.id, r.size, r.seaside, r.price, r.capacity, r.hotelid
+		FROM rooms r
+		JOIN hotels h ON h.id = r.hotelid
+		WHERE r.capacity >= $3
+			AND ($4::text = '' OR h.location ILIKE '%' || $4::text || '%' ESCAPE '\')
+			AND ($5::boolean IS NULL OR r.seaside = $5)
+			AND NOT EXISTS (
+				SELECT 1 FROM bookings b
+				WHERE b.roomid = r.id AND b.status <> 'cancelled'
+					AND b.fromdate < $2 AND b.todate > $1)
+		ORDER BY h.name, h.id, r.price`
+
+	rows, err := s.pool.DB.Query(ctx, sql,
+		query.From,
+		query.To,
+		query.Guests,
+		escapeLike(query.Location),
+		query.SeaSide)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	availability := []*types.HotelAvailability{}
+	byHotel := map[string]*types.HotelAvailability{}
+	for rows.Next() {
+		var (
+			hotel types.Hotel
+			room  types.Room
+		)
+		err := rows.Scan(&hotel.Id, &hotel.Name, &hotel.Location, &hotel.Rating,
+			&hotel.CancellationPolicy.FreeCancellationDays, &hotel.CancellationPolicy.PenaltyPercent,
+			&room.Id, &room.Size, &room.SeaSide, &room.Price, &room.Capacity, &room.HotelId)
+		if err != nil {
+			return nil, err
+		}
+
+		entry, ok := byHotel[hotel.Id]
+		if !ok {
+			entry = &types.HotelAvailability{Hotel: &hotel}
+			byHotel[hotel.Id] = entry
+			availability = append(availability, entry)
+		}
+		entry.Rooms = append(entry.Rooms, &types.AvailableRoom{Room: room})
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return availability, nil
+}
+
+func scanRoom(row pgx.Row, room *types.Room) error {
+	return row.Scan(&room.Id, &room.Size, &room.SeaSide, &room.Price, &room.Capacity, &room.HotelId)
+}
+
+// likeEscaper escapes the wildcards of a LIKE pattern, so the location is
+// matched literally as it is by the other stores.
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+func escapeLike(s string) string {
+	return likeEscaper.Replace(s)
+}
@@ -0,0 +1,20 @@
+package models
+
+import "testing"
+
+func TestEscapeLike(t *testing.T) {
+	tests := []struct {
+		value string
+		want  string
+	}{
+		{value: "Taipei", want: "Taipei"},
+		{value: "100%", want: `100\%`},
+		{value: "a_b", want: `a\_b`},
+		{value: `C:\`, want: `C:\\`},
+	}
+	for _, tt := range tests {
+		if got := escapeLike(tt.value); got != tt.want {
+			t.Errorf("escapeLike(%q) = %q, want %q", tt.value, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,79 @@
+package models
+
+import (
+	"context"
+	"github.com/ctchen222/hotel-system/internal/db"
+
+	"github.com/ctchen222/hotel-system/internal/auth"
+)
+
+type PostgresSessionStore struct {
+	pool *PostgresInstance
+}
+
+func NewPostgresSessionStore(pool *PostgresInstance) *PostgresSessionStore {
+	return &PostgresSessionStore{
+		pool: pool,
+	}
+}
+
+func (s *PostgresSessionStore) Insert(ctx context.Context, session *auth.Session) error {
+	query := `INSERT INTO sessions(id, userid, token_hash, created_at, expires_at)
+		VALUES($1, $2, $3, $4, $5)`
+
+	_, err := s.pool.DB.Exec(ctx, query,
+		session.Id,
+		session.UserId,
+		session.TokenHash,
+		session.CreatedAt,
+		session.ExpiresAt)
+	return err
+}
+
+func (s *PostgresSessionStore) GetSessionById(ctx context.Context, id string) (*auth.Session, error) {
+	query := `SELECT id, userid, token_hash, created_at, expires_at, revoked_at FROM sessions WHERE id = $1`
+
+	var session auth.Session
+	if err := s.pool.DB.QueryRow(ctx, query, id).Scan(
+		&session.Id,
+		&session.UserId,
+		&session.TokenHash,
+		&session.CreatedAt,
+		&session.ExpiresAt,
+		&session.RevokedAt); err != nil {
+		return nil, storeError(err)
+	}
+	return &session, nil
+}
+
+// Rotate stores the new refresh token of an active session, provided
+// its token is still previousHash. Of two requests using the same refresh
+// token only one can rotate it; the other gets db.ErrNotFound.
+func (s *PostgresSessionStore) Rotate(ctx context.Context, session *auth.Session, previousHash string) error {
+	query := `UPDATE sessions SET token_hash = $3, expires_at = $4
+		WHERE id = $1 AND token_hash = $2 AND revoked_at IS NULL`
+
+	tag, err := s.pool.DB.Exec(ctx, query, session.Id, previousHash, session.TokenHash, session.ExpiresAt)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return db.ErrNotFound
+	}
+	return nil
+}
+
+func (s *PostgresSessionStore) Revoke(ctx context.Context, id string) error {
+	_, err := s.pool.DB.Exec(ctx, `UPDATE sessions SET revoked_at = now() WHERE id = $1 AND revoked_at IS NULL`, id)
+	return err
+}
+
+func (s *PostgresSessionStore) RevokeByUserId(ctx context.Context, userId string) error {
+	_, err := s.pool.DB.Exec(ctx, `UPDATE sessions SET revoked_at = now() WHERE userid = $1 AND revoked_at IS NULL`, userId)
+	return err
+}
+
+func (s *PostgresSessionStore) RevokeOthers(ctx context.Context, userId, keepId string) error {
+	_, err := s.pool.DB.Exec(ctx, `UPDATE sessions SET revoked_at = now() WHERE userid = $1 AND id <> $2 AND revoked_at IS NULL`, userId, keepId)
+	return err
+}
@@ -0,0 +1,97 @@
+//go:build integration
+
+package models_test
+
+import (
+	"context"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/ctchen222/hotel-system/internal/db"
+	"github.com/ctchen222/hotel-system/internal/db/storetest"
+	models "github.com/ctchen222/hotel-system/internal/pg"
+)
+
+// TestPostgresStore runs against the PostgreSQL database at
+// POSTGRES_TEST_URI. It empties every table before each check, so never point
+// it at a database in use.
+func TestPostgresStore(t *testing.T) {
+	uri := os.Getenv("POSTGRES_TEST_URI")
+	if uri == "" {
+		t.Skip("POSTGRES_TEST_URI is not set")
+	}
+	ctx := context.Background()
+	pool := models.NewPostgresInstance(ctx, uri)
+	version, err := models.LatestSchemaVersion()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := pool.Migrate(ctx, version); err != nil {
+		t.Fatal(err)
+	}
+
+	storetest.Run(t, func(t *testing.T) *db.Store {
+		_, err := pool.DB.Exec(ctx, `
+			DO $$
+			DECLARE tables text;
+			BEGIN
+				SELECT string_agg(quote_ident(tablename), ', ') INTO tables
+				FROM pg_tables
+				WHERE schemaname = current_schema() AND tablename <> 'schema_migrations';
+				EXECUTE 'TRUNCATE ' || tables || ' RESTART IDENTITY CASCADE';
+			END $$`)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return models.NewStore(pool)
+	})
+}
+
+// TestMigrate_OverlappingBookings checks that bookings overlapping from
+// before migration 2 stop it with their ids, and leave the database at
+// version 1. It drops every table, so never point it at a database in use.
+func TestMigrate_OverlappingBookings(t *testing.T) {
+	uri := os.Getenv("POSTGRES_TEST_URI")
+	if uri == "" {
+		t.Skip("POSTGRES_TEST_URI is not set")
+	}
+	ctx := context.Background()
+	pool := models.NewPostgresInstance(ctx, uri)
+	if err := pool.Migrate(ctx, 0); err != nil {
+		t.Fatal(err)
+	}
+	if err := pool.Migrate(ctx, 1); err != nil {
+		t.Fatal(err)
+	}
+	_, err := pool.DB.Exec(ctx, `
+		INSERT INTO users(id, firstname, lastname, email, encrypted_password) VALUES (1, 'Test', 'Guest', 'guest@example.com', '-');
+		INSERT INTO hotels(id, name, location) VALUES (1, 'Hotel 1', 'Taipei');
+		INSERT INTO rooms(id, size, price, hotelid) VALUES (1, 'small', 100, 1);
+		INSERT INTO bookings(id, userid, roomid, numperson, fromdate, todate) VALUES
+			(1, 1, 1, 1, '2030-01-10', '2030-01-12'),
+			(2, 1, 1, 1, '2030-01-11', '2030-01-13'),
+			(3, 1, 1, 1, '2030-01-12', '2030-01-14')`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = pool.Migrate(ctx, 2)
+	if err == nil || !strings.Contains(err.Error(), "1 and 2 in room 1, 2 and 3 in room 1") {
+		t.Errorf("Migrate() error = %v, want the overlapping bookings named", err)
+	}
+	if version, err := pool.SchemaVersion(ctx); err != nil || version != 1 {
+		t.Errorf("SchemaVersion() = %d, %v, want 1, nil", version, err)
+	}
+
+	if _, err := pool.DB.Exec(ctx, `DELETE FROM bookings WHERE id = 2`); err != nil {
+		t.Fatal(err)
+	}
+	latest, err := models.LatestSchemaVersion()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := pool.Migrate(ctx, latest); err != nil {
+		t.Errorf("Migrate() once the overlap is resolved error = %v, want nil", err)
+	}
+}
@@ -0,0 +1,60 @@
+package models
+
+import (
+	"context"
+	"time"
+
+	"github.com/ctchen222/hotel-system/internal/auth"
+)
+
+type PostgresTokenStore struct {
+	pool *PostgresInstance
+}
+
+func NewPostgresTokenStore(pool *PostgresInstance) *PostgresTokenStore {
+	return &PostgresTokenStore{
+		pool: pool,
+	}
+}
+
+func (s *PostgresTokenStore) Insert(ctx context.Context, token *auth.OneTimeToken) error {
+	query := `INSERT INTO one_time_tokens(token_hash, userid, purpose, created_at, expires_at)
+		VALUES($1, $2, $3, $4, $5)`
+
+	_, err := s.pool.DB.Exec(ctx, query,
+		token.TokenHash,
+		token.UserId,
+		token.Purpose,
+		token.CreatedAt,
+		token.ExpiresAt)
+	return err
+}
+
+// Consume marks a usable token as used and returns it. A token can be
+// consumed once; afterwards, or once it expired, Consume returns
+// db.ErrNotFound.
+func (s *PostgresTokenStore) Consume(ctx context.Context, purpose auth.Purpose, tokenHash string, now time.Time) (*auth.OneTimeToken, error) {
+	query := `UPDATE one_time_tokens SET used_at = $3
+		WHERE token_hash = $1 AND purpose = $2 AND used_at IS NULL AND expires_at > $3
+		RETURNING token_hash, userid, purpose, created_at, expires_at, used_at`
+
+	var token auth.OneTimeToken
+	if err := s.pool.DB.QueryRow(ctx, query, tokenHash, purpose, now).Scan(
+		&token.TokenHash,
+		&token.UserId,
+		&token.Purpose,
+		&token.CreatedAt,
+		&token.ExpiresAt,
+		&token.UsedAt); err != nil {
+		return nil, storeError(err)
+	}
+	return &token, nil
+}
+
+// DeleteByUserId drops the unused tokens of the user for purpose, so only
+// the most recently sent token works.
+func (s *PostgresTokenStore) DeleteByUserId(ctx context.Context, userId string, purpose auth.Purpose) error {
+	query := `DELETE FROM one_time_tokens WHERE userid = $1 AND purpose = $2 AND used_at IS NULL`
+	_, err := s.pool.DB.Exec(ctx, query, userId, purpose)
+	return err
+}
@@ -0,0 +1,142 @@
+package models
+
+import (
+	"context"
+	"github.com/ctchen222/hotel-system/internal/db"
+
+	"github.com/ctchen222/hotel-system/internal/auth"
+)
+
+type PostgresTwoFactorStore struct {
+	pool *PostgresInstance
+}
+
+func NewPostgresTwoFactorStore(pool *PostgresInstance) *PostgresTwoFactorStore {
+	return &PostgresTwoFactorStore{
+		pool: pool,
+	}
+}
+
+func (s *PostgresTwoFactorStore) GetTwoFactor(ctx context.Context, userId string) (*auth.TwoFactor, error) {
+	query := `SELECT userid, secret, enabled, recovery_codes, last_step FROM two_factor WHERE userid = $1`
+
+	var tf auth.TwoFactor
+	if err := s.pool.DB.QueryRow(ctx, query, userId).Scan(
+		&tf.UserId,
+		&tf.Secret,
+		&tf.Enabled,
+		&tf.RecoveryCodes,
+		&tf.LastStep); err != nil {
+		return nil, storeError(err)
+	}
+	return &tf, nil
+}
+
+// Upsert replaces the enrollment of the user, e.g. to start over
+// with a new secret.
+func (s *PostgresTwoFactorStore) Upsert(ctx context.Context, tf *auth.TwoFactor) error {
+	query := `INSERT INTO two_factor(userid, secret, enabled, recovery_codes, last_step)
+		VALUES($1, $2, $3, $4, $5)
+		ON CONFLICT (userid) DO UPDATE SET
+			secret = EXCLUDED.secret,
+			enabled = EXCLUDED.enabled,
+			recovery_codes = EXCLUDED.recovery_codes,
+			last_step = EXCLUDED.last_step`
+
+	recoveryCodes := tf.RecoveryCodes
+	if recoveryCodes == nil {
+		recoveryCodes = []string{}
+	}
+	_, err := s.pool.DB.Exec(ctx, query, tf.UserId, tf.Secret, tf.Enabled, recoveryCodes, tf.LastStep)
+	return err
+}
+
+// Enable turns on a pending enrollment. step is the time step of the
+// code the user confirmed it with.
+func (s *PostgresTwoFactorStore) Enable(ctx context.Context, userId string, recoveryCodes []string, step int64) error {
+	query := `UPDATE two_factor SET enabled = true, recovery_codes = $2, last_step = $3
+		WHERE userid = $1 AND NOT enabled`
+
+	tag, err := s.pool.DB.Exec(ctx, query, userId, recoveryCodes, step)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return db.ErrNotFound
+	}
+	return nil
+}
+
+// UseStep records the time step of an accepted code. A code of the same
+// or an earlier step was used already, so UseStep returns db.ErrNotFound.
+func (s *PostgresTwoFactorStore) UseStep(ctx context.Context, userId string, step int64) error {
+	query := `UPDATE two_factor SET last_step = $2 WHERE userid = $1 AND enabled AND last_step < $2`
+
+	tag, err := s.pool.DB.Exec(ctx, query, userId, step)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return db.ErrNotFound
+	}
+	return nil
+}
+
+// UseRecoveryCode removes a recovery code. It returns db.ErrNotFound if the
+// code was used already.
+func (s *PostgresTwoFactorStore) UseRecoveryCode(ctx context.Context, userId string, codeHash string) error {
+	query := `UPDATE two_factor SET recovery_codes = array_remove(recovery_codes, $2)
+		WHERE userid = $1 AND enabled AND $2 = ANY(recovery_codes)`
+
+	tag, err := s.pool.DB.Exec(ctx, query, userId, codeHash)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return db.ErrNotFound
+	}
+	return nil
+}
+
+func (s *PostgresTwoFactorStore) Delete(ctx context.Context, userId string) error {
+	_, err := s.pool.DB.Exec(ctx, `DELETE FROM two_factor WHERE userid = $1`, userId)
+	return err
+}
+
+func (s *PostgresTwoFactorStore) GetRequiredRoles(ctx context.Context) ([]auth.Role, error) {
+	rows, err := s.pool.DB.Query(ctx, `SELECT role FROM two_factor_roles ORDER BY role`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var roles []auth.Role
+	for rows.Next() {
+		var role auth.Role
+		if err := rows.Scan(&role); err != nil {
+			return nil, err
+		}
+		roles = append(roles, role)
+	}
+	return roles, rows.Err()
+}
+
+// SetRequiredRoles replaces the roles that have to use two-factor
+// authentication.
+func (s *PostgresTwoFactorStore) SetRequiredRoles(ctx context.Context, roles []auth.Role) error {
+	tx, err := s.pool.DB.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	if _, err := tx.Exec(ctx, `DELETE FROM two_factor_roles`); err != nil {
+		return err
+	}
+	for _, role := range roles {
+		if _, err := tx.Exec(ctx, `INSERT INTO two_factor_roles(role) VALUES($1) ON CONFLICT DO NOTHING`, role); err != nil {
+			return err
+		}
+	}
+	return tx.Commit(ctx)
+}
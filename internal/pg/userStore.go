@@ -3,18 +3,15 @@ package models
 import (
 	"context"
 	"log"
+	"strconv"
 
-	"github.com/ctchen222/hotel-system/internal/pgtypes"
+	"github.com/ctchen222/hotel-system/internal/auth"
+	"github.com/ctchen222/hotel-system/internal/db"
+	"github.com/ctchen222/hotel-system/internal/types"
+	"github.com/jackc/pgx/v5"
 )
 
-type PgUserStore interface {
-	GetUsers(context.Context) ([]*pgtypes.PGUser, error)
-	GetUserById(ctx context.Context, id string) (*pgtypes.PGUser, error)
-	GetUserByEmail(ctx context.Context, email string) (*pgtypes.PGUser, error)
-	CreateUser(ctx context.Context, user *pgtypes.PGUser) error
-	DeleteUser(ctx context.Context, id string) error
-	UpdateUser(ctx context.Context, user *pgtypes.UpdateUserParams, id string) error
-}
+const userColumns = `id, firstname, lastname, email, encrypted_password, email_verified, role, COALESCE(hotel_ids, '{}')`
 
 type PostgresUserStore struct {
 	pool *PostgresInstance
@@ -26,20 +23,18 @@ func NewPostgresUserStore(pool *PostgresInstance) *PostgresUserStore {
 	}
 }
 
-func (s *PostgresUserStore) GetUsers(ctx context.Context) ([]*pgtypes.PGUser, error) {
-	query := `SELECT id, firstname, lastname, email  FROM users`
-
-	rows, err := s.pool.DB.Query(ctx, query)
+func (s *PostgresUserStore) GetUsers(ctx context.Context) ([]*types.User, error) {
+	rows, err := s.pool.DB.Query(ctx, `SELECT `+userColumns+` FROM users`)
 	if err != nil {
 		log.Printf("Error querying users: %v", err)
 		return nil, err
 	}
 	defer rows.Close()
 
-	var users []*pgtypes.PGUser
+	var users []*types.User
 	for rows.Next() {
-		var user pgtypes.PGUser
-		if err := rows.Scan(&user.Id, &user.FirstName, &user.LastName, &user.Email); err != nil {
+		var user types.User
+		if err := scanUser(rows, &user); err != nil {
 			log.Printf("Error scanning user: %v", err)
 			return nil, err
 		}
@@ -49,72 +44,157 @@ func (s *PostgresUserStore) GetUsers(ctx context.Context) ([]*pgtypes.PGUser, er
 	return users, nil
 }
 
-func (s *PostgresUserStore) GetUserById(ctx context.Context, id string) (*pgtypes.PGUser, error) {
-	query := `SELECT id, firstname, lastname, email FROM users WHERE id = $1`
+func (s *PostgresUserStore) GetUserById(ctx context.Context, id string) (*types.User, error) {
+	row := s.pool.DB.QueryRow(ctx, `SELECT `+userColumns+` FROM users WHERE id = $1`, id)
 
-	row := s.pool.DB.QueryRow(ctx, query, id)
-
-	var user pgtypes.PGUser
-	if err := row.Scan(&user.Id, &user.FirstName, &user.LastName, &user.Email); err != nil {
-		log.Printf("Error scanning user: %v", err)
-		return nil, err
+	var user types.User
+	if err := scanUser(row, &user); err != nil {
+		return nil, storeError(err)
 	}
 
 	return &user, nil
 }
 
-func (s *PostgresUserStore) GetUserByEmail(ctx context.Context, email string) (*pgtypes.PGUser, error) {
-	query := `SELECT id, firstname, lastname, email, encrypted_password FROM users WHERE email = $1`
+func (s *PostgresUserStore) GetUserByEmail(ctx context.Context, email string) (*types.User, error) {
+	row := s.pool.DB.QueryRow(ctx, `SELECT `+userColumns+` FROM users WHERE email = $1`, email)
 
-	var user pgtypes.PGUser
-	row := s.pool.DB.QueryRow(ctx, query, email)
-	if err := row.Scan(&user.Id, &user.FirstName, &user.LastName, &user.Email, &user.EncryptedPassword); err != nil {
-		return nil, err
+	var user types.User
+	if err := scanUser(row, &user); err != nil {
+		return nil, storeError(err)
 	}
 
 	return &user, nil
 }
 
-func (s *PostgresUserStore) CreateUser(ctx context.Context, user *pgtypes.PGUser) error {
-	query := `INSERT INTO users(firstname, lastname, email, encrypted_password) VALUES($1, $2, $3, $4) RETURNING id, firstname, lastname`
+func (s *PostgresUserStore) Create(ctx context.Context, user *types.User) (*types.User, error) {
+	query := `INSERT INTO users(firstname, lastname, email, encrypted_password, email_verified, role) VALUES($1, $2, $3, $4, $5, $6) RETURNING id`
 
-	row := s.pool.DB.QueryRow(ctx, query, user.FirstName, user.LastName, user.Email, user.EncryptedPassword)
+	row := s.pool.DB.QueryRow(ctx, query, user.FirstName, user.LastName, user.Email, user.EncryptedPassword, user.EmailVerified, user.Role)
 
-	var user_id, firstname, lastname string
-	if err := row.Scan(&user_id, &firstname, &lastname); err != nil {
-		log.Printf("Error scannind user_id: %v", err)
-		return err
+	if err := row.Scan(&user.Id); err != nil {
+		if isUniqueViolation(err) {
+			return nil, db.ErrEmailExists
+		}
+		log.Printf("Error scanning user_id: %v", err)
+		return nil, storeError(err)
 	}
 
-	return nil
+	return user, nil
 }
 
-func (s *PostgresUserStore) DeleteUser(ctx context.Context, id string) error {
+func (s *PostgresUserStore) DeleteById(ctx context.Context, id string) error {
 	query := `DELETE FROM users WHERE id = $1`
 
-	_, err := s.pool.DB.Exec(ctx, query, id)
+	tag, err := s.pool.DB.Exec(ctx, query, id)
 	if err != nil {
 		log.Printf("Error deleting user: %v", err)
-		return err
+		return deleteError(err)
+	}
+	if tag.RowsAffected() == 0 {
+		return db.ErrNotFound
 	}
 
 	return nil
 }
 
-func (s *PostgresUserStore) UpdateUser(ctx context.Context, user *pgtypes.UpdateUserParams, id string) error {
-	var user_id string
-	row := s.pool.DB.QueryRow(ctx, `SELECT id FROM users WHERE id = $1`, id)
-	if err := row.Scan(&user_id); err != nil {
+func (s *PostgresUserStore) Update(ctx context.Context, params types.UserUpdateParams, id string) error {
+	query := `UPDATE users SET firstname = $1, lastname = $2 WHERE id = $3`
+
+	tag, err := s.pool.DB.Exec(ctx, query, params.FirstName, params.LastName, id)
+	if err != nil {
+		log.Printf("Error updating user: %v", err)
+		return storeError(err)
+	}
+	if tag.RowsAffected() == 0 {
+		return db.ErrNotFound
+	}
+
+	return nil
+}
+
+// UpdateRole sets the role of the user and the hotels they work for.
+func (s *PostgresUserStore) UpdateRole(ctx context.Context, id string, role auth.Role, hotelIds []string) error {
+	ids, err := intIds(hotelIds)
+	if err != nil {
 		return err
 	}
 
-	query := `UPDATE users SET firstname = $1, lastname = $2  where id = $3`
+	query := `UPDATE users SET role = $1, hotel_ids = $2 WHERE id = $3`
 
-	_, err := s.pool.DB.Exec(ctx, query, user.FirstName, user.LastName, id)
+	tag, err := s.pool.DB.Exec(ctx, query, role, ids, id)
+	if err != nil {
+		log.Printf("Error updating user role: %v", err)
+		return storeError(err)
+	}
+	if tag.RowsAffected() == 0 {
+		return db.ErrNotFound
+	}
+
+	return nil
+}
+
+func (s *PostgresUserStore) UpdatePassword(ctx context.Context, id string, encryptedPassword string) error {
+	query := `UPDATE users SET encrypted_password = $1 WHERE id = $2`
+
+	tag, err := s.pool.DB.Exec(ctx, query, encryptedPassword, id)
+	if err != nil {
+		log.Printf("Error updating user password: %v", err)
+		return storeError(err)
+	}
+	if tag.RowsAffected() == 0 {
+		return db.ErrNotFound
+	}
+
+	return nil
+}
+
+func (s *PostgresUserStore) MarkEmailVerified(ctx context.Context, id string) error {
+	query := `UPDATE users SET email_verified = true WHERE id = $1`
+
+	tag, err := s.pool.DB.Exec(ctx, query, id)
+	if err != nil {
+		log.Printf("Error verifying user email: %v", err)
+		return storeError(err)
+	}
+	if tag.RowsAffected() == 0 {
+		return db.ErrNotFound
+	}
+
+	return nil
+}
+
+func scanUser(row pgx.Row, user *types.User) error {
+	var hotelIds []int
+	err := row.Scan(
+		&user.Id,
+		&user.FirstName,
+		&user.LastName,
+		&user.Email,
+		&user.EncryptedPassword,
+		&user.EmailVerified,
+		&user.Role,
+		&hotelIds)
 	if err != nil {
-		log.Printf("Error updating user: %v", err)
 		return err
 	}
 
+	user.HotelIds = nil
+	for _, hotelId := range hotelIds {
+		user.HotelIds = append(user.HotelIds, strconv.Itoa(hotelId))
+	}
 	return nil
 }
+
+// intIds parses the ids of rows referenced from an INT[] column. An id that
+// isn't a number can't name a row, so it gives db.ErrNotFound.
+func intIds(ids []string) ([]int, error) {
+	ints := make([]int, 0, len(ids))
+	for _, id := range ids {
+		i, err := strconv.Atoi(id)
+		if err != nil {
+			return nil, db.ErrNotFound
+		}
+		ints = append(ints, i)
+	}
+	return ints, nil
+}
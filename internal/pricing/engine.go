@@ -0,0 +1,115 @@
+package pricing
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/ctchen222/hotel-system/internal/utils"
+)
+
+const (
+	// DefaultTaxRate is the VAT charged on the room total, in basis points.
+	DefaultTaxRate = 500
+	// DefaultServiceFeeRate is the service charge on the room total, in basis
+	// points.
+	DefaultServiceFeeRate = 1000
+	DefaultCurrency       = "TWD"
+)
+
+// NightlyRate is the price of one night of a stay and the plan that set it.
+type NightlyRate struct {
+	Night    time.Time `bson:"night" json:"night"`
+	Rate     Money     `bson:"rate" json:"rate"`
+	RatePlan string    `bson:"ratePlan,omitempty" json:"ratePlan,omitempty"`
+}
+
+// Breakdown is what a guest pays for a stay.
+type Breakdown struct {
+	Nights       int           `bson:"nights" json:"nights"`
+	NightlyRates []NightlyRate `bson:"nightlyRates" json:"nightlyRates"`
+	RoomTotal    Money         `bson:"roomTotal" json:"roomTotal"`
+	PromoCode    string        `bson:"promoCode,omitempty" json:"promoCode,omitempty"`
+	Discount     Money         `bson:"discount" json:"discount"`
+	Taxes        Money         `bson:"taxes" json:"taxes"`
+	Fees         Money         `bson:"fees" json:"fees"`
+	Total        Money         `bson:"total" json:"total"`
+	Currency     string        `bson:"currency" json:"currency"`
+}
+
+// Engine computes the price of a stay: the sum of its nightly rates less any
+// discount, plus taxes and service fees charged as a percentage of the
+// discounted room total.
+type Engine struct {
+	taxRate        int
+	serviceFeeRate int
+	currency       string
+}
+
+func NewEngine(taxRate, serviceFeeRate int, currency string) *Engine {
+	return &Engine{
+		taxRate:        taxRate,
+		serviceFeeRate: serviceFeeRate,
+		currency:       currency,
+	}
+}
+
+func NewDefaultEngine() *Engine {
+	return NewEngine(DefaultTaxRate, DefaultServiceFeeRate, DefaultCurrency)
+}
+
+// Quote prices the stay [from, to) in a room whose price is baseRate. Each
+// night is priced by the rate plan covering it, or at baseRate when no plan
+// does. A RestrictionError is returned if the plan covering the arrival night
+// does not allow the stay.
+func (e *Engine) Quote(baseRate Money, plans []RatePlan, from, to time.Time) (Breakdown, error) {
+	nights := utils.StayNights(from, to)
+	if len(nights) > 0 {
+		if plan := selectPlan(plans, nights[0]); plan != nil {
+			if plan.closedToArrival(nights[0]) {
+				return Breakdown{}, &RestrictionError{
+					Plan:   plan.Name,
+					Reason: fmt.Sprintf("closed to arrival on %s", nights[0].Weekday()),
+				}
+			}
+			if len(nights) < plan.MinStay {
+				return Breakdown{}, &RestrictionError{
+					Plan:   plan.Name,
+					Reason: fmt.Sprintf("minimum stay is %d nights", plan.MinStay),
+				}
+			}
+		}
+	}
+
+	breakdown := Breakdown{
+		Nights:   len(nights),
+		Currency: e.currency,
+	}
+	for _, night := range nights {
+		rate := NightlyRate{Night: night, Rate: baseRate}
+		if plan := selectPlan(plans, night); plan != nil {
+			rate.Rate = plan.Rate(baseRate, night)
+			rate.RatePlan = plan.Name
+		}
+		breakdown.NightlyRates = append(breakdown.NightlyRates, rate)
+		breakdown.RoomTotal += rate.Rate
+	}
+	e.total(&breakdown)
+
+	return breakdown, nil
+}
+
+// ApplyPromotion discounts a quote with promotion. It doesn't check whether
+// the promotion may be used; see Promotion.Check.
+func (e *Engine) ApplyPromotion(breakdown Breakdown, promotion Promotion) Breakdown {
+	breakdown.PromoCode = promotion.Code
+	breakdown.Discount = promotion.Discount(breakdown.RoomTotal)
+	e.total(&breakdown)
+	return breakdown
+}
+
+func (e *Engine) total(breakdown *Breakdown) {
+	taxable := breakdown.RoomTotal - breakdown.Discount
+	breakdown.Taxes = taxable.Percent(e.taxRate)
+	breakdown.Fees = taxable.Percent(e.serviceFeeRate)
+	breakdown.Total = taxable + breakdown.Taxes + breakdown.Fees
+}
@@ -0,0 +1,189 @@
+package pricing
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func date(day int) time.Time {
+	return time.Date(2026, time.January, day, 0, 0, 0, 0, time.UTC)
+}
+
+func TestEngine_Quote(t *testing.T) {
+	// 2026-01-01 is a Thursday, so the nights of the 2nd and 3rd are a weekend.
+	promo := RatePlan{Name: "Promo", ValidFrom: date(1), ValidTo: date(31), NightlyRate: 8000, Priority: 1}
+	weekend := RatePlan{Name: "Weekend", ValidFrom: date(1), ValidTo: date(31), WeekendPercent: 150}
+	festival := RatePlan{Name: "Festival", ValidFrom: date(2), ValidTo: date(4), NightlyRate: 20000, MinStay: 2}
+
+	tests := []struct {
+		name     string
+		engine   *Engine
+		rate     Money
+		plans    []RatePlan
+		from, to time.Time
+		want     Breakdown
+		wantErr  bool
+	}{
+		{
+			name:   "Default rates",
+			engine: NewDefaultEngine(),
+			rate:   FromFloat(120),
+			from:   date(5),
+			to:     date(8),
+			want: Breakdown{
+				Nights: 3,
+				NightlyRates: []NightlyRate{
+					{Night: date(5), Rate: 12000},
+					{Night: date(6), Rate: 12000},
+					{Night: date(7), Rate: 12000},
+				},
+				RoomTotal: 36000,
+				Taxes:     1800,
+				Fees:      3600,
+				Total:     41400,
+				Currency:  DefaultCurrency,
+			},
+		},
+		{
+			name:   "Taxes are rounded to the cent",
+			engine: NewEngine(825, 0, "USD"),
+			rate:   FromFloat(99.99),
+			from:   date(5),
+			to:     date(6),
+			want: Breakdown{
+				Nights:       1,
+				NightlyRates: []NightlyRate{{Night: date(5), Rate: 9999}},
+				RoomTotal:    9999,
+				Taxes:        825,
+				Fees:         0,
+				Total:        10824,
+				Currency:     "USD",
+			},
+		},
+		{
+			name:   "Weekend multiplier",
+			engine: NewEngine(0, 0, "USD"),
+			rate:   10000,
+			plans:  []RatePlan{weekend},
+			from:   date(1),
+			to:     date(4),
+			want: Breakdown{
+				Nights: 3,
+				NightlyRates: []NightlyRate{
+					{Night: date(1), Rate: 10000, RatePlan: "Weekend"},
+					{Night: date(2), Rate: 15000, RatePlan: "Weekend"},
+					{Night: date(3), Rate: 15000, RatePlan: "Weekend"},
+				},
+				RoomTotal: 40000,
+				Total:     40000,
+				Currency:  "USD",
+			},
+		},
+		{
+			name:   "Narrower plan wins a tie and nights outside plans use the room rate",
+			engine: NewEngine(0, 0, "USD"),
+			rate:   10000,
+			plans:  []RatePlan{{Name: "January", ValidFrom: date(1), ValidTo: date(5), NightlyRate: 9000}, festival},
+			from:   date(2),
+			to:     date(6),
+			want: Breakdown{
+				Nights: 4,
+				NightlyRates: []NightlyRate{
+					{Night: date(2), Rate: 20000, RatePlan: "Festival"},
+					{Night: date(3), Rate: 20000, RatePlan: "Festival"},
+					{Night: date(4), Rate: 9000, RatePlan: "January"},
+					{Night: date(5), Rate: 10000},
+				},
+				RoomTotal: 59000,
+				Total:     59000,
+				Currency:  "USD",
+			},
+		},
+		{
+			name:   "Higher priority wins",
+			engine: NewEngine(0, 0, "USD"),
+			rate:   10000,
+			plans:  []RatePlan{festival, promo},
+			from:   date(2),
+			to:     date(4),
+			want: Breakdown{
+				Nights: 2,
+				NightlyRates: []NightlyRate{
+					{Night: date(2), Rate: 8000, RatePlan: "Promo"},
+					{Night: date(3), Rate: 8000, RatePlan: "Promo"},
+				},
+				RoomTotal: 16000,
+				Total:     16000,
+				Currency:  "USD",
+			},
+		},
+		{
+			name:    "Shorter than the minimum stay",
+			engine:  NewDefaultEngine(),
+			rate:    10000,
+			plans:   []RatePlan{festival},
+			from:    date(2),
+			to:      date(3),
+			wantErr: true,
+		},
+		{
+			name:    "Closed to arrival",
+			engine:  NewDefaultEngine(),
+			rate:    10000,
+			plans:   []RatePlan{{Name: "No Friday", ValidFrom: date(1), ValidTo: date(31), ClosedToArrival: []time.Weekday{time.Friday}}},
+			from:    date(2),
+			to:      date(4),
+			wantErr: true,
+		},
+		{
+			name:   "No nights",
+			engine: NewDefaultEngine(),
+			rate:   FromFloat(120),
+			from:   date(5),
+			to:     date(5),
+			want: Breakdown{
+				Currency: DefaultCurrency,
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.engine.Quote(tt.rate, tt.plans, tt.from, tt.to)
+			if tt.wantErr {
+				var restrictionErr *RestrictionError
+				if !errors.As(err, &restrictionErr) {
+					t.Fatalf("Engine.Quote() error = %v, want a RestrictionError", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Engine.Quote() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Engine.Quote() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMoney(t *testing.T) {
+	tests := []struct {
+		name  string
+		money Money
+		want  string
+	}{
+		{name: "From float", money: FromFloat(19.99), want: "19.99"},
+		{name: "Float rounding", money: FromFloat(0.1 + 0.2), want: "0.30"},
+		{name: "Percent rounds half up", money: Money(250).Percent(500), want: "0.13"},
+		{name: "Negative", money: Money(-1050), want: "-10.50"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.money.String(); got != tt.want {
+				t.Errorf("Money.String() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,34 @@
+package pricing
+
+import (
+	"fmt"
+	"math"
+)
+
+// Money is an amount in minor currency units (cents), so totals add up
+// exactly.
+type Money int64
+
+// FromFloat converts an amount in major units, e.g. a room price of 99.5, to
+// Money, rounding to the nearest minor unit.
+func FromFloat(amount float64) Money {
+	return Money(math.Round(amount * 100))
+}
+
+// Percent returns basisPoints/10000 of m, rounded half away from zero.
+func (m Money) Percent(basisPoints int) Money {
+	product := int64(m) * int64(basisPoints)
+	if product < 0 {
+		return Money((product - 5000) / 10000)
+	}
+	return Money((product + 5000) / 10000)
+}
+
+func (m Money) String() string {
+	sign := ""
+	if m < 0 {
+		sign = "-"
+		m = -m
+	}
+	return fmt.Sprintf("%s%d.%02d", sign, m/100, m%100)
+}
@@ -0,0 +1,137 @@
+package pricing
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/ctchen222/hotel-system/internal/utils"
+)
+
+type PromotionKind string
+
+const (
+	PromotionPercentage PromotionKind = "percentage"
+	PromotionFixed      PromotionKind = "fixed"
+)
+
+// Promotion is the discount a promo code gives on the room total. It can be
+// redeemed from ValidFrom until ValidTo, for stays of at least MinNights.
+type Promotion struct {
+	Code      string        `bson:"code" json:"code"`
+	Kind      PromotionKind `bson:"kind" json:"kind"`
+	Percent   int           `bson:"percent,omitempty" json:"percent,omitempty"`
+	Amount    Money         `bson:"amount,omitempty" json:"amount,omitempty"`
+	ValidFrom time.Time     `bson:"validFrom" json:"validFrom"`
+	ValidTo   time.Time     `bson:"validTo" json:"validTo"`
+	MinNights int           `bson:"minNights" json:"minNights"`
+	// MaxRedemptions caps how many bookings may use the code; zero means no
+	// limit.
+	MaxRedemptions int `bson:"maxRedemptions" json:"maxRedemptions"`
+}
+
+func (p Promotion) Validate() map[string]string {
+	errors := map[string]string{}
+	if p.Code == "" {
+		errors["code"] = "code is required"
+	}
+	switch p.Kind {
+	case PromotionPercentage:
+		if p.Percent < 1 || p.Percent > 100 {
+			errors["percent"] = "percent must be between 1 and 100"
+		}
+	case PromotionFixed:
+		if p.Amount <= 0 {
+			errors["amount"] = "amount must be positive"
+		}
+	default:
+		errors["kind"] = fmt.Sprintf("kind must be %q or %q", PromotionPercentage, PromotionFixed)
+	}
+	if !p.ValidTo.After(p.ValidFrom) {
+		errors["validTo"] = "validTo must be after validFrom"
+	}
+	if p.MinNights < 0 {
+		errors["minNights"] = "minNights must not be negative"
+	}
+	if p.MaxRedemptions < 0 {
+		errors["maxRedemptions"] = "maxRedemptions must not be negative"
+	}
+	return errors
+}
+
+// Check returns a PromotionError if the promotion can't be used at now for a
+// stay of the given number of nights. Redemption limits are enforced by the
+// stores.
+func (p Promotion) Check(nights int, now time.Time) error {
+	if now.Before(p.ValidFrom) || !now.Before(p.ValidTo) {
+		return &PromotionError{Code: p.Code, Reason: "is not valid at this time"}
+	}
+	if nights < p.MinNights {
+		return &PromotionError{Code: p.Code, Reason: fmt.Sprintf("requires a stay of at least %d nights", p.MinNights)}
+	}
+	return nil
+}
+
+// Discount returns the amount taken off roomTotal. A fixed discount never
+// exceeds the room total.
+func (p Promotion) Discount(roomTotal Money) Money {
+	if p.Kind == PromotionPercentage {
+		return roomTotal.Percent(p.Percent * 100)
+	}
+	return min(p.Amount, roomTotal)
+}
+
+// PromotionError is returned when a promo code doesn't apply to a booking.
+type PromotionError struct {
+	Code   string
+	Reason string
+}
+
+func (e *PromotionError) Error() string {
+	return fmt.Sprintf("promo code %q %s", e.Code, e.Reason)
+}
+
+// NormalizeCode makes promo codes case-insensitive.
+func NormalizeCode(code string) string {
+	return strings.ToUpper(strings.TrimSpace(code))
+}
+
+// PromotionParams is the request body for creating a promotion. ValidFrom and
+// ValidTo are dates in utils.DateLayout.
+type PromotionParams struct {
+	Code           string        `json:"code"`
+	Kind           PromotionKind `json:"kind"`
+	Percent        int           `json:"percent"`
+	Amount         Money         `json:"amount"`
+	ValidFrom      string        `json:"validFrom"`
+	ValidTo        string        `json:"validTo"`
+	MinNights      int           `json:"minNights"`
+	MaxRedemptions int           `json:"maxRedemptions"`
+}
+
+// Promotion parses the params. Unparsable dates are reported alongside the
+// promotion's own validation errors.
+func (p PromotionParams) Promotion() (Promotion, map[string]string) {
+	promotion := Promotion{
+		Code:           NormalizeCode(p.Code),
+		Kind:           p.Kind,
+		Percent:        p.Percent,
+		Amount:         p.Amount,
+		MinNights:      p.MinNights,
+		MaxRedemptions: p.MaxRedemptions,
+	}
+
+	dateErrors := map[string]string{}
+	var err error
+	if promotion.ValidFrom, err = utils.ParseDate(p.ValidFrom); err != nil {
+		dateErrors["validFrom"] = "validFrom must be a date like " + utils.DateLayout
+	}
+	if promotion.ValidTo, err = utils.ParseDate(p.ValidTo); err != nil {
+		dateErrors["validTo"] = "validTo must be a date like " + utils.DateLayout
+	}
+	if len(dateErrors) > 0 {
+		return promotion, dateErrors
+	}
+
+	return promotion, promotion.Validate()
+}
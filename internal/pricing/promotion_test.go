@@ -0,0 +1,86 @@
+package pricing
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestPromotion_Check(t *testing.T) {
+	promotion := Promotion{
+		Code:      "SPRING",
+		Kind:      PromotionPercentage,
+		Percent:   10,
+		ValidFrom: date(1),
+		ValidTo:   date(10),
+		MinNights: 2,
+	}
+
+	tests := []struct {
+		name    string
+		nights  int
+		now     time.Time
+		wantErr bool
+	}{
+		{name: "Within the window", nights: 2, now: date(1)},
+		{name: "Before the window", nights: 2, now: date(1).Add(-time.Second), wantErr: true},
+		{name: "Window end is exclusive", nights: 2, now: date(10), wantErr: true},
+		{name: "Too few nights", nights: 1, now: date(5), wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := promotion.Check(tt.nights, tt.now)
+			var promotionErr *PromotionError
+			if got := errors.As(err, &promotionErr); got != tt.wantErr {
+				t.Errorf("Promotion.Check() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestEngine_ApplyPromotion(t *testing.T) {
+	engine := NewDefaultEngine()
+	quote, err := engine.Quote(10000, nil, date(5), date(7))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name         string
+		promotion    Promotion
+		wantDiscount Money
+		wantTotal    Money
+	}{
+		{
+			name:         "Percentage",
+			promotion:    Promotion{Code: "TEN", Kind: PromotionPercentage, Percent: 10},
+			wantDiscount: 2000,
+			// taxes and fees are charged on the discounted 180
+			wantTotal: 20700,
+		},
+		{
+			name:         "Fixed amount",
+			promotion:    Promotion{Code: "FIFTY", Kind: PromotionFixed, Amount: 5000},
+			wantDiscount: 5000,
+			wantTotal:    17250,
+		},
+		{
+			name:         "Fixed amount above the room total",
+			promotion:    Promotion{Code: "FREE", Kind: PromotionFixed, Amount: 50000},
+			wantDiscount: 20000,
+			wantTotal:    0,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := engine.ApplyPromotion(quote, tt.promotion)
+			if got.PromoCode != tt.promotion.Code || got.Discount != tt.wantDiscount || got.Total != tt.wantTotal {
+				t.Errorf("Engine.ApplyPromotion() = %+v, want code %s, discount %v, total %v",
+					got, tt.promotion.Code, tt.wantDiscount, tt.wantTotal)
+			}
+			if got.RoomTotal != quote.RoomTotal {
+				t.Errorf("Engine.ApplyPromotion() changed the room total to %v", got.RoomTotal)
+			}
+		})
+	}
+}
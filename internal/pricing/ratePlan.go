@@ -0,0 +1,177 @@
+package pricing
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/ctchen222/hotel-system/internal/utils"
+)
+
+// RatePlan sets the nightly rate of a room for the nights in
+// [ValidFrom, ValidTo). When several plans cover a night, the one with the
+// highest Priority wins, and among equal priorities the shorter plan.
+type RatePlan struct {
+	Name      string    `bson:"name" json:"name"`
+	ValidFrom time.Time `bson:"validFrom" json:"validFrom"`
+	ValidTo   time.Time `bson:"validTo" json:"validTo"`
+	Priority  int       `bson:"priority" json:"priority"`
+
+	// NightlyRate replaces the room price when set.
+	NightlyRate Money `bson:"nightlyRate" json:"nightlyRate"`
+	// WeekdayPercent and WeekendPercent scale the nightly rate on Sunday to
+	// Thursday nights and on Friday and Saturday nights. Zero means 100.
+	WeekdayPercent int `bson:"weekdayPercent" json:"weekdayPercent"`
+	WeekendPercent int `bson:"weekendPercent" json:"weekendPercent"`
+
+	// MinStay and ClosedToArrival restrict stays arriving on a night
+	// covered by the plan.
+	MinStay         int            `bson:"minStay" json:"minStay"`
+	ClosedToArrival []time.Weekday `bson:"closedToArrival" json:"closedToArrival"`
+}
+
+func (p RatePlan) Validate() map[string]string {
+	errors := map[string]string{}
+	if p.Name == "" {
+		errors["name"] = "name is required"
+	}
+	if !p.ValidTo.After(p.ValidFrom) {
+		errors["validTo"] = "validTo must be after validFrom"
+	}
+	if p.NightlyRate < 0 {
+		errors["nightlyRate"] = "nightlyRate must not be negative"
+	}
+	if p.WeekdayPercent < 0 || p.WeekendPercent < 0 {
+		errors["percent"] = "weekdayPercent and weekendPercent must not be negative"
+	}
+	if p.MinStay < 0 {
+		errors["minStay"] = "minStay must not be negative"
+	}
+	for _, day := range p.ClosedToArrival {
+		if day < time.Sunday || day > time.Saturday {
+			errors["closedToArrival"] = "closedToArrival must hold weekdays between 0 (Sunday) and 6 (Saturday)"
+		}
+	}
+	return errors
+}
+
+// Covers reports whether the plan applies to night.
+func (p RatePlan) Covers(night time.Time) bool {
+	return !night.Before(p.ValidFrom) && night.Before(p.ValidTo)
+}
+
+// Rate returns the price of night under the plan, starting from the room's
+// base rate.
+func (p RatePlan) Rate(base Money, night time.Time) Money {
+	rate := base
+	if p.NightlyRate > 0 {
+		rate = p.NightlyRate
+	}
+
+	percent := p.WeekdayPercent
+	if isWeekendNight(night) {
+		percent = p.WeekendPercent
+	}
+	if percent == 0 {
+		return rate
+	}
+	return rate.Percent(percent * 100)
+}
+
+func (p RatePlan) closedToArrival(night time.Time) bool {
+	for _, day := range p.ClosedToArrival {
+		if night.Weekday() == day {
+			return true
+		}
+	}
+	return false
+}
+
+// RestrictionError is returned when a stay breaks the minimum stay or
+// closed-to-arrival rule of the plan covering its arrival night.
+type RestrictionError struct {
+	Plan   string
+	Reason string
+}
+
+func (e *RestrictionError) Error() string {
+	return fmt.Sprintf("rate plan %q: %s", e.Plan, e.Reason)
+}
+
+// selectPlan returns the plan that prices night, or nil if none covers it.
+func selectPlan(plans []RatePlan, night time.Time) *RatePlan {
+	var selected *RatePlan
+	for i := range plans {
+		plan := &plans[i]
+		if !plan.Covers(night) {
+			continue
+		}
+		if selected == nil ||
+			plan.Priority > selected.Priority ||
+			plan.Priority == selected.Priority && plan.ValidTo.Sub(plan.ValidFrom) < selected.ValidTo.Sub(selected.ValidFrom) {
+			selected = plan
+		}
+	}
+	return selected
+}
+
+func isWeekendNight(night time.Time) bool {
+	return night.Weekday() == time.Friday || night.Weekday() == time.Saturday
+}
+
+// RatePlanParams is the request body for creating or changing a rate plan.
+// ValidFrom and ValidTo are dates in utils.DateLayout.
+type RatePlanParams struct {
+	Name            string         `json:"name"`
+	ValidFrom       string         `json:"validFrom"`
+	ValidTo         string         `json:"validTo"`
+	Priority        int            `json:"priority"`
+	NightlyRate     Money          `json:"nightlyRate"`
+	WeekdayPercent  int            `json:"weekdayPercent"`
+	WeekendPercent  int            `json:"weekendPercent"`
+	MinStay         int            `json:"minStay"`
+	ClosedToArrival []time.Weekday `json:"closedToArrival"`
+}
+
+// NewRatePlanParams returns the params describing plan, so a partial update
+// can be decoded on top of them.
+func NewRatePlanParams(plan RatePlan) RatePlanParams {
+	return RatePlanParams{
+		Name:            plan.Name,
+		ValidFrom:       plan.ValidFrom.Format(utils.DateLayout),
+		ValidTo:         plan.ValidTo.Format(utils.DateLayout),
+		Priority:        plan.Priority,
+		NightlyRate:     plan.NightlyRate,
+		WeekdayPercent:  plan.WeekdayPercent,
+		WeekendPercent:  plan.WeekendPercent,
+		MinStay:         plan.MinStay,
+		ClosedToArrival: plan.ClosedToArrival,
+	}
+}
+
+// RatePlan parses the params. Unparsable dates are reported alongside the
+// plan's own validation errors.
+func (p RatePlanParams) RatePlan() (RatePlan, map[string]string) {
+	plan := RatePlan{
+		Name:            p.Name,
+		Priority:        p.Priority,
+		NightlyRate:     p.NightlyRate,
+		WeekdayPercent:  p.WeekdayPercent,
+		WeekendPercent:  p.WeekendPercent,
+		MinStay:         p.MinStay,
+		ClosedToArrival: p.ClosedToArrival,
+	}
+
+	dateErrors := map[string]string{}
+	var err error
+	if plan.ValidFrom, err = utils.ParseDate(p.ValidFrom); err != nil {
+		dateErrors["validFrom"] = "validFrom must be a date like " + utils.DateLayout
+	}
+	if plan.ValidTo, err = utils.ParseDate(p.ValidTo); err != nil {
+		dateErrors["validTo"] = "validTo must be a date like " + utils.DateLayout
+	}
+	if len(dateErrors) > 0 {
+		return plan, dateErrors
+	}
+
+	return plan, plan.Validate()
+}
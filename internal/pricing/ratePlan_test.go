@@ -0,0 +1,53 @@
+package pricing
+
+import (
+	"testing"
+	"time"
+)
+
+func TestRatePlanParams_RatePlan(t *testing.T) {
+	tests := []struct {
+		name       string
+		params     RatePlanParams
+		wantErrors []string
+	}{
+		{
+			name:   "Valid",
+			params: RatePlanParams{Name: "Summer", ValidFrom: "2026-07-01", ValidTo: "2026-09-01", WeekendPercent: 120},
+		},
+		{
+			name:       "Unparsable date",
+			params:     RatePlanParams{Name: "Summer", ValidFrom: "07/01/2026", ValidTo: "2026-09-01"},
+			wantErrors: []string{"validFrom"},
+		},
+		{
+			name:       "Empty range",
+			params:     RatePlanParams{Name: "Summer", ValidFrom: "2026-09-01", ValidTo: "2026-09-01"},
+			wantErrors: []string{"validTo"},
+		},
+		{
+			name: "Invalid rules",
+			params: RatePlanParams{
+				ValidFrom:       "2026-07-01",
+				ValidTo:         "2026-09-01",
+				NightlyRate:     -1,
+				MinStay:         -1,
+				ClosedToArrival: []time.Weekday{7},
+			},
+			wantErrors: []string{"name", "nightlyRate", "minStay", "closedToArrival"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, errs := tt.params.RatePlan()
+			if len(errs) != len(tt.wantErrors) {
+				t.Fatalf("RatePlanParams.RatePlan() errors = %v, want %v", errs, tt.wantErrors)
+			}
+			for _, key := range tt.wantErrors {
+				if _, ok := errs[key]; !ok {
+					t.Errorf("RatePlanParams.RatePlan() errors = %v, missing %q", errs, key)
+				}
+			}
+		})
+	}
+}
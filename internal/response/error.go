@@ -5,6 +5,9 @@ import "net/http"
 type Error struct {
 	Code   int    `json:"code"`
 	Extras string `json:"extras"`
+	// Fields holds what is wrong with each invalid request parameter. Such
+	// errors are answered with the fields as extras.
+	Fields map[string]string `json:"-"`
 }
 
 func (e Error) Error() string {
@@ -18,6 +21,21 @@ func NewError(code int, message string) Error {
 	}
 }
 
+// ErrValidation is the error for request parameters that failed validation.
+func ErrValidation(fields map[string]string) Error {
+	return Error{
+		Code:   http.StatusUnprocessableEntity,
+		Extras: "Invalid request parameters",
+		Fields: fields,
+	}
+}
+
+// ErrInternal is the error for failures the client can't do anything about.
+// Their cause is logged rather than answered.
+func ErrInternal() Error {
+	return NewError(http.StatusInternalServerError, "Internal server error")
+}
+
 func ErrInvalidId() Error {
 	return NewError(http.StatusBadRequest, "Invalid ID")
 }
@@ -35,11 +53,7 @@ func ErrBadRequest() Error {
 }
 
 func ErrResourceNotFound() Error {
-	return NewError(http.StatusBadRequest, "Resource not found")
-}
-
-func ErrInvalidLocation() Error {
-	return NewError(http.StatusBadRequest, "Invalid location")
+	return NewError(http.StatusNotFound, "Resource not found")
 }
 
 func ErrInvalidDate() Error {
@@ -49,3 +63,111 @@ func ErrInvalidDate() Error {
 func ErrParseInt() Error {
 	return NewError(http.StatusBadRequest, "Parse Int from string")
 }
+
+func ErrRoomAlreadyBooked() Error {
+	return NewError(http.StatusConflict, "Room is already booked")
+}
+
+func ErrInvalidBookingStatus() Error {
+	return NewError(http.StatusConflict, "Booking can't be changed in its current status")
+}
+
+func ErrPaidBookingPriceChange() Error {
+	return NewError(http.StatusConflict, "The booking is paid, so its price can't change; cancel it and book again")
+}
+
+func ErrRatePlanRestriction(message string) Error {
+	return NewError(http.StatusUnprocessableEntity, message)
+}
+
+func ErrInvalidPromoCode(message string) Error {
+	return NewError(http.StatusUnprocessableEntity, message)
+}
+
+func ErrPromoCodeExhausted() Error {
+	return NewError(http.StatusConflict, "Promo code has been fully redeemed")
+}
+
+func ErrHotelExists() Error {
+	return NewError(http.StatusConflict, "A hotel with this name already exists")
+}
+
+func ErrPromoCodeExists() Error {
+	return NewError(http.StatusConflict, "Promo code already exists")
+}
+
+func ErrHoldExpired() Error {
+	return NewError(http.StatusGone, "Booking hold has expired")
+}
+
+func ErrPaymentDeclined() Error {
+	return NewError(http.StatusPaymentRequired, "Payment was declined")
+}
+
+func ErrPaymentCaptureFailed() Error {
+	return NewError(http.StatusBadGateway, "Payment could not be captured, confirm the booking again to retry")
+}
+
+func ErrInvalidRefreshToken() Error {
+	return NewError(http.StatusUnauthorized, "Invalid refresh token")
+}
+
+func ErrRefreshTokenReused() Error {
+	return NewError(http.StatusUnauthorized, "Refresh token has already been used")
+}
+
+func ErrInvalidResetToken() Error {
+	return NewError(http.StatusBadRequest, "Invalid or expired password reset token")
+}
+
+func ErrInvalidVerificationToken() Error {
+	return NewError(http.StatusBadRequest, "Invalid or expired email verification token")
+}
+
+func ErrEmailNotVerified() Error {
+	return NewError(http.StatusForbidden, "Email address has not been verified")
+}
+
+func ErrInvalidLoginChallenge() Error {
+	return NewError(http.StatusUnauthorized, "Invalid or expired login challenge")
+}
+
+func ErrInvalidTwoFactorCode() Error {
+	return NewError(http.StatusUnauthorized, "Invalid two-factor authentication code")
+}
+
+func ErrTwoFactorAlreadyEnabled() Error {
+	return NewError(http.StatusConflict, "Two-factor authentication is already enabled")
+}
+
+func ErrTwoFactorNotEnrolled() Error {
+	return NewError(http.StatusConflict, "Two-factor authentication has not been enrolled")
+}
+
+func ErrTwoFactorEnrollmentRequired() Error {
+	return NewError(http.StatusForbidden, "Two-factor authentication is required for your role")
+}
+
+func ErrInvalidCredentials() Error {
+	return NewError(http.StatusUnauthorized, "Invalid credentials")
+}
+
+func ErrTooManyLoginAttempts() Error {
+	return NewError(http.StatusTooManyRequests, "Too many failed login attempts, try again later")
+}
+
+func ErrInvalidSingleSignOn() Error {
+	return NewError(http.StatusBadRequest, "Invalid or expired single sign-on login")
+}
+
+func ErrSingleSignOnFailed() Error {
+	return NewError(http.StatusUnauthorized, "Single sign-on login failed")
+}
+
+func ErrSingleSignOnNotAllowed() Error {
+	return NewError(http.StatusForbidden, "Single sign-on can't be used for staff accounts, log in with your password")
+}
+
+func ErrEmailInUse() Error {
+	return NewError(http.StatusConflict, "An account with this email already exists")
+}
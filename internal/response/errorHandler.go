@@ -0,0 +1,84 @@
+package response
+
+import (
+	"errors"
+	"log"
+	"net/http"
+
+	"github.com/ctchen222/hotel-system/internal/db"
+	"github.com/gofiber/fiber/v2"
+)
+
+// storeErrors are the store errors that are answered with their own message,
+// and for ErrHoldExpired their own status, rather than by their kind alone.
+var storeErrors = []struct {
+	err      error
+	apiError func() Error
+}{
+	{db.ErrEmailExists, ErrEmailInUse},
+	{db.ErrHotelExists, ErrHotelExists},
+	{db.ErrPromoCodeExists, ErrPromoCodeExists},
+	{db.ErrPromoCodeExhausted, ErrPromoCodeExhausted},
+	{db.ErrInvalidBookingTransition, ErrInvalidBookingStatus},
+	{db.ErrBookingChanged, ErrInvalidBookingStatus},
+	{db.ErrHoldExpired, ErrHoldExpired},
+}
+
+// FromError returns the API error err is answered with. API errors are kept as
+// they are, store errors are mapped by their kind and fiber's errors keep
+// their status; anything else is an internal server error, whose cause isn't
+// told to the client.
+func FromError(err error) Error {
+	var apiError Error
+	if errors.As(err, &apiError) {
+		return apiError
+	}
+	for _, e := range storeErrors {
+		if errors.Is(err, e.err) {
+			return e.apiError()
+		}
+	}
+	var conflictErr *db.BookingConflictError
+	if errors.As(err, &conflictErr) {
+		return ErrRoomAlreadyBooked()
+	}
+
+	switch {
+	case errors.Is(err, db.ErrNotFound):
+		return ErrResourceNotFound()
+	case errors.Is(err, db.ErrConflict):
+		return NewError(http.StatusConflict, err.Error())
+	case errors.Is(err, db.ErrValidation):
+		return NewError(http.StatusUnprocessableEntity, err.Error())
+	case errors.Is(err, db.ErrForbidden):
+		return NewError(http.StatusForbidden, err.Error())
+	}
+
+	var fiberErr *fiber.Error
+	if errors.As(err, &fiberErr) {
+		return NewError(fiberErr.Code, fiberErr.Message)
+	}
+	return ErrInternal()
+}
+
+// ErrorHandler is the fiber error handler that answers every error a handler
+// returns with the status of FromError. Clients that opt in get problem
+// details, the others the Error, or for invalid parameters a Response with
+// the invalid fields as extras. The causes of internal server errors are
+// logged.
+func ErrorHandler(c *fiber.Ctx, err error) error {
+	apiError := FromError(err)
+	if apiError.Code == http.StatusInternalServerError {
+		log.Printf("%s %s: %v", c.Method(), c.Path(), err)
+	}
+
+	if wantsProblem(c) {
+		problem := NewProblem(c, err)
+		return c.Status(problem.Status).JSON(problem, MIMEApplicationProblemJSON)
+	}
+
+	if apiError.Fields != nil {
+		return c.Status(apiError.Code).JSON(NewResponse(apiError.Code, apiError.Fields))
+	}
+	return c.Status(apiError.Code).JSON(apiError)
+}
@@ -0,0 +1,80 @@
+package response
+
+import (
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// MIMEApplicationProblemJSON is the content type of problem details.
+const MIMEApplicationProblemJSON = "application/problem+json"
+
+// HeaderAPIVersion is the request header clients pick the API version with.
+// Requests without it use version 1.
+const HeaderAPIVersion = "API-Version"
+
+// problemVersion is the first API version that answers errors with problem
+// details instead of an Error.
+const problemVersion = 2
+
+// Problem is an error as described by RFC 7807, answered to clients that opt
+// in with API version 2 or by accepting application/problem+json.
+type Problem struct {
+	Type      string         `json:"type"`
+	Title     string         `json:"title"`
+	Status    int            `json:"status"`
+	Detail    string         `json:"detail,omitempty"`
+	Instance  string         `json:"instance,omitempty"`
+	Errors    []FieldProblem `json:"errors,omitempty"`
+	RequestId string         `json:"requestId,omitempty"`
+}
+
+// FieldProblem is what is wrong with one request parameter.
+type FieldProblem struct {
+	Field  string `json:"field"`
+	Detail string `json:"detail"`
+}
+
+// NewProblem returns the problem details err is answered with. The type is
+// derived from the status, e.g. /problems/not-found, and the details of
+// internal server errors are left out.
+func NewProblem(c *fiber.Ctx, err error) Problem {
+	apiError := FromError(err)
+	problem := Problem{
+		Type:      problemType(apiError.Code),
+		Title:     http.StatusText(apiError.Code),
+		Status:    apiError.Code,
+		Detail:    apiError.Extras,
+		Instance:  c.Path(),
+		RequestId: c.GetRespHeader(fiber.HeaderXRequestID),
+	}
+	if apiError.Code == http.StatusInternalServerError {
+		problem.Detail = ""
+	}
+	for field, detail := range apiError.Fields {
+		problem.Errors = append(problem.Errors, FieldProblem{Field: field, Detail: detail})
+	}
+	sort.Slice(problem.Errors, func(i, j int) bool {
+		return problem.Errors[i].Field < problem.Errors[j].Field
+	})
+	return problem
+}
+
+// wantsProblem reports whether the client opted in to problem details.
+func wantsProblem(c *fiber.Ctx) bool {
+	if version, err := strconv.Atoi(c.Get(HeaderAPIVersion)); err == nil && version >= problemVersion {
+		return true
+	}
+	return c.Accepts(fiber.MIMEApplicationJSON, MIMEApplicationProblemJSON) == MIMEApplicationProblemJSON
+}
+
+func problemType(status int) string {
+	title := http.StatusText(status)
+	if title == "" {
+		return "about:blank"
+	}
+	return "/problems/" + strings.ReplaceAll(strings.ToLower(title), " ", "-")
+}
@@ -22,6 +22,8 @@ func SuccessResponse(c *fiber.Ctx, extras any) error {
 	return c.JSON(NewResponse(http.StatusOK, fiber.Map{"data": extras}))
 }
 
-func ErrorResponse(c *fiber.Ctx, extras any) error {
-	return c.JSON(NewResponse(http.StatusInternalServerError, extras))
+// ErrorResponse answers a request whose parameters failed validation, with
+// what is wrong with each of them.
+func ErrorResponse(c *fiber.Ctx, fields map[string]string) error {
+	return ErrorHandler(c, ErrValidation(fields))
 }
@@ -0,0 +1,41 @@
+package types
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/ctchen222/hotel-system/internal/auth"
+)
+
+type CreateAPIKeyParams struct {
+	Name string `json:"name"`
+	// UserId is the user the key acts as, the admin creating it if empty.
+	UserId      string            `json:"userId"`
+	Permissions []auth.Permission `json:"permissions"`
+	ExpiresAt   *time.Time        `json:"expiresAt"`
+}
+
+func (params CreateAPIKeyParams) Validate(now time.Time) map[string]string {
+	errors := map[string]string{}
+	if params.Name == "" {
+		errors["name"] = "name is required"
+	}
+	if len(params.Permissions) == 0 {
+		errors["permissions"] = "at least one permission is required"
+	}
+	for _, permission := range params.Permissions {
+		if !permission.Valid() {
+			errors["permissions"] = fmt.Sprintf("permission %q is invalid", permission)
+		}
+	}
+	if params.ExpiresAt != nil && !params.ExpiresAt.After(now) {
+		errors["expiresAt"] = "expiresAt must be in the future"
+	}
+	return errors
+}
+
+// APIKeyResponse returns a new key. The key is only shown this once.
+type APIKeyResponse struct {
+	APIKey *auth.APIKey `json:"apiKey"`
+	Key    string       `json:"key"`
+}
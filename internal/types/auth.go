@@ -1,11 +1,86 @@
 package types
 
+import (
+	"fmt"
+
+	"github.com/ctchen222/hotel-system/internal/auth"
+)
+
 type AuthParams struct {
-	Email    string `json: "email"`
-	Password string `json: "password`
+	Email    string `json:"email"`
+	Password string `json:"password"`
 }
 
 type AuthResponse struct {
-	User  *User  `json:"user,omitempty"`
-	Token string `json:"token,omitempty"`
+	User         *User  `json:"user,omitempty"`
+	Token        string `json:"token,omitempty"`
+	RefreshToken string `json:"refreshToken,omitempty"`
+	// TwoFactorRequired is set instead of the tokens when the user has to
+	// finish the login with a code; Challenge identifies the login.
+	TwoFactorRequired bool   `json:"twoFactorRequired,omitempty"`
+	Challenge         string `json:"challenge,omitempty"`
+	// TwoFactorEnrollmentRequired tells users whose role requires two-factor
+	// authentication to enroll before using the admin API.
+	TwoFactorEnrollmentRequired bool `json:"twoFactorEnrollmentRequired,omitempty"`
+}
+
+type RefreshParams struct {
+	RefreshToken string `json:"refreshToken"`
+}
+
+type ForgotPasswordParams struct {
+	Email string `json:"email"`
+}
+
+type ResendVerificationParams struct {
+	Email string `json:"email"`
+}
+
+type ResetPasswordParams struct {
+	Token    string `json:"token"`
+	Password string `json:"password"`
+}
+
+func (params ResetPasswordParams) Validate() map[string]string {
+	errors := map[string]string{}
+	if params.Token == "" {
+		errors["token"] = "token is required"
+	}
+	if len(params.Password) < MinPasswordLength {
+		errors["password"] = fmt.Sprintf("password must be at least %d characters long", MinPasswordLength)
+	}
+	return errors
+}
+
+type TwoFactorLoginParams struct {
+	Challenge string `json:"challenge"`
+	// Code is a TOTP code or a recovery code.
+	Code string `json:"code"`
+}
+
+type TwoFactorCodeParams struct {
+	Code string `json:"code"`
+}
+
+type TwoFactorEnrollment struct {
+	Secret          string `json:"secret"`
+	ProvisioningURI string `json:"provisioningUri"`
+}
+
+type RecoveryCodesResponse struct {
+	RecoveryCodes []string `json:"recoveryCodes"`
+}
+
+type TwoFactorPolicyParams struct {
+	Roles []auth.Role `json:"roles"`
+}
+
+func (params TwoFactorPolicyParams) Validate() map[string]string {
+	errors := map[string]string{}
+	for _, role := range params.Roles {
+		if !role.Valid() {
+			errors["roles"] = fmt.Sprintf("role %q is invalid", role)
+		}
+	}
+	return errors
 }
@@ -0,0 +1,57 @@
+package types
+
+import (
+	"time"
+
+	"github.com/ctchen222/hotel-system/internal/pricing"
+)
+
+type AvailabilityRawQuery struct {
+	From     string  `query:"from"`
+	To       string  `query:"to"`
+	Guests   int     `query:"guests"`
+	Location string  `query:"location"`
+	SeaSide  *bool   `query:"seaside"`
+	MaxPrice float64 `query:"maxPrice"`
+}
+
+type AvailabilityQuery struct {
+	From     time.Time
+	To       time.Time
+	Guests   int
+	Location string
+	SeaSide  *bool
+	// MaxPrice is the most a night may cost. Rate plans set the price of each
+	// night, so it is applied to the quotes rather than by the stores.
+	MaxPrice float64
+}
+
+func (q *AvailabilityQuery) Validate() map[string]string {
+	now := time.Now()
+	errors := map[string]string{}
+	if now.After(q.From) {
+		errors["from"] = "Can't search availability in the past"
+	}
+	if !q.To.After(q.From) {
+		errors["order"] = "From Date After To Date"
+	}
+	if q.Guests < 0 {
+		errors["guests"] = "guests must not be negative"
+	}
+	if q.MaxPrice < 0 {
+		errors["maxPrice"] = "maxPrice must not be negative"
+	}
+	return errors
+}
+
+// HotelAvailability groups the rooms of a hotel that are free for a stay.
+type HotelAvailability struct {
+	Hotel *Hotel           `json:"hotel"`
+	Rooms []*AvailableRoom `json:"rooms"`
+}
+
+// AvailableRoom is a free room and the quote for the searched stay in it.
+type AvailableRoom struct {
+	Room
+	Quote pricing.Breakdown `json:"quote"`
+}
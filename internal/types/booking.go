@@ -4,16 +4,51 @@ import (
 	"fmt"
 	"time"
 
-	"go.mongodb.org/mongo-driver/bson/primitive"
+	"github.com/ctchen222/hotel-system/internal/pricing"
 )
 
+type BookingStatus string
+
+const (
+	BookingPending   BookingStatus = "pending"
+	BookingConfirmed BookingStatus = "confirmed"
+	BookingCancelled BookingStatus = "cancelled"
+)
+
+// CanTransitionTo reports whether a booking in status s may move to next.
+// Bookings go pending -> confirmed -> cancelled, and a pending booking may
+// also be cancelled directly.
+func (s BookingStatus) CanTransitionTo(next BookingStatus) bool {
+	switch s {
+	case BookingPending:
+		return next == BookingConfirmed || next == BookingCancelled
+	case BookingConfirmed:
+		return next == BookingCancelled
+	}
+	return false
+}
+
 type Booking struct {
-	Id        primitive.ObjectID `bson:"_id,omitempty" json:"id,omitempty"`
-	UserId    primitive.ObjectID `bson:"userId,omitempty" json:"userId,omitempty"`
-	RoomId    primitive.ObjectID `bson:"roomId,omitempty" json:"roomId,omitempty"`
-	NumPerson int                `bson:"numPerson,omitempty" json:"numPerson,omitempty"`
-	From      time.Time          `bson:"from,omitempty" json:"from,omitempty"`
-	To        time.Time          `bson:"to,omitempty" json:"to,omitempty"`
+	Id        string            `json:"id,omitempty"`
+	UserId    string            `json:"userId,omitempty"`
+	RoomId    string            `json:"roomId,omitempty"`
+	NumPerson int               `json:"numPerson,omitempty"`
+	From      time.Time         `json:"from,omitempty"`
+	To        time.Time         `json:"to,omitempty"`
+	Price     pricing.Breakdown `json:"price"`
+	Status    BookingStatus     `json:"status,omitempty"`
+	// ExpiresAt is set while the booking is a hold; the hold is released if
+	// it isn't confirmed by then.
+	ExpiresAt    *time.Time    `json:"expiresAt,omitempty"`
+	CancelledAt  *time.Time    `json:"cancelledAt,omitempty"`
+	RefundAmount pricing.Money `json:"refundAmount,omitempty"`
+}
+
+// Overlaps reports whether the booking's half-open [From, To) range intersects
+// [from, to). Back-to-back stays, where one guest checks in on the day the
+// other checks out, do not overlap.
+func (b *Booking) Overlaps(from, to time.Time) bool {
+	return b.From.Before(to) && b.To.After(from)
 }
 
 type BookingParams struct {
@@ -31,9 +66,12 @@ func (p *BookingParams) Validate() map[string]string {
 	if now.After(p.To) {
 		errors["to"] = fmt.Sprintf("Can't book room in the past")
 	}
-	if p.From.After(p.To) {
+	if !p.To.After(p.From) {
 		errors["order"] = fmt.Sprintf("From Date After To Date")
 	}
+	if p.NumPerson < 1 {
+		errors["numPerson"] = "numPerson must be at least 1"
+	}
 	return errors
 }
 
@@ -41,9 +79,29 @@ type BookingRawParams struct {
 	From      string `json:"from"`
 	To        string `json:"to"`
 	NumPerson int    `json:"numPerson"`
+	PromoCode string `json:"promoCode"`
+}
+
+type BookingHoldParams struct {
+	BookingRawParams
+	RoomId string `json:"roomId"`
+}
+
+// BookingUpdateParams holds the fields of a booking a guest may change. Fields
+// left out of the request keep their current value.
+type BookingUpdateParams struct {
+	From      *string `json:"from"`
+	To        *string `json:"to"`
+	RoomId    *string `json:"roomId"`
+	NumPerson *int    `json:"numPerson"`
 }
 
 type BookingQuery struct {
 	From time.Time
 	To   time.Time
 }
+
+// BookingFilter selects bookings; empty fields match every booking.
+type BookingFilter struct {
+	UserId string
+}
@@ -51,6 +51,17 @@ func TestBookingParams_Validate(t *testing.T) {
 				"order": "From Date After To Date",
 			},
 		},
+		{
+			name: "No guests",
+			fields: fields{
+				From:      time.Now().Add(time.Hour * 24),
+				To:        time.Now().Add(time.Hour * 48),
+				NumPerson: 0,
+			},
+			want: map[string]string{
+				"numPerson": "numPerson must be at least 1",
+			},
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -65,3 +76,38 @@ func TestBookingParams_Validate(t *testing.T) {
 		})
 	}
 }
+
+func TestBooking_Overlaps(t *testing.T) {
+	day := func(d int) time.Time {
+		return time.Date(2030, time.January, d, 0, 0, 0, 0, time.UTC)
+	}
+	// existing booking: Jan 10 -> Jan 15
+	booking := &Booking{From: day(10), To: day(15)}
+
+	tests := []struct {
+		name string
+		from time.Time
+		to   time.Time
+		want bool
+	}{
+		{name: "identical range", from: day(10), to: day(15), want: true},
+		{name: "inside existing", from: day(11), to: day(14), want: true},
+		{name: "encloses existing", from: day(8), to: day(17), want: true},
+		{name: "overlaps start", from: day(8), to: day(12), want: true},
+		{name: "overlaps end", from: day(13), to: day(17), want: true},
+		{name: "same start, ends later", from: day(10), to: day(20), want: true},
+		{name: "starts earlier, same end", from: day(5), to: day(15), want: true},
+		{name: "single night inside", from: day(12), to: day(13), want: true},
+		{name: "checks out on existing check-in", from: day(5), to: day(10), want: false},
+		{name: "checks in on existing check-out", from: day(15), to: day(20), want: false},
+		{name: "entirely before", from: day(1), to: day(5), want: false},
+		{name: "entirely after", from: day(20), to: day(25), want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := booking.Overlaps(tt.from, tt.to); got != tt.want {
+				t.Errorf("Booking.Overlaps() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -1,21 +1,20 @@
 package types
 
-import "go.mongodb.org/mongo-driver/bson/primitive"
+import "github.com/ctchen222/hotel-system/internal/cancellation"
 
 type Hotel struct {
-	Id       primitive.ObjectID   `bson:"_id,omitempty" json:"id,omitempty"`
-	Name     string               `bson:"name" json:"name"`
-	Location string               `bson:"location" json:"location"`
-	Rooms    []primitive.ObjectID `bson:"rooms" json:"rooms"`
-	Rating   int                  `bson:"rating" json:"rating"`
+	Id       string `json:"id,omitempty"`
+	Name     string `json:"name"`
+	Location string `json:"location"`
+	Rating   int    `json:"rating"`
+
+	CancellationPolicy cancellation.Policy `json:"cancellationPolicy"`
 }
 
+// HotelEmbed is a hotel together with its rooms.
 type HotelEmbed struct {
-	Id       primitive.ObjectID `bson:"_id,omitempty" json:"id,omitempty"`
-	Name     string             `bson:"name" json:"name"`
-	Location string             `bson:"location" json:"location"`
-	Rooms    []Room             `bson:"rooms" json:"rooms"`
-	Rating   int                `bson:"rating" json:"rating"`
+	Hotel
+	Rooms []*Room `json:"rooms"`
 }
 
 type HotelQuery struct {
@@ -24,13 +23,15 @@ type HotelQuery struct {
 }
 
 type CreateHotelParams struct {
-	Name     string `json:"name"`
-	Location string `json:"location"`
-	Rating   int    `json:"rating"`
+	Name               string              `json:"name"`
+	Location           string              `json:"location"`
+	Rating             int                 `json:"rating"`
+	CancellationPolicy cancellation.Policy `json:"cancellationPolicy"`
 }
 
 type HotelUpdateParams struct {
-	Name     string `json:"name"`
-	Location string `json:"location"`
-	Rating   int    `json:"rating"`
+	Name               string              `json:"name"`
+	Location           string              `json:"location"`
+	Rating             int                 `json:"rating"`
+	CancellationPolicy cancellation.Policy `json:"cancellationPolicy"`
 }
@@ -0,0 +1,13 @@
+package types
+
+import "github.com/ctchen222/hotel-system/internal/payments"
+
+type Payment struct {
+	Id        string `json:"id,omitempty"`
+	BookingId string `json:"bookingId"`
+	payments.Record
+}
+
+type ConfirmBookingParams struct {
+	PaymentToken string `json:"paymentToken"`
+}
@@ -0,0 +1,21 @@
+package types
+
+import "github.com/ctchen222/hotel-system/internal/pricing"
+
+type PromoCode struct {
+	Id string `json:"id,omitempty"`
+	pricing.Promotion
+	// HotelId limits the code to one hotel; empty means every hotel.
+	HotelId     string `json:"hotelId,omitempty"`
+	Redemptions int    `json:"redemptions"`
+}
+
+// AppliesTo reports whether the code may be used for a room of hotelId.
+func (p *PromoCode) AppliesTo(hotelId string) bool {
+	return p.HotelId == "" || p.HotelId == hotelId
+}
+
+type CreatePromoCodeParams struct {
+	pricing.PromotionParams
+	HotelId string `json:"hotelId"`
+}
@@ -0,0 +1,18 @@
+package types
+
+import "github.com/ctchen222/hotel-system/internal/pricing"
+
+type RatePlan struct {
+	Id     string `json:"id,omitempty"`
+	RoomId string `json:"roomId"`
+	pricing.RatePlan
+}
+
+// Plans returns the pricing rules of the rate plans.
+func Plans(ratePlans []*RatePlan) []pricing.RatePlan {
+	plans := make([]pricing.RatePlan, 0, len(ratePlans))
+	for _, ratePlan := range ratePlans {
+		plans = append(plans, ratePlan.RatePlan)
+	}
+	return plans
+}
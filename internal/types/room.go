@@ -1,13 +1,33 @@
 package types
 
-import "go.mongodb.org/mongo-driver/bson/primitive"
+import "fmt"
+
+// DefaultRoomCapacity is the number of guests a room sleeps if it is created
+// without a capacity.
+const DefaultRoomCapacity = 1
 
 type Room struct {
-	Id      primitive.ObjectID `bson:"_id,omitempty" json:"_id,omitempty"`
-	Size    string             `bson:"size" json:"size"`
-	SeaSide bool               `bson:"seaside" json:"seaside"`
-	Price   float64            `bson:"price" json:"price"`
-	HotelId primitive.ObjectID `bson:"hotelId" json:"hotelId"`
+	Id       string  `json:"id,omitempty"`
+	Size     string  `json:"size"`
+	SeaSide  bool    `json:"seaside"`
+	Price    float64 `json:"price"`
+	Capacity int     `json:"capacity"`
+	HotelId  string  `json:"hotelId"`
+}
+
+type CreateRoomParams struct {
+	Size     string  `json:"size,omitempty"`
+	SeaSide  bool    `json:"seaside,omitempty"`
+	Price    float64 `json:"price,omitempty"`
+	Capacity int     `json:"capacity,omitempty"`
+}
+
+func (params CreateRoomParams) Validate() map[string]string {
+	errors := map[string]string{}
+	if params.Capacity < 0 {
+		errors["capacity"] = fmt.Sprintf("capacity %d is invalid, leave it out for a room sleeping %d", params.Capacity, DefaultRoomCapacity)
+	}
+	return errors
 }
 
 type RoomType int
@@ -4,7 +4,7 @@ import (
 	"fmt"
 	"regexp"
 
-	"go.mongodb.org/mongo-driver/bson/primitive"
+	"github.com/ctchen222/hotel-system/internal/auth"
 	"golang.org/x/crypto/bcrypt"
 )
 
@@ -47,12 +47,28 @@ func IsValidPassword(encpw, password string) bool {
 	return bcrypt.CompareHashAndPassword([]byte(encpw), []byte(password)) == nil
 }
 
+func EncryptPassword(password string) (string, error) {
+	encpw, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return "", err
+	}
+	return string(encpw), nil
+}
+
 type User struct {
-	Id                primitive.ObjectID `bson:"_id,omitempty" json:"id,omitempty"`
-	FirstName         string             `bson:"firstName" json:"firstName"`
-	LastName          string             `bson:"lastName" json:"lastName"`
-	Email             string             `bson:"email" json:"email"`
-	EncryptedPassword string             `bson:"encryptedPassword" json:"-"`
+	Id                string    `json:"id,omitempty"`
+	FirstName         string    `json:"firstName"`
+	LastName          string    `json:"lastName"`
+	Email             string    `json:"email"`
+	EncryptedPassword string    `json:"-"`
+	EmailVerified     bool      `json:"emailVerified"`
+	Role              auth.Role `json:"role"`
+	// HotelIds are the hotels a hotel staff member or manager works for.
+	HotelIds []string `json:"hotelIds,omitempty"`
+}
+
+func (u *User) CanManageHotel(hotelId string) bool {
+	return auth.CanManageHotel(u.Role, u.HotelIds, hotelId)
 }
 
 type UserUpdateParams struct {
@@ -60,6 +76,19 @@ type UserUpdateParams struct {
 	LastName  string `json:"lastName"`
 }
 
+type UpdateRoleParams struct {
+	Role     auth.Role `json:"role"`
+	HotelIds []string  `json:"hotelIds"`
+}
+
+func (params UpdateRoleParams) Validate() map[string]string {
+	errors := map[string]string{}
+	if !params.Role.Valid() {
+		errors["role"] = fmt.Sprintf("role %q is invalid", params.Role)
+	}
+	return errors
+}
+
 func NewUserFromParams(params CreateUserParams) (*User, error) {
 	encpw, err := bcrypt.GenerateFromPassword([]byte(params.Password), bcrypt.DefaultCost)
 
@@ -71,5 +100,6 @@ func NewUserFromParams(params CreateUserParams) (*User, error) {
 		LastName:          params.LastName,
 		Email:             params.Email,
 		EncryptedPassword: string(encpw),
+		Role:              auth.RoleGuest,
 	}, nil
 }
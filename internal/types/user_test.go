@@ -5,6 +5,7 @@ import (
 	"reflect"
 	"testing"
 
+	"github.com/ctchen222/hotel-system/internal/auth"
 	"golang.org/x/crypto/bcrypt"
 )
 
@@ -105,6 +106,7 @@ func TestNewUserFromParams(t *testing.T) {
 				LastName:          "TestLastName",
 				Email:             "EmailTest@gmail.com",
 				EncryptedPassword: string(encpw),
+				Role:              auth.RoleGuest,
 			},
 			wantErr: false,
 		},
@@ -1 +1,50 @@
 package utils
+
+import "time"
+
+const (
+	DateLayout    = "2006-01-02"
+	HotelTimezone = "Asia/Taipei"
+)
+
+// ParseDate parses a YYYY-MM-DD date and returns it in the hotel timezone.
+func ParseDate(value string) (time.Time, error) {
+	loc, err := time.LoadLocation(HotelTimezone)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	date, err := time.Parse(DateLayout, value)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	return date.In(loc), nil
+}
+
+// Nights returns the number of nights between two dates, ignoring the time of
+// day. It is zero when to is not after from.
+func Nights(from, to time.Time) int {
+	from = from.UTC()
+	to = to.UTC()
+	start := time.Date(from.Year(), from.Month(), from.Day(), 0, 0, 0, 0, time.UTC)
+	end := time.Date(to.Year(), to.Month(), to.Day(), 0, 0, 0, 0, time.UTC)
+	if !end.After(start) {
+		return 0
+	}
+	return int(end.Sub(start).Hours() / 24)
+}
+
+// StayNights returns the calendar nights (as UTC midnights) covered by the
+// half-open range [from, to).
+func StayNights(from, to time.Time) []time.Time {
+	from = from.UTC()
+	night := time.Date(from.Year(), from.Month(), from.Day(), 0, 0, 0, 0, time.UTC)
+
+	var nights []time.Time
+	for night.Before(to) {
+		nights = append(nights, night)
+		night = night.AddDate(0, 0, 1)
+	}
+	return nights
+}
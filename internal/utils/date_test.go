@@ -0,0 +1,34 @@
+package utils
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestStayNights(t *testing.T) {
+	day := func(d int) time.Time {
+		return time.Date(2030, time.January, d, 0, 0, 0, 0, time.UTC)
+	}
+	taipei := time.FixedZone("Asia/Taipei", 8*60*60)
+
+	tests := []struct {
+		name string
+		from time.Time
+		to   time.Time
+		want []time.Time
+	}{
+		{name: "single night", from: day(10), to: day(11), want: []time.Time{day(10)}},
+		{name: "three nights", from: day(10), to: day(13), want: []time.Time{day(10), day(11), day(12)}},
+		{name: "empty range", from: day(10), to: day(10), want: nil},
+		{name: "inverted range", from: day(12), to: day(10), want: nil},
+		{name: "non-UTC location", from: day(10).In(taipei), to: day(12).In(taipei), want: []time.Time{day(10), day(11)}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := StayNights(tt.from, tt.to); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("StayNights() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
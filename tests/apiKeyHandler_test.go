@@ -0,0 +1,166 @@
+package api_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/ctchen222/hotel-system/internal/api"
+	"github.com/ctchen222/hotel-system/internal/api/middleware"
+	"github.com/ctchen222/hotel-system/internal/auth"
+	"github.com/ctchen222/hotel-system/internal/db"
+	"github.com/ctchen222/hotel-system/internal/response"
+	"github.com/ctchen222/hotel-system/internal/types"
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/suite"
+)
+
+type APIKeySuiteHandler struct {
+	suite.Suite
+	seed  *seeder
+	store *db.Store
+	app   *fiber.App
+
+	admin *types.User
+}
+
+func (suite *APIKeySuiteHandler) SetupTest() {
+	suite.seed = newSeeder(suite.Require())
+	suite.store = suite.seed.store
+	apiKeyHandler := api.NewAPIKeyHandler(suite.store)
+	issuer := newTestIssuer(suite.Require())
+
+	suite.admin = suite.seed.user(&types.User{
+		Email: "admin@twobao.com",
+		Role:  auth.RoleAdmin,
+	})
+
+	suite.app = fiber.New(fiber.Config{
+		ErrorHandler: response.ErrorHandler,
+	})
+	ok := func(c *fiber.Ctx) error {
+		return c.SendStatus(http.StatusOK)
+	}
+	asAdmin := func(c *fiber.Ctx) error {
+		c.Context().SetUserValue("user", suite.admin)
+		return c.Next()
+	}
+	mongoAuth := middleware.JWTAuthentication(issuer, suite.store.User, suite.store.Session, suite.store.APIKey)
+
+	suite.app.Post("/apikey", asAdmin, apiKeyHandler.HandlePostAPIKey)
+	suite.app.Delete("/apikey/:id", asAdmin, apiKeyHandler.HandleRevokeAPIKey)
+	suite.app.Get("/booking", mongoAuth, middleware.RequirePermission(auth.PermBookingsRead), ok)
+	suite.app.Post("/booking", mongoAuth, middleware.RequirePermission(auth.PermBookingsWrite), ok)
+	suite.app.Get("/session", mongoAuth, middleware.RequireSession(), ok)
+}
+
+func (suite *APIKeySuiteHandler) request(method, path, bearer string, params any) *http.Response {
+	body, _ := json.Marshal(params)
+	req := httptest.NewRequest(method, path, bytes.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	if bearer != "" {
+		req.Header.Set("Authorization", "Bearer "+bearer)
+	}
+	resp, err := suite.app.Test(req)
+	suite.Require().NoError(err)
+	return resp
+}
+
+func (suite *APIKeySuiteHandler) TestAPIKeyHandler_HandlePostAPIKey() {
+	params := types.CreateAPIKeyParams{Name: "channel manager", Permissions: []auth.Permission{auth.PermBookingsRead}}
+	resp := suite.request(http.MethodPost, "/apikey", "", params)
+	suite.Require().Equal(http.StatusOK, resp.StatusCode)
+
+	var body struct {
+		Extras struct {
+			Data types.APIKeyResponse `json:"data"`
+		} `json:"extras"`
+	}
+	suite.NoError(json.NewDecoder(resp.Body).Decode(&body))
+	created, err := suite.store.APIKey.GetAPIKeyById(context.Background(), body.Extras.Data.APIKey.Id)
+	suite.Require().NoError(err)
+	suite.Equal(suite.admin.Id, created.UserId)
+	suite.Equal(params.Permissions, created.Permissions)
+	suite.True(created.Matches(body.Extras.Data.Key))
+
+	// the key that was handed out authenticates
+	suite.Equal(http.StatusOK, suite.request(http.MethodGet, "/booking", body.Extras.Data.Key, nil).StatusCode)
+}
+
+func (suite *APIKeySuiteHandler) TestAPIKeyHandler_HandlePostAPIKey_Invalid() {
+	past := time.Now().Add(-time.Hour)
+	tests := []struct {
+		name   string
+		params types.CreateAPIKeyParams
+		field  string
+	}{
+		{name: "No name", params: types.CreateAPIKeyParams{Permissions: []auth.Permission{auth.PermHotelsRead}}, field: "name"},
+		{name: "No permissions", params: types.CreateAPIKeyParams{Name: "reporting"}, field: "permissions"},
+		{name: "Unknown permission", params: types.CreateAPIKeyParams{Name: "reporting", Permissions: []auth.Permission{"hotels:delete"}}, field: "permissions"},
+		{name: "Expired", params: types.CreateAPIKeyParams{Name: "reporting", Permissions: []auth.Permission{auth.PermHotelsRead}, ExpiresAt: &past}, field: "expiresAt"},
+	}
+	for _, tt := range tests {
+		suite.Run(tt.name, func() {
+			resp := suite.request(http.MethodPost, "/apikey", "", tt.params)
+
+			var body struct {
+				Extras map[string]string `json:"extras"`
+			}
+			suite.NoError(json.NewDecoder(resp.Body).Decode(&body))
+			suite.Contains(body.Extras, tt.field)
+		})
+	}
+}
+
+func (suite *APIKeySuiteHandler) TestAPIKeyHandler_HandleRevokeAPIKey() {
+	apiKey, key := suite.seed.apiKey(suite.admin.Id, []auth.Permission{auth.PermBookingsRead}, nil)
+
+	suite.Equal(http.StatusNotFound, suite.request(http.MethodDelete, "/apikey/missing", "", nil).StatusCode)
+	suite.Equal(http.StatusOK, suite.request(http.MethodDelete, "/apikey/"+apiKey.Id, "", nil).StatusCode)
+	suite.Equal(http.StatusNotFound, suite.request(http.MethodDelete, "/apikey/"+apiKey.Id, "", nil).StatusCode, "a key is revoked once")
+	suite.Equal(http.StatusForbidden, suite.request(http.MethodGet, "/booking", key, nil).StatusCode)
+}
+
+func (suite *APIKeySuiteHandler) TestAPIKeyAuthentication() {
+	reporter := suite.seed.user(&types.User{Email: "reporter@twobao.com", Role: auth.RoleAdmin})
+	apiKey, key := suite.seed.apiKey(reporter.Id, []auth.Permission{auth.PermBookingsRead}, nil)
+
+	suite.Equal(http.StatusOK, suite.request(http.MethodGet, "/booking", key, nil).StatusCode)
+	suite.Equal(http.StatusForbidden, suite.request(http.MethodPost, "/booking", key, nil).StatusCode)
+	suite.Equal(http.StatusForbidden, suite.request(http.MethodGet, "/session", key, nil).StatusCode)
+
+	used, err := suite.store.APIKey.GetAPIKeyById(context.Background(), apiKey.Id)
+	suite.Require().NoError(err)
+	suite.NotNil(used.LastUsedAt)
+}
+
+func (suite *APIKeySuiteHandler) TestAPIKeyAuthentication_Rejected() {
+	expiredAt := time.Now().Add(-time.Minute)
+	tests := []struct {
+		name   string
+		apiKey func(*auth.APIKey)
+		key    func(string) string
+	}{
+		{name: "Revoked", apiKey: func(k *auth.APIKey) { k.RevokedAt = &expiredAt }},
+		{name: "Expired", apiKey: func(k *auth.APIKey) { k.ExpiresAt = &expiredAt }},
+		{name: "Wrong secret", key: func(key string) string { return key + "x" }},
+	}
+	for _, tt := range tests {
+		suite.Run(tt.name, func() {
+			_, key := suite.seed.apiKey(suite.admin.Id, []auth.Permission{auth.PermBookingsRead}, tt.apiKey)
+			if tt.key != nil {
+				key = tt.key(key)
+			}
+
+			suite.Equal(http.StatusForbidden, suite.request(http.MethodGet, "/booking", key, nil).StatusCode)
+		})
+	}
+}
+
+func TestAPIKeySuiteHandler(t *testing.T) {
+	suite.Run(t, new(APIKeySuiteHandler))
+}
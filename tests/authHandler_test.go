@@ -2,70 +2,82 @@ package api_test
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
-	"fmt"
+	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 
 	"github.com/ctchen222/hotel-system/internal/api"
+	"github.com/ctchen222/hotel-system/internal/auth"
 	"github.com/ctchen222/hotel-system/internal/db"
-	"github.com/ctchen222/hotel-system/internal/db/mocks"
+	"github.com/ctchen222/hotel-system/internal/notify"
+	"github.com/ctchen222/hotel-system/internal/response"
 	"github.com/ctchen222/hotel-system/internal/types"
 	"github.com/gofiber/fiber/v2"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/suite"
-	"go.mongodb.org/mongo-driver/bson/primitive"
-	"go.uber.org/mock/gomock"
 )
 
 type AuthSuiteHandler struct {
 	suite.Suite
-	mockUserStore *mocks.MockUserStore
-	authHandler   *api.AuthHandler
-	userHandler   *api.UserHandler
+	seed        *seeder
+	store       *db.Store
+	issuer      *auth.Issuer
+	guard       *auth.LoginGuard
+	authHandler *api.AuthHandler
+	userHandler *api.UserHandler
 
-	userId primitive.ObjectID
-	user   *types.User
+	user *types.User
 }
 
 func (suite *AuthSuiteHandler) SetupTest() {
-	ctrl := gomock.NewController(suite.T())
-	defer ctrl.Finish()
-
-	suite.mockUserStore = mocks.NewMockUserStore(ctrl)
-	store := db.Store{
-		User:    suite.mockUserStore,
-		Hotel:   mocks.NewMockHotelStore(ctrl),
-		Room:    mocks.NewMockRoomStore(ctrl),
-		Booking: mocks.NewMockBookingStore(ctrl),
-	}
-
-	suite.authHandler = api.NewAuthHandler(suite.mockUserStore)
-	suite.userHandler = api.NewUserHandler(&store)
-}
+	suite.seed = newSeeder(suite.Require())
+	suite.store = suite.seed.store
+	suite.issuer = newTestIssuer(suite.Require())
+	suite.guard = auth.NewLoginGuard(auth.DefaultAccountPolicy, auth.DefaultIPPolicy)
+	suite.authHandler = api.NewAuthHandler(suite.store.User, suite.store.Session, suite.store.Token, suite.store.TwoFactor, suite.issuer, suite.guard)
+	suite.userHandler = api.NewUserHandler(suite.store, notify.NewOutbox())
 
-func (suite *AuthSuiteHandler) BeforeTest(suiteName, testName string) {
-	suite.userId = primitive.NewObjectID()
-	suite.user = &types.User{
-		Id:                suite.userId,
+	suite.user = suite.seed.user(&types.User{
 		FirstName:         "TwoBao",
 		LastName:          "Chen",
 		Email:             "twobao@twobao.com",
-		EncryptedPassword: "$2a$10$fXSf7.i3RluVG3GMGPa7FORF2NdWB9Els7veSo13teTYXChpVHJQG",
+		EncryptedPassword: testPasswordHash,
+	})
+}
+
+// authResponse decodes the tokens of a login or refresh response.
+func (suite *AuthSuiteHandler) authResponse(resp *http.Response) types.AuthResponse {
+	var body struct {
+		Extras struct {
+			Data types.AuthResponse `json:"data"`
+		} `json:"extras"`
 	}
+	suite.Require().NoError(json.NewDecoder(resp.Body).Decode(&body))
+	return body.Extras.Data
+}
+
+// session returns the stored session an access token was issued for.
+func (suite *AuthSuiteHandler) session(token string) *auth.Session {
+	claims, err := suite.issuer.Parse(token)
+	suite.Require().NoError(err)
+	suite.Equal(suite.user.Id, claims.Subject)
+	session, err := suite.store.Session.GetSessionById(context.Background(), claims.SessionId)
+	suite.Require().NoError(err)
+	return session
 }
 
 func (suite *AuthSuiteHandler) TestAuthHandler_HandleRegister() {
 	userCreateParams := &types.CreateUserParams{
-		FirstName: "TwoBao",
+		FirstName: "Shaun",
 		LastName:  "Chen",
-		Email:     "twobao@twobao.com",
+		Email:     "shaun@twobao.com",
 		Password:  "test1234",
 	}
 	userBody, _ := json.Marshal(userCreateParams)
 
-	suite.mockUserStore.EXPECT().Create(gomock.Any(), gomock.Any()).Return(suite.user, nil)
-
 	app := fiber.New()
 	app.Post("/register", suite.userHandler.HandlePostUser)
 	req := httptest.NewRequest("POST", "/register", bytes.NewReader(userBody))
@@ -73,58 +85,287 @@ func (suite *AuthSuiteHandler) TestAuthHandler_HandleRegister() {
 	resp, _ := app.Test(req)
 
 	assert.Equal(suite.T(), 200, resp.StatusCode)
+
+	// the new user can log in
+	resp = suite.postJSON(suite.newRefreshApp(), "/login", types.AuthParams{Email: userCreateParams.Email, Password: userCreateParams.Password})
+	assert.Equal(suite.T(), 200, resp.StatusCode)
 }
 
 func (suite *AuthSuiteHandler) TestAuthHandler_HandleLogin() {
-	type args struct {
-		params types.AuthParams
-	}
-	type want struct {
-		User *types.User
+	app := suite.newRefreshApp()
+	resp := suite.postJSON(app, "/login", types.AuthParams{Email: suite.user.Email, Password: testPassword})
+	suite.Require().Equal(http.StatusOK, resp.StatusCode)
+
+	data := suite.authResponse(resp)
+	session := suite.session(data.Token)
+	suite.Equal(suite.user.Id, session.UserId)
+	suite.True(session.Matches(data.RefreshToken))
+}
+
+func (suite *AuthSuiteHandler) TestAuthHandler_HandleLogin_TwoFactor() {
+	suite.seed.twoFactor(suite.user.Id)
+
+	app := suite.newRefreshApp()
+	resp := suite.postJSON(app, "/login", types.AuthParams{Email: suite.user.Email, Password: testPassword})
+	suite.Equal(http.StatusOK, resp.StatusCode)
+
+	data := suite.authResponse(resp)
+	suite.True(data.TwoFactorRequired)
+	suite.Empty(data.Token)
+	suite.Empty(data.RefreshToken)
+	challenge, err := suite.store.Token.Consume(context.Background(), auth.PurposeLoginChallenge, auth.HashToken(data.Challenge), time.Now())
+	suite.Require().NoError(err)
+	suite.Equal(suite.user.Id, challenge.UserId)
+}
+
+func (suite *AuthSuiteHandler) TestAuthHandler_HandleLoginTwoFactor() {
+	totp := func(tf *auth.TwoFactor) string {
+		code, err := auth.TOTPCode(tf.Secret, time.Now())
+		suite.Require().NoError(err)
+		return code
 	}
+
 	tests := []struct {
-		name    string
-		args    args
-		want    want
-		wantErr bool
+		name string
+		code func(tf *auth.TwoFactor, recoveryCodes []string) string
+		// replay logs in with the code once before the request under test
+		replay bool
+		check  func(tf *auth.TwoFactor)
+		want   int
 	}{
 		{
-			name: "Test login success",
-			args: args{
-				params: types.AuthParams{
-					Email:    "twobao@twbao.com",
-					Password: "test1234",
-				},
-			},
-			want: want{
-				User: suite.user,
+			name: "TOTP code",
+			code: func(tf *auth.TwoFactor, _ []string) string { return totp(tf) },
+			want: http.StatusOK,
+		},
+		{
+			name: "Recovery code",
+			code: func(_ *auth.TwoFactor, recoveryCodes []string) string { return recoveryCodes[0] },
+			check: func(tf *auth.TwoFactor) {
+				used, err := suite.store.TwoFactor.GetTwoFactor(context.Background(), suite.user.Id)
+				suite.Require().NoError(err)
+				suite.Len(used.RecoveryCodes, auth.RecoveryCodeCount-1)
+				suite.NotContains(used.RecoveryCodes, tf.RecoveryCodes[0])
 			},
-			wantErr: false,
+			want: http.StatusOK,
+		},
+		{
+			name:   "Replayed TOTP code",
+			code:   func(tf *auth.TwoFactor, _ []string) string { return totp(tf) },
+			replay: true,
+			want:   http.StatusUnauthorized,
+		},
+		{
+			name: "Wrong code",
+			code: func(_ *auth.TwoFactor, _ []string) string { return "not-a-code" },
+			want: http.StatusUnauthorized,
 		},
 	}
 
-	suite.mockUserStore.EXPECT().GetUserByEmail(gomock.Any(), gomock.Any()).Return(suite.user, nil).Times(1)
-
-	app := fiber.New()
-	app.Post("/login", suite.authHandler.HandleLogin)
 	for _, tt := range tests {
 		suite.Run(tt.name, func() {
-			userBody, _ := json.Marshal(tt.args.params)
+			suite.SetupTest()
+			app := suite.newRefreshApp()
+			tf, recoveryCodes := suite.seed.twoFactor(suite.user.Id)
+			code := tt.code(tf, recoveryCodes)
+			if tt.replay {
+				challenge := suite.seed.token(suite.user.Id, auth.PurposeLoginChallenge, auth.LoginChallengeTTL)
+				resp := suite.postJSON(app, "/login/2fa", types.TwoFactorLoginParams{Challenge: challenge, Code: code})
+				suite.Require().Equal(http.StatusOK, resp.StatusCode)
+			}
 
-			req := httptest.NewRequest("POST", "/login", bytes.NewReader(userBody))
-			req.Header.Set("Content-Type", "application/json")
+			challenge := suite.seed.token(suite.user.Id, auth.PurposeLoginChallenge, auth.LoginChallengeTTL)
+			resp := suite.postJSON(app, "/login/2fa", types.TwoFactorLoginParams{Challenge: challenge, Code: code})
+			suite.Equal(tt.want, resp.StatusCode)
+			if tt.want == http.StatusOK {
+				suite.session(suite.authResponse(resp).Token)
+			}
+			if tt.check != nil {
+				tt.check(tf)
+			}
+		})
+	}
+}
 
-			resp, _ := app.Test(req)
+func (suite *AuthSuiteHandler) TestAuthHandler_HandleLoginTwoFactor_InvalidChallenge() {
+	tf, _ := suite.seed.twoFactor(suite.user.Id)
+	code, err := auth.TOTPCode(tf.Secret, time.Now())
+	suite.Require().NoError(err)
+	expired := suite.seed.token(suite.user.Id, auth.PurposeLoginChallenge, -time.Minute)
+	reset := suite.seed.token(suite.user.Id, auth.PurposePasswordReset, auth.PasswordResetTTL)
 
-			if err := json.NewDecoder(resp.Body).Decode(tt.want.User); err != nil {
-				fmt.Println(err)
-			}
-			assert.Equal(suite.T(), 200, resp.StatusCode)
-			assert.Equal(suite.T(), tt.want.User, suite.user)
+	app := suite.newRefreshApp()
+	for _, challenge := range []string{"unknown", expired, reset} {
+		resp := suite.postJSON(app, "/login/2fa", types.TwoFactorLoginParams{Challenge: challenge, Code: code})
+		suite.Equal(http.StatusUnauthorized, resp.StatusCode)
+	}
+}
+
+func (suite *AuthSuiteHandler) TestAuthHandler_HandleLogin_InvalidCredentials() {
+	decode := func(resp *http.Response) response.Error {
+		var body response.Error
+		suite.NoError(json.NewDecoder(resp.Body).Decode(&body))
+		return body
+	}
+
+	app := suite.newRefreshApp()
+	unknown := suite.postJSON(app, "/login", types.AuthParams{Email: "nobody@twobao.com", Password: testPassword})
+	wrong := suite.postJSON(app, "/login", types.AuthParams{Email: suite.user.Email, Password: "wrong"})
+
+	suite.Equal(http.StatusUnauthorized, unknown.StatusCode)
+	suite.Equal(http.StatusUnauthorized, wrong.StatusCode)
+	suite.Equal(decode(unknown), decode(wrong))
+}
+
+func (suite *AuthSuiteHandler) TestAuthHandler_HandleLogin_Throttled() {
+	app := suite.newRefreshApp()
+	for range auth.DefaultAccountPolicy.FreeAttempts {
+		resp := suite.postJSON(app, "/login", types.AuthParams{Email: suite.user.Email, Password: "wrong"})
+		suite.Equal(http.StatusUnauthorized, resp.StatusCode)
+	}
+
+	// The right password has to wait too, or guessing could go on.
+	resp := suite.postJSON(app, "/login", types.AuthParams{Email: suite.user.Email, Password: testPassword})
+	suite.Equal(http.StatusTooManyRequests, resp.StatusCode)
+	suite.NotEmpty(resp.Header.Get(fiber.HeaderRetryAfter))
+
+	resp = suite.postJSON(app, "/user/"+suite.user.Id+"/unlock", nil)
+	suite.Equal(http.StatusOK, resp.StatusCode)
+
+	resp = suite.postJSON(app, "/login", types.AuthParams{Email: suite.user.Email, Password: testPassword})
+	suite.Equal(http.StatusOK, resp.StatusCode)
+}
+
+func (suite *AuthSuiteHandler) TestAuthHandler_HandleLoginTwoFactor_Throttled() {
+	suite.seed.twoFactor(suite.user.Id)
+
+	// The right password doesn't reset the failures of wrong codes, or each
+	// new challenge would allow more guesses.
+	app := suite.newRefreshApp()
+	for range auth.DefaultAccountPolicy.FreeAttempts {
+		resp := suite.postJSON(app, "/login", types.AuthParams{Email: suite.user.Email, Password: testPassword})
+		suite.Require().Equal(http.StatusOK, resp.StatusCode)
+		challenge := suite.authResponse(resp).Challenge
+
+		resp = suite.postJSON(app, "/login/2fa", types.TwoFactorLoginParams{Challenge: challenge, Code: "not-a-code"})
+		suite.Equal(http.StatusUnauthorized, resp.StatusCode)
+	}
+
+	resp := suite.postJSON(app, "/login", types.AuthParams{Email: suite.user.Email, Password: testPassword})
+	suite.Equal(http.StatusTooManyRequests, resp.StatusCode)
+	suite.NotEmpty(resp.Header.Get(fiber.HeaderRetryAfter))
+}
+
+func (suite *AuthSuiteHandler) postJSON(app *fiber.App, path string, params any) *http.Response {
+	body, _ := json.Marshal(params)
+	req := httptest.NewRequest(http.MethodPost, path, bytes.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := app.Test(req)
+	suite.Require().NoError(err)
+	return resp
+}
+
+func (suite *AuthSuiteHandler) newRefreshApp() *fiber.App {
+	app := fiber.New(fiber.Config{
+		ErrorHandler: response.ErrorHandler,
+	})
+	app.Post("/login", suite.authHandler.HandleLogin)
+	app.Post("/login/2fa", suite.authHandler.HandleLoginTwoFactor)
+	app.Post("/refresh", suite.authHandler.HandleRefresh)
+	app.Post("/logout", suite.authHandler.HandleLogout)
+	app.Post("/user/:id/unlock", suite.authHandler.HandleUnlockUser)
+	return app
+}
+
+func (suite *AuthSuiteHandler) postRefreshToken(app *fiber.App, path, refreshToken string) *http.Response {
+	body, _ := json.Marshal(types.RefreshParams{RefreshToken: refreshToken})
+	req := httptest.NewRequest(http.MethodPost, path, bytes.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := app.Test(req)
+	suite.Require().NoError(err)
+	return resp
+}
+
+func (suite *AuthSuiteHandler) TestAuthHandler_HandleRefresh() {
+	session, refreshToken := suite.seed.session(suite.user.Id)
+
+	resp := suite.postRefreshToken(suite.newRefreshApp(), "/refresh", refreshToken)
+	suite.Equal(http.StatusOK, resp.StatusCode)
+
+	data := suite.authResponse(resp)
+	rotated := suite.session(data.Token)
+	suite.Equal(session.Id, rotated.Id)
+	suite.NotEqual(refreshToken, data.RefreshToken)
+	suite.True(rotated.Matches(data.RefreshToken))
+	suite.False(rotated.Matches(refreshToken))
+}
+
+func (suite *AuthSuiteHandler) TestAuthHandler_HandleRefresh_Reused() {
+	session, refreshToken := suite.seed.session(suite.user.Id)
+
+	app := suite.newRefreshApp()
+	resp := suite.postRefreshToken(app, "/refresh", refreshToken)
+	suite.Require().Equal(http.StatusOK, resp.StatusCode)
+	rotatedToken := suite.authResponse(resp).RefreshToken
+
+	// Reusing a rotated token ends the session, so the new token stops
+	// working as well.
+	resp = suite.postRefreshToken(app, "/refresh", refreshToken)
+	suite.Equal(http.StatusUnauthorized, resp.StatusCode)
+	resp = suite.postRefreshToken(app, "/refresh", rotatedToken)
+	suite.Equal(http.StatusUnauthorized, resp.StatusCode)
+
+	stored, err := suite.store.Session.GetSessionById(context.Background(), session.Id)
+	suite.Require().NoError(err)
+	suite.NotNil(stored.RevokedAt)
+}
+
+func (suite *AuthSuiteHandler) TestAuthHandler_HandleRefresh_Rejected() {
+	tests := []struct {
+		name    string
+		session func() string
+	}{
+		{name: "Malformed token", session: func() string { return "not-a-token" }},
+		{name: "Unknown session", session: func() string {
+			_, refreshToken, err := auth.NewSession(suite.user.Id, time.Now())
+			suite.Require().NoError(err)
+			return refreshToken
+		}},
+		{name: "Revoked session", session: func() string {
+			session, refreshToken := suite.seed.session(suite.user.Id)
+			suite.Require().NoError(suite.store.Session.Revoke(context.Background(), session.Id))
+			return refreshToken
+		}},
+		{name: "Expired session", session: func() string {
+			session, refreshToken, err := auth.NewSession(suite.user.Id, time.Now().Add(-auth.RefreshTokenTTL-time.Minute))
+			suite.Require().NoError(err)
+			suite.Require().NoError(suite.store.Session.Insert(context.Background(), session))
+			return refreshToken
+		}},
+	}
+
+	app := suite.newRefreshApp()
+	for _, tt := range tests {
+		suite.Run(tt.name, func() {
+			resp := suite.postRefreshToken(app, "/refresh", tt.session())
+			suite.Equal(http.StatusUnauthorized, resp.StatusCode)
 		})
 	}
 }
 
+func (suite *AuthSuiteHandler) TestAuthHandler_HandleLogout() {
+	session, refreshToken := suite.seed.session(suite.user.Id)
+
+	app := suite.newRefreshApp()
+	resp := suite.postRefreshToken(app, "/logout", refreshToken)
+	suite.Equal(http.StatusOK, resp.StatusCode)
+
+	stored, err := suite.store.Session.GetSessionById(context.Background(), session.Id)
+	suite.Require().NoError(err)
+	suite.NotNil(stored.RevokedAt)
+	suite.Equal(http.StatusUnauthorized, suite.postRefreshToken(app, "/refresh", refreshToken).StatusCode)
+}
+
 func TestAuthSuiteHandler(t *testing.T) {
 	suite.Run(t, new(AuthSuiteHandler))
 }
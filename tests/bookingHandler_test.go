@@ -0,0 +1,520 @@
+package api_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ctchen222/hotel-system/internal/api"
+	"github.com/ctchen222/hotel-system/internal/cancellation"
+	"github.com/ctchen222/hotel-system/internal/db"
+	"github.com/ctchen222/hotel-system/internal/db/mocks"
+	"github.com/ctchen222/hotel-system/internal/holds"
+	"github.com/ctchen222/hotel-system/internal/payments"
+	"github.com/ctchen222/hotel-system/internal/pricing"
+	"github.com/ctchen222/hotel-system/internal/response"
+	"github.com/ctchen222/hotel-system/internal/types"
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/suite"
+	"go.uber.org/mock/gomock"
+)
+
+type BookingSuiteHandler struct {
+	suite.Suite
+	seed           *seeder
+	store          *db.Store
+	provider       *payments.FakeProvider
+	bookingHandler *api.BookingHandler
+
+	user    *types.User
+	hotel   *types.Hotel
+	room    *types.Room
+	booking *types.Booking
+}
+
+func (suite *BookingSuiteHandler) SetupTest() {
+	suite.seed = newSeeder(suite.Require())
+	suite.store = suite.seed.store
+	suite.provider = payments.NewFakeProvider()
+	suite.bookingHandler = suite.newHandler(suite.store)
+
+	suite.user = suite.seed.user(&types.User{Email: "guest@twobao.com"})
+	suite.hotel = suite.seed.hotel(&types.Hotel{
+		Name: "Hotel 1",
+		CancellationPolicy: cancellation.Policy{
+			FreeCancellationDays: 7,
+			PenaltyPercent:       50,
+		},
+	})
+	suite.room = suite.seed.room(&types.Room{
+		Price:    100,
+		Capacity: 2,
+		HotelId:  suite.hotel.Id,
+	})
+	from := time.Now().AddDate(0, 0, 2).Truncate(24 * time.Hour)
+	price, err := pricing.NewDefaultEngine().Quote(10000, nil, from, from.AddDate(0, 0, 3))
+	suite.Require().NoError(err)
+	suite.booking = suite.seed.booking(&types.Booking{
+		UserId:    suite.user.Id,
+		RoomId:    suite.room.Id,
+		NumPerson: 2,
+		From:      from,
+		To:        from.AddDate(0, 0, 3),
+		Price:     price,
+		Status:    types.BookingConfirmed,
+	})
+}
+
+func (suite *BookingSuiteHandler) newHandler(store *db.Store) *api.BookingHandler {
+	return api.NewBookingHandler(store, pricing.NewDefaultEngine(), payments.NewProcessor(suite.provider), holds.DefaultDuration)
+}
+
+func (suite *BookingSuiteHandler) newApp() *fiber.App {
+	app := fiber.New(fiber.Config{
+		ErrorHandler: response.ErrorHandler,
+	})
+	app.Use(func(c *fiber.Ctx) error {
+		c.Context().SetUserValue("user", suite.user)
+		return c.Next()
+	})
+	app.Post("/booking/hold", suite.bookingHandler.HandleHoldBooking)
+	app.Post("/booking/:id/confirm", suite.bookingHandler.HandleConfirmBooking)
+	app.Patch("/booking/:id", suite.bookingHandler.HandleUpdateBooking)
+	app.Post("/booking/:id/cancel", suite.bookingHandler.HandleCancelBooking)
+	return app
+}
+
+func (suite *BookingSuiteHandler) storedBooking(id string) *types.Booking {
+	booking, err := suite.store.Booking.GetBookingById(context.Background(), id)
+	suite.Require().NoError(err)
+	return booking
+}
+
+func (suite *BookingSuiteHandler) storedPayments(bookingId string) []*types.Payment {
+	bookingPayments, err := suite.store.Payment.GetPaymentsByBookingId(context.Background(), bookingId)
+	suite.Require().NoError(err)
+	return bookingPayments
+}
+
+func (suite *BookingSuiteHandler) TestBookingHandler_HandleCancelBooking() {
+	suite.seed.payment(suite.provider, suite.booking)
+
+	req := httptest.NewRequest(http.MethodPost, "/booking/"+suite.booking.Id+"/cancel", nil)
+	resp, err := suite.newApp().Test(req)
+	suite.NoError(err)
+	suite.Equal(http.StatusOK, resp.StatusCode)
+
+	var body struct {
+		Extras struct {
+			Data *types.Booking `json:"data"`
+		} `json:"extras"`
+	}
+	suite.NoError(json.NewDecoder(resp.Body).Decode(&body))
+	suite.Equal(types.BookingCancelled, body.Extras.Data.Status)
+	// inside the 7 day window: half of 3 nights at 100 plus tax and fees
+	suite.Equal(pricing.Money(17250), body.Extras.Data.RefundAmount)
+
+	cancelled := suite.storedBooking(suite.booking.Id)
+	suite.Equal(types.BookingCancelled, cancelled.Status)
+	suite.Equal(pricing.Money(17250), cancelled.RefundAmount)
+	bookingPayments := suite.storedPayments(suite.booking.Id)
+	suite.Require().Len(bookingPayments, 1)
+	suite.Equal(payments.StatusCaptured, bookingPayments[0].Status)
+	suite.Equal(pricing.Money(17250), bookingPayments[0].Refunded)
+}
+
+func (suite *BookingSuiteHandler) TestBookingHandler_HandleCancelBooking_Rejected() {
+	tests := []struct {
+		name       string
+		setup      func()
+		wantStatus int
+	}{
+		{
+			name: "Booking of another guest",
+			setup: func() {
+				suite.user = suite.seed.user(&types.User{Email: "other@twobao.com"})
+			},
+			wantStatus: http.StatusForbidden,
+		},
+		{
+			name: "Already cancelled",
+			setup: func() {
+				_, err := suite.store.Booking.CancelBooking(context.Background(), suite.booking.Id, 0)
+				suite.Require().NoError(err)
+			},
+			wantStatus: http.StatusConflict,
+		},
+	}
+
+	for _, tt := range tests {
+		suite.Run(tt.name, func() {
+			suite.SetupTest()
+			tt.setup()
+
+			req := httptest.NewRequest(http.MethodPost, "/booking/"+suite.booking.Id+"/cancel", nil)
+			resp, err := suite.newApp().Test(req)
+			suite.NoError(err)
+			suite.Equal(tt.wantStatus, resp.StatusCode)
+		})
+	}
+}
+
+func (suite *BookingSuiteHandler) TestBookingHandler_HandleCancelBooking_RefundFails() {
+	suite.seed.payment(suite.provider, suite.booking)
+
+	app := suite.newApp()
+	cancel := func() *http.Response {
+		resp, err := app.Test(httptest.NewRequest(http.MethodPost, "/booking/"+suite.booking.Id+"/cancel", nil))
+		suite.Require().NoError(err)
+		return resp
+	}
+
+	suite.provider.RefundErr = payments.ErrUnavailable
+	suite.Equal(http.StatusInternalServerError, cancel().StatusCode)
+	suite.Equal(types.BookingCancelled, suite.storedBooking(suite.booking.Id).Status)
+	suite.Equal(pricing.Money(0), suite.storedPayments(suite.booking.Id)[0].Refunded)
+
+	// cancelling again retries the refund
+	suite.provider.RefundErr = nil
+	suite.Equal(http.StatusOK, cancel().StatusCode)
+	suite.Equal(pricing.Money(17250), suite.storedPayments(suite.booking.Id)[0].Refunded)
+
+	// once refunded, there is nothing left to retry
+	suite.Equal(http.StatusConflict, cancel().StatusCode)
+	suite.Equal(pricing.Money(17250), suite.storedPayments(suite.booking.Id)[0].Refunded)
+}
+
+func (suite *BookingSuiteHandler) TestBookingHandler_HandleUpdateBooking() {
+	newTo := suite.booking.To.AddDate(0, 0, 1)
+	// discount gives the booking a promo code of the hotel that needs a stay
+	// of three nights
+	discount := func() {
+		promoCode := suite.seed.promoCode(&types.PromoCode{
+			Promotion: pricing.Promotion{
+				Code:      "STAY3",
+				Kind:      pricing.PromotionPercentage,
+				Percent:   10,
+				ValidFrom: time.Now().AddDate(0, 0, -1),
+				ValidTo:   time.Now().AddDate(0, 0, 1),
+				MinNights: 3,
+			},
+			HotelId: suite.hotel.Id,
+		})
+		discounted := *suite.booking
+		discounted.Price = pricing.NewDefaultEngine().ApplyPromotion(suite.booking.Price, promoCode.Promotion)
+		updated, err := suite.store.Booking.UpdateBooking(context.Background(), suite.booking, &discounted)
+		suite.Require().NoError(err)
+		*suite.booking = *updated
+	}
+
+	tests := []struct {
+		name       string
+		body       func() string
+		check      func(booking *types.Booking)
+		wantStatus int
+	}{
+		{
+			name: "Extend the stay and move to a seaside room",
+			body: func() string {
+				seasideRoom := suite.seed.room(&types.Room{SeaSide: true, Price: 150, Capacity: 2, HotelId: suite.hotel.Id})
+				return `{"to":"` + newTo.Format("2006-01-02") + `","roomId":"` + seasideRoom.Id + `"}`
+			},
+			check: func(booking *types.Booking) {
+				suite.NotEqual(suite.room.Id, booking.RoomId)
+				suite.True(suite.booking.From.Equal(booking.From))
+				suite.True(newTo.Equal(booking.To))
+				// 4 nights at the seaside rate
+				suite.Equal(pricing.Money(60000), booking.Price.RoomTotal)
+				suite.Equal(pricing.Money(69000), booking.Price.Total)
+			},
+			wantStatus: http.StatusOK,
+		},
+		{
+			name: "New dates clash with another booking",
+			body: func() string {
+				suite.seed.booking(&types.Booking{UserId: suite.user.Id, RoomId: suite.room.Id, NumPerson: 1, From: suite.booking.To, To: newTo})
+				return `{"to":"` + newTo.Format("2006-01-02") + `"}`
+			},
+			check: func(booking *types.Booking) {
+				suite.True(suite.booking.To.Equal(booking.To))
+			},
+			wantStatus: http.StatusConflict,
+		},
+		{
+			name: "Rate plan prices the modified stay",
+			body: func() string {
+				suite.seed.ratePlan(&types.RatePlan{RoomId: suite.room.Id, RatePlan: pricing.RatePlan{
+					Name:           "Double",
+					ValidFrom:      suite.booking.From,
+					ValidTo:        newTo,
+					WeekdayPercent: 200,
+					WeekendPercent: 200,
+				}})
+				return `{"to":"` + newTo.Format("2006-01-02") + `"}`
+			},
+			check: func(booking *types.Booking) {
+				// 4 nights at twice the room rate
+				suite.Equal(pricing.Money(80000), booking.Price.RoomTotal)
+				suite.Len(booking.Price.NightlyRates, 4)
+			},
+			wantStatus: http.StatusOK,
+		},
+		{
+			name: "More guests than the room sleeps",
+			body: func() string {
+				singleRoom := suite.seed.room(&types.Room{Price: 80, Capacity: 1, HotelId: suite.hotel.Id})
+				return `{"roomId":"` + singleRoom.Id + `"}`
+			},
+			check: func(booking *types.Booking) {
+				suite.Equal(suite.room.Id, booking.RoomId)
+			},
+			wantStatus: http.StatusUnprocessableEntity,
+		},
+		{
+			name: "Price of a paid booking can't change",
+			body: func() string {
+				suite.seed.payment(suite.provider, suite.booking)
+				return `{"to":"` + newTo.Format("2006-01-02") + `"}`
+			},
+			check: func(booking *types.Booking) {
+				suite.True(suite.booking.To.Equal(booking.To))
+				suite.Equal(suite.booking.Price.Total, booking.Price.Total)
+			},
+			wantStatus: http.StatusConflict,
+		},
+		{
+			name: "Paid booking may change what keeps its price",
+			body: func() string {
+				suite.seed.payment(suite.provider, suite.booking)
+				return `{"numPerson":1}`
+			},
+			check: func(booking *types.Booking) {
+				suite.Equal(1, booking.NumPerson)
+			},
+			wantStatus: http.StatusOK,
+		},
+		{
+			name: "Promo code is kept for the new stay",
+			body: func() string {
+				discount()
+				return `{"to":"` + newTo.Format("2006-01-02") + `"}`
+			},
+			check: func(booking *types.Booking) {
+				suite.Equal("STAY3", booking.Price.PromoCode)
+				// 10% off 4 nights
+				suite.Equal(pricing.Money(4000), booking.Price.Discount)
+			},
+			wantStatus: http.StatusOK,
+		},
+		{
+			name: "Promo code is not valid at the new room's hotel",
+			body: func() string {
+				discount()
+				otherHotel := suite.seed.hotel(&types.Hotel{Name: "Hotel 2"})
+				otherRoom := suite.seed.room(&types.Room{Price: 100, Capacity: 2, HotelId: otherHotel.Id})
+				return `{"roomId":"` + otherRoom.Id + `"}`
+			},
+			check: func(booking *types.Booking) {
+				suite.Equal(suite.room.Id, booking.RoomId)
+			},
+			wantStatus: http.StatusUnprocessableEntity,
+		},
+		{
+			name: "Stay shorter than the promo code's minimum",
+			body: func() string {
+				discount()
+				return `{"to":"` + suite.booking.From.AddDate(0, 0, 2).Format("2006-01-02") + `"}`
+			},
+			check: func(booking *types.Booking) {
+				suite.True(suite.booking.To.Equal(booking.To))
+				suite.Equal(suite.booking.Price, booking.Price)
+			},
+			wantStatus: http.StatusUnprocessableEntity,
+		},
+		{
+			name:       "Unknown room",
+			body:       func() string { return `{"roomId":"not-an-id"}` },
+			wantStatus: http.StatusNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		suite.Run(tt.name, func() {
+			suite.SetupTest()
+
+			req := httptest.NewRequest(http.MethodPatch, "/booking/"+suite.booking.Id, strings.NewReader(tt.body()))
+			req.Header.Set("Content-Type", "application/json")
+			resp, err := suite.newApp().Test(req)
+			suite.NoError(err)
+			suite.Equal(tt.wantStatus, resp.StatusCode)
+			if tt.check != nil {
+				tt.check(suite.storedBooking(suite.booking.Id))
+			}
+		})
+	}
+}
+
+func (suite *BookingSuiteHandler) TestBookingHandler_HandleHoldBooking() {
+	from := time.Now().AddDate(0, 0, 10)
+	body, _ := json.Marshal(types.BookingHoldParams{
+		BookingRawParams: types.BookingRawParams{
+			From:      from.Format("2006-01-02"),
+			To:        from.AddDate(0, 0, 2).Format("2006-01-02"),
+			NumPerson: 2,
+		},
+		RoomId: suite.room.Id,
+	})
+	req := httptest.NewRequest(http.MethodPost, "/booking/hold", strings.NewReader(string(body)))
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := suite.newApp().Test(req)
+	suite.NoError(err)
+	suite.Equal(http.StatusOK, resp.StatusCode)
+
+	var respBody struct {
+		Extras struct {
+			Data *types.Booking `json:"data"`
+		} `json:"extras"`
+	}
+	suite.NoError(json.NewDecoder(resp.Body).Decode(&respBody))
+	hold := suite.storedBooking(respBody.Extras.Data.Id)
+	suite.Equal(types.BookingPending, hold.Status)
+	suite.Require().NotNil(hold.ExpiresAt)
+	suite.WithinDuration(time.Now().Add(holds.DefaultDuration), *hold.ExpiresAt, time.Minute)
+}
+
+func (suite *BookingSuiteHandler) TestBookingHandler_HandleConfirmBooking() {
+	tests := []struct {
+		name         string
+		paymentToken string
+		// confirmErr makes the store fail to confirm, as when the hold
+		// expires or is confirmed by another request after it was read
+		confirmErr    error
+		captureErr    error
+		wantPayment   payments.Status
+		wantConfirmed bool
+		wantStatus    int
+	}{
+		{name: "Hold is confirmed", paymentToken: "tok_visa", wantPayment: payments.StatusCaptured, wantConfirmed: true, wantStatus: http.StatusOK},
+		{name: "Hold has expired", paymentToken: "tok_visa", confirmErr: db.ErrHoldExpired, wantPayment: payments.StatusVoided, wantStatus: http.StatusGone},
+		{name: "Booking is not a hold", paymentToken: "tok_visa", confirmErr: db.ErrInvalidBookingTransition, wantPayment: payments.StatusVoided, wantStatus: http.StatusConflict},
+		{name: "Capture fails", paymentToken: "tok_visa", captureErr: payments.ErrUnavailable, wantPayment: payments.StatusAuthorized, wantConfirmed: true, wantStatus: http.StatusBadGateway},
+		{name: "Payment is declined", paymentToken: payments.DeclinedSource, wantStatus: http.StatusPaymentRequired},
+	}
+
+	for _, tt := range tests {
+		suite.Run(tt.name, func() {
+			suite.SetupTest()
+			expiresAt := time.Now().Add(time.Minute)
+			from := suite.booking.To.AddDate(0, 0, 7)
+			hold := suite.seed.booking(&types.Booking{
+				UserId:    suite.user.Id,
+				RoomId:    suite.room.Id,
+				NumPerson: 2,
+				From:      from,
+				To:        from.AddDate(0, 0, 3),
+				Price:     suite.booking.Price,
+				Status:    types.BookingPending,
+				ExpiresAt: &expiresAt,
+			})
+			suite.provider.CaptureErr = tt.captureErr
+			if tt.confirmErr != nil {
+				bookingStore := mocks.NewMockBookingStore(gomock.NewController(suite.T()))
+				bookingStore.EXPECT().GetBookingById(gomock.Any(), hold.Id).Return(hold, nil)
+				bookingStore.EXPECT().ConfirmBooking(gomock.Any(), hold.Id).Return(nil, tt.confirmErr)
+				store := *suite.store
+				store.Booking = bookingStore
+				suite.bookingHandler = suite.newHandler(&store)
+			}
+
+			body := `{"paymentToken":"` + tt.paymentToken + `"}`
+			req := httptest.NewRequest(http.MethodPost, "/booking/"+hold.Id+"/confirm", strings.NewReader(body))
+			req.Header.Set("Content-Type", "application/json")
+			resp, err := suite.newApp().Test(req)
+			suite.NoError(err)
+			suite.Equal(tt.wantStatus, resp.StatusCode)
+
+			wantStatus := types.BookingPending
+			if tt.wantConfirmed {
+				wantStatus = types.BookingConfirmed
+			}
+			suite.Equal(wantStatus, suite.storedBooking(hold.Id).Status)
+			bookingPayments := suite.storedPayments(hold.Id)
+			if tt.wantPayment == "" {
+				suite.Empty(bookingPayments)
+				return
+			}
+			suite.Require().Len(bookingPayments, 1)
+			suite.Equal(tt.wantPayment, bookingPayments[0].Status)
+			suite.Equal(hold.Price.Total, bookingPayments[0].Amount)
+		})
+	}
+}
+
+func (suite *BookingSuiteHandler) TestBookingHandler_HandleConfirmBooking_RetriesCapture() {
+	expiresAt := time.Now().Add(time.Minute)
+	from := suite.booking.To.AddDate(0, 0, 7)
+	hold := suite.seed.booking(&types.Booking{
+		UserId:    suite.user.Id,
+		RoomId:    suite.room.Id,
+		NumPerson: 2,
+		From:      from,
+		To:        from.AddDate(0, 0, 3),
+		Price:     suite.booking.Price,
+		Status:    types.BookingPending,
+		ExpiresAt: &expiresAt,
+	})
+
+	app := suite.newApp()
+	confirm := func() *http.Response {
+		req := httptest.NewRequest(http.MethodPost, "/booking/"+hold.Id+"/confirm", strings.NewReader(`{"paymentToken":"tok_visa"}`))
+		req.Header.Set("Content-Type", "application/json")
+		resp, err := app.Test(req)
+		suite.Require().NoError(err)
+		return resp
+	}
+
+	suite.provider.CaptureErr = payments.ErrUnavailable
+	suite.Equal(http.StatusBadGateway, confirm().StatusCode)
+	suite.Equal(types.BookingConfirmed, suite.storedBooking(hold.Id).Status)
+	suite.Equal(payments.StatusAuthorized, suite.storedPayments(hold.Id)[0].Status)
+
+	// confirming again retries the capture
+	suite.provider.CaptureErr = nil
+	suite.Equal(http.StatusOK, confirm().StatusCode)
+	bookingPayments := suite.storedPayments(hold.Id)
+	suite.Require().Len(bookingPayments, 1)
+	suite.Equal(payments.StatusCaptured, bookingPayments[0].Status)
+	suite.Equal(hold.Price.Total, bookingPayments[0].Captured)
+
+	// once captured, there is nothing left to retry
+	suite.Equal(http.StatusConflict, confirm().StatusCode)
+}
+
+// A guest who cancels a booking whose capture failed still pays the
+// cancellation penalty.
+func (suite *BookingSuiteHandler) TestBookingHandler_HandleCancelBooking_CapturesPenalty() {
+	suite.provider.CaptureErr = payments.ErrUnavailable
+	record, err := payments.NewProcessor(suite.provider).Pay(context.Background(), suite.booking.Price.Total, suite.booking.Price.Currency, "tok_visa", func() error { return nil })
+	suite.Require().ErrorIs(err, payments.ErrCaptureFailed)
+	_, err = suite.store.Payment.Insert(context.Background(), &types.Payment{BookingId: suite.booking.Id, Record: *record})
+	suite.Require().NoError(err)
+	suite.provider.CaptureErr = nil
+
+	resp, err := suite.newApp().Test(httptest.NewRequest(http.MethodPost, "/booking/"+suite.booking.Id+"/cancel", nil))
+	suite.Require().NoError(err)
+	suite.Equal(http.StatusOK, resp.StatusCode)
+
+	bookingPayments := suite.storedPayments(suite.booking.Id)
+	suite.Require().Len(bookingPayments, 1)
+	suite.Equal(payments.StatusCaptured, bookingPayments[0].Status)
+	suite.Equal(suite.booking.Price.Total, bookingPayments[0].Captured)
+	suite.Equal(pricing.Money(17250), bookingPayments[0].Refunded)
+}
+
+func TestBookingSuiteHandler(t *testing.T) {
+	suite.Run(t, new(BookingSuiteHandler))
+}
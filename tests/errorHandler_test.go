@@ -0,0 +1,38 @@
+package api_test
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/ctchen222/hotel-system/internal/db"
+	"github.com/ctchen222/hotel-system/internal/response"
+	"github.com/gofiber/fiber/v2"
+)
+
+func TestFromError(t *testing.T) {
+	tests := []struct {
+		name       string
+		err        error
+		wantStatus int
+	}{
+		{name: "API error", err: response.ErrHoldExpired(), wantStatus: http.StatusGone},
+		{name: "Not found", err: db.ErrNotFound, wantStatus: http.StatusNotFound},
+		{name: "Wrapped not found", err: fmt.Errorf("loading room: %w", db.ErrNotFound), wantStatus: http.StatusNotFound},
+		{name: "Specific conflict", err: db.ErrEmailExists, wantStatus: http.StatusConflict},
+		{name: "Booking conflict", err: &db.BookingConflictError{RoomId: "1"}, wantStatus: http.StatusConflict},
+		{name: "Conflict", err: &db.Error{Kind: db.ErrConflict, Message: "record is still referenced"}, wantStatus: http.StatusConflict},
+		{name: "Validation", err: &db.Error{Kind: db.ErrValidation, Message: "invalid"}, wantStatus: http.StatusUnprocessableEntity},
+		{name: "Forbidden", err: &db.Error{Kind: db.ErrForbidden, Message: "forbidden"}, wantStatus: http.StatusForbidden},
+		{name: "Fiber error", err: fiber.ErrMethodNotAllowed, wantStatus: http.StatusMethodNotAllowed},
+		{name: "Unknown error", err: errors.New("connection refused"), wantStatus: http.StatusInternalServerError},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := response.FromError(tt.err); got.Code != tt.wantStatus {
+				t.Errorf("FromError() code = %d, want %d", got.Code, tt.wantStatus)
+			}
+		})
+	}
+}
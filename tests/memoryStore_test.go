@@ -0,0 +1,222 @@
+package api_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/ctchen222/hotel-system/internal/api"
+	"github.com/ctchen222/hotel-system/internal/auth"
+	"github.com/ctchen222/hotel-system/internal/db"
+	"github.com/ctchen222/hotel-system/internal/holds"
+	"github.com/ctchen222/hotel-system/internal/notify"
+	"github.com/ctchen222/hotel-system/internal/payments"
+	"github.com/ctchen222/hotel-system/internal/pricing"
+	"github.com/ctchen222/hotel-system/internal/response"
+	"github.com/ctchen222/hotel-system/internal/types"
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/suite"
+)
+
+// MemorySuiteHandler drives whole flows through the routes, from creating
+// hotels to cancelling bookings.
+type MemorySuiteHandler struct {
+	suite.Suite
+	store *db.Store
+	app   *fiber.App
+
+	admin *types.User
+	guest *types.User
+}
+
+func (suite *MemorySuiteHandler) SetupTest() {
+	seed := newSeeder(suite.Require())
+	suite.store = seed.store
+	pricer := pricing.NewDefaultEngine()
+	var (
+		userHandler    = api.NewUserHandler(suite.store, notify.NewOutbox())
+		hotelHandler   = api.NewHotelHandler(suite.store)
+		roomHandler    = api.NewRoomHandler(suite.store, pricer)
+		bookingHandler = api.NewBookingHandler(suite.store, pricer, payments.NewProcessor(payments.NewFakeProvider()), holds.DefaultDuration)
+	)
+
+	suite.admin = seed.user(&types.User{Email: "admin@example.com", Role: auth.RoleAdmin, EmailVerified: true})
+	suite.guest = seed.user(&types.User{Email: "guest@example.com", Role: auth.RoleGuest, EmailVerified: true})
+
+	suite.app = fiber.New(fiber.Config{
+		ErrorHandler: response.ErrorHandler,
+	})
+	// The tests pick the acting user with the X-User header.
+	suite.app.Use(func(c *fiber.Ctx) error {
+		switch c.Get("X-User") {
+		case "admin":
+			c.Context().SetUserValue("user", suite.admin)
+		case "guest":
+			c.Context().SetUserValue("user", suite.guest)
+		}
+		return c.Next()
+	})
+	suite.app.Post("/register", userHandler.HandlePostUser)
+	suite.app.Post("/hotel", hotelHandler.HandlePostHotel)
+	suite.app.Get("/hotel/:id", hotelHandler.HandleGetHotel)
+	suite.app.Delete("/hotel/:id", hotelHandler.HandleDeleteHotel)
+	suite.app.Post("/hotel/:id/rooms", roomHandler.HandlePostRoom)
+	suite.app.Get("/room/:id", roomHandler.HandleGetRoom)
+	suite.app.Post("/room/:id/book", roomHandler.HandleBookRoom)
+	suite.app.Get("/availability", roomHandler.HandleGetAvailability)
+	suite.app.Post("/booking/:id/cancel", bookingHandler.HandleCancelBooking)
+}
+
+// do sends a request as the given user and returns the status and, if the
+// request succeeded, the id of the returned record.
+func (suite *MemorySuiteHandler) do(method, url, user string, body any) (int, string) {
+	var reader *bytes.Reader
+	if body != nil {
+		b, err := json.Marshal(body)
+		suite.Require().NoError(err)
+		reader = bytes.NewReader(b)
+	} else {
+		reader = bytes.NewReader(nil)
+	}
+	req := httptest.NewRequest(method, url, reader)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-User", user)
+	resp, err := suite.app.Test(req)
+	suite.Require().NoError(err)
+
+	var result struct {
+		Extras struct {
+			Data struct {
+				Id string `json:"id"`
+			} `json:"data"`
+		} `json:"extras"`
+	}
+	json.NewDecoder(resp.Body).Decode(&result)
+	return resp.StatusCode, result.Extras.Data.Id
+}
+
+func (suite *MemorySuiteHandler) book(roomId string, from, to int) int {
+	status, _ := suite.do(http.MethodPost, "/room/"+roomId+"/book", "guest", types.BookingRawParams{
+		From:      time.Now().AddDate(0, 0, from).Format("2006-01-02"),
+		To:        time.Now().AddDate(0, 0, to).Format("2006-01-02"),
+		NumPerson: 2,
+	})
+	return status
+}
+
+func (suite *MemorySuiteHandler) TestMemoryStore_Booking() {
+	status, hotelId := suite.do(http.MethodPost, "/hotel", "admin", types.CreateHotelParams{Name: "Seaside Inn", Location: "Kenting", Rating: 4})
+	suite.Require().Equal(http.StatusOK, status)
+	status, roomId := suite.do(http.MethodPost, "/hotel/"+hotelId+"/rooms", "admin", types.CreateRoomParams{Size: "Double", SeaSide: true, Price: 100, Capacity: 2})
+	suite.Require().Equal(http.StatusOK, status)
+
+	status, bookingId := suite.do(http.MethodPost, "/room/"+roomId+"/book", "guest", types.BookingRawParams{
+		From:      time.Now().AddDate(0, 0, 10).Format("2006-01-02"),
+		To:        time.Now().AddDate(0, 0, 12).Format("2006-01-02"),
+		NumPerson: 2,
+	})
+	suite.Require().Equal(http.StatusOK, status)
+
+	suite.Equal(http.StatusConflict, suite.book(roomId, 11, 13), "overlapping stay")
+	status, _ = suite.do(http.MethodDelete, "/hotel/"+hotelId, "admin", nil)
+	suite.Equal(http.StatusConflict, status, "hotel with bookings")
+	suite.Equal(http.StatusOK, suite.book(roomId, 12, 14), "stay starting on the check-out day")
+
+	bookings, err := suite.store.Booking.GetBookings(context.Background(), types.BookingFilter{UserId: suite.guest.Id})
+	suite.Require().NoError(err)
+	suite.Len(bookings, 2)
+
+	availability, err := suite.store.Room.GetAvailableRooms(context.Background(), types.AvailabilityQuery{
+		From: time.Now().AddDate(0, 0, 10),
+		To:   time.Now().AddDate(0, 0, 11),
+	})
+	suite.Require().NoError(err)
+	suite.Empty(availability)
+
+	status, _ = suite.do(http.MethodPost, "/booking/"+bookingId+"/cancel", "guest", nil)
+	suite.Equal(http.StatusOK, status)
+	status, _ = suite.do(http.MethodPost, "/booking/"+bookingId+"/cancel", "guest", nil)
+	suite.Equal(http.StatusConflict, status, "cancelling twice")
+	suite.Equal(http.StatusOK, suite.book(roomId, 10, 12), "cancelled nights are free again")
+}
+
+func (suite *MemorySuiteHandler) TestMemoryStore_Availability() {
+	_, hotelId := suite.do(http.MethodPost, "/hotel", "admin", types.CreateHotelParams{Name: "Harbour Hotel", Location: "Keelung"})
+	_, singleId := suite.do(http.MethodPost, "/hotel/"+hotelId+"/rooms", "admin", types.CreateRoomParams{Size: "Single", Price: 80, Capacity: 1})
+	_, doubleId := suite.do(http.MethodPost, "/hotel/"+hotelId+"/rooms", "admin", types.CreateRoomParams{Size: "Double", Price: 60, Capacity: 2})
+	_, _ = suite.do(http.MethodPost, "/hotel", "admin", types.CreateHotelParams{Name: "Airport Hotel", Location: "Taoyuan"})
+	suite.Require().Equal(http.StatusOK, suite.book(doubleId, 20, 22))
+
+	query := types.AvailabilityQuery{
+		From:     time.Now().AddDate(0, 0, 10),
+		To:       time.Now().AddDate(0, 0, 12),
+		Location: "keelung",
+	}
+	availability, err := suite.store.Room.GetAvailableRooms(context.Background(), query)
+	suite.Require().NoError(err)
+	suite.Require().Len(availability, 1)
+	suite.Equal(hotelId, availability[0].Hotel.Id)
+	suite.Require().Len(availability[0].Rooms, 2)
+	suite.Equal(doubleId, availability[0].Rooms[0].Id, "cheapest room first")
+	suite.Equal(singleId, availability[0].Rooms[1].Id)
+
+	query.From = time.Now().AddDate(0, 0, 21)
+	query.To = time.Now().AddDate(0, 0, 25)
+	query.Guests = 2
+	availability, err = suite.store.Room.GetAvailableRooms(context.Background(), query)
+	suite.Require().NoError(err)
+	suite.Empty(availability, "the only double room is booked")
+
+	status, _ := suite.do(http.MethodGet, "/availability?from="+time.Now().AddDate(0, 0, 10).Format("2006-01-02")+"&to="+time.Now().AddDate(0, 0, 12).Format("2006-01-02"), "guest", nil)
+	suite.Equal(http.StatusOK, status)
+}
+
+func (suite *MemorySuiteHandler) TestMemoryStore_RoomCapacity() {
+	_, hotelId := suite.do(http.MethodPost, "/hotel", "admin", types.CreateHotelParams{Name: "Harbour Hotel", Location: "Keelung"})
+	status, roomId := suite.do(http.MethodPost, "/hotel/"+hotelId+"/rooms", "admin", types.CreateRoomParams{Size: "Single", Price: 80})
+	suite.Require().Equal(http.StatusOK, status)
+	room, err := suite.store.Room.GetRoomById(context.Background(), roomId)
+	suite.Require().NoError(err)
+	suite.Equal(types.DefaultRoomCapacity, room.Capacity)
+
+	status, _ = suite.do(http.MethodPost, "/hotel/"+hotelId+"/rooms", "admin", types.CreateRoomParams{Size: "Single", Price: 80, Capacity: -1})
+	suite.Equal(http.StatusUnprocessableEntity, status)
+}
+
+func (suite *MemorySuiteHandler) TestMemoryStore_Uniqueness() {
+	user := types.CreateUserParams{FirstName: "Jane", LastName: "Doe", Email: "jane@example.com", Password: "supersecret"}
+	status, _ := suite.do(http.MethodPost, "/register", "", user)
+	suite.Equal(http.StatusOK, status)
+	status, _ = suite.do(http.MethodPost, "/register", "", user)
+	suite.Equal(http.StatusConflict, status)
+
+	hotel := types.CreateHotelParams{Name: "Seaside Inn", Location: "Kenting"}
+	status, _ = suite.do(http.MethodPost, "/hotel", "admin", hotel)
+	suite.Equal(http.StatusOK, status)
+	status, _ = suite.do(http.MethodPost, "/hotel", "admin", hotel)
+	suite.Equal(http.StatusConflict, status)
+}
+
+func (suite *MemorySuiteHandler) TestMemoryStore_NotFound() {
+	status, _ := suite.do(http.MethodGet, "/hotel/missing", "admin", nil)
+	suite.Equal(http.StatusNotFound, status)
+	status, _ = suite.do(http.MethodPost, "/hotel/missing/rooms", "admin", types.CreateRoomParams{Price: 100})
+	suite.Equal(http.StatusNotFound, status)
+	status, _ = suite.do(http.MethodPost, "/booking/missing/cancel", "guest", nil)
+	suite.Equal(http.StatusNotFound, status)
+
+	_, hotelId := suite.do(http.MethodPost, "/hotel", "admin", types.CreateHotelParams{Name: "Seaside Inn"})
+	_, roomId := suite.do(http.MethodPost, "/hotel/"+hotelId+"/rooms", "admin", types.CreateRoomParams{Price: 100, Capacity: 2})
+	status, _ = suite.do(http.MethodDelete, "/hotel/"+hotelId, "admin", nil)
+	suite.Equal(http.StatusOK, status)
+	status, _ = suite.do(http.MethodGet, "/room/"+roomId, "admin", nil)
+	suite.Equal(http.StatusNotFound, status, "rooms are deleted with their hotel")
+}
+
+func TestMemorySuiteHandler(t *testing.T) {
+	suite.Run(t, new(MemorySuiteHandler))
+}
@@ -0,0 +1,230 @@
+package api_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/ctchen222/hotel-system/internal/api"
+	"github.com/ctchen222/hotel-system/internal/auth"
+	"github.com/ctchen222/hotel-system/internal/db"
+	"github.com/ctchen222/hotel-system/internal/oidc"
+	"github.com/ctchen222/hotel-system/internal/oidc/oidctest"
+	"github.com/ctchen222/hotel-system/internal/response"
+	"github.com/ctchen222/hotel-system/internal/types"
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/suite"
+)
+
+type OIDCSuiteHandler struct {
+	suite.Suite
+	idp    *oidctest.Server
+	issuer *auth.Issuer
+	store  *db.Store
+	seed   *seeder
+	app    *fiber.App
+}
+
+func (suite *OIDCSuiteHandler) SetupTest() {
+	suite.idp = oidctest.NewServer("hotel-system", "secret")
+	suite.idp.SetUser(oidctest.User{Subject: "alice", Email: "alice@corp.example", EmailVerified: true, GivenName: "Alice", FamilyName: "Chen"})
+	provider, err := oidc.NewProvider(context.Background(), oidc.Config{
+		Issuer:       suite.idp.Issuer(),
+		ClientId:     suite.idp.ClientId,
+		ClientSecret: suite.idp.ClientSecret,
+		RedirectURL:  "http://hotel.test/api/oidc/callback",
+	}, suite.idp.Client())
+	suite.Require().NoError(err)
+
+	suite.seed = newSeeder(suite.Require())
+	suite.store = suite.seed.store
+	suite.issuer = newTestIssuer(suite.Require())
+	authHandler := api.NewAuthHandler(suite.store.User, suite.store.Session, suite.store.Token, suite.store.TwoFactor, suite.issuer,
+		auth.NewLoginGuard(auth.DefaultAccountPolicy, auth.DefaultIPPolicy))
+	oidcHandler := api.NewOIDCHandler(provider, suite.store.User, suite.store.Identity, authHandler, "/api/oidc")
+
+	suite.app = fiber.New(fiber.Config{
+		ErrorHandler: response.ErrorHandler,
+	})
+	suite.app.Get("/api/oidc/login", oidcHandler.HandleLogin)
+	suite.app.Get("/api/oidc/callback", oidcHandler.HandleCallback)
+}
+
+func (suite *OIDCSuiteHandler) TearDownTest() {
+	suite.idp.Close()
+}
+
+// startLogin starts a login and returns the callback the provider sends the
+// browser back to, and the login cookie.
+func (suite *OIDCSuiteHandler) startLogin() (string, *http.Cookie) {
+	resp, err := suite.app.Test(httptest.NewRequest(http.MethodGet, "/api/oidc/login", nil))
+	suite.Require().NoError(err)
+	suite.Require().Equal(http.StatusFound, resp.StatusCode)
+	cookies := resp.Cookies()
+	suite.Require().Len(cookies, 1)
+	suite.True(cookies[0].HttpOnly)
+
+	callback, err := suite.idp.Authorize(resp.Header.Get(fiber.HeaderLocation))
+	suite.Require().NoError(err)
+	return callback.RequestURI(), cookies[0]
+}
+
+func (suite *OIDCSuiteHandler) callback(uri string, cookie *http.Cookie) *http.Response {
+	req := httptest.NewRequest(http.MethodGet, uri, nil)
+	if cookie != nil {
+		req.AddCookie(cookie)
+	}
+	resp, err := suite.app.Test(req)
+	suite.Require().NoError(err)
+	return resp
+}
+
+func (suite *OIDCSuiteHandler) TestOIDCHandler_Callback() {
+	tests := []struct {
+		name     string
+		subject  string
+		email    string
+		verified bool
+		// setup seeds the local account the login should end up in, if any
+		setup func(subject, email string) *types.User
+		check func(user *types.User)
+		want  int
+	}{
+		{
+			name:     "First login creates the user",
+			subject:  "alice",
+			email:    "alice@corp.example",
+			verified: true,
+			check: func(user *types.User) {
+				suite.Equal("Alice", user.FirstName)
+				suite.Equal("Chen", user.LastName)
+				suite.True(user.EmailVerified)
+				suite.Equal(auth.RoleGuest, user.Role)
+				suite.Empty(user.EncryptedPassword)
+			},
+			want: http.StatusOK,
+		},
+		{
+			name:     "Linked identity",
+			subject:  "bob",
+			email:    "bob@corp.example",
+			verified: true,
+			setup: func(subject, email string) *types.User {
+				// the email at the provider no longer matters once linked
+				user := suite.seed.user(&types.User{Email: "bob@twobao.com", Role: auth.RoleHotelStaff})
+				suite.Require().NoError(suite.store.Identity.Insert(context.Background(), suite.idp.Issuer(), subject, user.Id))
+				return user
+			},
+			check: func(user *types.User) {
+				suite.Equal(auth.RoleHotelStaff, user.Role)
+			},
+			want: http.StatusOK,
+		},
+		{
+			name:     "Local account with a verified email is linked",
+			subject:  "carol",
+			email:    "carol@corp.example",
+			verified: true,
+			setup: func(_, email string) *types.User {
+				return suite.seed.user(&types.User{Email: email, EncryptedPassword: testPasswordHash})
+			},
+			check: func(user *types.User) {
+				suite.True(user.EmailVerified)
+				suite.Equal(testPasswordHash, user.EncryptedPassword)
+			},
+			want: http.StatusOK,
+		},
+		{
+			name:     "Admin account is not taken over",
+			subject:  "erin",
+			email:    "erin@corp.example",
+			verified: true,
+			setup: func(_, email string) *types.User {
+				suite.seed.user(&types.User{Email: email, EncryptedPassword: testPasswordHash, Role: auth.RoleAdmin, EmailVerified: true})
+				return nil
+			},
+			want: http.StatusForbidden,
+		},
+		{
+			name:     "Hotel staff account is not taken over",
+			subject:  "frank",
+			email:    "frank@corp.example",
+			verified: true,
+			setup: func(_, email string) *types.User {
+				suite.seed.user(&types.User{Email: email, EncryptedPassword: testPasswordHash, Role: auth.RoleHotelStaff})
+				return nil
+			},
+			want: http.StatusForbidden,
+		},
+		{
+			name:     "Local account with an unverified email is not taken over",
+			subject:  "dave",
+			email:    "dave@corp.example",
+			verified: false,
+			setup: func(_, email string) *types.User {
+				suite.seed.user(&types.User{Email: email, Role: auth.RoleHotelStaff})
+				return nil
+			},
+			want: http.StatusConflict,
+		},
+	}
+	for _, tt := range tests {
+		suite.Run(tt.name, func() {
+			suite.idp.SetUser(oidctest.User{Subject: tt.subject, Email: tt.email, EmailVerified: tt.verified, GivenName: "Alice", FamilyName: "Chen"})
+			var want *types.User
+			if tt.setup != nil {
+				want = tt.setup(tt.subject, tt.email)
+			}
+
+			resp := suite.callback(suite.startLogin())
+			suite.Require().Equal(tt.want, resp.StatusCode)
+			userId, err := suite.store.Identity.GetIdentityUserId(context.Background(), suite.idp.Issuer(), tt.subject)
+			if tt.want != http.StatusOK {
+				suite.ErrorIs(err, db.ErrNotFound)
+				return
+			}
+			suite.Require().NoError(err)
+			if want != nil {
+				suite.Equal(want.Id, userId)
+			}
+
+			var body struct {
+				Extras struct {
+					Data types.AuthResponse `json:"data"`
+				} `json:"extras"`
+			}
+			suite.NoError(json.NewDecoder(resp.Body).Decode(&body))
+			claims, err := suite.issuer.Parse(body.Extras.Data.Token)
+			suite.Require().NoError(err)
+			suite.Equal(userId, claims.Subject)
+			suite.NotEmpty(body.Extras.Data.RefreshToken)
+
+			user, err := suite.store.User.GetUserById(context.Background(), userId)
+			suite.Require().NoError(err)
+			tt.check(user)
+		})
+	}
+}
+
+func (suite *OIDCSuiteHandler) TestOIDCHandler_Callback_InvalidState() {
+	uri, _ := suite.startLogin()
+	_, otherCookie := suite.startLogin()
+
+	suite.Equal(http.StatusBadRequest, suite.callback(uri, nil).StatusCode)
+	suite.Equal(http.StatusBadRequest, suite.callback(uri, otherCookie).StatusCode)
+}
+
+func (suite *OIDCSuiteHandler) TestOIDCHandler_Callback_Denied() {
+	_, cookie := suite.startLogin()
+	state, _, _ := strings.Cut(cookie.Value, ".")
+
+	resp := suite.callback("/api/oidc/callback?error=access_denied&state="+state, cookie)
+	suite.Equal(http.StatusUnauthorized, resp.StatusCode)
+}
+
+func TestOIDCSuiteHandler(t *testing.T) {
+	suite.Run(t, new(OIDCSuiteHandler))
+}
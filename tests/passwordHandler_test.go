@@ -0,0 +1,134 @@
+package api_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ctchen222/hotel-system/internal/api"
+	"github.com/ctchen222/hotel-system/internal/auth"
+	"github.com/ctchen222/hotel-system/internal/db"
+	"github.com/ctchen222/hotel-system/internal/notify"
+	"github.com/ctchen222/hotel-system/internal/response"
+	"github.com/ctchen222/hotel-system/internal/types"
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/suite"
+)
+
+type PasswordSuiteHandler struct {
+	suite.Suite
+	seed   *seeder
+	store  *db.Store
+	outbox *notify.Outbox
+	app    *fiber.App
+
+	user *types.User
+}
+
+func (suite *PasswordSuiteHandler) SetupTest() {
+	suite.seed = newSeeder(suite.Require())
+	suite.store = suite.seed.store
+	suite.outbox = notify.NewOutbox()
+	passwordHandler := api.NewPasswordHandler(suite.store, suite.outbox)
+
+	suite.app = fiber.New(fiber.Config{
+		ErrorHandler: response.ErrorHandler,
+	})
+	suite.app.Post("/password/forgot", passwordHandler.HandleForgotPassword)
+	suite.app.Post("/password/reset", passwordHandler.HandleResetPassword)
+
+	suite.user = suite.seed.user(&types.User{
+		FirstName:         "TwoBao",
+		LastName:          "Chen",
+		Email:             "twobao@twobao.com",
+		EncryptedPassword: testPasswordHash,
+	})
+}
+
+func (suite *PasswordSuiteHandler) post(path string, params any) *http.Response {
+	body, _ := json.Marshal(params)
+	req := httptest.NewRequest("POST", path, bytes.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := suite.app.Test(req)
+	suite.Require().NoError(err)
+	return resp
+}
+
+func (suite *PasswordSuiteHandler) TestPasswordHandler_HandleForgotPassword() {
+	resp := suite.post("/password/forgot", types.ForgotPasswordParams{Email: suite.user.Email})
+	suite.Equal(http.StatusOK, resp.StatusCode)
+
+	messages := suite.outbox.Messages()
+	suite.Require().Len(messages, 1)
+	suite.Equal(suite.user.Email, messages[0].To)
+
+	// Only the hash is stored; the token itself is in the message.
+	_, after, ok := strings.Cut(messages[0].Body, "password: ")
+	suite.Require().True(ok)
+	resetToken, _, _ := strings.Cut(after, "\n")
+	stored, err := suite.store.Token.Consume(context.Background(), auth.PurposePasswordReset, auth.HashToken(resetToken), time.Now())
+	suite.Require().NoError(err)
+	suite.NotEqual(resetToken, stored.TokenHash)
+	suite.Equal(suite.user.Id, stored.UserId)
+	suite.WithinDuration(time.Now().Add(auth.PasswordResetTTL), stored.ExpiresAt, time.Minute)
+}
+
+func (suite *PasswordSuiteHandler) TestPasswordHandler_HandleForgotPassword_UnknownEmail() {
+	resp := suite.post("/password/forgot", types.ForgotPasswordParams{Email: "nobody@twobao.com"})
+	suite.Equal(http.StatusOK, resp.StatusCode)
+	suite.Empty(suite.outbox.Messages())
+}
+
+func (suite *PasswordSuiteHandler) TestPasswordHandler_HandleResetPassword() {
+	resetToken := suite.seed.token(suite.user.Id, auth.PurposePasswordReset, auth.PasswordResetTTL)
+	session, _ := suite.seed.session(suite.user.Id)
+
+	resp := suite.post("/password/reset", types.ResetPasswordParams{Token: resetToken, Password: "new-password"})
+	suite.Equal(http.StatusOK, resp.StatusCode)
+
+	user, err := suite.store.User.GetUserById(context.Background(), suite.user.Id)
+	suite.Require().NoError(err)
+	suite.True(types.IsValidPassword(user.EncryptedPassword, "new-password"))
+	stored, err := suite.store.Session.GetSessionById(context.Background(), session.Id)
+	suite.Require().NoError(err)
+	suite.NotNil(stored.RevokedAt, "sessions end with the old password")
+
+	resp = suite.post("/password/reset", types.ResetPasswordParams{Token: resetToken, Password: "other-password"})
+	suite.Equal(http.StatusBadRequest, resp.StatusCode, "a reset token works once")
+}
+
+func (suite *PasswordSuiteHandler) TestPasswordHandler_HandleResetPassword_InvalidToken() {
+	expired := suite.seed.token(suite.user.Id, auth.PurposePasswordReset, -time.Minute)
+	verification := suite.seed.token(suite.user.Id, auth.PurposeEmailVerification, auth.EmailVerificationTTL)
+
+	for _, token := range []string{"unknown-token", expired, verification} {
+		resp := suite.post("/password/reset", types.ResetPasswordParams{Token: token, Password: "new-password"})
+		suite.Equal(http.StatusBadRequest, resp.StatusCode)
+	}
+
+	user, err := suite.store.User.GetUserById(context.Background(), suite.user.Id)
+	suite.Require().NoError(err)
+	suite.True(types.IsValidPassword(user.EncryptedPassword, testPassword))
+}
+
+func (suite *PasswordSuiteHandler) TestPasswordHandler_HandleResetPassword_ShortPassword() {
+	resp := suite.post("/password/reset", types.ResetPasswordParams{Token: "token", Password: "short"})
+
+	var body struct {
+		Code   int               `json:"code"`
+		Extras map[string]string `json:"extras"`
+	}
+	suite.NoError(json.NewDecoder(resp.Body).Decode(&body))
+	suite.Equal(http.StatusUnprocessableEntity, resp.StatusCode)
+	suite.Equal(http.StatusUnprocessableEntity, body.Code)
+	suite.Contains(body.Extras, "password")
+}
+
+func TestPasswordSuiteHandler(t *testing.T) {
+	suite.Run(t, new(PasswordSuiteHandler))
+}
@@ -0,0 +1,143 @@
+package api_test
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/ctchen222/hotel-system/internal/db"
+	"github.com/ctchen222/hotel-system/internal/response"
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/middleware/requestid"
+)
+
+func newProblemApp() *fiber.App {
+	app := fiber.New(fiber.Config{ErrorHandler: response.ErrorHandler})
+	app.Use(requestid.New())
+	app.Get("/hotel/:id", func(c *fiber.Ctx) error {
+		return db.ErrNotFound
+	})
+	app.Get("/booking", func(c *fiber.Ctx) error {
+		return errors.New("dial tcp 10.0.0.5:5432: connect: connection refused")
+	})
+	app.Post("/register", func(c *fiber.Ctx) error {
+		return response.ErrorResponse(c, map[string]string{"password": "password is too short", "email": "email is invalid"})
+	})
+	return app
+}
+
+func TestErrorHandler_Problem(t *testing.T) {
+	app := newProblemApp()
+
+	tests := []struct {
+		name   string
+		header string
+		value  string
+	}{
+		{name: "API version 2", header: response.HeaderAPIVersion, value: "2"},
+		{name: "Accept header", header: fiber.HeaderAccept, value: response.MIMEApplicationProblemJSON},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/register", nil)
+			req.Header.Set(tt.header, tt.value)
+			resp, err := app.Test(req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := resp.Header.Get(fiber.HeaderContentType); got != response.MIMEApplicationProblemJSON {
+				t.Errorf("Content-Type = %q, want %q", got, response.MIMEApplicationProblemJSON)
+			}
+
+			var problem response.Problem
+			if err := json.NewDecoder(resp.Body).Decode(&problem); err != nil {
+				t.Fatal(err)
+			}
+			want := []response.FieldProblem{
+				{Field: "email", Detail: "email is invalid"},
+				{Field: "password", Detail: "password is too short"},
+			}
+			if problem.Status != http.StatusUnprocessableEntity || resp.StatusCode != http.StatusUnprocessableEntity {
+				t.Errorf("status = %d, %d, want %d", resp.StatusCode, problem.Status, http.StatusUnprocessableEntity)
+			}
+			if problem.Type != "/problems/unprocessable-entity" || problem.Instance != "/register" {
+				t.Errorf("type, instance = %q, %q, want /problems/unprocessable-entity, /register", problem.Type, problem.Instance)
+			}
+			if len(problem.Errors) != len(want) || problem.Errors[0] != want[0] || problem.Errors[1] != want[1] {
+				t.Errorf("errors = %v, want %v", problem.Errors, want)
+			}
+			if problem.RequestId == "" || problem.RequestId != resp.Header.Get(fiber.HeaderXRequestID) {
+				t.Errorf("requestId = %q, want the X-Request-ID %q", problem.RequestId, resp.Header.Get(fiber.HeaderXRequestID))
+			}
+		})
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/hotel/1", nil)
+	req.Header.Set(response.HeaderAPIVersion, "2")
+	resp, err := app.Test(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var problem response.Problem
+	if err := json.NewDecoder(resp.Body).Decode(&problem); err != nil {
+		t.Fatal(err)
+	}
+	if problem.Status != http.StatusNotFound || problem.Title != "Not Found" || problem.Detail != "Resource not found" {
+		t.Errorf("problem = %+v, want a 404 Not Found", problem)
+	}
+}
+
+func TestErrorHandler_Version1(t *testing.T) {
+	app := newProblemApp()
+
+	resp, err := app.Test(httptest.NewRequest(http.MethodPost, "/register", nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var body struct {
+		Code   int               `json:"code"`
+		Extras map[string]string `json:"extras"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		t.Fatal(err)
+	}
+	if resp.StatusCode != http.StatusUnprocessableEntity || body.Code != http.StatusUnprocessableEntity {
+		t.Errorf("status = %d, %d, want %d", resp.StatusCode, body.Code, http.StatusUnprocessableEntity)
+	}
+	if body.Extras["email"] != "email is invalid" {
+		t.Errorf("extras = %v, want the invalid fields", body.Extras)
+	}
+
+	resp, err = app.Test(httptest.NewRequest(http.MethodGet, "/hotel/1", nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var apiError response.Error
+	if err := json.NewDecoder(resp.Body).Decode(&apiError); err != nil {
+		t.Fatal(err)
+	}
+	if got := resp.Header.Get(fiber.HeaderContentType); got != fiber.MIMEApplicationJSON {
+		t.Errorf("Content-Type = %q, want %q", got, fiber.MIMEApplicationJSON)
+	}
+	if apiError.Code != http.StatusNotFound || apiError.Extras != "Resource not found" {
+		t.Errorf("body = %+v, want a 404 Error", apiError)
+	}
+}
+
+func TestErrorHandler_InternalError(t *testing.T) {
+	app := newProblemApp()
+
+	resp, err := app.Test(httptest.NewRequest(http.MethodGet, "/booking", nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var apiError response.Error
+	if err := json.NewDecoder(resp.Body).Decode(&apiError); err != nil {
+		t.Fatal(err)
+	}
+	if want := response.ErrInternal(); resp.StatusCode != want.Code || apiError.Extras != want.Extras {
+		t.Errorf("status, body = %d, %+v, want %+v", resp.StatusCode, apiError, want)
+	}
+}
@@ -1,80 +1,282 @@
 package api_test
 
 import (
+	"bytes"
+	"context"
+	"encoding/json"
 	"net/http"
 	"net/http/httptest"
 	"testing"
 	"time"
 
 	"github.com/ctchen222/hotel-system/internal/api"
-	"github.com/ctchen222/hotel-system/internal/db"
-	"github.com/ctchen222/hotel-system/internal/db/mocks"
+	"github.com/ctchen222/hotel-system/internal/auth"
+	"github.com/ctchen222/hotel-system/internal/pricing"
+	"github.com/ctchen222/hotel-system/internal/response"
 	"github.com/ctchen222/hotel-system/internal/types"
 	"github.com/gofiber/fiber/v2"
 	"github.com/stretchr/testify/suite"
-	"go.mongodb.org/mongo-driver/bson/primitive"
-	"go.uber.org/mock/gomock"
 )
 
 type RoomSuiteHandler struct {
 	suite.Suite
-	mockBookingStore *mocks.MockBookingStore
-	roomHandler      *api.RoomHandler
+	seed        *seeder
+	roomHandler *api.RoomHandler
 
-	bookings []*types.Booking
+	user  *types.User
+	hotel *types.Hotel
+	room  *types.Room
 }
 
 func (suite *RoomSuiteHandler) SetupTest() {
-	ctrl := gomock.NewController(suite.T())
-	defer ctrl.Finish()
-
-	suite.mockBookingStore = mocks.NewMockBookingStore(ctrl)
-	mockUserStore := mocks.NewMockUserStore(ctrl)
-	mockHotelStore := mocks.NewMockHotelStore(ctrl)
-	mockRoomStore := mocks.NewMockRoomStore(ctrl)
-	store := &db.Store{
-		User:    mockUserStore,
-		Hotel:   mockHotelStore,
-		Room:    mockRoomStore,
-		Booking: suite.mockBookingStore,
-	}
-	suite.roomHandler = api.NewRoomHandler(store)
+	suite.seed = newSeeder(suite.Require())
+	suite.roomHandler = api.NewRoomHandler(suite.seed.store, pricing.NewDefaultEngine())
+
+	suite.user = suite.seed.user(&types.User{Email: "guest@twobao.com", Role: auth.RoleGuest})
+	suite.hotel = suite.seed.hotel(&types.Hotel{Name: "Hotel 1", Location: "Taipei"})
+	suite.room = suite.seed.room(&types.Room{Size: "Double", Price: 100, Capacity: 2, HotelId: suite.hotel.Id})
 }
 
-func (suite *RoomSuiteHandler) BeforeTest(suiteName, testName string) {
-	suite.bookings = []*types.Booking{
+func (suite *RoomSuiteHandler) TestRoomHandler_HandleBookRoom() {
+	from := time.Now().AddDate(0, 0, 10)
+	to := time.Now().AddDate(0, 0, 12)
+	promoCode := func(hotelId string, redemptions int) func() {
+		return func() {
+			suite.seed.promoCode(&types.PromoCode{
+				Promotion: pricing.Promotion{
+					Code:           "SPRING",
+					Kind:           pricing.PromotionPercentage,
+					Percent:        10,
+					ValidFrom:      time.Now().AddDate(0, 0, -1),
+					ValidTo:        time.Now().AddDate(0, 0, 1),
+					MaxRedemptions: 1,
+				},
+				HotelId:     hotelId,
+				Redemptions: redemptions,
+			})
+		}
+	}
+
+	tests := []struct {
+		name      string
+		promoCode string
+		// numPerson defaults to the two guests the room sleeps
+		numPerson  int
+		setup      func()
+		check      func(booking *types.Booking)
+		wantStatus int
+	}{
+		{
+			name:  "Room available",
+			setup: func() {},
+			check: func(booking *types.Booking) {
+				// 2 nights at 100 plus 5% tax and 10% service fee
+				suite.Equal(pricing.Money(23000), booking.Price.Total)
+				suite.Equal(suite.user.Id, booking.UserId)
+			},
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:      "Promo code is stored on the price",
+			promoCode: "spring",
+			setup:     promoCode(suite.hotel.Id, 0),
+			check: func(booking *types.Booking) {
+				// 10% off the 200 room total, then tax and service fee
+				suite.Equal("SPRING", booking.Price.PromoCode)
+				suite.Equal(pricing.Money(2000), booking.Price.Discount)
+				suite.Equal(pricing.Money(20700), booking.Price.Total)
+
+				promoCode, err := suite.seed.store.PromoCode.GetPromoCodeByCode(context.Background(), "SPRING")
+				suite.Require().NoError(err)
+				suite.Equal(1, promoCode.Redemptions)
+			},
+			wantStatus: http.StatusOK,
+		},
 		{
-			Id:        primitive.NewObjectID(),
-			UserId:    primitive.NewObjectID(),
-			RoomId:    primitive.NewObjectID(),
-			NumPerson: 1,
-			From:      time.Now(),
-			To:        time.Now().AddDate(0, 0, 1),
+			name:       "Promo code is fully redeemed",
+			promoCode:  "SPRING",
+			setup:      promoCode(suite.hotel.Id, 1),
+			wantStatus: http.StatusConflict,
 		},
 		{
-			Id:        primitive.NewObjectID(),
-			UserId:    primitive.NewObjectID(),
-			RoomId:    primitive.NewObjectID(),
-			NumPerson: 3,
-			From:      time.Now(),
-			To:        time.Now().AddDate(0, 0, 3),
+			name:      "Promo code of another hotel",
+			promoCode: "SPRING",
+			setup: func() {
+				other := suite.seed.hotel(&types.Hotel{Name: "Hotel 2"})
+				promoCode(other.Id, 0)()
+			},
+			wantStatus: http.StatusUnprocessableEntity,
+		},
+		{
+			name: "Stay breaks the minimum stay of the rate plan",
+			setup: func() {
+				suite.seed.ratePlan(&types.RatePlan{RoomId: suite.room.Id, RatePlan: pricing.RatePlan{Name: "Long stay", ValidFrom: from.AddDate(0, 0, -1), ValidTo: to, MinStay: 3}})
+			},
+			wantStatus: http.StatusUnprocessableEntity,
+		},
+		{
+			name:       "Negative number of guests",
+			numPerson:  -1,
+			setup:      func() {},
+			wantStatus: http.StatusUnprocessableEntity,
+		},
+		{
+			name:       "More guests than the room sleeps",
+			numPerson:  3,
+			setup:      func() {},
+			wantStatus: http.StatusUnprocessableEntity,
+		},
+		{
+			name: "Room is booked for one of the nights",
+			setup: func() {
+				suite.seed.booking(&types.Booking{UserId: suite.user.Id, RoomId: suite.room.Id, NumPerson: 1, From: from.AddDate(0, 0, 1), To: to.AddDate(0, 0, 1)})
+			},
+			wantStatus: http.StatusConflict,
 		},
 	}
-}
 
-func (suite *RoomSuiteHandler) TestRoomHandler_HandleBookRoom() {
+	for _, tt := range tests {
+		suite.Run(tt.name, func() {
+			suite.SetupTest()
+			tt.setup()
+
+			app := fiber.New(fiber.Config{
+				ErrorHandler: response.ErrorHandler,
+			})
+			app.Post("/room/:id/book", func(c *fiber.Ctx) error {
+				c.Context().SetUserValue("user", suite.user)
+				return c.Next()
+			}, suite.roomHandler.HandleBookRoom)
+
+			numPerson := tt.numPerson
+			if numPerson == 0 {
+				numPerson = 2
+			}
+			body, _ := json.Marshal(types.BookingRawParams{
+				From:      from.Format("2006-01-02"),
+				To:        to.Format("2006-01-02"),
+				NumPerson: numPerson,
+				PromoCode: tt.promoCode,
+			})
+			req := httptest.NewRequest(http.MethodPost, "/room/"+suite.room.Id+"/book", bytes.NewReader(body))
+			req.Header.Set("Content-Type", "application/json")
+			resp, err := app.Test(req)
+			suite.NoError(err)
+			suite.Require().Equal(tt.wantStatus, resp.StatusCode)
+			if tt.check == nil {
+				return
+			}
+
+			var respBody struct {
+				Extras struct {
+					Data *types.Booking `json:"data"`
+				} `json:"extras"`
+			}
+			suite.NoError(json.NewDecoder(resp.Body).Decode(&respBody))
+			booking, err := suite.seed.store.Booking.GetBookingById(context.Background(), respBody.Extras.Data.Id)
+			suite.Require().NoError(err)
+			tt.check(booking)
+		})
+	}
 }
 
 func (suite *RoomSuiteHandler) TestRoomHandler_HandleGetBookings() {
-	suite.mockBookingStore.EXPECT().GetBookings(gomock.Any(), gomock.Any()).Return(
-		suite.bookings, nil).AnyTimes()
+	for i := range 2 {
+		from := time.Now().AddDate(0, 0, 10*(i+1))
+		suite.seed.booking(&types.Booking{UserId: suite.user.Id, RoomId: suite.room.Id, NumPerson: 1, From: from, To: from.AddDate(0, 0, 1)})
+	}
 
 	app := fiber.New()
 	app.Get("/room/booking", suite.roomHandler.HandleGetBookings)
 
-	req := httptest.NewRequest(http.MethodGet, "/room/booking", nil)
-	resp, _ := app.Test(req)
+	resp, err := app.Test(httptest.NewRequest(http.MethodGet, "/room/booking", nil))
+	suite.Require().NoError(err)
 	suite.Equal(http.StatusOK, resp.StatusCode)
+
+	var body struct {
+		Extras struct {
+			Data []*types.Booking `json:"data"`
+		} `json:"extras"`
+	}
+	suite.NoError(json.NewDecoder(resp.Body).Decode(&body))
+	suite.Len(body.Extras.Data, 2)
+}
+
+func (suite *RoomSuiteHandler) TestRoomHandler_HandleGetAvailability() {
+	fromDate := time.Now().AddDate(0, 0, 10)
+	from := fromDate.Format("2006-01-02")
+	to := time.Now().AddDate(0, 0, 12).Format("2006-01-02")
+
+	doubleRoom := suite.seed.room(&types.Room{Size: "Double", SeaSide: true, Price: 120, Capacity: 2, HotelId: suite.hotel.Id})
+	suiteRoom := suite.seed.room(&types.Room{Size: "Suite", SeaSide: true, Price: 140, Capacity: 2, HotelId: suite.hotel.Id})
+	suite.seed.room(&types.Room{Size: "Penthouse", SeaSide: true, Price: 300, Capacity: 4, HotelId: suite.hotel.Id})
+	elsewhere := suite.seed.hotel(&types.Hotel{Name: "Hotel 2", Location: "Kenting"})
+	suite.seed.room(&types.Room{Size: "Double", SeaSide: true, Price: 90, Capacity: 2, HotelId: elsewhere.Id})
+	// the suite only takes stays of three nights or more
+	suite.seed.ratePlan(&types.RatePlan{RoomId: suiteRoom.Id, RatePlan: pricing.RatePlan{Name: "Long stay", ValidFrom: fromDate.AddDate(0, 0, -1), ValidTo: fromDate.AddDate(0, 0, 30), MinStay: 3}})
+
+	app := fiber.New()
+	app.Get("/availability", suite.roomHandler.HandleGetAvailability)
+
+	suite.Run("Filters narrow the rooms down", func() {
+		url := "/availability?from=" + from + "&to=" + to + "&guests=2&location=Taipei&seaside=true&maxPrice=150"
+		resp, err := app.Test(httptest.NewRequest(http.MethodGet, url, nil))
+		suite.NoError(err)
+		suite.Equal(http.StatusOK, resp.StatusCode)
+
+		var body struct {
+			Extras struct {
+				Data []*types.HotelAvailability `json:"data"`
+			} `json:"extras"`
+		}
+		suite.NoError(json.NewDecoder(resp.Body).Decode(&body))
+		suite.Require().Len(body.Extras.Data, 1)
+		suite.Equal(suite.hotel.Id, body.Extras.Data[0].Hotel.Id)
+		suite.Require().Len(body.Extras.Data[0].Rooms, 1)
+		suite.Equal(*doubleRoom, body.Extras.Data[0].Rooms[0].Room)
+		// 2 nights at 120 plus 5% tax and 10% service fee
+		suite.Equal(pricing.Money(27600), body.Extras.Data[0].Rooms[0].Quote.Total)
+	})
+
+	suite.Run("Max price applies to the quoted nightly rates", func() {
+		hualien := suite.seed.hotel(&types.Hotel{Name: "Hotel 3", Location: "Hualien"})
+		discounted := suite.seed.room(&types.Room{Size: "Suite", Price: 200, Capacity: 2, HotelId: hualien.Id})
+		surcharged := suite.seed.room(&types.Room{Size: "Double", Price: 120, Capacity: 2, HotelId: hualien.Id})
+		for roomId, percent := range map[string]int{discounted.Id: 50, surcharged.Id: 150} {
+			suite.seed.ratePlan(&types.RatePlan{RoomId: roomId, RatePlan: pricing.RatePlan{
+				Name:           "Season",
+				ValidFrom:      fromDate.AddDate(0, 0, -1),
+				ValidTo:        fromDate.AddDate(0, 0, 30),
+				WeekdayPercent: percent,
+				WeekendPercent: percent,
+			}})
+		}
+
+		url := "/availability?from=" + from + "&to=" + to + "&location=Hualien&maxPrice=150"
+		resp, err := app.Test(httptest.NewRequest(http.MethodGet, url, nil))
+		suite.NoError(err)
+		suite.Equal(http.StatusOK, resp.StatusCode)
+
+		var body struct {
+			Extras struct {
+				Data []*types.HotelAvailability `json:"data"`
+			} `json:"extras"`
+		}
+		suite.NoError(json.NewDecoder(resp.Body).Decode(&body))
+		suite.Require().Len(body.Extras.Data, 1)
+		suite.Require().Len(body.Extras.Data[0].Rooms, 1)
+		suite.Equal(discounted.Id, body.Extras.Data[0].Rooms[0].Id)
+	})
+
+	suite.Run("Inverted range is rejected", func() {
+		resp, err := app.Test(httptest.NewRequest(http.MethodGet, "/availability?from="+to+"&to="+from, nil))
+		suite.NoError(err)
+
+		var body response.Response
+		suite.NoError(json.NewDecoder(resp.Body).Decode(&body))
+		suite.Equal(http.StatusUnprocessableEntity, resp.StatusCode)
+		suite.Equal(http.StatusUnprocessableEntity, body.Code)
+	})
 }
 
 func TestRoomSuiteHandler(t *testing.T) {
@@ -0,0 +1,138 @@
+package api_test
+
+import (
+	"context"
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rand"
+	"time"
+
+	"github.com/ctchen222/hotel-system/internal/auth"
+	"github.com/ctchen222/hotel-system/internal/db"
+	"github.com/ctchen222/hotel-system/internal/memory"
+	"github.com/ctchen222/hotel-system/internal/payments"
+	"github.com/ctchen222/hotel-system/internal/types"
+	"github.com/stretchr/testify/require"
+)
+
+// testPassword is the password of the users seeded with testPasswordHash.
+const testPassword = "test1234"
+
+const testPasswordHash = "$2a$10$fXSf7.i3RluVG3GMGPa7FORF2NdWB9Els7veSo13teTYXChpVHJQG"
+
+// seeder fills an in-memory store through the stores themselves, so the
+// handler suites run end-to-end against records stored like the handlers
+// store them. Suites that need a store to fail put a mock in its place.
+type seeder struct {
+	store   *db.Store
+	require *require.Assertions
+}
+
+// newSeeder returns a seeder for an empty in-memory store.
+func newSeeder(require *require.Assertions) *seeder {
+	return &seeder{
+		store:   memory.NewStore(),
+		require: require,
+	}
+}
+
+func (s *seeder) user(user *types.User) *types.User {
+	user, err := s.store.User.Create(context.Background(), user)
+	s.require.NoError(err)
+	return user
+}
+
+func (s *seeder) hotel(hotel *types.Hotel) *types.Hotel {
+	hotel, err := s.store.Hotel.Insert(context.Background(), hotel)
+	s.require.NoError(err)
+	return hotel
+}
+
+func (s *seeder) room(room *types.Room) *types.Room {
+	room, err := s.store.Room.Insert(context.Background(), room)
+	s.require.NoError(err)
+	return room
+}
+
+func (s *seeder) ratePlan(ratePlan *types.RatePlan) *types.RatePlan {
+	ratePlan, err := s.store.RatePlan.Insert(context.Background(), ratePlan)
+	s.require.NoError(err)
+	return ratePlan
+}
+
+func (s *seeder) promoCode(promoCode *types.PromoCode) *types.PromoCode {
+	promoCode, err := s.store.PromoCode.Insert(context.Background(), promoCode)
+	s.require.NoError(err)
+	return promoCode
+}
+
+func (s *seeder) booking(booking *types.Booking) *types.Booking {
+	booking, err := s.store.Booking.Insert(context.Background(), booking)
+	s.require.NoError(err)
+	return booking
+}
+
+// payment charges the booking's total to the provider and stores the
+// captured payment.
+func (s *seeder) payment(provider payments.PaymentProvider, booking *types.Booking) *types.Payment {
+	record, err := payments.NewProcessor(provider).Pay(context.Background(), booking.Price.Total, booking.Price.Currency, "tok_visa", func() error { return nil })
+	s.require.NoError(err)
+	payment, err := s.store.Payment.Insert(context.Background(), &types.Payment{BookingId: booking.Id, Record: *record})
+	s.require.NoError(err)
+	return payment
+}
+
+// twoFactor enables two-factor authentication for the user and returns it
+// with its recovery codes.
+func (s *seeder) twoFactor(userId string) (*auth.TwoFactor, []string) {
+	ctx := context.Background()
+	tf, err := auth.NewTwoFactor(userId)
+	s.require.NoError(err)
+	codes, hashes, err := auth.NewRecoveryCodes()
+	s.require.NoError(err)
+	s.require.NoError(s.store.TwoFactor.Upsert(ctx, tf))
+	s.require.NoError(s.store.TwoFactor.Enable(ctx, userId, hashes, 0))
+
+	tf, err = s.store.TwoFactor.GetTwoFactor(ctx, userId)
+	s.require.NoError(err)
+	return tf, codes
+}
+
+// session starts a session for the user and returns its refresh token.
+func (s *seeder) session(userId string) (*auth.Session, string) {
+	session, refreshToken, err := auth.NewSession(userId, time.Now())
+	s.require.NoError(err)
+	s.require.NoError(s.store.Session.Insert(context.Background(), session))
+	return session, refreshToken
+}
+
+// token stores a one-time token and returns what is sent to the user.
+func (s *seeder) token(userId string, purpose auth.Purpose, ttl time.Duration) string {
+	token, secret, err := auth.NewOneTimeToken(userId, purpose, ttl, time.Now())
+	s.require.NoError(err)
+	s.require.NoError(s.store.Token.Insert(context.Background(), token))
+	return secret
+}
+
+// apiKey stores an API key of the user and returns the key integrations
+// send.
+func (s *seeder) apiKey(userId string, permissions []auth.Permission, change func(*auth.APIKey)) (*auth.APIKey, string) {
+	apiKey, key, err := auth.NewAPIKey(userId, "reporting", permissions, nil, time.Now())
+	s.require.NoError(err)
+	if change != nil {
+		change(apiKey)
+	}
+	s.require.NoError(s.store.APIKey.Insert(context.Background(), apiKey))
+	return apiKey, key
+}
+
+// newTestIssuer returns an issuer signing with a fresh key.
+func newTestIssuer(require *require.Assertions) *auth.Issuer {
+	_, key, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(err)
+	keySet, err := auth.NewKeySet("", map[string]crypto.Signer{"test": key})
+	require.NoError(err)
+	issuer, err := auth.NewIssuer(keySet, auth.DefaultIssuer, auth.DefaultAudience)
+	require.NoError(err)
+	return issuer
+}
@@ -0,0 +1,239 @@
+package api_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/ctchen222/hotel-system/internal/api"
+	"github.com/ctchen222/hotel-system/internal/api/middleware"
+	"github.com/ctchen222/hotel-system/internal/auth"
+	"github.com/ctchen222/hotel-system/internal/db"
+	"github.com/ctchen222/hotel-system/internal/response"
+	"github.com/ctchen222/hotel-system/internal/types"
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/suite"
+)
+
+type TwoFactorSuiteHandler struct {
+	suite.Suite
+	seed  *seeder
+	store *db.Store
+	app   *fiber.App
+
+	user    *types.User
+	session *auth.Session
+}
+
+func (suite *TwoFactorSuiteHandler) SetupTest() {
+	suite.seed = newSeeder(suite.Require())
+	suite.store = suite.seed.store
+	twoFactorHandler := api.NewTwoFactorHandler(suite.store, auth.NewLoginGuard(auth.DefaultAccountPolicy, auth.DefaultIPPolicy))
+
+	suite.user = suite.seed.user(&types.User{
+		Email: "staff@twobao.com",
+		Role:  auth.RoleHotelStaff,
+	})
+
+	suite.app = fiber.New(fiber.Config{
+		ErrorHandler: response.ErrorHandler,
+	})
+	suite.app.Use(func(c *fiber.Ctx) error {
+		c.Context().SetUserValue("user", suite.user)
+		if suite.session != nil {
+			c.Context().SetUserValue("session", suite.session)
+		}
+		return c.Next()
+	})
+	suite.app.Post("/2fa/enroll", twoFactorHandler.HandleEnroll)
+	suite.app.Post("/2fa/confirm", twoFactorHandler.HandleConfirmEnrollment)
+	suite.app.Post("/2fa/disable", twoFactorHandler.HandleDisable)
+	suite.app.Put("/2fa/policy", twoFactorHandler.HandleUpdatePolicy)
+	suite.app.Get("/admin", middleware.TwoFactorEnforcement(suite.store.TwoFactor), func(c *fiber.Ctx) error {
+		return c.SendStatus(http.StatusOK)
+	})
+}
+
+func (suite *TwoFactorSuiteHandler) post(path string, params any) *http.Response {
+	body, _ := json.Marshal(params)
+	req := httptest.NewRequest("POST", path, bytes.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := suite.app.Test(req)
+	suite.Require().NoError(err)
+	return resp
+}
+
+func (suite *TwoFactorSuiteHandler) TestTwoFactorHandler_Enrollment() {
+	resp := suite.post("/2fa/enroll", nil)
+	suite.Require().Equal(http.StatusOK, resp.StatusCode)
+	var enrollBody struct {
+		Extras struct {
+			Data types.TwoFactorEnrollment `json:"data"`
+		} `json:"extras"`
+	}
+	suite.NoError(json.NewDecoder(resp.Body).Decode(&enrollBody))
+	pending, err := suite.store.TwoFactor.GetTwoFactor(context.Background(), suite.user.Id)
+	suite.Require().NoError(err)
+	suite.False(pending.Enabled)
+	suite.Equal(pending.Secret, enrollBody.Extras.Data.Secret)
+	uri, err := url.Parse(enrollBody.Extras.Data.ProvisioningURI)
+	suite.Require().NoError(err)
+	suite.Equal(pending.Secret, uri.Query().Get("secret"))
+
+	// confirming with a code from the authenticator enables it
+	code, err := auth.TOTPCode(pending.Secret, time.Now())
+	suite.Require().NoError(err)
+
+	resp = suite.post("/2fa/confirm", types.TwoFactorCodeParams{Code: code})
+	suite.Require().Equal(http.StatusOK, resp.StatusCode)
+	var confirmBody struct {
+		Extras struct {
+			Data types.RecoveryCodesResponse `json:"data"`
+		} `json:"extras"`
+	}
+	suite.NoError(json.NewDecoder(resp.Body).Decode(&confirmBody))
+	suite.Len(confirmBody.Extras.Data.RecoveryCodes, auth.RecoveryCodeCount)
+
+	enabled, err := suite.store.TwoFactor.GetTwoFactor(context.Background(), suite.user.Id)
+	suite.Require().NoError(err)
+	suite.True(enabled.Enabled)
+	suite.Require().Len(enabled.RecoveryCodes, auth.RecoveryCodeCount)
+	suite.Equal(auth.HashRecoveryCode(confirmBody.Extras.Data.RecoveryCodes[0]), enabled.RecoveryCodes[0])
+}
+
+func (suite *TwoFactorSuiteHandler) TestTwoFactorHandler_HandleConfirmEnrollment_RevokesOtherSessions() {
+	suite.session, _ = suite.seed.session(suite.user.Id)
+	other, _ := suite.seed.session(suite.user.Id)
+	tf, err := auth.NewTwoFactor(suite.user.Id)
+	suite.Require().NoError(err)
+	suite.Require().NoError(suite.store.TwoFactor.Upsert(context.Background(), tf))
+	code, err := auth.TOTPCode(tf.Secret, time.Now())
+	suite.Require().NoError(err)
+
+	resp := suite.post("/2fa/confirm", types.TwoFactorCodeParams{Code: code})
+	suite.Require().Equal(http.StatusOK, resp.StatusCode)
+
+	current, err := suite.store.Session.GetSessionById(context.Background(), suite.session.Id)
+	suite.Require().NoError(err)
+	suite.True(current.Active(time.Now()), "the session that enabled two-factor authentication stays")
+	revoked, err := suite.store.Session.GetSessionById(context.Background(), other.Id)
+	suite.Require().NoError(err)
+	suite.False(revoked.Active(time.Now()), "sessions started without a code are revoked")
+}
+
+func (suite *TwoFactorSuiteHandler) TestTwoFactorHandler_HandleConfirmEnrollment_WrongCode() {
+	tf, err := auth.NewTwoFactor(suite.user.Id)
+	suite.Require().NoError(err)
+	suite.Require().NoError(suite.store.TwoFactor.Upsert(context.Background(), tf))
+
+	resp := suite.post("/2fa/confirm", types.TwoFactorCodeParams{Code: "000000"})
+	suite.Equal(http.StatusUnauthorized, resp.StatusCode)
+}
+
+func (suite *TwoFactorSuiteHandler) TestTwoFactorHandler_HandleEnroll_AlreadyEnabled() {
+	suite.seed.twoFactor(suite.user.Id)
+
+	resp := suite.post("/2fa/enroll", nil)
+	suite.Equal(http.StatusConflict, resp.StatusCode)
+}
+
+func (suite *TwoFactorSuiteHandler) TestTwoFactorHandler_HandleDisable() {
+	_, codes := suite.seed.twoFactor(suite.user.Id)
+
+	resp := suite.post("/2fa/disable", types.TwoFactorCodeParams{Code: codes[0]})
+	suite.Equal(http.StatusOK, resp.StatusCode)
+
+	_, err := suite.store.TwoFactor.GetTwoFactor(context.Background(), suite.user.Id)
+	suite.ErrorIs(err, db.ErrNotFound)
+}
+
+func (suite *TwoFactorSuiteHandler) TestTwoFactorHandler_HandleDisable_Required() {
+	suite.seed.twoFactor(suite.user.Id)
+	suite.Require().NoError(suite.store.TwoFactor.SetRequiredRoles(context.Background(), []auth.Role{auth.RoleHotelStaff}))
+
+	resp := suite.post("/2fa/disable", types.TwoFactorCodeParams{Code: "000000"})
+	suite.Equal(http.StatusForbidden, resp.StatusCode)
+}
+
+func (suite *TwoFactorSuiteHandler) TestTwoFactorHandler_HandleDisable_Throttled() {
+	tf, _ := suite.seed.twoFactor(suite.user.Id)
+	code, err := auth.TOTPCode(tf.Secret, time.Now())
+	suite.Require().NoError(err)
+
+	for range auth.DefaultAccountPolicy.FreeAttempts {
+		resp := suite.post("/2fa/disable", types.TwoFactorCodeParams{Code: "not-a-code"})
+		suite.Equal(http.StatusUnauthorized, resp.StatusCode)
+	}
+
+	// The right code has to wait too, or guessing could go on.
+	resp := suite.post("/2fa/disable", types.TwoFactorCodeParams{Code: code})
+	suite.Equal(http.StatusTooManyRequests, resp.StatusCode)
+	suite.NotEmpty(resp.Header.Get(fiber.HeaderRetryAfter))
+
+	enabled, err := suite.store.TwoFactor.GetTwoFactor(context.Background(), suite.user.Id)
+	suite.Require().NoError(err)
+	suite.True(enabled.Enabled)
+}
+
+func (suite *TwoFactorSuiteHandler) TestTwoFactorHandler_HandleUpdatePolicy() {
+	body, _ := json.Marshal(types.TwoFactorPolicyParams{Roles: []auth.Role{auth.RoleHotelStaff, auth.RoleAdmin}})
+	req := httptest.NewRequest("PUT", "/2fa/policy", bytes.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := suite.app.Test(req)
+	suite.Require().NoError(err)
+	suite.Equal(http.StatusOK, resp.StatusCode)
+
+	roles, err := suite.store.TwoFactor.GetRequiredRoles(context.Background())
+	suite.Require().NoError(err)
+	suite.Equal([]auth.Role{auth.RoleHotelStaff, auth.RoleAdmin}, roles)
+}
+
+func (suite *TwoFactorSuiteHandler) TestMongoTwoFactorEnforcement() {
+	tests := []struct {
+		name     string
+		required []auth.Role
+		enroll   func()
+		want     int
+	}{
+		{name: "Not required", required: []auth.Role{auth.RoleAdmin}, want: http.StatusOK},
+		{
+			name:     "Required and enrolled",
+			required: []auth.Role{auth.RoleHotelStaff},
+			enroll:   func() { suite.seed.twoFactor(suite.user.Id) },
+			want:     http.StatusOK,
+		},
+		{
+			name:     "Required and pending",
+			required: []auth.Role{auth.RoleHotelStaff},
+			enroll: func() {
+				tf, err := auth.NewTwoFactor(suite.user.Id)
+				suite.Require().NoError(err)
+				suite.Require().NoError(suite.store.TwoFactor.Upsert(context.Background(), tf))
+			},
+			want: http.StatusForbidden,
+		},
+		{name: "Required and not enrolled", required: []auth.Role{auth.RoleHotelStaff}, want: http.StatusForbidden},
+	}
+	for _, tt := range tests {
+		suite.Run(tt.name, func() {
+			suite.SetupTest()
+			suite.Require().NoError(suite.store.TwoFactor.SetRequiredRoles(context.Background(), tt.required))
+			if tt.enroll != nil {
+				tt.enroll()
+			}
+
+			resp, err := suite.app.Test(httptest.NewRequest("GET", "/admin", nil))
+			suite.Require().NoError(err)
+			suite.Equal(tt.want, resp.StatusCode)
+		})
+	}
+}
+
+func TestTwoFactorSuiteHandler(t *testing.T) {
+	suite.Run(t, new(TwoFactorSuiteHandler))
+}
@@ -2,172 +2,249 @@ package api_test
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
 	"testing"
 
 	"github.com/ctchen222/hotel-system/internal/api"
+	"github.com/ctchen222/hotel-system/internal/auth"
 	"github.com/ctchen222/hotel-system/internal/db"
-	"github.com/ctchen222/hotel-system/internal/db/mocks"
+	"github.com/ctchen222/hotel-system/internal/notify"
+	"github.com/ctchen222/hotel-system/internal/response"
 	"github.com/ctchen222/hotel-system/internal/types"
 	"github.com/gofiber/fiber/v2"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/suite"
-	"github.com/valyala/fasthttp"
-	"go.mongodb.org/mongo-driver/bson/primitive"
-	"go.uber.org/mock/gomock"
 )
 
 type UserSuiteHandler struct {
 	suite.Suite
-	mockUserStore    *mocks.MockUserStore
-	mockBookingStore *mocks.MockBookingStore
-	mockHotelStore   *mocks.MockHotelStore
-	mockRoomStore    *mocks.MockRoomStore
-	userHandler      *api.UserHandler
+	seed        *seeder
+	store       *db.Store
+	outbox      *notify.Outbox
+	userHandler *api.UserHandler
+
+	user *types.User
 }
 
 func (suite *UserSuiteHandler) SetupTest() {
-	ctrl := gomock.NewController(suite.T())
-	defer ctrl.Finish()
-
-	suite.mockUserStore = mocks.NewMockUserStore(ctrl)
-	suite.mockBookingStore = mocks.NewMockBookingStore(ctrl)
-	suite.mockHotelStore = mocks.NewMockHotelStore(ctrl)
-	suite.mockRoomStore = mocks.NewMockRoomStore(ctrl)
-
-	store := &db.Store{
-		User:    suite.mockUserStore,
-		Booking: suite.mockBookingStore,
-		Hotel:   suite.mockHotelStore,
-		Room:    suite.mockRoomStore,
-	}
-	suite.userHandler = api.NewUserHandler(store)
-}
+	suite.seed = newSeeder(suite.Require())
+	suite.store = suite.seed.store
+	suite.outbox = notify.NewOutbox()
+	suite.userHandler = api.NewUserHandler(suite.store, suite.outbox)
 
-func (suite *UserSuiteHandler) TestUserHandler_HandleGetUser() {
-	userId := primitive.NewObjectID()
-	user := &types.User{
-		Id:                userId,
+	suite.user = suite.seed.user(&types.User{
 		FirstName:         "TwoBao",
 		LastName:          "Chen",
 		Email:             "twobao@twobao.com",
-		EncryptedPassword: "123456",
-	}
-
-	suite.mockUserStore.EXPECT().GetUserById(gomock.Any(), userId.Hex()).Return(user, nil)
+		EncryptedPassword: testPasswordHash,
+	})
+}
 
-	app := fiber.New()
+func (suite *UserSuiteHandler) TestUserHandler_HandleGetUser() {
+	app := fiber.New(fiber.Config{
+		ErrorHandler: response.ErrorHandler,
+	})
 	app.Get("/users/:id", suite.userHandler.HandleGetUser)
 
-	req := httptest.NewRequest("GET", "/users/"+userId.Hex(), nil)
-	resp, err := app.Test(req)
-
+	resp, err := app.Test(httptest.NewRequest("GET", "/users/"+suite.user.Id, nil))
 	assert.NoError(suite.T(), err)
 	assert.Equal(suite.T(), 200, resp.StatusCode)
+
+	resp, err = app.Test(httptest.NewRequest("GET", "/users/missing", nil))
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), http.StatusNotFound, resp.StatusCode)
 }
 
 func (suite *UserSuiteHandler) TestUserHandler_HandleGetUsers() {
-
-	userId1 := primitive.NewObjectID()
-	userId2 := primitive.NewObjectID()
-	userId3 := primitive.NewObjectID()
-	users := []*types.User{
-		{
-			Id:                userId1,
-			FirstName:         "TwoBao",
-			LastName:          "Chen",
-			Email:             "twobao@twobao.com",
-			EncryptedPassword: "123456",
-		},
-		{
-			Id:                userId2,
-			FirstName:         "Shaun",
-			LastName:          "Chen",
-			Email:             "shaun@twobao.com",
-			EncryptedPassword: "123456",
-		},
-		{
-			Id:                userId3,
-			FirstName:         "Joanne",
-			LastName:          "Lin",
-			Email:             "joanne@twobao.com",
-			EncryptedPassword: "123456",
-		},
-	}
-
-	suite.mockUserStore.EXPECT().GetUsers(gomock.Any()).Return(
-		users, nil)
+	suite.seed.user(&types.User{FirstName: "Shaun", LastName: "Chen", Email: "shaun@twobao.com"})
+	suite.seed.user(&types.User{FirstName: "Joanne", LastName: "Lin", Email: "joanne@twobao.com"})
 
 	app := fiber.New()
-	ctx := app.AcquireCtx(&fasthttp.RequestCtx{})
-
-	// var resp response.Response
-	err := suite.userHandler.HandleGetUsers(ctx)
+	app.Get("/users", suite.userHandler.HandleGetUsers)
 
+	resp, err := app.Test(httptest.NewRequest("GET", "/users", nil))
 	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), 200, resp.StatusCode)
+
+	var body struct {
+		Extras struct {
+			Data []*types.User `json:"data"`
+		} `json:"extras"`
+	}
+	assert.NoError(suite.T(), json.NewDecoder(resp.Body).Decode(&body))
+	assert.Len(suite.T(), body.Extras.Data, 3)
 }
 
 func (suite *UserSuiteHandler) TestUserHandler_HandlePostUser() {
-	userId := primitive.NewObjectID()
-	user := &types.User{
-		Id:                userId,
-		FirstName:         "TwoBao",
-		LastName:          "Chen",
-		Email:             "twobao@twobao.com",
-		EncryptedPassword: "test12345678",
-	}
 	userCreateParams := &types.CreateUserParams{
-		FirstName: "TwoBao",
-		LastName:  "Chen",
-		Email:     "twobao@twobao.com",
+		FirstName: "Joanne",
+		LastName:  "Lin",
+		Email:     "joanne@twobao.com",
 		Password:  "test1234",
 	}
 	userBody, _ := json.Marshal(userCreateParams)
 
-	suite.mockUserStore.EXPECT().Create(gomock.Any(), gomock.Any()).Return(user, nil)
-
-	app := fiber.New()
+	app := fiber.New(fiber.Config{
+		ErrorHandler: response.ErrorHandler,
+	})
 	app.Post("/users", suite.userHandler.HandlePostUser)
-	req := httptest.NewRequest(http.MethodPost, "/users", bytes.NewReader(userBody))
-	req.Header.Set("Content-Type", "application/json")
+	post := func() *http.Response {
+		req := httptest.NewRequest(http.MethodPost, "/users", bytes.NewReader(userBody))
+		req.Header.Set("Content-Type", "application/json")
+		resp, err := app.Test(req)
+		suite.Require().NoError(err)
+		return resp
+	}
 
-	resp, _ := app.Test(req)
+	resp := post()
 	assert.Equal(suite.T(), 200, resp.StatusCode)
 	assert.Equal(suite.T(), "application/json", resp.Header.Get("Content-Type"))
+
+	created, err := suite.store.User.GetUserByEmail(context.Background(), userCreateParams.Email)
+	suite.Require().NoError(err)
+	assert.False(suite.T(), created.EmailVerified)
+	assert.True(suite.T(), types.IsValidPassword(created.EncryptedPassword, userCreateParams.Password))
+
+	messages := suite.outbox.Messages()
+	if assert.Len(suite.T(), messages, 1) {
+		assert.Equal(suite.T(), created.Email, messages[0].To)
+	}
+
+	assert.Equal(suite.T(), http.StatusConflict, post().StatusCode)
 }
 
 func (suite *UserSuiteHandler) TestUserHandler_HandleDeleteUser() {
-	suite.mockUserStore.EXPECT().DeleteById(gomock.Any(), gomock.Any()).Return(nil)
+	session, _ := suite.seed.session(suite.user.Id)
 
-	userId := primitive.NewObjectID()
-	app := fiber.New()
+	app := fiber.New(fiber.Config{
+		ErrorHandler: response.ErrorHandler,
+	})
 	app.Delete("/users/:id", suite.userHandler.HandleDeleteUser)
 
-	req := httptest.NewRequest("DELETE", "/users/"+userId.Hex(), nil)
-	resp, _ := app.Test(req)
+	resp, _ := app.Test(httptest.NewRequest("DELETE", "/users/"+suite.user.Id, nil))
 	assert.Equal(suite.T(), 200, resp.StatusCode)
+
+	_, err := suite.store.User.GetUserById(context.Background(), suite.user.Id)
+	assert.ErrorIs(suite.T(), err, db.ErrNotFound)
+	stored, err := suite.store.Session.GetSessionById(context.Background(), session.Id)
+	suite.Require().NoError(err)
+	assert.NotNil(suite.T(), stored.RevokedAt)
+
+	resp, _ = app.Test(httptest.NewRequest("DELETE", "/users/"+suite.user.Id, nil))
+	assert.Equal(suite.T(), http.StatusNotFound, resp.StatusCode)
 }
 
 func (suite *UserSuiteHandler) TestUserHandler_HandleUpdateUser() {
-	suite.mockUserStore.EXPECT().Update(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil)
-
-	userId := primitive.NewObjectID()
 	userUpdateParams := &types.UserUpdateParams{
-		FirstName: "TwoBao",
-		LastName:  "Chen",
+		FirstName: "Shaun",
+		LastName:  "Lin",
 	}
 	patchBody, _ := json.Marshal(userUpdateParams)
 
-	app := fiber.New()
+	app := fiber.New(fiber.Config{
+		ErrorHandler: response.ErrorHandler,
+	})
 	app.Patch("/users/:id", suite.userHandler.HandleUpdateUser)
 
-	req := httptest.NewRequest("PATCH", "/users/"+userId.Hex(), bytes.NewReader(patchBody))
+	req := httptest.NewRequest("PATCH", "/users/"+suite.user.Id, bytes.NewReader(patchBody))
 	req.Header.Set("Content-Type", "application/json")
 
 	resp, _ := app.Test(req)
 	assert.Equal(suite.T(), 200, resp.StatusCode)
+
+	updated, err := suite.store.User.GetUserById(context.Background(), suite.user.Id)
+	suite.Require().NoError(err)
+	assert.Equal(suite.T(), "Shaun", updated.FirstName)
+	assert.Equal(suite.T(), "Lin", updated.LastName)
+
+	req = httptest.NewRequest("PATCH", "/users/missing", bytes.NewReader(patchBody))
+	req.Header.Set("Content-Type", "application/json")
+	resp, _ = app.Test(req)
+	assert.Equal(suite.T(), http.StatusNotFound, resp.StatusCode)
+}
+
+func (suite *UserSuiteHandler) TestUserHandler_HandleUpdateRole() {
+	hotel := suite.seed.hotel(&types.Hotel{Name: "Hotel 1"})
+	tests := []struct {
+		name       string
+		userId     string
+		params     types.UpdateRoleParams
+		wantStatus int
+	}{
+		{
+			name:       "Hotel manager of a hotel",
+			userId:     suite.user.Id,
+			params:     types.UpdateRoleParams{Role: auth.RoleHotelManager, HotelIds: []string{hotel.Id}},
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:       "User does not exist",
+			userId:     "missing",
+			params:     types.UpdateRoleParams{Role: auth.RoleAdmin},
+			wantStatus: http.StatusNotFound,
+		},
+	}
+
+	app := fiber.New(fiber.Config{
+		ErrorHandler: response.ErrorHandler,
+	})
+	app.Patch("/users/:id/role", suite.userHandler.HandleUpdateRole)
+	for _, tt := range tests {
+		suite.Run(tt.name, func() {
+			body, _ := json.Marshal(tt.params)
+			req := httptest.NewRequest("PATCH", "/users/"+tt.userId+"/role", bytes.NewReader(body))
+			req.Header.Set("Content-Type", "application/json")
+			resp, err := app.Test(req)
+			suite.NoError(err)
+			suite.Equal(tt.wantStatus, resp.StatusCode)
+		})
+	}
+
+	updated, err := suite.store.User.GetUserById(context.Background(), suite.user.Id)
+	suite.Require().NoError(err)
+	suite.Equal(auth.RoleHotelManager, updated.Role)
+	suite.Equal([]string{hotel.Id}, updated.HotelIds)
+}
+
+func (suite *UserSuiteHandler) TestUserHandler_HandleUpdateRole_InvalidRole() {
+	app := fiber.New()
+	app.Patch("/users/:id/role", suite.userHandler.HandleUpdateRole)
+
+	body, _ := json.Marshal(fiber.Map{"role": "owner"})
+	req := httptest.NewRequest("PATCH", "/users/"+suite.user.Id+"/role", bytes.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := app.Test(req)
+	suite.NoError(err)
+
+	var respBody struct {
+		Extras map[string]string `json:"extras"`
+	}
+	suite.NoError(json.NewDecoder(resp.Body).Decode(&respBody))
+	suite.Contains(respBody.Extras, "role")
+}
+
+func (suite *UserSuiteHandler) TestUserHandler_HandleUpdateRole_UnknownHotel() {
+	app := fiber.New()
+	app.Patch("/users/:id/role", suite.userHandler.HandleUpdateRole)
+
+	body, _ := json.Marshal(types.UpdateRoleParams{Role: auth.RoleHotelStaff, HotelIds: []string{"missing"}})
+	req := httptest.NewRequest("PATCH", "/users/"+suite.user.Id+"/role", bytes.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := app.Test(req)
+	suite.NoError(err)
+
+	var respBody struct {
+		Extras map[string]string `json:"extras"`
+	}
+	suite.NoError(json.NewDecoder(resp.Body).Decode(&respBody))
+	suite.Contains(respBody.Extras, "hotelIds")
+
+	unchanged, err := suite.store.User.GetUserById(context.Background(), suite.user.Id)
+	suite.Require().NoError(err)
+	suite.Equal(auth.RoleGuest, unchanged.Role)
 }
 
 func TestUserSuiteHandler(t *testing.T) {
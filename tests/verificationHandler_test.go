@@ -0,0 +1,159 @@
+package api_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ctchen222/hotel-system/internal/api"
+	"github.com/ctchen222/hotel-system/internal/api/middleware"
+	"github.com/ctchen222/hotel-system/internal/auth"
+	"github.com/ctchen222/hotel-system/internal/db"
+	"github.com/ctchen222/hotel-system/internal/notify"
+	"github.com/ctchen222/hotel-system/internal/response"
+	"github.com/ctchen222/hotel-system/internal/types"
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/suite"
+)
+
+type VerificationSuiteHandler struct {
+	suite.Suite
+	seed   *seeder
+	store  *db.Store
+	outbox *notify.Outbox
+	app    *fiber.App
+
+	user *types.User
+}
+
+func (suite *VerificationSuiteHandler) SetupTest() {
+	suite.seed = newSeeder(suite.Require())
+	suite.store = suite.seed.store
+	suite.outbox = notify.NewOutbox()
+	verificationHandler := api.NewVerificationHandler(suite.store, suite.outbox)
+
+	suite.app = fiber.New(fiber.Config{
+		ErrorHandler: response.ErrorHandler,
+	})
+	suite.app.Get("/verify", verificationHandler.HandleVerifyEmail)
+	suite.app.Post("/verify/resend", verificationHandler.HandleResendVerification)
+
+	suite.user = suite.seed.user(&types.User{
+		FirstName: "TwoBao",
+		LastName:  "Chen",
+		Email:     "twobao@twobao.com",
+	})
+}
+
+func (suite *VerificationSuiteHandler) emailVerified() bool {
+	user, err := suite.store.User.GetUserById(context.Background(), suite.user.Id)
+	suite.Require().NoError(err)
+	return user.EmailVerified
+}
+
+func (suite *VerificationSuiteHandler) TestVerificationHandler_HandleVerifyEmail() {
+	verifyToken := suite.seed.token(suite.user.Id, auth.PurposeEmailVerification, auth.EmailVerificationTTL)
+
+	resp, err := suite.app.Test(httptest.NewRequest("GET", "/verify?token="+verifyToken, nil))
+	suite.Require().NoError(err)
+	suite.Equal(http.StatusOK, resp.StatusCode)
+	suite.True(suite.emailVerified())
+
+	resp, err = suite.app.Test(httptest.NewRequest("GET", "/verify?token="+verifyToken, nil))
+	suite.Require().NoError(err)
+	suite.Equal(http.StatusBadRequest, resp.StatusCode, "a verification token works once")
+}
+
+func (suite *VerificationSuiteHandler) TestVerificationHandler_HandleVerifyEmail_InvalidToken() {
+	expired := suite.seed.token(suite.user.Id, auth.PurposeEmailVerification, -time.Minute)
+	reset := suite.seed.token(suite.user.Id, auth.PurposePasswordReset, auth.PasswordResetTTL)
+
+	for _, target := range []string{"/verify?token=unknown-token", "/verify?token=" + expired, "/verify?token=" + reset, "/verify"} {
+		resp, err := suite.app.Test(httptest.NewRequest("GET", target, nil))
+		suite.Require().NoError(err)
+		suite.Equal(http.StatusBadRequest, resp.StatusCode, target)
+	}
+	suite.False(suite.emailVerified())
+}
+
+func (suite *VerificationSuiteHandler) TestVerificationHandler_HandleResendVerification() {
+	tests := []struct {
+		name     string
+		verified bool
+		wantSent int
+	}{
+		{name: "Unverified", verified: false, wantSent: 1},
+		{name: "Already verified", verified: true, wantSent: 0},
+	}
+	for _, tt := range tests {
+		suite.Run(tt.name, func() {
+			suite.SetupTest()
+			if tt.verified {
+				suite.Require().NoError(suite.store.User.MarkEmailVerified(context.Background(), suite.user.Id))
+			}
+
+			body, _ := json.Marshal(types.ResendVerificationParams{Email: suite.user.Email})
+			req := httptest.NewRequest("POST", "/verify/resend", bytes.NewReader(body))
+			req.Header.Set("Content-Type", "application/json")
+			resp, err := suite.app.Test(req)
+			suite.Require().NoError(err)
+			suite.Equal(http.StatusOK, resp.StatusCode)
+
+			messages := suite.outbox.Messages()
+			suite.Require().Len(messages, tt.wantSent)
+			if tt.wantSent == 0 {
+				return
+			}
+
+			// the token in the message verifies the email
+			_, after, ok := strings.Cut(messages[0].Body, "email: ")
+			suite.Require().True(ok)
+			verifyToken, _, _ := strings.Cut(after, "\n")
+			resp, err = suite.app.Test(httptest.NewRequest("GET", "/verify?token="+verifyToken, nil))
+			suite.Require().NoError(err)
+			suite.Equal(http.StatusOK, resp.StatusCode)
+			suite.True(suite.emailVerified())
+		})
+	}
+}
+
+func (suite *VerificationSuiteHandler) TestRequireVerifiedEmail() {
+	tests := []struct {
+		name     string
+		verified any
+		want     int
+	}{
+		{name: "Verified", verified: true, want: http.StatusOK},
+		{name: "Unverified", verified: false, want: http.StatusForbidden},
+		{name: "Unauthenticated", verified: nil, want: http.StatusUnauthorized},
+	}
+	for _, tt := range tests {
+		suite.Run(tt.name, func() {
+			app := fiber.New(fiber.Config{
+				ErrorHandler: response.ErrorHandler,
+			})
+			app.Use(func(c *fiber.Ctx) error {
+				if tt.verified != nil {
+					c.Context().SetUserValue("emailVerified", tt.verified)
+				}
+				return c.Next()
+			})
+			app.Post("/booking/hold", middleware.RequireVerifiedEmail(), func(c *fiber.Ctx) error {
+				return c.SendStatus(http.StatusOK)
+			})
+
+			resp, err := app.Test(httptest.NewRequest("POST", "/booking/hold", nil))
+			suite.Require().NoError(err)
+			suite.Equal(tt.want, resp.StatusCode)
+		})
+	}
+}
+
+func TestVerificationSuiteHandler(t *testing.T) {
+	suite.Run(t, new(VerificationSuiteHandler))
+}